@@ -12,10 +12,13 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"sf-finances/src/banking"
 	"sf-finances/src/config"
 	"sf-finances/src/handlers"
 	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
 	"sf-finances/src/repository"
+	"sf-finances/src/scheduler"
 	"sf-finances/src/services"
 )
 
@@ -31,6 +34,23 @@ func main() {
 	dbCfg := config.GetDBConfig()
 	jwtCfg := config.GetJWTConfig()
 	cryptoCfg := config.GetCryptoConfig()
+	cardCfg := config.GetCardConfig()
+	merchantCfg := config.GetMerchantConfig()
+	paymentCfg := config.GetPaymentConfig()
+	disputeCfg := config.GetDisputeConfig()
+	settlementCfg := config.GetSettlementConfig()
+	profileCfg := config.GetProfileConfig()
+	standingOrderCfg := config.GetStandingOrderConfig()
+	transferBatchCfg := config.GetTransferBatchConfig()
+	statementCfg := config.GetStatementConfig()
+	bankingCfg := config.GetBankingConfig()
+	interbankCfg := config.GetInterbankConfig()
+	sbpCfg := config.GetSBPConfig()
+	paymentRequestCfg := config.GetPaymentRequestConfig()
+	depositCfg := config.GetDepositConfig()
+	overdraftCfg := config.GetOverdraftConfig()
+	feeCfg := config.GetFeeConfig()
+	savingsGoalCfg := config.GetSavingsGoalConfig()
 
 	pool, err := config.CreatePgPool(ctx, dbCfg)
 	if err != nil {
@@ -44,19 +64,105 @@ func main() {
 	accountRepo := repository.NewAccountRepository(pool)
 	transactionRepo := repository.NewTransactionRepository(pool)
 	cardRepo := repository.NewCardRepository(pool)
+	paymentRepo := repository.NewPaymentRepository(pool)
+	paymentConfirmationRepo := repository.NewPaymentConfirmationRepository(pool)
+	disputeRepo := repository.NewDisputeRepository(pool)
+	merchantRepo := repository.NewMerchantRepository(pool)
+	settlementRepo := repository.NewSettlementRepository(pool)
+	phoneVerificationRepo := repository.NewPhoneVerificationRepository(pool)
+	standingOrderRepo := repository.NewStandingOrderRepository(pool)
+	transferBatchRepo := repository.NewTransferBatchRepository(pool)
+	interbankPaymentRepo := repository.NewInterbankPaymentRepository(pool)
+	sbpRepo := repository.NewSBPRepository(pool)
+	paymentRequestRepo := repository.NewPaymentRequestRepository(pool)
+	depositRepo := repository.NewDepositRepository(pool)
+	overdraftRepo := repository.NewOverdraftRepository(pool)
+	feeRepo := repository.NewFeeRepository(pool)
+	savingsGoalRepo := repository.NewSavingsGoalRepository(pool)
+	exchangeRepo := repository.NewExchangeRepository(pool)
+	pinResetRepo := repository.NewPINResetRepository(pool)
+
+	// Справочник БИК для платежей в другие банки. Без него ни одно поручение
+	// не пройдет проверку реквизитов, поэтому сервер не запускается
+	bicDirectory, err := banking.LoadBICDirectory(interbankCfg.BICDirectoryPath)
+	if err != nil {
+		logger.Fatalf("Ошибка загрузки справочника БИК: %v", err)
+	}
+	if bicDirectory.Len() == 0 {
+		logger.Fatalf("Справочник БИК %s пуст", interbankCfg.BICDirectoryPath)
+	}
+	logger.Infof("Загружен справочник БИК: %d участников", bicDirectory.Len())
 
 	// Инициализация сервисов
+	notifier := services.NewLogNotifier(logger)
+	smsSender := services.NewLogSMSSender(logger)
 	authService := services.NewAuthService(userRepo, jwtCfg)
-	accountService := services.NewAccountService(accountRepo, transactionRepo)
-	cardService := services.NewCardService(cardRepo, pool, cryptoCfg.HMACKey)
+	feeService := services.NewFeeService(feeRepo, accountRepo, feeCfg)
+	accountService := services.NewAccountService(accountRepo, transactionRepo, feeService, bankingCfg)
+	profileService := services.NewProfileService(userRepo, phoneVerificationRepo, smsSender, cryptoCfg, profileCfg)
+	p2pService := services.NewP2PService(accountService, accountRepo, userRepo)
+	standingOrderService := services.NewStandingOrderService(standingOrderRepo, accountService, p2pService, notifier, standingOrderCfg)
+	transferBatchService := services.NewTransferBatchService(transferBatchRepo, accountService, notifier, transferBatchCfg)
+	interbankPaymentService := services.NewInterbankPaymentService(interbankPaymentRepo, accountService, bicDirectory,
+		services.NewFakeInterbankGateway(logger), notifier, bankingCfg, interbankCfg)
+	sbpService := services.NewSBPService(sbpRepo, accountService, userRepo, sbpCfg, statementCfg)
+	paymentRequestService := services.NewPaymentRequestService(paymentRequestRepo, accountService, p2pService, userRepo,
+		notifier, paymentRequestCfg)
+	depositService := services.NewDepositService(depositRepo, accountService, notifier, depositCfg)
+	overdraftService := services.NewOverdraftService(overdraftRepo, accountRepo, accountService, notifier, overdraftCfg)
+	savingsGoalService := services.NewSavingsGoalService(savingsGoalRepo, transactionRepo, accountService, notifier,
+		savingsGoalCfg)
+	exchangeService := services.NewExchangeService(exchangeRepo, accountService, userRepo, statementCfg)
+	statementService := services.NewStatementService(accountService, userRepo, statementCfg, bankingCfg)
+	if err := statementService.LoadFonts(); err != nil {
+		logger.Fatalf("Ошибка загрузки шрифтов выписки: %v", err)
+	}
+	cardService := services.NewCardService(cardRepo, pinResetRepo, accountService, notifier, pool, cryptoCfg, cardCfg)
+	riskChecker := services.NewNewCardRiskChecker(paymentCfg.NewCardRiskWindow)
+	paymentService := services.NewPaymentService(cardService, accountService, paymentRepo, paymentConfirmationRepo,
+		riskChecker, notifier, cryptoCfg, paymentCfg)
+	merchantService := services.NewMerchantService(merchantRepo, accountService, cryptoCfg, merchantCfg)
+	disputeService := services.NewDisputeService(disputeRepo, paymentService, merchantService, accountService, notifier,
+		disputeCfg)
+	settlementService := services.NewSettlementService(settlementRepo, merchantService, accountService, settlementCfg)
+	merchantAuthService := services.NewMerchantAuthService(merchantService, services.NewMemoryNonceStore(), merchantCfg.SignatureWindow)
+
+	// Счета, открытые до появления номеров, получают номера при старте
+	assigned, err := accountService.AssignMissingNumbers(ctx)
+	if err != nil {
+		logger.Fatalf("Ошибка присвоения номеров счетам: %v", err)
+	}
+	if assigned > 0 {
+		logger.Infof("Присвоены номера %d счетам", assigned)
+	}
 
 	// Инициализация обработчиков
 	authHandler := handler.NewAuthHandler(authService, logger)
 	accountHandler := handler.NewAccountHandler(accountService, logger)
+	profileHandler := handler.NewProfileHandler(profileService, logger)
+	p2pHandler := handler.NewP2PHandler(p2pService, logger)
+	standingOrderHandler := handler.NewStandingOrderHandler(standingOrderService, logger)
+	transferBatchHandler := handler.NewTransferBatchHandler(transferBatchService, transferBatchCfg.MaxUploadSize, logger)
+	interbankPaymentHandler := handler.NewInterbankPaymentHandler(interbankPaymentService, logger)
+	sbpHandler := handler.NewSBPHandler(sbpService, logger)
+	paymentRequestHandler := handler.NewPaymentRequestHandler(paymentRequestService, logger)
+	depositHandler := handler.NewDepositHandler(depositService, logger)
+	overdraftHandler := handler.NewOverdraftHandler(overdraftService, logger)
+	feeHandler := handler.NewFeeHandler(feeService, logger)
+	savingsGoalHandler := handler.NewSavingsGoalHandler(savingsGoalService, logger)
+	exchangeHandler := handler.NewExchangeHandler(exchangeService, statementCfg, logger)
+	statementHandler := handler.NewStatementHandler(statementService, statementCfg, logger)
 	cardHandler := handler.NewCardHandler(cardService, logger)
+	paymentHandler := handler.NewPaymentHandler(paymentService, logger)
+	disputeHandler := handler.NewDisputeHandler(disputeService, logger)
+	merchantHandler := handler.NewMerchantHandler(merchantService, logger)
+	settlementHandler := handler.NewSettlementHandler(settlementService, logger)
 
 	// JWT middleware
 	jwtMiddleware := middlewares.NewJWTMiddleware(authService, logger)
+	merchantAuthMiddleware := middlewares.NewMerchantAuthMiddleware(merchantService, logger)
+	merchantSignatureMiddleware := middlewares.NewMerchantSignatureMiddleware(merchantAuthService, logger)
+	adminMiddleware := middlewares.NewAdminMiddleware(authService, logger)
 
 	// Настройка маршрутизатора
 	r := mux.NewRouter().PathPrefix("/api").Subrouter()
@@ -65,6 +171,17 @@ func main() {
 	r.HandleFunc("/register", authHandler.Register).Methods(http.MethodPost)
 	r.HandleFunc("/login", authHandler.Login).Methods(http.MethodPost)
 
+	// Маршруты мерчантов (API-ключ и HMAC-подпись вместо JWT)
+	merchantRouter := r.PathPrefix("/merchant").Subrouter()
+	merchantRouter.Handle("/payments", merchantAuthMiddleware.Require(models.ScopePaymentsWrite)(
+		merchantSignatureMiddleware.Middleware(http.HandlerFunc(paymentHandler.ProcessPayment)))).Methods(http.MethodPost)
+	merchantRouter.Handle("/payments/{id}", merchantAuthMiddleware.Require(models.ScopePaymentsRead)(
+		merchantSignatureMiddleware.Middleware(http.HandlerFunc(paymentHandler.GetMerchantPayment)))).Methods(http.MethodGet)
+	merchantRouter.Handle("/settlements", merchantAuthMiddleware.Require(models.ScopeSettlementsRead)(
+		merchantSignatureMiddleware.Middleware(http.HandlerFunc(settlementHandler.GetMerchantBatches)))).Methods(http.MethodGet)
+	merchantRouter.Handle("/settlements/{id}/report", merchantAuthMiddleware.Require(models.ScopeSettlementsRead)(
+		merchantSignatureMiddleware.Middleware(http.HandlerFunc(settlementHandler.GetMerchantReport)))).Methods(http.MethodGet)
+
 	// Защищенные маршруты (с проверкой JWT)
 	apiRouter := r.PathPrefix("").Subrouter()
 	apiRouter.Use(jwtMiddleware.Middleware)
@@ -72,15 +189,133 @@ func main() {
 	// Маршруты для счетов
 	apiRouter.HandleFunc("/accounts", accountHandler.CreateAccount).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/accounts", accountHandler.GetAccounts).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/accounts/number/{number}", accountHandler.GetAccountByNumber).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/accounts/{id}/balance", accountHandler.UpdateBalance).Methods(http.MethodPatch)
+	apiRouter.HandleFunc("/accounts/{id}/nickname", accountHandler.SetNickname).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/accounts/{id}/close", accountHandler.CloseAccount).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/accounts/{id}/overdraft-charges", overdraftHandler.GetCharges).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/accounts/{id}/fees", feeHandler.GetAccountFees).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/accounts/{id}/transactions", accountHandler.GetTransactions).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/accounts/{id}/statement", statementHandler.GetStatement).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/accounts/{id}/statement.pdf", statementHandler.GetStatementPDF).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/transfer", accountHandler.Transfer).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/transfer/p2p", p2pHandler.Transfer).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/fees/preview", feeHandler.Preview).Methods(http.MethodGet)
+
+	// Обмен с 1С
+	apiRouter.HandleFunc("/exchange/1c/payment-orders", exchangeHandler.ImportPaymentOrders).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/accounts/{id}/statement/1c", exchangeHandler.ExportStatement).Methods(http.MethodGet)
+
+	// Маршруты для пакетных переводов
+	apiRouter.HandleFunc("/transfer-batches", transferBatchHandler.CreateBatch).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/transfer-batches", transferBatchHandler.GetBatches).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/transfer-batches/{id}", transferBatchHandler.GetBatch).Methods(http.MethodGet)
+
+	// Платежи в другие банки
+	apiRouter.HandleFunc("/interbank-payments", interbankPaymentHandler.CreatePayment).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/interbank-payments", interbankPaymentHandler.GetPayments).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/interbank-payments/{id}", interbankPaymentHandler.GetPayment).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/banks/{bic}", interbankPaymentHandler.LookupBank).Methods(http.MethodGet)
+
+	// Платежные ссылки и QR-коды СБП
+	apiRouter.HandleFunc("/sbp/qr", sbpHandler.CreateQR).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/sbp/qr", sbpHandler.GetQRs).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/sbp/qr/parse", sbpHandler.ParsePayload).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/sbp/qr/pay", sbpHandler.Pay).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/sbp/qr/{id:[0-9]+}", sbpHandler.GetQR).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/sbp/qr/{id:[0-9]+}/image.png", sbpHandler.GetQRImage).Methods(http.MethodGet)
+
+	// Запросы денег у других пользователей
+	apiRouter.HandleFunc("/payment-requests", paymentRequestHandler.CreateRequest).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/payment-requests/incoming", paymentRequestHandler.GetIncoming).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/payment-requests/outgoing", paymentRequestHandler.GetOutgoing).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/payment-requests/{id:[0-9]+}", paymentRequestHandler.GetRequest).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/payment-requests/{id:[0-9]+}/pay", paymentRequestHandler.Pay).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/payment-requests/{id:[0-9]+}/decline", paymentRequestHandler.Decline).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/payment-requests/{id:[0-9]+}/cancel", paymentRequestHandler.Cancel).Methods(http.MethodPost)
+
+	// Вклады и накопительные счета
+	apiRouter.HandleFunc("/deposits", depositHandler.OpenDeposit).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/deposits", depositHandler.GetDeposits).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/deposits/rates", depositHandler.GetRates).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/deposits/{id:[0-9]+}", depositHandler.GetDeposit).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/deposits/{id:[0-9]+}/close", depositHandler.CloseDeposit).Methods(http.MethodPost)
+
+	// Цели накоплений
+	apiRouter.HandleFunc("/savings-goals", savingsGoalHandler.CreateGoal).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/savings-goals", savingsGoalHandler.GetGoals).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/savings-goals/{id:[0-9]+}", savingsGoalHandler.GetGoal).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/savings-goals/{id:[0-9]+}/rules", savingsGoalHandler.UpdateRules).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/savings-goals/{id:[0-9]+}/close", savingsGoalHandler.CloseGoal).Methods(http.MethodPost)
+
+	// Маршруты для регулярных переводов
+	apiRouter.HandleFunc("/standing-orders", standingOrderHandler.CreateOrder).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/standing-orders", standingOrderHandler.GetOrders).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/standing-orders/{id}", standingOrderHandler.GetOrder).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/standing-orders/{id}/pause", standingOrderHandler.PauseOrder).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/standing-orders/{id}/resume", standingOrderHandler.ResumeOrder).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/standing-orders/{id}/cancel", standingOrderHandler.CancelOrder).Methods(http.MethodPost)
+
+	// Маршруты профиля
+	apiRouter.HandleFunc("/profile", profileHandler.GetProfile).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/profile", profileHandler.UpdateProfile).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/profile/phone", profileHandler.RequestPhoneVerification).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/profile/phone/verify", profileHandler.VerifyPhone).Methods(http.MethodPost)
 
 	// Маршруты для карт
 	apiRouter.HandleFunc("/cards", cardHandler.CreateCard).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/cards", cardHandler.GetCards).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/cards/{id}", cardHandler.GetCardDetails).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/payments", cardHandler.ProcessPayment).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/cards/{id}/activate", cardHandler.ActivateCard).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/cards/{id}/pin", cardHandler.SetPIN).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/cards/{id}/pin/reset/code", cardHandler.RequestPINReset).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/cards/{id}/pin/reset", cardHandler.ResetPIN).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/cards/{id}/payments", paymentHandler.GetCardPayments).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/payments/{id}", paymentHandler.GetPayment).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/payments/{id}/confirm", paymentHandler.ConfirmPayment).Methods(http.MethodPost)
+
+	// Маршруты для споров
+	apiRouter.HandleFunc("/payments/{id}/disputes", disputeHandler.OpenDispute).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/disputes", disputeHandler.GetDisputes).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/disputes/{id}", disputeHandler.GetDispute).Methods(http.MethodGet)
+
+	// Маршруты администратора
+	adminRouter := apiRouter.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(adminMiddleware.Middleware)
+	adminRouter.HandleFunc("/accounts/{id}/freeze", accountHandler.FreezeAccount).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/accounts/{id}/unfreeze", accountHandler.UnfreezeAccount).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/accounts/{id}/overdraft", overdraftHandler.SetLimit).Methods(http.MethodPut)
+	adminRouter.HandleFunc("/disputes", disputeHandler.ListDisputes).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/disputes/{id}/review", disputeHandler.StartReview).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/disputes/{id}/resolve", disputeHandler.ResolveDispute).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/merchants", merchantHandler.CreateMerchant).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/merchants", merchantHandler.GetMerchants).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/merchants/{id}", merchantHandler.GetMerchant).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/merchants/{id}/signing-secret", merchantHandler.RotateSigningSecret).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/merchants/{id}/keys", merchantHandler.CreateAPIKey).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/merchants/{id}/keys", merchantHandler.GetAPIKeys).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/merchants/{id}/keys/{keyID}/rotate", merchantHandler.RotateAPIKey).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/merchants/{id}/keys/{keyID}", merchantHandler.RevokeAPIKey).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/settlements", settlementHandler.GetBatches).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/settlements/run", settlementHandler.RunSettlement).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/settlements/{id}/report", settlementHandler.GetReport).Methods(http.MethodGet)
+
+	// Фоновые задачи
+	jobs := scheduler.New(logger)
+	jobs.Add("card-expiry", 24*time.Hour, cardService.ProcessExpiringCards)
+	jobs.Add("payment-confirmation-expiry", time.Minute, paymentService.ExpirePendingPayments)
+	jobs.Add("merchant-settlement", settlementCfg.Interval, settlementService.SettleDaily)
+	jobs.Add("standing-orders", standingOrderCfg.Interval, standingOrderService.ExecuteDue)
+	jobs.Add("transfer-batches", transferBatchCfg.Interval, transferBatchService.ProcessBatches)
+	jobs.Add("interbank-payments", interbankCfg.Interval, interbankPaymentService.SubmitPending)
+	jobs.Add("payment-request-expiry", paymentRequestCfg.ExpiryInterval, paymentRequestService.ExpireRequests)
+	jobs.Add("deposit-interest", depositCfg.Interval, depositService.AccrueInterest)
+	jobs.Add("overdrafts", overdraftCfg.Interval, overdraftService.ProcessOverdrafts)
+	jobs.Add("fees", feeCfg.Interval, accountService.PostFees)
+	jobs.Add("savings-goals", savingsGoalCfg.Interval, savingsGoalService.ApplyRules)
+
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	jobs.Start(jobsCtx)
 
 	// Настройка сервера
 	srv := &http.Server{
@@ -112,5 +347,8 @@ func main() {
 	if err := srv.Shutdown(ctxShutdown); err != nil {
 		logger.Fatalf("Ошибка остановки сервера: %v", err)
 	}
+
+	stopJobs()
+	jobs.Wait()
 	logger.Info("Сервер остановлен")
 }
\ No newline at end of file
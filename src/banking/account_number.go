@@ -0,0 +1,150 @@
+// Package banking содержит правила банковских реквизитов РФ: номера счетов,
+// БИК и контрольные ключи.
+package banking
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sf-finances/src/models"
+)
+
+var (
+	ErrInvalidAccountNumber = errors.New("неверный номер счета")
+	ErrInvalidBIC           = errors.New("неверный БИК")
+)
+
+// AccountNumberLength - длина номера счета по Положению Банка России № 579-П
+const AccountNumberLength = 20
+
+// Коды валют в номере счета. Для рубля используется код 810, а не 643 из ОКВ
+var currencyCodes = map[models.Currency]string{
+	models.RUB: "810",
+	models.USD: "840",
+	models.EUR: "978",
+}
+
+func CurrencyCode(currency models.Currency) (string, bool) {
+	code, ok := currencyCodes[currency]
+	return code, ok
+}
+
+func ValidateBIC(bic string) error {
+	if len(bic) != 9 || !isDigits(bic) {
+		return fmt.Errorf("%w: БИК состоит из 9 цифр", ErrInvalidBIC)
+	}
+	return nil
+}
+
+// NewAccountNumber собирает номер счета: балансовый счет второго порядка
+// (5 цифр), код валюты (3), контрольный ключ (1), код подразделения (4) и
+// порядковый номер (7)
+func NewAccountNumber(bic, balanceAccount string, currency models.Currency, branch string, serial int64) (string, error) {
+	code, ok := CurrencyCode(currency)
+	if !ok {
+		return "", fmt.Errorf("%w: нет кода валюты %s", ErrInvalidAccountNumber, currency)
+	}
+	if len(balanceAccount) != 5 || !isDigits(balanceAccount) {
+		return "", fmt.Errorf("%w: балансовый счет %q", ErrInvalidAccountNumber, balanceAccount)
+	}
+	if len(branch) != 4 || !isDigits(branch) {
+		return "", fmt.Errorf("%w: код подразделения %q", ErrInvalidAccountNumber, branch)
+	}
+	if serial <= 0 || serial > 9999999 {
+		return "", fmt.Errorf("%w: порядковый номер %d вне диапазона", ErrInvalidAccountNumber, serial)
+	}
+
+	number := fmt.Sprintf("%s%s0%s%07d", balanceAccount, code, branch, serial)
+	key, err := ControlKey(bic, number)
+	if err != nil {
+		return "", err
+	}
+	return number[:8] + string(key) + number[9:], nil
+}
+
+// ControlKey вычисляет контрольный ключ (9-й разряд) номера счета в
+// кредитной организации по алгоритму Банка России. Текущее значение 9-го
+// разряда не учитывается
+func ControlKey(bic, account string) (byte, error) {
+	if err := ValidateBIC(bic); err != nil {
+		return 0, err
+	}
+	return controlKey(bicKeyPrefix(bic), account)
+}
+
+func controlKey(prefix, account string) (byte, error) {
+	if len(account) != AccountNumberLength || !isDigits(account) {
+		return 0, fmt.Errorf("%w: номер счета состоит из %d цифр", ErrInvalidAccountNumber, AccountNumberLength)
+	}
+
+	sum := checksum(prefix + account[:8] + "0" + account[9:])
+	return byte('0' + sum*3%10), nil
+}
+
+// ValidateAccountNumber проверяет формат номера счета и контрольный ключ
+// относительно БИК банка, в котором открыт счет
+func ValidateAccountNumber(bic, account string) error {
+	account = strings.TrimSpace(account)
+	key, err := ControlKey(bic, account)
+	if err != nil {
+		return err
+	}
+	if account[8] != key {
+		return fmt.Errorf("%w: не сходится контрольный ключ", ErrInvalidAccountNumber)
+	}
+	return nil
+}
+
+// ValidateCorrespondentAccount проверяет корреспондентский счет банка с
+// БИК bic: балансовый счет 30101 и ключ, рассчитанный от "0" и 5-6 цифр БИК
+func ValidateCorrespondentAccount(bic, account string) error {
+	if err := ValidateBIC(bic); err != nil {
+		return err
+	}
+	account = strings.TrimSpace(account)
+	if !strings.HasPrefix(account, "30101") {
+		return fmt.Errorf("%w: корреспондентский счет должен начинаться с 30101", ErrInvalidAccountNumber)
+	}
+
+	key, err := controlKey("0"+bic[4:6], account)
+	if err != nil {
+		return err
+	}
+	if account[8] != key {
+		return fmt.Errorf("%w: не сходится контрольный ключ корреспондентского счета", ErrInvalidAccountNumber)
+	}
+	return nil
+}
+
+// bicKeyPrefix - три цифры БИК для расчета ключа: для кредитной организации
+// последние три цифры БИК, для счетов в подразделениях Банка России (РКЦ,
+// БИК оканчивается на 000-002) - "0" и 5-6 цифры БИК
+func bicKeyPrefix(bic string) string {
+	switch bic[6:] {
+	case "000", "001", "002":
+		return "0" + bic[4:6]
+	default:
+		return bic[6:]
+	}
+}
+
+// checksum - сумма младших разрядов произведений цифр на весовые
+// коэффициенты 7, 1, 3, взятая по модулю 10
+func checksum(digits string) int {
+	weights := [3]int{7, 1, 3}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * weights[i%3] % 10
+	}
+	return sum % 10
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,124 @@
+package banking
+
+import (
+	"errors"
+	"testing"
+
+	"sf-finances/src/models"
+)
+
+func TestNewAccountNumber(t *testing.T) {
+	tests := []struct {
+		name           string
+		bic            string
+		balanceAccount string
+		currency       models.Currency
+		branch         string
+		serial         int64
+		want           string
+		wantErr        error
+	}{
+		{"счет физлица в рублях", "044525000", "40817", models.RUB, "0000", 1, "40817810500000000001", nil},
+		{"валютный счет", "044525225", "40817", models.USD, "1234", 42, "40817840512340000042", nil},
+		{"счет банка", "044525000", "30232", models.RUB, "0000", 1, "30232810700000000001", nil},
+		{"неизвестная валюта", "044525000", "40817", models.Currency("JPY"), "0000", 1, "", ErrInvalidAccountNumber},
+		{"короткий балансовый счет", "044525000", "4081", models.RUB, "0000", 1, "", ErrInvalidAccountNumber},
+		{"код подразделения с буквой", "044525000", "40817", models.RUB, "00A0", 1, "", ErrInvalidAccountNumber},
+		{"нулевой порядковый номер", "044525000", "40817", models.RUB, "0000", 0, "", ErrInvalidAccountNumber},
+		{"порядковый номер больше 7 цифр", "044525000", "40817", models.RUB, "0000", 10000000, "", ErrInvalidAccountNumber},
+		{"неверный БИК", "04452500", "40817", models.RUB, "0000", 1, "", ErrInvalidBIC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewAccountNumber(tt.bic, tt.balanceAccount, tt.currency, tt.branch, tt.serial)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("номер %q, ожидался %q", got, tt.want)
+			}
+			if tt.wantErr == nil {
+				if err := ValidateAccountNumber(tt.bic, got); err != nil {
+					t.Errorf("собранный номер не проходит проверку: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestControlKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		bic     string
+		account string
+		want    byte
+	}{
+		// Для БИК подразделения Банка России ключ считается от "0" и 5-6 цифр БИК
+		{"подразделение Банка России 000", "044525000", "40817810000000000001", '5'},
+		{"подразделение Банка России 001", "044525001", "40817810000000000001", '5'},
+		{"кредитная организация", "044525225", "40817840012340000042", '5'},
+		// Текущее значение 9-го разряда не учитывается
+		{"ключ в номере игнорируется", "044525000", "40817810900000000001", '5'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ControlKey(tt.bic, tt.account)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ключ %c, ожидался %c", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAccountNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		bic     string
+		account string
+		wantErr error
+	}{
+		{"верный номер", "044525000", "40817810500000000001", nil},
+		{"пробелы по краям", "044525000", " 40817810500000000001 ", nil},
+		{"неверный ключ", "044525000", "40817810400000000001", ErrInvalidAccountNumber},
+		{"ключ от другого БИК", "044525225", "40817810500000000001", ErrInvalidAccountNumber},
+		{"19 цифр", "044525000", "4081781050000000001", ErrInvalidAccountNumber},
+		{"буква в номере", "044525000", "40817810500000000O01", ErrInvalidAccountNumber},
+		{"неверный БИК", "0445250001", "40817810500000000001", ErrInvalidBIC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAccountNumber(tt.bic, tt.account); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCorrespondentAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		bic     string
+		account string
+		wantErr error
+	}{
+		{"ПАО Сбербанк", "044525225", "30101810400000000225", nil},
+		{"Банк ВТБ", "044525187", "30101810700000000187", nil},
+		{"неверный ключ", "044525225", "30101810500000000225", ErrInvalidAccountNumber},
+		{"не корсчет", "044525225", "40817810500000000001", ErrInvalidAccountNumber},
+		{"неверный БИК", "04452522", "30101810400000000225", ErrInvalidBIC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCorrespondentAccount(tt.bic, tt.account); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
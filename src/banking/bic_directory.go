@@ -0,0 +1,137 @@
+package banking
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Bank - участник платежной системы Банка России из справочника БИК
+type Bank struct {
+	BIC  string
+	Name string
+	City string
+	// Действующие корреспондентские счета. У подразделений Банка России и
+	// участников без корсчета список пуст
+	CorrespondentAccounts []string
+	// Участник исключен из справочника или его участие в переводах
+	// ограничено
+	Restricted bool
+}
+
+// HasCorrespondentAccount сообщает, что account - действующий корсчет банка
+func (b *Bank) HasCorrespondentAccount(account string) bool {
+	for _, a := range b.CorrespondentAccounts {
+		if a == account {
+			return true
+		}
+	}
+	return false
+}
+
+// BICDirectory - справочник БИК, загруженный из электронного сообщения ED807
+type BICDirectory struct {
+	banks map[string]*Bank
+}
+
+func NewBICDirectory(banks []*Bank) *BICDirectory {
+	d := &BICDirectory{banks: make(map[string]*Bank, len(banks))}
+	for _, b := range banks {
+		d.banks[b.BIC] = b
+	}
+	return d
+}
+
+func (d *BICDirectory) Lookup(bic string) (*Bank, bool) {
+	b, ok := d.banks[bic]
+	return b, ok
+}
+
+func (d *BICDirectory) Len() int {
+	return len(d.banks)
+}
+
+type ed807 struct {
+	Entries []ed807Entry `xml:"BICDirectoryEntry"`
+}
+
+type ed807Entry struct {
+	BIC         string `xml:"BIC,attr"`
+	Participant struct {
+		Name     string `xml:"NameP,attr"`
+		Locality string `xml:"Nnp,attr"`
+		Status   string `xml:"ParticipantStatus,attr"`
+		Rstr     []struct {
+			Code string `xml:"Rstr,attr"`
+		} `xml:"RstrList"`
+	} `xml:"ParticipantInfo"`
+	Accounts []struct {
+		Account string `xml:"Account,attr"`
+		Type    string `xml:"RegulationAccountType,attr"`
+		Status  string `xml:"AccountStatus,attr"`
+	} `xml:"Accounts"`
+}
+
+// Коды из справочника ED807
+const (
+	ed807ParticipantDeleted = "PSDL"
+	ed807CorrespondentType  = "CRSA"
+	ed807AccountDeleted     = "ACDL"
+)
+
+// ParseED807 читает полный справочник БИК в формате ED807. Файлы Банка
+// России приходят в windows-1251, кодировка берется из XML-декларации
+func ParseED807(r io.Reader) (*BICDirectory, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(label) {
+		case "windows-1251", "cp1251":
+			return charmap.Windows1251.NewDecoder().Reader(input), nil
+		case "utf-8":
+			return input, nil
+		default:
+			return nil, fmt.Errorf("неподдерживаемая кодировка справочника БИК: %s", label)
+		}
+	}
+
+	var doc ed807
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("разбор справочника БИК: %w", err)
+	}
+
+	banks := make([]*Bank, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		if ValidateBIC(e.BIC) != nil {
+			continue
+		}
+
+		bank := &Bank{
+			BIC:        e.BIC,
+			Name:       e.Participant.Name,
+			City:       e.Participant.Locality,
+			Restricted: e.Participant.Status == ed807ParticipantDeleted || len(e.Participant.Rstr) > 0,
+		}
+		for _, a := range e.Accounts {
+			if a.Type == ed807CorrespondentType && a.Status != ed807AccountDeleted {
+				bank.CorrespondentAccounts = append(bank.CorrespondentAccounts, a.Account)
+			}
+		}
+		banks = append(banks, bank)
+	}
+	return NewBICDirectory(banks), nil
+}
+
+// LoadBICDirectory загружает справочник БИК из файла ED807
+func LoadBICDirectory(path string) (*BICDirectory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseED807(f)
+}
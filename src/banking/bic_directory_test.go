@@ -0,0 +1,111 @@
+package banking
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const testED807 = `<?xml version="1.0" encoding="%s"?>
+<ED807 xmlns="urn:cbr-ru:ed:v2.0" EDNo="1" EDDate="2025-03-17">
+	<BICDirectoryEntry BIC="044525225">
+		<ParticipantInfo NameP="ПАО Сбербанк" Nnp="Москва" ParticipantStatus="PSAC"/>
+		<Accounts Account="30101810400000000225" RegulationAccountType="CRSA" AccountStatus="ACAC"/>
+		<Accounts Account="30101810000000000999" RegulationAccountType="CRSA" AccountStatus="ACDL"/>
+		<Accounts Account="30102810000000000225" RegulationAccountType="CBRA" AccountStatus="ACAC"/>
+	</BICDirectoryEntry>
+	<BICDirectoryEntry BIC="044525000">
+		<ParticipantInfo NameP="ГУ Банка России по ЦФО" Nnp="Москва" ParticipantStatus="PSAC"/>
+	</BICDirectoryEntry>
+	<BICDirectoryEntry BIC="044525999">
+		<ParticipantInfo NameP="Банк с ограничениями" Nnp="Москва" ParticipantStatus="PSAC">
+			<RstrList Rstr="URRS" RstrDate="2025-01-10"/>
+		</ParticipantInfo>
+	</BICDirectoryEntry>
+	<BICDirectoryEntry BIC="044525998">
+		<ParticipantInfo NameP="Исключенный банк" Nnp="Москва" ParticipantStatus="PSDL"/>
+	</BICDirectoryEntry>
+	<BICDirectoryEntry BIC="04452">
+		<ParticipantInfo NameP="Неверный БИК" Nnp="Москва" ParticipantStatus="PSAC"/>
+	</BICDirectoryEntry>
+</ED807>`
+
+func TestParseED807(t *testing.T) {
+	utf8 := strings.Replace(testED807, "%s", "UTF-8", 1)
+	cp1251, err := charmap.Windows1251.NewEncoder().String(strings.Replace(testED807, "%s", "windows-1251", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, doc := range map[string]string{"utf-8": utf8, "windows-1251": cp1251} {
+		t.Run(name, func(t *testing.T) {
+			d, err := ParseED807(strings.NewReader(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Len() != 4 {
+				t.Errorf("банков %d, ожидалось 4", d.Len())
+			}
+
+			tests := []struct {
+				bic        string
+				name       string
+				accounts   []string
+				restricted bool
+			}{
+				{"044525225", "ПАО Сбербанк", []string{"30101810400000000225"}, false},
+				{"044525000", "ГУ Банка России по ЦФО", nil, false},
+				{"044525999", "Банк с ограничениями", nil, true},
+				{"044525998", "Исключенный банк", nil, true},
+			}
+			for _, tt := range tests {
+				b, ok := d.Lookup(tt.bic)
+				if !ok {
+					t.Errorf("%s: банк не найден", tt.bic)
+					continue
+				}
+				if b.Name != tt.name || b.City != "Москва" {
+					t.Errorf("%s: %q, %q", tt.bic, b.Name, b.City)
+				}
+				if !slices.Equal(b.CorrespondentAccounts, tt.accounts) {
+					t.Errorf("%s: корсчета %v, ожидались %v", tt.bic, b.CorrespondentAccounts, tt.accounts)
+				}
+				if b.Restricted != tt.restricted {
+					t.Errorf("%s: Restricted=%v", tt.bic, b.Restricted)
+				}
+			}
+		})
+	}
+}
+
+func TestParseED807UnknownCharset(t *testing.T) {
+	doc := strings.Replace(testED807, "%s", "koi8-r", 1)
+	if _, err := ParseED807(strings.NewReader(doc)); err == nil {
+		t.Error("ожидалась ошибка кодировки")
+	}
+}
+
+// Справочник, который поставляется с сервисом, должен читаться и содержать
+// только корсчета с верным ключом
+func TestLoadBICDirectoryShipped(t *testing.T) {
+	d, err := LoadBICDirectory(filepath.Join("..", "..", "data", "ED807.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() == 0 {
+		t.Fatal("справочник пуст")
+	}
+	for bic, b := range d.banks {
+		if len(b.CorrespondentAccounts) == 0 {
+			t.Errorf("%s: нет корсчета", bic)
+		}
+		for _, a := range b.CorrespondentAccounts {
+			if err := ValidateCorrespondentAccount(bic, a); err != nil {
+				t.Errorf("%s: %v", bic, err)
+			}
+		}
+	}
+}
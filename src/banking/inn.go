@@ -0,0 +1,69 @@
+package banking
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidINN = errors.New("неверный ИНН")
+	ErrInvalidKPP = errors.New("неверный КПП")
+)
+
+// Весовые коэффициенты контрольных цифр ИНН
+var (
+	innWeights10   = []int{2, 4, 10, 3, 5, 9, 4, 6, 8}
+	innWeights12_1 = []int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+	innWeights12_2 = []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+)
+
+// ValidateINN проверяет ИНН организации (10 цифр) или физического лица
+// (12 цифр) по контрольным цифрам
+func ValidateINN(inn string) error {
+	if !isDigits(inn) {
+		return fmt.Errorf("%w: ИНН состоит из цифр", ErrInvalidINN)
+	}
+
+	switch len(inn) {
+	case 10:
+		if innCheckDigit(inn, innWeights10) != inn[9] {
+			return fmt.Errorf("%w: не сходится контрольная цифра", ErrInvalidINN)
+		}
+	case 12:
+		if innCheckDigit(inn, innWeights12_1) != inn[10] || innCheckDigit(inn, innWeights12_2) != inn[11] {
+			return fmt.Errorf("%w: не сходятся контрольные цифры", ErrInvalidINN)
+		}
+	default:
+		return fmt.Errorf("%w: ИНН состоит из 10 или 12 цифр", ErrInvalidINN)
+	}
+	return nil
+}
+
+// IsLegalEntityINN сообщает, что ИНН принадлежит организации
+func IsLegalEntityINN(inn string) bool {
+	return len(inn) == 10
+}
+
+// ValidateKPP проверяет формат КПП: код налогового органа (4 цифры),
+// причина постановки на учет (2 цифры или латинские буквы) и порядковый
+// номер (3 цифры)
+func ValidateKPP(kpp string) error {
+	if len(kpp) != 9 || !isDigits(kpp[:4]) || !isDigits(kpp[6:]) {
+		return fmt.Errorf("%w: КПП состоит из 9 знаков", ErrInvalidKPP)
+	}
+	for i := 4; i < 6; i++ {
+		c := kpp[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'A' && c <= 'Z') {
+			return fmt.Errorf("%w: 5-6 знаки КПП - цифры или заглавные латинские буквы", ErrInvalidKPP)
+		}
+	}
+	return nil
+}
+
+func innCheckDigit(inn string, weights []int) byte {
+	sum := 0
+	for i, w := range weights {
+		sum += int(inn[i]-'0') * w
+	}
+	return byte('0' + sum%11%10)
+}
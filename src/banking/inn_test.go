@@ -0,0 +1,54 @@
+package banking
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateINN(t *testing.T) {
+	tests := []struct {
+		name    string
+		inn     string
+		wantErr error
+	}{
+		{"организация", "7707083893", nil},
+		{"физическое лицо", "500100732259", nil},
+		{"организация, неверная контрольная цифра", "7707083894", ErrInvalidINN},
+		{"физлицо, неверная первая контрольная цифра", "500100732249", ErrInvalidINN},
+		{"физлицо, неверная вторая контрольная цифра", "500100732258", ErrInvalidINN},
+		{"11 цифр", "77070838931", ErrInvalidINN},
+		{"буква", "77070838O3", ErrInvalidINN},
+		{"пустой", "", ErrInvalidINN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateINN(tt.inn); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateKPP(t *testing.T) {
+	tests := []struct {
+		name    string
+		kpp     string
+		wantErr error
+	}{
+		{"цифры", "773601001", nil},
+		{"латинские буквы в причине постановки", "7736AB001", nil},
+		{"строчные буквы", "7736ab001", ErrInvalidKPP},
+		{"кириллица", "7736АБ001", ErrInvalidKPP},
+		{"буква в коде налогового органа", "77A601001", ErrInvalidKPP},
+		{"8 знаков", "77360100", ErrInvalidKPP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateKPP(tt.kpp); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+type BankingConfig struct {
+	// БИК банка. От него считается контрольный ключ номеров счетов, он же
+	// указывается в выписках OFX и camt.053
+	BIC string
+	// Балансовый счет второго порядка для новых счетов (счета физических лиц)
+	BalanceAccount string
+	// Код подразделения в номере счета
+	Branch string
+}
+
+func GetBankingConfig() BankingConfig {
+	return BankingConfig{
+		BIC:            "044525000",
+		BalanceAccount: "40817",
+		Branch:         "0000",
+	}
+}
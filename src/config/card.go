@@ -0,0 +1,54 @@
+package config
+
+import (
+	"time"
+
+	"sf-finances/src/models"
+)
+
+// BINRange - диапазон BIN (первые 6 цифр номера карты), включительно
+type BINRange struct {
+	From uint64
+	To   uint64
+}
+
+type CardConfig struct {
+	DefaultProduct models.CardProduct
+	BINRanges      map[models.CardProduct][]BINRange
+	MaxPANAttempts int
+	MaxPINAttempts int
+	// Одноразовый код, без которого не сбросить PIN
+	PINResetCodeTTL         time.Duration
+	PINResetCodeLength      int
+	MaxPINResetCodeAttempts int
+	// Срок действия карты в месяцах по типу карты
+	ValidityMonths map[models.CardType]int
+	// За сколько дней до истечения срока карта перевыпускается
+	ReissueBeforeDays int
+	// Типы карт, которые перевыпускаются автоматически
+	ReissueTypes []models.CardType
+}
+
+func GetCardConfig() CardConfig {
+	return CardConfig{
+		DefaultProduct: models.VisaDebit,
+		BINRanges: map[models.CardProduct][]BINRange{
+			models.VisaDebit:  {{From: 400000, To: 400099}},
+			models.VisaCredit: {{From: 450000, To: 450099}},
+			models.MirDebit:   {{From: 220000, To: 220099}},
+			models.MirCredit:  {{From: 220400, To: 220499}},
+		},
+		MaxPANAttempts:          10,
+		MaxPINAttempts:          3,
+		PINResetCodeTTL:         5 * time.Minute,
+		PINResetCodeLength:      6,
+		MaxPINResetCodeAttempts: 3,
+		ValidityMonths: map[models.CardType]int{
+			models.PhysicalCard:     36,
+			models.VirtualSingleUse: 1,
+			models.MerchantLocked:   12,
+		},
+		ReissueBeforeDays: 30,
+		ReissueTypes:      []models.CardType{models.PhysicalCard, models.MerchantLocked},
+	}
+}
@@ -1,15 +1,19 @@
 package config
 
 type CryptoConfig struct {
-	PGPKey  string
-	HMACKey string
+	PGPKey     string
+	HMACKey    string
+	PANHashKey string
+	PINKey     string
 }
 
 func GetCryptoConfig() CryptoConfig {
 	cfg := CryptoConfig{
-		PGPKey:  "pgpkey",
-		HMACKey: "hmackey",
+		PGPKey:     "pgpkey",
+		HMACKey:    "hmackey",
+		PANHashKey: "pankey",
+		PINKey:     "pinkey",
 	}
 
 	return cfg
-}
\ No newline at end of file
+}
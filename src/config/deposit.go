@@ -0,0 +1,37 @@
+package config
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type DepositConfig struct {
+	// Ставка накопительного счета, % годовых
+	SavingsRate decimal.Decimal
+	// Ставки срочных вкладов по сроку в месяцах, % годовых
+	TermRates map[int]decimal.Decimal
+	// Минимальная сумма открытия срочного вклада
+	MinTermAmount decimal.Decimal
+	// Номер счета расходов банка на выплату процентов (балансовый счет 70606)
+	InterestExpenseAccount string
+	// Операционный день закрывается в полночь по этому часовому поясу
+	Location *time.Location
+	// Как часто проверять, не пора ли начислить проценты
+	Interval time.Duration
+}
+
+func GetDepositConfig() DepositConfig {
+	return DepositConfig{
+		SavingsRate: decimal.RequireFromString("12"),
+		TermRates: map[int]decimal.Decimal{
+			3:  decimal.RequireFromString("16"),
+			6:  decimal.RequireFromString("17"),
+			12: decimal.RequireFromString("15"),
+		},
+		MinTermAmount:          decimal.NewFromInt(10000),
+		InterestExpenseAccount: "70606810600000000001",
+		Location:               time.FixedZone("MSK", 3*60*60),
+		Interval:               time.Hour,
+	}
+}
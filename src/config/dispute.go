@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type DisputeConfig struct {
+	// Оспорить можно только платеж, списанный не ранее Window назад
+	Window time.Duration
+	// Номер счета сумм до выяснения (балансовый счет 47416), с которого
+	// временно зачисляется сумма спора и на который она возвращается
+	SuspenseAccount string
+}
+
+func GetDisputeConfig() DisputeConfig {
+	return DisputeConfig{
+		Window:          120 * 24 * time.Hour,
+		SuspenseAccount: "47416810700000000001",
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// FeeRule - тариф на вид операции. Комиссия равна Fixed плюс Percent от
+// суммы сверх бесплатного объема, но не меньше Min и не больше Max (0 -
+// без ограничения). Первые FreeCount операций в месяце бесплатны
+type FeeRule struct {
+	Percent    decimal.Decimal
+	Fixed      decimal.Decimal
+	Min        decimal.Decimal
+	Max        decimal.Decimal
+	FreeCount  int
+	FreeAmount decimal.Decimal
+}
+
+type FeeConfig struct {
+	// Тарифы по видам операций. Операции без тарифа бесплатны и не учитываются.
+	// Суммы тарифов в рублях, комиссия берется только по рублевым счетам
+	Rules map[models.FeeOperation]FeeRule
+	// Номер счета доходов банка, на который зачисляются комиссии (балансовый счет 70601)
+	IncomeAccount string
+	// Месяц для бесплатных лимитов считается по этому часовому поясу
+	Location *time.Location
+	// Как часто повторять списание комиссий, не прошедших сразу
+	Interval time.Duration
+}
+
+func GetFeeConfig() FeeConfig {
+	return FeeConfig{
+		Rules: map[models.FeeOperation]FeeRule{
+			models.FeeP2PTransfer: {
+				Percent:    decimal.RequireFromString("1.5"),
+				Min:        decimal.NewFromInt(30),
+				Max:        decimal.NewFromInt(1500),
+				FreeAmount: decimal.NewFromInt(100000),
+			},
+			models.FeeWithdrawal: {
+				Percent:   decimal.NewFromInt(1),
+				Min:       decimal.NewFromInt(100),
+				FreeCount: 3,
+			},
+			models.FeeCardPayment: {},
+		},
+		IncomeAccount: "70601810400000000002",
+		Location:      time.FixedZone("MSK", 3*60*60),
+		Interval:      5 * time.Minute,
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import "time"
+
+type InterbankConfig struct {
+	// Файл справочника БИК Банка России в формате ED807
+	BICDirectoryPath string
+	// Транзитный счет банка: на него списываются платежи в другие банки до
+	// расчетов через корсчет, с него же возвращаются отклоненные платежи
+	ClearingAccount string
+	// Допустимые ставки НДС в процентах
+	VATRates []int
+	// Как часто поручения в статусе PENDING отправляются в шлюз
+	Interval time.Duration
+	// На сколько поручение блокируется на время отправки
+	Lease time.Duration
+	// После стольких неудачных попыток отправки поручение отклоняется
+	MaxAttempts int
+}
+
+func GetInterbankConfig() InterbankConfig {
+	return InterbankConfig{
+		BICDirectoryPath: "data/ED807.xml",
+		ClearingAccount:  "30232810700000000001",
+		VATRates:         []int{5, 7, 10, 20},
+		Interval:         time.Minute,
+		Lease:            5 * time.Minute,
+		MaxAttempts:      5,
+	}
+}
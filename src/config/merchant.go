@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+type MerchantConfig struct {
+	SignatureWindow time.Duration
+	// После ротации старый API-ключ продолжает работать еще KeyRotationGrace
+	KeyRotationGrace time.Duration
+}
+
+func GetMerchantConfig() MerchantConfig {
+	return MerchantConfig{
+		SignatureWindow:  5 * time.Minute,
+		KeyRotationGrace: 24 * time.Hour,
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type OverdraftConfig struct {
+	// Ставка за пользование овердрафтом, % годовых
+	AnnualRate decimal.Decimal
+	// Максимальный лимит, который может установить администратор
+	MaxLimit decimal.Decimal
+	// Номер счета доходов банка, на который зачисляются проценты (балансовый счет 70601)
+	InterestIncomeAccount string
+	// Операционный день закрывается в полночь по этому часовому поясу
+	Location *time.Location
+	// Как часто проверять выход счетов в минус и начислять проценты
+	Interval time.Duration
+}
+
+func GetOverdraftConfig() OverdraftConfig {
+	return OverdraftConfig{
+		AnnualRate:            decimal.RequireFromString("29.9"),
+		MaxLimit:              decimal.NewFromInt(500000),
+		InterestIncomeAccount: "70601810100000000001",
+		Location:              time.FixedZone("MSK", 3*60*60),
+		Interval:              time.Minute,
+	}
+}
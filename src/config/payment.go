@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PaymentConfig struct {
+	// Платежи от этой суммы требуют подтверждения одноразовым кодом
+	ConfirmationThreshold   decimal.Decimal
+	ConfirmationTTL         time.Duration
+	ConfirmationCodeLength  int
+	MaxConfirmationAttempts int
+	// Платежи по картам, выпущенным менее NewCardRiskWindow назад, считаются рискованными
+	NewCardRiskWindow time.Duration
+}
+
+func GetPaymentConfig() PaymentConfig {
+	return PaymentConfig{
+		ConfirmationThreshold:   decimal.NewFromInt(10000),
+		ConfirmationTTL:         5 * time.Minute,
+		ConfirmationCodeLength:  6,
+		MaxConfirmationAttempts: 3,
+		NewCardRiskWindow:       24 * time.Hour,
+	}
+}
@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+type PaymentRequestConfig struct {
+	// Срок действия запроса, если он не указан
+	DefaultTTL time.Duration
+	// Максимальный срок действия запроса
+	MaxTTL time.Duration
+	// Как часто истекшие запросы закрываются
+	ExpiryInterval time.Duration
+}
+
+func GetPaymentRequestConfig() PaymentRequestConfig {
+	return PaymentRequestConfig{
+		DefaultTTL:     7 * 24 * time.Hour,
+		MaxTTL:         30 * 24 * time.Hour,
+		ExpiryInterval: 10 * time.Minute,
+	}
+}
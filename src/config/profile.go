@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+type ProfileConfig struct {
+	PhoneCodeTTL         time.Duration
+	PhoneCodeLength      int
+	MaxPhoneCodeAttempts int
+}
+
+func GetProfileConfig() ProfileConfig {
+	return ProfileConfig{
+		PhoneCodeTTL:         10 * time.Minute,
+		PhoneCodeLength:      6,
+		MaxPhoneCodeAttempts: 5,
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type SavingsGoalConfig struct {
+	// До скольких рублей можно округлять покупки
+	RoundUpSteps []int
+	// Максимальный процент от поступлений, который можно откладывать
+	MaxDepositPercent decimal.Decimal
+	// Сколько целей пользователь может вести одновременно
+	MaxActiveGoals int
+	// Месяц для процента от поступлений считается по этому часовому поясу
+	Location *time.Location
+	// Как часто применять правила пополнения
+	Interval time.Duration
+}
+
+func GetSavingsGoalConfig() SavingsGoalConfig {
+	return SavingsGoalConfig{
+		RoundUpSteps:      []int{10, 100},
+		MaxDepositPercent: decimal.NewFromInt(50),
+		MaxActiveGoals:    10,
+		Location:          time.FixedZone("MSK", 3*60*60),
+		Interval:          time.Minute,
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+type SBPConfig struct {
+	// Адрес, с которого начинаются платежные ссылки
+	BaseURL string
+	// Идентификатор банка - участника СБП
+	MemberID string
+	// Срок действия динамической ссылки
+	DynamicTTL time.Duration
+	// Размер модуля QR-кода в пикселях по умолчанию и максимальный
+	DefaultScale int
+	MaxScale     int
+}
+
+func GetSBPConfig() SBPConfig {
+	return SBPConfig{
+		BaseURL:      "https://qr.nspk.ru/",
+		MemberID:     "100000000999",
+		DynamicTTL:   30 * time.Minute,
+		DefaultScale: 8,
+		MaxScale:     20,
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type SettlementConfig struct {
+	// Комиссия эквайринга в долях от суммы платежа
+	DefaultFeeRate decimal.Decimal
+	// Особые ставки комиссии по MCC мерчанта
+	FeeRatesByMCC map[string]decimal.Decimal
+	// Номер счета расчетов по операциям с картами (балансовый счет 30233),
+	// с которого выплачиваются пакеты
+	ClearingAccount string
+	// Номер счета доходов банка, на который зачисляется комиссия эквайринга (балансовый счет 70601)
+	FeeIncomeAccount string
+	// Операционный день закрывается в полночь по этому часовому поясу
+	Location *time.Location
+	// Как часто проверять, не пора ли провести расчет
+	Interval time.Duration
+}
+
+func GetSettlementConfig() SettlementConfig {
+	return SettlementConfig{
+		DefaultFeeRate: decimal.RequireFromString("0.018"),
+		FeeRatesByMCC: map[string]decimal.Decimal{
+			"5411": decimal.RequireFromString("0.012"),
+			"5912": decimal.RequireFromString("0.012"),
+		},
+		ClearingAccount:  "30233810000000000001",
+		FeeIncomeAccount: "70601810400000000002",
+		Location:         time.FixedZone("MSK", 3*60*60),
+		Interval:         time.Hour,
+	}
+}
+
+// FeeRate возвращает ставку комиссии эквайринга для MCC
+func (c SettlementConfig) FeeRate(mcc string) decimal.Decimal {
+	if rate, ok := c.FeeRatesByMCC[mcc]; ok {
+		return rate
+	}
+	return c.DefaultFeeRate
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+type StandingOrderConfig struct {
+	// Еженедельные и ежемесячные поручения выполняются в RunHour:00 по Location
+	Location *time.Location
+	RunHour  int
+	// Паузы между повторами при нехватке средств; после последней попытки
+	// запуск считается неудавшимся
+	RetryBackoff []time.Duration
+	// Как часто искать поручения к исполнению
+	Interval time.Duration
+	// На сколько исполнитель блокирует поручение на время выполнения
+	Lease time.Duration
+}
+
+func GetStandingOrderConfig() StandingOrderConfig {
+	return StandingOrderConfig{
+		Location:     time.FixedZone("MSK", 3*60*60),
+		RunHour:      9,
+		RetryBackoff: []time.Duration{time.Hour, 4 * time.Hour, 12 * time.Hour},
+		Interval:     time.Minute,
+		Lease:        5 * time.Minute,
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import "time"
+
+type StatementConfig struct {
+	// Границы дней периода выписки считаются по этому часовому поясу
+	Location *time.Location
+	// Максимальная длина периода одной выписки в днях
+	MaxPeriodDays int
+	// Название банка в печатной форме выписки
+	BankName string
+	// Шрифты TrueType с кириллицей для печатной формы выписки
+	FontPath     string
+	BoldFontPath string
+	// Отправитель в файлах обмена с 1С
+	ExchangeSender string
+	// Максимальный размер загружаемого файла обмена
+	MaxExchangeFileSize int64
+}
+
+func GetStatementConfig() StatementConfig {
+	return StatementConfig{
+		Location:            time.FixedZone("MSK", 3*60*60),
+		MaxPeriodDays:       366,
+		BankName:            "SF Finances",
+		FontPath:            "data/fonts/DejaVuSans.ttf",
+		BoldFontPath:        "data/fonts/DejaVuSans-Bold.ttf",
+		ExchangeSender:      "SF Finances",
+		MaxExchangeFileSize: 5 << 20,
+	}
+}
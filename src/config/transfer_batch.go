@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+type TransferBatchConfig struct {
+	// Максимальное число переводов в одном пакете
+	MaxItems int
+	// Максимальный размер загружаемого CSV
+	MaxUploadSize int64
+	// Как часто исполнитель забирает пакеты в обработку
+	Interval time.Duration
+	// На сколько исполнитель блокирует пакет на время обработки
+	Lease time.Duration
+}
+
+func GetTransferBatchConfig() TransferBatchConfig {
+	return TransferBatchConfig{
+		MaxItems:      1000,
+		MaxUploadSize: 1 << 20,
+		Interval:      30 * time.Second,
+		Lease:         5 * time.Minute,
+	}
+}
@@ -0,0 +1,230 @@
+// Package exchange читает и пишет файлы обмена с 1С:Предприятием в формате
+// 1CClientBankExchange (версия 1.03): платежные поручения для загрузки в банк
+// и выписки по счетам для загрузки в 1С.
+//
+// Реквизиты хранятся в порядке следования в файле, включая неизвестные
+// пакету, а кодировка прочитанного файла запоминается, поэтому прочитанный и
+// записанный обратно файл с переводами строк CRLF совпадает с исходным
+// побайтно.
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidFormat = errors.New("неверный формат файла обмена 1С")
+
+const (
+	FileHeader    = "1CClientBankExchange"
+	FormatVersion = "1.03"
+
+	EncodingWindows = "Windows"
+	EncodingDOS     = "DOS"
+
+	DocumentPaymentOrder = "Платежное поручение"
+
+	dateLayout = "02.01.2006"
+	timeLayout = "15:04:05"
+)
+
+// Реквизиты заголовка файла
+const (
+	KeyFormatVersion = "ВерсияФормата"
+	KeyEncoding      = "Кодировка"
+	KeySender        = "Отправитель"
+	KeyReceiver      = "Получатель"
+	KeyCreatedDate   = "ДатаСоздания"
+	KeyCreatedTime   = "ВремяСоздания"
+	KeyDateFrom      = "ДатаНачала"
+	KeyDateTo        = "ДатаКонца"
+	KeyAccount       = "РасчСчет"
+	KeyDocumentKind  = "Документ"
+)
+
+// Реквизиты секции расчетного счета
+const (
+	KeyOpeningBalance = "НачальныйОстаток"
+	KeyTotalIn        = "ВсегоПоступило"
+	KeyTotalOut       = "ВсегоСписано"
+	KeyClosingBalance = "КонечныйОстаток"
+)
+
+// Реквизиты документа
+const (
+	KeyNumber           = "Номер"
+	KeyDate             = "Дата"
+	KeyAmount           = "Сумма"
+	KeyPayerAccount     = "ПлательщикСчет"
+	KeyPayer            = "Плательщик"
+	KeyPayerINN         = "ПлательщикИНН"
+	KeyDebitedDate      = "ДатаСписано"
+	KeyRecipientAccount = "ПолучательСчет"
+	KeyRecipient        = "Получатель"
+	KeyRecipientINN     = "ПолучательИНН"
+	KeyCreditedDate     = "ДатаПоступило"
+	KeyPaymentKind      = "ВидОплаты"
+	KeyPriority         = "Очередность"
+	KeyPurpose          = "НазначениеПлатежа"
+)
+
+const (
+	sectionAccount    = "СекцияРасчСчет"
+	sectionAccountEnd = "КонецРасчСчет"
+	sectionDocument   = "СекцияДокумент"
+	sectionDocEnd     = "КонецДокумента"
+	fileEnd           = "КонецФайла"
+)
+
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Fields - реквизиты в порядке следования в файле. Ключи могут повторяться
+// (например, РасчСчет в заголовке)
+type Fields []Field
+
+func (f Fields) Get(key string) string {
+	for _, field := range f {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+func (f Fields) GetAll(key string) []string {
+	var values []string
+	for _, field := range f {
+		if field.Key == key {
+			values = append(values, field.Value)
+		}
+	}
+	return values
+}
+
+// Set заменяет значение первого реквизита key или добавляет его в конец
+func (f *Fields) Set(key, value string) {
+	for i := range *f {
+		if (*f)[i].Key == key {
+			(*f)[i].Value = value
+			return
+		}
+	}
+	f.Add(key, value)
+}
+
+func (f *Fields) Add(key, value string) {
+	*f = append(*f, Field{Key: key, Value: value})
+}
+
+func (f Fields) date(key string) (time.Time, error) {
+	value := f.Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s=%q", ErrInvalidFormat, key, value)
+	}
+	return t, nil
+}
+
+func (f Fields) amount(key string) (decimal.Decimal, error) {
+	value := f.Get(key)
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	d, err := decimal.NewFromString(strings.Replace(value, ",", ".", 1))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("%w: %s=%q", ErrInvalidFormat, key, value)
+	}
+	return d, nil
+}
+
+func FormatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+func FormatAmount(d decimal.Decimal) string {
+	return d.StringFixed(2)
+}
+
+// Кодировки, в которых читаются файлы обмена
+const (
+	charsetUTF8    = "utf-8"
+	charsetWindows = "windows-1251"
+	charsetDOS     = "cp866"
+)
+
+type File struct {
+	Header    Fields
+	Accounts  []*AccountSection
+	Documents []*Document
+	// Кодировка, в которой файл был прочитан. Пусто у созданных файлов
+	charset string
+}
+
+// NewFile создает файл с обязательными реквизитами заголовка
+func NewFile(sender string, created time.Time) *File {
+	f := &File{}
+	f.Header.Add(KeyFormatVersion, FormatVersion)
+	f.Header.Add(KeyEncoding, EncodingWindows)
+	f.Header.Add(KeySender, sender)
+	f.Header.Add(KeyReceiver, "")
+	f.Header.Add(KeyCreatedDate, FormatDate(created))
+	f.Header.Add(KeyCreatedTime, created.Format(timeLayout))
+	return f
+}
+
+// AccountSection - остатки и обороты по счету за период (СекцияРасчСчет)
+type AccountSection struct {
+	Fields
+}
+
+func (s *AccountSection) Account() string {
+	return s.Get(KeyAccount)
+}
+
+func (s *AccountSection) OpeningBalance() (decimal.Decimal, error) {
+	return s.amount(KeyOpeningBalance)
+}
+
+func (s *AccountSection) ClosingBalance() (decimal.Decimal, error) {
+	return s.amount(KeyClosingBalance)
+}
+
+// Document - документ из секции СекцияДокумент=<Kind>
+type Document struct {
+	Kind string
+	Fields
+}
+
+func (d *Document) Number() string {
+	return d.Get(KeyNumber)
+}
+
+func (d *Document) Date() (time.Time, error) {
+	return d.date(KeyDate)
+}
+
+func (d *Document) Amount() (decimal.Decimal, error) {
+	return d.amount(KeyAmount)
+}
+
+func (d *Document) PayerAccount() string {
+	return d.Get(KeyPayerAccount)
+}
+
+func (d *Document) RecipientAccount() string {
+	return d.Get(KeyRecipientAccount)
+}
+
+func (d *Document) Purpose() string {
+	return d.Get(KeyPurpose)
+}
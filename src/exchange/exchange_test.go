@@ -0,0 +1,177 @@
+package exchange
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRoundTripFixtures(t *testing.T) {
+	tests := []struct {
+		file     string
+		charset  string
+		encoding string
+	}{
+		{"payment_orders_utf8.txt", charsetUTF8, EncodingWindows},
+		{"payment_orders_cp1251.txt", charsetWindows, EncodingWindows},
+		{"payment_orders_cp866.txt", charsetDOS, EncodingDOS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := Parse(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if f.charset != tt.charset {
+				t.Errorf("кодировка %q, ожидалась %q", f.charset, tt.charset)
+			}
+			if got := f.Header.Get(KeyEncoding); got != tt.encoding {
+				t.Errorf("Кодировка=%q, ожидалось %q", got, tt.encoding)
+			}
+
+			var buf bytes.Buffer
+			if err := Write(&buf, f); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), data) {
+				t.Errorf("записанный файл отличается от исходного:\n%q\n%q", buf.Bytes(), data)
+			}
+		})
+	}
+}
+
+func TestParseKeepsFieldOrder(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "payment_orders_cp1251.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	wantHeader := []string{KeyFormatVersion, KeyEncoding, KeySender, KeyReceiver, KeyCreatedDate, KeyCreatedTime,
+		KeyDateFrom, KeyDateTo, KeyAccount, KeyAccount, KeyDocumentKind}
+	if got := keys(f.Header); !slices.Equal(got, wantHeader) {
+		t.Errorf("реквизиты заголовка %v, ожидались %v", got, wantHeader)
+	}
+	if got := f.Header.GetAll(KeyAccount); !slices.Equal(got, []string{"40817810000000000017", "40817810000000000025"}) {
+		t.Errorf("счета заголовка %v", got)
+	}
+
+	if len(f.Accounts) != 1 || len(f.Documents) != 2 {
+		t.Fatalf("секций счетов %d, документов %d", len(f.Accounts), len(f.Documents))
+	}
+
+	// Неизвестные пакету реквизиты остаются на своих местах
+	wantDoc := []string{KeyNumber, KeyDate, KeyAmount, KeyPayerAccount, KeyPayer, KeyPayerINN, "ПлательщикКПП",
+		KeyRecipientAccount, KeyRecipient, KeyRecipientINN, KeyPaymentKind, KeyPriority, KeyPurpose, "КодНазПлатежа"}
+	doc := f.Documents[0]
+	if got := keys(doc.Fields); !slices.Equal(got, wantDoc) {
+		t.Errorf("реквизиты документа %v, ожидались %v", got, wantDoc)
+	}
+	if doc.Kind != DocumentPaymentOrder {
+		t.Errorf("вид документа %q", doc.Kind)
+	}
+	if doc.Purpose() != "Оплата по счету № 45 от 10.03.2025, без НДС" {
+		t.Errorf("назначение платежа %q", doc.Purpose())
+	}
+
+	amount, err := f.Documents[1].Amount()
+	if err != nil || !amount.Equal(decimal.RequireFromString("0.99")) {
+		t.Errorf("сумма с запятой: %s, %v", amount, err)
+	}
+	closing, err := f.Accounts[0].ClosingBalance()
+	if err != nil || !closing.Equal(decimal.RequireFromString("109699.50")) {
+		t.Errorf("конечный остаток: %s, %v", closing, err)
+	}
+}
+
+func TestWriteNewFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+	}{
+		{"windows", EncodingWindows},
+		{"dos", EncodingDOS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFile("SF Finances", time.Date(2025, 3, 15, 10, 42, 7, 0, time.UTC))
+			f.Header.Set(KeyEncoding, tt.encoding)
+			f.Header.Add(KeyAccount, "40817810000000000017")
+			doc := &Document{Kind: DocumentPaymentOrder}
+			doc.Add(KeyNumber, "1")
+			doc.Add(KeyAmount, FormatAmount(decimal.RequireFromString("10.5")))
+			doc.Add(KeyPurpose, "Перевод\nмежду счетами")
+			f.Documents = append(f.Documents, doc)
+
+			var buf bytes.Buffer
+			if err := Write(&buf, f); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			parsed, err := Parse(&buf)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if !slices.Equal(parsed.Header, f.Header) {
+				t.Errorf("заголовок %v, ожидался %v", parsed.Header, f.Header)
+			}
+			if len(parsed.Documents) != 1 {
+				t.Fatalf("документов %d", len(parsed.Documents))
+			}
+			got := parsed.Documents[0]
+			if got.Get(KeyAmount) != "10.50" {
+				t.Errorf("Сумма=%q", got.Get(KeyAmount))
+			}
+			// Перевод строки в значении заменяется пробелом
+			if got.Purpose() != "Перевод между счетами" {
+				t.Errorf("НазначениеПлатежа=%q", got.Purpose())
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"пустой файл", ""},
+		{"нет заголовка", "ВерсияФормата=1.03\r\nКонецФайла\r\n"},
+		{"нет конца файла", "1CClientBankExchange\r\nВерсияФормата=1.03\r\n"},
+		{"незакрытая секция", "1CClientBankExchange\r\nСекцияДокумент=Платежное поручение\r\nКонецФайла\r\n"},
+		{"вложенная секция", "1CClientBankExchange\r\nСекцияРасчСчет\r\nСекцияДокумент=Платежное поручение\r\n"},
+		{"строка без значения", "1CClientBankExchange\r\nВерсияФормата\r\nКонецФайла\r\n"},
+		{"данные после конца", "1CClientBankExchange\r\nКонецФайла\r\nНомер=1\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader([]byte(tt.text))); err == nil {
+				t.Error("ожидалась ошибка")
+			}
+		})
+	}
+}
+
+func keys(fields Fields) []string {
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		result = append(result, field.Key)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package exchange
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Parse читает файл обмена. Кодировка определяется по содержимому: UTF-8,
+// Windows-1251 (Кодировка=Windows) или CP866 (Кодировка=DOS)
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text, charset, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := parseText(text)
+	if err != nil {
+		return nil, err
+	}
+	f.charset = charset
+	return f, nil
+}
+
+// decode возвращает текст файла и кодировку, в которой он записан
+func decode(data []byte) (string, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return string(data), charsetUTF8, nil
+	}
+
+	// В CP866 и Windows-1251 кириллица кодируется разными байтами, поэтому
+	// строка "Кодировка=DOS" найдется только при верной кодировке
+	dos, err := charmap.CodePage866.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.Contains(string(dos), KeyEncoding+"="+EncodingDOS) {
+		return string(dos), charsetDOS, nil
+	}
+
+	win, err := charmap.Windows1251.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", err
+	}
+	return string(win), charsetWindows, nil
+}
+
+func parseText(text string) (*File, error) {
+	f := &File{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var (
+		lineNo   int
+		started  bool
+		finished bool
+		account  *AccountSection
+		document *Document
+	)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if !started {
+			if strings.TrimSpace(line) != FileHeader {
+				return nil, fmt.Errorf("%w: файл должен начинаться с %s", ErrInvalidFormat, FileHeader)
+			}
+			started = true
+			continue
+		}
+		if finished {
+			return nil, fmt.Errorf("%w: строка %d: данные после %s", ErrInvalidFormat, lineNo, fileEnd)
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+
+		switch {
+		case key == fileEnd:
+			if account != nil || document != nil {
+				return nil, fmt.Errorf("%w: строка %d: незакрытая секция", ErrInvalidFormat, lineNo)
+			}
+			finished = true
+
+		case key == sectionAccount:
+			if account != nil || document != nil {
+				return nil, fmt.Errorf("%w: строка %d: вложенная секция", ErrInvalidFormat, lineNo)
+			}
+			account = &AccountSection{}
+
+		case key == sectionAccountEnd:
+			if account == nil {
+				return nil, fmt.Errorf("%w: строка %d: %s без начала секции", ErrInvalidFormat, lineNo, key)
+			}
+			f.Accounts = append(f.Accounts, account)
+			account = nil
+
+		case key == sectionDocument:
+			if account != nil || document != nil {
+				return nil, fmt.Errorf("%w: строка %d: вложенная секция", ErrInvalidFormat, lineNo)
+			}
+			document = &Document{Kind: strings.TrimSpace(value)}
+
+		case key == sectionDocEnd:
+			if document == nil {
+				return nil, fmt.Errorf("%w: строка %d: %s без начала секции", ErrInvalidFormat, lineNo, key)
+			}
+			f.Documents = append(f.Documents, document)
+			document = nil
+
+		case !hasValue || key == "":
+			return nil, fmt.Errorf("%w: строка %d: ожидается Реквизит=Значение", ErrInvalidFormat, lineNo)
+
+		case account != nil:
+			account.Add(key, value)
+		case document != nil:
+			document.Add(key, value)
+		default:
+			f.Header.Add(key, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !started {
+		return nil, fmt.Errorf("%w: пустой файл", ErrInvalidFormat)
+	}
+	if !finished {
+		return nil, fmt.Errorf("%w: нет строки %s", ErrInvalidFormat, fileEnd)
+	}
+	return f, nil
+}
@@ -0,0 +1,57 @@
+package exchange
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Write записывает файл обмена с переводами строк CRLF. Прочитанный файл
+// записывается в исходной кодировке, созданный - в кодировке из реквизита
+// Кодировка заголовка (по умолчанию Windows-1251). Символы, которых нет в
+// кодировке, заменяются
+func Write(w io.Writer, f *File) error {
+	var bw *bufio.Writer
+	switch {
+	case f.charset == charsetUTF8:
+		bw = bufio.NewWriter(w)
+	case f.charset == charsetDOS, f.charset == "" && f.Header.Get(KeyEncoding) == EncodingDOS:
+		bw = bufio.NewWriter(encoding.ReplaceUnsupported(charmap.CodePage866.NewEncoder()).Writer(w))
+	default:
+		bw = bufio.NewWriter(encoding.ReplaceUnsupported(charmap.Windows1251.NewEncoder()).Writer(w))
+	}
+
+	writeLine(bw, FileHeader)
+	writeFields(bw, f.Header)
+	for _, account := range f.Accounts {
+		writeLine(bw, sectionAccount)
+		writeFields(bw, account.Fields)
+		writeLine(bw, sectionAccountEnd)
+	}
+	for _, document := range f.Documents {
+		writeLine(bw, sectionDocument+"="+document.Kind)
+		writeFields(bw, document.Fields)
+		writeLine(bw, sectionDocEnd)
+	}
+	writeLine(bw, fileEnd)
+
+	return bw.Flush()
+}
+
+// Значение реквизита занимает одну строку, переводы строк заменяются пробелами
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+func writeFields(bw *bufio.Writer, fields Fields) {
+	for _, field := range fields {
+		writeLine(bw, field.Key+"="+lineBreaks.Replace(field.Value))
+	}
+}
+
+// Ошибки записи накапливаются в bufio.Writer и возвращаются из Flush
+func writeLine(bw *bufio.Writer, line string) {
+	bw.WriteString(line)
+	bw.WriteString("\r\n")
+}
@@ -8,9 +8,11 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"sf-finances/src/banking"
 	"sf-finances/src/types"
 	"sf-finances/src/middlewares"
 	"sf-finances/src/models"
+	"sf-finances/src/repository"
 	"sf-finances/src/services"
 )
 
@@ -54,13 +56,7 @@ func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := types.AccountRes{
-		ID:        newAccount.ID,
-		UserID:    newAccount.UserID,
-		Balance:   newAccount.Balance,
-		Currency:  newAccount.Currency,
-		CreatedAt: newAccount.CreatedAt.Format("2006-01-02T15:04:05Z"),
-	}
+	resp := newAccountRes(newAccount)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -89,13 +85,7 @@ func (h *AccountHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, acc := range accounts {
-		resp.Accounts = append(resp.Accounts, types.AccountRes{
-			ID:        acc.ID,
-			UserID:    acc.UserID,
-			Balance:   acc.Balance,
-			Currency:  acc.Currency,
-			CreatedAt: acc.CreatedAt.Format("2025-05-04T18:39:05Z"),
-		})
+		resp.Accounts = append(resp.Accounts, newAccountRes(acc))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -104,6 +94,37 @@ func (h *AccountHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *AccountHandler) GetAccountByNumber(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	acc, err := h.accountService.GetUserAccountByNumber(r.Context(), mux.Vars(r)["number"], userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, banking.ErrInvalidAccountNumber):
+			h.logger.Warnf("Неверный номер счета: %v", err)
+			http.Error(w, "Неверный номер счета", http.StatusBadRequest)
+		case errors.Is(err, services.ErrAccountNotFound):
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+		default:
+			h.logger.Errorf("Ошибка получения счета: %v", err)
+			http.Error(w, "Не удалось получить данные счета", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := newAccountRes(acc)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
 func (h *AccountHandler) UpdateBalance(w http.ResponseWriter, r *http.Request) {
 	userID, err := middlewares.GetUserID(r.Context())
 	if err != nil {
@@ -130,6 +151,12 @@ func (h *AccountHandler) UpdateBalance(w http.ResponseWriter, r *http.Request) {
 	err = h.accountService.UpdateBalance(r.Context(), accountID, userID, req.Amount)
 	if err != nil {
 		switch {
+		case errors.Is(err, services.ErrAccountFrozen):
+			http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+		case errors.Is(err, services.ErrAccountClosed):
+			http.Error(w, "Счет закрыт", http.StatusConflict)
+		case errors.Is(err, services.ErrAccountLocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
 		case errors.Is(err, services.ErrInsufficientFunds):
 			h.logger.Warnf("Недостаточно средств: %v", err)
 			http.Error(w, "Недостаточно средств", http.StatusBadRequest)
@@ -147,13 +174,7 @@ func (h *AccountHandler) UpdateBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := types.AccountRes{
-		ID:        updatedAccount.ID,
-		UserID:    updatedAccount.UserID,
-		Balance:   updatedAccount.Balance,
-		Currency:  updatedAccount.Currency,
-		CreatedAt: updatedAccount.CreatedAt.Format("2025-05-04T18:39:05Z"),
-	}
+	resp := newAccountRes(updatedAccount)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -176,9 +197,19 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.accountService.Transfer(r.Context(), req.FromAccountID, req.ToAccountID, userID, req.Amount)
+	if req.ToAccountNumber != "" {
+		err = h.accountService.TransferByNumber(r.Context(), req.FromAccountID, userID, req.ToAccountNumber, req.Amount, nil)
+	} else {
+		err = h.accountService.Transfer(r.Context(), req.FromAccountID, req.ToAccountID, userID, req.Amount)
+	}
 	if err != nil {
 		switch {
+		case errors.Is(err, services.ErrAccountFrozen):
+			http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+		case errors.Is(err, services.ErrAccountClosed):
+			http.Error(w, "Счет закрыт", http.StatusConflict)
+		case errors.Is(err, services.ErrAccountLocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
 		case errors.Is(err, services.ErrInsufficientFunds):
 			h.logger.Warnf("Недостаточно средств: %v", err)
 			http.Error(w, "Недостаточно средств", http.StatusBadRequest)
@@ -188,6 +219,15 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, services.ErrNegativeAmount):
 			h.logger.Warnf("перевод отрицательной суммы: %v", err)
 			http.Error(w, "Сумма перевода должна быть положительной", http.StatusBadRequest)
+		case errors.Is(err, banking.ErrInvalidAccountNumber):
+			h.logger.Warnf("Неверный номер счета получателя: %v", err)
+			http.Error(w, "Неверный номер счета получателя", http.StatusBadRequest)
+		case errors.Is(err, services.ErrAccountNotFound):
+			h.logger.Warnf("Счет получателя не найден (пользователь %d): %v", userID, err)
+			http.Error(w, "Счет получателя не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrCurrencyMismatch):
+			h.logger.Warnf("Перевод между счетами в разных валютах: %v", err)
+			http.Error(w, "Счета в разных валютах", http.StatusBadRequest)
 		default:
 			h.logger.Errorf("Ошибка перевода: %v", err)
 			http.Error(w, "Не удалось перевести", http.StatusInternalServerError)
@@ -236,6 +276,9 @@ func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 			Amount:    tx.Amount,
 			Type:      tx.Type,
 			Status:    tx.Status,
+			CounterpartyAccountID: tx.CounterpartyAccountID,
+			CounterpartyAccountNumber: tx.CounterpartyAccountNumber,
+			Description:           tx.Description,
 			CreatedAt: tx.CreatedAt.Format("2025-05-04T18:39:05Z"),
 		})
 	}
@@ -244,4 +287,163 @@ func (h *AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		h.logger.Errorf("Ошибка кодирования: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func (h *AccountHandler) SetNickname(w http.ResponseWriter, r *http.Request) {
+	userID, accountID, ok := h.userAndAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.SetAccountNicknameReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	acc, err := h.accountService.SetNickname(r.Context(), accountID, userID, req.Nickname)
+	if err != nil {
+		h.writeLifecycleError(w, accountID, err)
+		return
+	}
+	h.writeAccount(w, acc)
+}
+
+// CloseAccount закрывает счет пользователя, при необходимости переводя
+// остаток на другой его счет
+func (h *AccountHandler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	userID, accountID, ok := h.userAndAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.CloseAccountReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.logger.Errorf("Ошибка декодирования: %v", err)
+			http.Error(w, "Неверный формат", http.StatusBadRequest)
+			return
+		}
+	}
+
+	acc, err := h.accountService.Close(r.Context(), accountID, userID, req.SweepToAccountID)
+	if err != nil {
+		h.writeLifecycleError(w, accountID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d закрыл счет %d", userID, accountID)
+	h.writeAccount(w, acc)
+}
+
+func (h *AccountHandler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	adminID, accountID, ok := h.userAndAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.FreezeAccountReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	acc, err := h.accountService.Freeze(r.Context(), accountID, adminID, req.Reason)
+	if err != nil {
+		h.writeLifecycleError(w, accountID, err)
+		return
+	}
+
+	h.logger.Infof("Администратор %d заморозил счет %d: %s", adminID, accountID, req.Reason)
+	h.writeAccount(w, acc)
+}
+
+func (h *AccountHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	adminID, accountID, ok := h.userAndAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	acc, err := h.accountService.Unfreeze(r.Context(), accountID)
+	if err != nil {
+		h.writeLifecycleError(w, accountID, err)
+		return
+	}
+
+	h.logger.Infof("Администратор %d разморозил счет %d", adminID, accountID)
+	h.writeAccount(w, acc)
+}
+
+func (h *AccountHandler) userAndAccountID(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Warnf("Неверный ID счета: %v", err)
+		http.Error(w, "Неверный ID счета", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, accountID, true
+}
+
+func (h *AccountHandler) writeLifecycleError(w http.ResponseWriter, accountID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		http.Error(w, "Счет не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidNickname), errors.Is(err, services.ErrFreezeReason):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountNotEmpty):
+		http.Error(w, "На счете остались средства: укажите счет для перевода остатка", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountFrozen):
+		http.Error(w, "Счет заморожен", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountNotFrozen):
+		http.Error(w, "Счет не заморожен", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountClosed):
+		http.Error(w, "Счет закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountLocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrSameAccount):
+		http.Error(w, "Остаток нельзя перевести на закрываемый счет", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCurrencyMismatch):
+		http.Error(w, "Счет для остатка в другой валюте", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrAccountStatusConflict):
+		http.Error(w, "Состояние счета изменилось, повторите запрос", http.StatusConflict)
+	default:
+		h.logger.Errorf("Ошибка изменения счета %d: %v", accountID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
+
+func (h *AccountHandler) writeAccount(w http.ResponseWriter, acc *models.Account) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newAccountRes(acc)); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func newAccountRes(acc *models.Account) types.AccountRes {
+	res := types.AccountRes{
+		ID:             acc.ID,
+		UserID:         acc.UserID,
+		Number:         acc.Number,
+		Nickname:       acc.Nickname,
+		Balance:        acc.Balance,
+		Currency:       acc.Currency,
+		Status:         acc.Status,
+		FreezeReason:   acc.FreezeReason,
+		OverdraftLimit: acc.OverdraftLimit,
+		CreatedAt:      acc.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if acc.ClosedAt != nil {
+		closedAt := acc.ClosedAt.Format("2006-01-02T15:04:05Z")
+		res.ClosedAt = &closedAt
+	}
+	return res
+}
@@ -2,9 +2,9 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -46,17 +46,39 @@ func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	card, cardDetails, err := h.cardService.CreateCard(r.Context(), userID, req.PGPKey)
+	if req.AccountID == 0 {
+		h.logger.Warn("Не указан счет карты")
+		http.Error(w, "Счет карты обязателен", http.StatusBadRequest)
+		return
+	}
+
+	card, cardDetails, err := h.cardService.CreateCard(r.Context(), userID, req)
 	if err != nil {
-		h.logger.Errorf("Ошибка создания карты: %v", err)
-		http.Error(w, "Не удалось создать карту", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, services.ErrUnknownCardProduct):
+			h.logger.Warnf("Неизвестный продукт карты: %s", req.Product)
+			http.Error(w, "Неизвестный продукт карты", http.StatusBadRequest)
+		case errors.Is(err, services.ErrUnknownCardType):
+			h.logger.Warnf("Неизвестный тип карты: %s", req.Type)
+			http.Error(w, "Неизвестный тип карты", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidPaymentLimit):
+			h.logger.Warnf("Неверный лимит карты: %v", req.PaymentLimit)
+			http.Error(w, "Лимит платежа должен быть положительным", http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка создания карты: %v", err)
+			http.Error(w, "Не удалось создать карту", http.StatusInternalServerError)
+		}
 		return
 	}
 
 	resp := types.CreateCardRes{
 		ID:         card.ID,
 		UserID:     card.UserID,
+		AccountID:  card.AccountID,
+		Product:    card.Product,
+		Type:       card.Type,
 		CreatedAt:  card.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		ExpiresAt:  card.ExpiresAt.Format("2006-01-02T15:04:05Z"),
 		CardNumber: cardDetails["number"],
 		Expire:     cardDetails["expire"],
 		CVV:        cardDetails["cvv"],
@@ -92,6 +114,11 @@ func (h *CardHandler) GetCards(w http.ResponseWriter, r *http.Request) {
 		resp.Cards = append(resp.Cards, types.CardRes{
 			ID:        card.ID,
 			UserID:    card.UserID,
+			AccountID: card.AccountID,
+			Product:   card.Product,
+			Type:      card.Type,
+			Status:    card.Status,
+			ExpiresAt: card.ExpiresAt.Format("2006-01-02T15:04:05Z"),
 			CreatedAt: card.CreatedAt.Format("2025-05-04T18:39:05Z"),
 		})
 	}
@@ -144,42 +171,205 @@ func (h *CardHandler) GetCardDetails(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *CardHandler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
-	var req types.PaymentReq
+
+func (h *CardHandler) ActivateCard(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	cardID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.logger.Warnf("Неверный формат ID: %v", err)
+		http.Error(w, "Неверный ID карты", http.StatusBadRequest)
+		return
+	}
+
+	var req types.ActivateCardReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Errorf("Ошибка декодирования: %v", err)
 		http.Error(w, "Неверный формат", http.StatusBadRequest)
 		return
 	}
 
-	if req.CardID == 0 || req.CVV == "" || req.Amount == "" || req.PGPKey == "" {
-		h.logger.Warn("Отсутствуют обязательные поля")
-		http.Error(w, "Все поля обязательны", http.StatusBadRequest)
+	if req.PGPKey == "" {
+		h.logger.Warn("Нет PGP ключа")
+		http.Error(w, "PGP ключ обязателен", http.StatusBadRequest)
 		return
 	}
 
-	isValid, err := h.cardService.VerifyCardPayment(r.Context(), req.CardID, req.CVV, req.PGPKey)
+	card, cardDetails, err := h.cardService.ActivateCard(r.Context(), cardID, userID, req.PGPKey)
 	if err != nil {
-		h.logger.Errorf("Ошибка проверки данных карты: %v", err)
-		http.Error(w, "Ошибка проверки данных карты", http.StatusBadRequest)
+		switch {
+		case errors.Is(err, services.ErrCardNotFound), errors.Is(err, services.ErrCardAccessDenied):
+			h.logger.Warnf("Карта %d не найдена: %v", cardID, err)
+			http.Error(w, "Карта не найдена", http.StatusNotFound)
+		case errors.Is(err, services.ErrCardAlreadyActive):
+			http.Error(w, "Карта не ожидает активации", http.StatusConflict)
+		default:
+			h.logger.Errorf("Ошибка активации карты: %v", err)
+			http.Error(w, "Не удалось активировать карту", http.StatusInternalServerError)
+		}
 		return
 	}
 
-	if !isValid {
-		h.logger.Warn("Неверные данные карты")
-		http.Error(w, "Неверные данные карты", http.StatusBadRequest)
+	resp := types.CreateCardRes{
+		ID:         card.ID,
+		UserID:     card.UserID,
+		AccountID:  card.AccountID,
+		Product:    card.Product,
+		Type:       card.Type,
+		CreatedAt:  card.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		ExpiresAt:  card.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+		CardNumber: cardDetails["number"],
+		Expire:     cardDetails["expire"],
+		CVV:        cardDetails["cvv"],
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *CardHandler) SetPIN(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	cardID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.logger.Warnf("Неверный формат ID: %v", err)
+		http.Error(w, "Неверный ID карты", http.StatusBadRequest)
 		return
 	}
 
-	paymentID := strconv.FormatInt(time.Now().UnixNano(), 10)
-	resp := types.PaymentRes{
-		Success:     true,
-		PaymentID:   paymentID,
-		Description: "Платеж обработан",
+	var req types.SetPINReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	err = h.cardService.SetPIN(r.Context(), cardID, userID, req)
+	if err != nil {
+		h.writePINError(w, cardID, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// RequestPINReset отправляет владельцу карты код, без которого не сбросить PIN
+func (h *CardHandler) RequestPINReset(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	cardID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.logger.Warnf("Неверный формат ID: %v", err)
+		http.Error(w, "Неверный ID карты", http.StatusBadRequest)
+		return
+	}
+
+	err = h.cardService.RequestPINReset(r.Context(), cardID, userID)
+	if err != nil {
+		h.writePINError(w, cardID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "code_sent"}); err != nil {
 		h.logger.Errorf("Ошибка кодирования: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func (h *CardHandler) ResetPIN(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	cardID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.logger.Warnf("Неверный формат ID: %v", err)
+		http.Error(w, "Неверный ID карты", http.StatusBadRequest)
+		return
+	}
+
+	var req types.ResetPINReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	if req.CVV == "" {
+		h.logger.Warn("Нет CVV для сброса PIN")
+		http.Error(w, "CVV обязателен", http.StatusBadRequest)
+		return
+	}
+
+	if req.Code == "" {
+		h.logger.Warn("Нет кода для сброса PIN")
+		http.Error(w, "Код подтверждения обязателен", http.StatusBadRequest)
+		return
+	}
+
+	err = h.cardService.ResetPIN(r.Context(), cardID, userID, req)
+	if err != nil {
+		h.writePINError(w, cardID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *CardHandler) writePINError(w http.ResponseWriter, cardID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPINFormat):
+		http.Error(w, "PIN должен состоять из 4 цифр", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCardNotFound):
+		http.Error(w, "Карта не найдена", http.StatusNotFound)
+	case errors.Is(err, services.ErrCardAccessDenied):
+		h.logger.Warnf("Попытка доступа к чужой карте %d", cardID)
+		http.Error(w, "Карта не найдена", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidPIN), errors.Is(err, services.ErrInvalidCVV):
+		h.logger.Warnf("Неверные данные карты %d: %v", cardID, err)
+		http.Error(w, "Неверные данные карты", http.StatusForbidden)
+	case errors.Is(err, services.ErrCardBlocked):
+		h.logger.Warnf("Карта %d заблокирована", cardID)
+		http.Error(w, "Карта заблокирована", http.StatusForbidden)
+	case errors.Is(err, services.ErrCardInactive):
+		http.Error(w, "Карта не активна", http.StatusBadRequest)
+	case errors.Is(err, services.ErrPINResetNotRequested), errors.Is(err, services.ErrPINResetCodeExpired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrPINResetCodeInvalid), errors.Is(err, services.ErrPINResetAttempts):
+		h.logger.Warnf("Неверный код сброса PIN карты %d: %v", cardID, err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		h.logger.Errorf("Ошибка установки PIN: %v", err)
+		http.Error(w, "Не удалось установить PIN", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type DepositHandler struct {
+	depositService *services.DepositService
+	logger         *logrus.Logger
+}
+
+func NewDepositHandler(depositService *services.DepositService, logger *logrus.Logger) *DepositHandler {
+	return &DepositHandler{
+		depositService: depositService,
+		logger:         logger,
+	}
+}
+
+func (h *DepositHandler) GetRates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.DepositRatesRes{Rates: h.depositService.GetRates()}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *DepositHandler) OpenDeposit(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.OpenDepositReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.depositService.OpenDeposit(r.Context(), userID, req)
+	if err != nil {
+		h.writeError(w, 0, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d открыл вклад %d (%s) на %s руб.", userID, res.Deposit.ID, res.Deposit.Type,
+		res.Balance.StringFixed(2))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *DepositHandler) GetDeposits(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	deposits, err := h.depositService.GetUserDeposits(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения вкладов: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.DepositListRes{Deposits: deposits}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *DepositHandler) GetDeposit(w http.ResponseWriter, r *http.Request) {
+	userID, depositID, ok := h.userAndDepositID(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.depositService.GetDeposit(r.Context(), depositID, userID)
+	if err != nil {
+		h.writeError(w, depositID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// CloseDeposit закрывает вклад с переводом средств на счет to_account_id
+func (h *DepositHandler) CloseDeposit(w http.ResponseWriter, r *http.Request) {
+	userID, depositID, ok := h.userAndDepositID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.CloseDepositReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.depositService.CloseDeposit(r.Context(), depositID, userID, req)
+	if err != nil {
+		h.writeError(w, depositID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d закрыл вклад %d", userID, depositID)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *DepositHandler) userAndDepositID(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	depositID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID вклада: %v", err)
+		http.Error(w, "Неверный ID вклада", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, depositID, true
+}
+
+func (h *DepositHandler) writeError(w http.ResponseWriter, depositID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrDepositNotFound):
+		h.logger.Warnf("Вклад %d не найден", depositID)
+		http.Error(w, "Вклад не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrDepositClosed):
+		http.Error(w, "Вклад уже закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidDeposit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrNegativeAmount):
+		http.Error(w, "Сумма не может быть отрицательной", http.StatusBadRequest)
+	case errors.Is(err, services.ErrSameAccount):
+		http.Error(w, "Средства вклада нельзя перевести на счет самого вклада", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCurrencyMismatch):
+		http.Error(w, "Вклады открываются только в рублях", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountFrozen):
+		http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+	case errors.Is(err, services.ErrAccountClosed):
+		http.Error(w, "Счет закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrInsufficientFunds):
+		h.logger.Warnf("Недостаточно средств для открытия вклада: %v", err)
+		http.Error(w, "Недостаточно средств", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountNotFound):
+		http.Error(w, "Счет не найден", http.StatusNotFound)
+	default:
+		h.logger.Errorf("Ошибка операции по вкладу %d: %v", depositID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type DisputeHandler struct {
+	disputeService *services.DisputeService
+	logger         *logrus.Logger
+}
+
+func NewDisputeHandler(disputeService *services.DisputeService, logger *logrus.Logger) *DisputeHandler {
+	return &DisputeHandler{
+		disputeService: disputeService,
+		logger:         logger,
+	}
+}
+
+func (h *DisputeHandler) OpenDispute(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID платежа: %v", err)
+		http.Error(w, "Неверный ID платежа", http.StatusBadRequest)
+		return
+	}
+
+	var req types.OpenDisputeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.disputeService.OpenDispute(r.Context(), paymentID, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDisputeReason):
+			h.logger.Warnf("Неизвестный код причины спора: %s", req.ReasonCode)
+			http.Error(w, "Неизвестный код причины спора", http.StatusBadRequest)
+		case errors.Is(err, services.ErrPaymentNotFound):
+			h.logger.Warnf("Платеж %d не найден", paymentID)
+			http.Error(w, "Платеж не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrPaymentNotDisputable):
+			h.logger.Warnf("Платеж %d нельзя оспорить", paymentID)
+			http.Error(w, "Оспорить можно только списанный платеж", http.StatusConflict)
+		case errors.Is(err, services.ErrDisputeWindowExpired):
+			h.logger.Warnf("Истек срок оспаривания платежа %d", paymentID)
+			http.Error(w, "Истек срок оспаривания платежа", http.StatusConflict)
+		case errors.Is(err, repository.ErrDisputeExists):
+			h.logger.Warnf("По платежу %d уже открыт спор", paymentID)
+			http.Error(w, "По платежу уже открыт спор", http.StatusConflict)
+		default:
+			h.logger.Errorf("Ошибка открытия спора: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Пользователь %d открыл спор %d по платежу %d", userID, dispute.ID, paymentID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(dispute); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *DisputeHandler) GetDisputes(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	disputes, err := h.disputeService.GetUserDisputes(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения споров: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeDisputes(w, disputes)
+}
+
+func (h *DisputeHandler) GetDispute(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	disputeID, ok := h.parseDisputeID(w, r)
+	if !ok {
+		return
+	}
+
+	dispute, err := h.disputeService.GetDispute(r.Context(), disputeID, userID)
+	if err != nil {
+		h.writeDisputeError(w, disputeID, err)
+		return
+	}
+
+	h.writeDispute(w, dispute)
+}
+
+func (h *DisputeHandler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	status := models.DisputeStatus(r.URL.Query().Get("status"))
+
+	disputes, err := h.disputeService.ListDisputes(r.Context(), status)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения споров: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeDisputes(w, disputes)
+}
+
+func (h *DisputeHandler) StartReview(w http.ResponseWriter, r *http.Request) {
+	adminID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	disputeID, ok := h.parseDisputeID(w, r)
+	if !ok {
+		return
+	}
+
+	dispute, err := h.disputeService.StartReview(r.Context(), disputeID)
+	if err != nil {
+		h.writeDisputeError(w, disputeID, err)
+		return
+	}
+
+	h.logger.Infof("Администратор %d взял на рассмотрение спор %d", adminID, disputeID)
+	h.writeDispute(w, dispute)
+}
+
+func (h *DisputeHandler) ResolveDispute(w http.ResponseWriter, r *http.Request) {
+	adminID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	disputeID, ok := h.parseDisputeID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.ResolveDisputeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.disputeService.Resolve(r.Context(), disputeID, adminID, req)
+	if err != nil {
+		h.writeDisputeError(w, disputeID, err)
+		return
+	}
+
+	h.logger.Infof("Администратор %d закрыл спор %d: %s", adminID, disputeID, dispute.Status)
+	h.writeDispute(w, dispute)
+}
+
+func (h *DisputeHandler) parseDisputeID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	disputeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID спора: %v", err)
+		http.Error(w, "Неверный ID спора", http.StatusBadRequest)
+		return 0, false
+	}
+	return disputeID, true
+}
+
+func (h *DisputeHandler) writeDisputeError(w http.ResponseWriter, disputeID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrDisputeNotFound):
+		h.logger.Warnf("Спор %d не найден", disputeID)
+		http.Error(w, "Спор не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidDisputeOutcome):
+		http.Error(w, "Решение по спору должно быть WON или LOST", http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidDisputeTransition):
+		h.logger.Warnf("Недопустимая смена статуса спора %d", disputeID)
+		http.Error(w, "Недопустимая смена статуса спора", http.StatusConflict)
+	default:
+		h.logger.Errorf("Ошибка обработки спора %d: %v", disputeID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
+
+func (h *DisputeHandler) writeDispute(w http.ResponseWriter, dispute *models.Dispute) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dispute); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *DisputeHandler) writeDisputes(w http.ResponseWriter, disputes []*models.Dispute) {
+	if disputes == nil {
+		disputes = []*models.Dispute{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.DisputeListRes{Disputes: disputes}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/config"
+	"sf-finances/src/exchange"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/services"
+)
+
+type ExchangeHandler struct {
+	exchangeService *services.ExchangeService
+	statementCfg    config.StatementConfig
+	logger          *logrus.Logger
+}
+
+func NewExchangeHandler(exchangeService *services.ExchangeService, statementCfg config.StatementConfig, logger *logrus.Logger) *ExchangeHandler {
+	return &ExchangeHandler{
+		exchangeService: exchangeService,
+		statementCfg:    statementCfg,
+		logger:          logger,
+	}
+}
+
+// ImportPaymentOrders принимает файл обмена телом запроса или в поле file
+// формы multipart/form-data
+func (h *ExchangeHandler) ImportPaymentOrders(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.statementCfg.MaxExchangeFileSize)
+
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			h.logger.Errorf("Ошибка чтения файла обмена: %v", err)
+			http.Error(w, "Неверный формат", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	res, err := h.exchangeService.ImportPaymentOrders(r.Context(), userID, body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			http.Error(w, "Слишком большой файл", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, exchange.ErrInvalidFormat):
+			h.logger.Warnf("Неверный файл обмена 1С от пользователя %d: %v", userID, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка загрузки файла обмена 1С: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Пользователь %d загрузил файл обмена 1С: исполнено %d, ошибок %d, пропущено %d",
+		userID, res.Succeeded, res.Failed, res.Skipped)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// ExportStatement отдает выписку по счету в формате 1CClientBankExchange.
+// Период задается параметрами from и to (YYYY-MM-DD, включительно)
+func (h *ExchangeHandler) ExportStatement(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID счета: %v", err)
+		http.Error(w, "Неверный ID счета", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := services.ParseStatementPeriod(query.Get("from"), query.Get("to"), h.statementCfg, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, err := h.exchangeService.ExportStatement(r.Context(), accountID, userID, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountNotFound):
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrInvalidPeriod):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка выгрузки выписки в 1С по счету %d: %v", accountID, err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=windows-1251")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="kl_to_1c_%d.txt"`, accountID))
+	if err := exchange.Write(w, file); err != nil {
+		h.logger.Errorf("Ошибка записи файла обмена: %v", err)
+	}
+}
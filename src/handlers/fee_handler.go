@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type FeeHandler struct {
+	feeService *services.FeeService
+	logger     *logrus.Logger
+}
+
+func NewFeeHandler(feeService *services.FeeService, logger *logrus.Logger) *FeeHandler {
+	return &FeeHandler{
+		feeService: feeService,
+		logger:     logger,
+	}
+}
+
+// Preview показывает комиссию до проведения операции:
+// GET /fees/preview?operation=P2P_TRANSFER&amount=5000
+func (h *FeeHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	amount, err := decimal.NewFromString(query.Get("amount"))
+	if err != nil {
+		http.Error(w, "Неверная сумма", http.StatusBadRequest)
+		return
+	}
+	operation := models.FeeOperation(query.Get("operation"))
+
+	quote, err := h.feeService.Preview(r.Context(), userID, operation, amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownFeeOperation):
+			http.Error(w, "Неизвестный вид операции", http.StatusBadRequest)
+		case errors.Is(err, services.ErrNegativeAmount):
+			http.Error(w, "Сумма должна быть положительной", http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка расчета комиссии: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	res := types.FeePreviewRes{Quote: quote, Total: quote.Amount.Add(quote.Fee)}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// GetAccountFees возвращает комиссии, взятые по счету пользователя
+func (h *FeeHandler) GetAccountFees(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Warnf("Неверный ID счета: %v", err)
+		http.Error(w, "Неверный ID счета", http.StatusBadRequest)
+		return
+	}
+
+	fees, err := h.feeService.GetAccountFees(r.Context(), accountID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) {
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("Ошибка получения комиссий по счету %d: %v", accountID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.FeeListRes{Fees: fees}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
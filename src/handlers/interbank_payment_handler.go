@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/banking"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type InterbankPaymentHandler struct {
+	paymentService *services.InterbankPaymentService
+	logger         *logrus.Logger
+}
+
+func NewInterbankPaymentHandler(paymentService *services.InterbankPaymentService, logger *logrus.Logger) *InterbankPaymentHandler {
+	return &InterbankPaymentHandler{
+		paymentService: paymentService,
+		logger:         logger,
+	}
+}
+
+func (h *InterbankPaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.CreateInterbankPaymentReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.paymentService.CreatePayment(r.Context(), userID, req)
+	if err != nil {
+		var validationErr *services.InterbankPaymentValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			h.logger.Warnf("Платежное поручение пользователя %d отклонено: %v", userID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			if err := json.NewEncoder(w).Encode(types.FieldErrorsRes{Errors: validationErr.Errors}); err != nil {
+				h.logger.Errorf("Ошибка кодирования: %v", err)
+			}
+		case errors.Is(err, services.ErrAccountNotFound):
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrCurrencyMismatch):
+			http.Error(w, "Платежи в другие банки возможны только со счета в рублях", http.StatusBadRequest)
+		case errors.Is(err, services.ErrAccountFrozen):
+			http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+		case errors.Is(err, services.ErrAccountClosed):
+			http.Error(w, "Счет закрыт", http.StatusConflict)
+		case errors.Is(err, services.ErrAccountLocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, services.ErrInsufficientFunds):
+			h.logger.Warnf("Недостаточно средств для платежного поручения пользователя %d", userID)
+			http.Error(w, "Недостаточно средств", http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка создания платежного поручения: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Пользователь %d создал платежное поручение %d на %s руб. в банк %s",
+		userID, payment.ID, payment.Amount.StringFixed(2), payment.RecipientBIC)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(payment); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *InterbankPaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	payments, err := h.paymentService.GetUserPayments(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения платежных поручений: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	if payments == nil {
+		payments = []*models.InterbankPayment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.InterbankPaymentListRes{Payments: payments}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *InterbankPaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID поручения: %v", err)
+		http.Error(w, "Неверный ID поручения", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.paymentService.GetPayment(r.Context(), paymentID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInterbankPaymentNotFound) {
+			h.logger.Warnf("Платежное поручение %d не найдено", paymentID)
+			http.Error(w, "Платежное поручение не найдено", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("Ошибка получения платежного поручения %d: %v", paymentID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(payment); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// LookupBank возвращает банк из справочника БИК, чтобы клиент мог показать
+// его название и подставить корсчет
+func (h *InterbankPaymentHandler) LookupBank(w http.ResponseWriter, r *http.Request) {
+	bank, err := h.paymentService.LookupBank(mux.Vars(r)["bic"])
+	if err != nil {
+		switch {
+		case errors.Is(err, banking.ErrInvalidBIC):
+			http.Error(w, "Неверный БИК", http.StatusBadRequest)
+		case errors.Is(err, services.ErrBankNotFound):
+			http.Error(w, "Банк не найден", http.StatusNotFound)
+		default:
+			h.logger.Errorf("Ошибка поиска банка: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := types.BankRes{
+		BIC:                   bank.BIC,
+		Name:                  bank.Name,
+		City:                  bank.City,
+		CorrespondentAccounts: bank.CorrespondentAccounts,
+		Restricted:            bank.Restricted,
+	}
+	if resp.CorrespondentAccounts == nil {
+		resp.CorrespondentAccounts = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
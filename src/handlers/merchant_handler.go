@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type MerchantHandler struct {
+	merchantService *services.MerchantService
+	logger          *logrus.Logger
+}
+
+func NewMerchantHandler(merchantService *services.MerchantService, logger *logrus.Logger) *MerchantHandler {
+	return &MerchantHandler{
+		merchantService: merchantService,
+		logger:          logger,
+	}
+}
+
+func (h *MerchantHandler) CreateMerchant(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateMerchantReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	merchant, secret, err := h.merchantService.CreateMerchant(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMerchantName):
+			http.Error(w, "Название мерчанта не указано", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidMCC):
+			http.Error(w, "MCC должен состоять из 4 цифр", http.StatusBadRequest)
+		case errors.Is(err, services.ErrSettlementAccountNotFound):
+			h.logger.Warnf("Расчетный счет %d не найден", req.SettlementAccountID)
+			http.Error(w, "Расчетный счет не найден", http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка создания мерчанта: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Зарегистрирован мерчант %d (%s)", merchant.ID, merchant.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(types.CreateMerchantRes{Merchant: merchant, SigningSecret: secret}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *MerchantHandler) GetMerchants(w http.ResponseWriter, r *http.Request) {
+	merchants, err := h.merchantService.GetMerchants(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения мерчантов: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	if merchants == nil {
+		merchants = []*models.Merchant{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.MerchantListRes{Merchants: merchants}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *MerchantHandler) GetMerchant(w http.ResponseWriter, r *http.Request) {
+	merchantID, ok := h.parseID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	merchant, err := h.merchantService.GetMerchant(r.Context(), merchantID)
+	if err != nil {
+		h.writeMerchantError(w, merchantID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(merchant); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *MerchantHandler) RotateSigningSecret(w http.ResponseWriter, r *http.Request) {
+	merchantID, ok := h.parseID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	secret, err := h.merchantService.RotateSigningSecret(r.Context(), merchantID)
+	if err != nil {
+		h.writeMerchantError(w, merchantID, err)
+		return
+	}
+
+	h.logger.Infof("Секрет подписи мерчанта %d заменен", merchantID)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.SigningSecretRes{SigningSecret: secret}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *MerchantHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	merchantID, ok := h.parseID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req types.CreateAPIKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	key, raw, err := h.merchantService.CreateAPIKey(r.Context(), merchantID, req.Scopes)
+	if err != nil {
+		h.writeMerchantError(w, merchantID, err)
+		return
+	}
+
+	h.logger.Infof("Мерчанту %d выпущен API-ключ %s", merchantID, key.Prefix)
+	h.writeAPIKey(w, key, raw)
+}
+
+func (h *MerchantHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	merchantID, ok := h.parseID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	keys, err := h.merchantService.GetAPIKeys(r.Context(), merchantID)
+	if err != nil {
+		h.writeMerchantError(w, merchantID, err)
+		return
+	}
+
+	if keys == nil {
+		keys = []*models.MerchantAPIKey{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.APIKeyListRes{Keys: keys}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *MerchantHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	merchantID, ok := h.parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	keyID, ok := h.parseID(w, r, "keyID")
+	if !ok {
+		return
+	}
+
+	key, raw, err := h.merchantService.RotateAPIKey(r.Context(), merchantID, keyID)
+	if err != nil {
+		h.writeMerchantError(w, merchantID, err)
+		return
+	}
+
+	h.logger.Infof("API-ключ %d мерчанта %d заменен ключом %s", keyID, merchantID, key.Prefix)
+	h.writeAPIKey(w, key, raw)
+}
+
+func (h *MerchantHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	merchantID, ok := h.parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	keyID, ok := h.parseID(w, r, "keyID")
+	if !ok {
+		return
+	}
+
+	if err := h.merchantService.RevokeAPIKey(r.Context(), merchantID, keyID); err != nil {
+		h.writeMerchantError(w, merchantID, err)
+		return
+	}
+
+	h.logger.Infof("API-ключ %d мерчанта %d отозван", keyID, merchantID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MerchantHandler) parseID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID: %v", err)
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *MerchantHandler) writeAPIKey(w http.ResponseWriter, key *models.MerchantAPIKey, raw string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(types.CreateAPIKeyRes{Key: key, APIKey: raw}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *MerchantHandler) writeMerchantError(w http.ResponseWriter, merchantID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrMerchantNotFound):
+		h.logger.Warnf("Мерчант %d не найден", merchantID)
+		http.Error(w, "Мерчант не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		h.logger.Warnf("API-ключ мерчанта %d не найден", merchantID)
+		http.Error(w, "API-ключ не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidScope):
+		http.Error(w, "Неизвестная область доступа API-ключа", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Ошибка обработки мерчанта %d: %v", merchantID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
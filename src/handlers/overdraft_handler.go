@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type OverdraftHandler struct {
+	overdraftService *services.OverdraftService
+	logger           *logrus.Logger
+}
+
+func NewOverdraftHandler(overdraftService *services.OverdraftService, logger *logrus.Logger) *OverdraftHandler {
+	return &OverdraftHandler{
+		overdraftService: overdraftService,
+		logger:           logger,
+	}
+}
+
+// SetLimit задает лимит овердрафта по счету (для администратора)
+func (h *OverdraftHandler) SetLimit(w http.ResponseWriter, r *http.Request) {
+	adminID, accountID, ok := h.userAndAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.SetOverdraftLimitReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	acc, err := h.overdraftService.SetLimit(r.Context(), accountID, req.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountNotFound):
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrInvalidOverdraftLimit):
+			http.Error(w, "Лимит должен быть неотрицательным, не более двух знаков после запятой", http.StatusBadRequest)
+		case errors.Is(err, services.ErrOverdraftLimitTooLarge), errors.Is(err, services.ErrOverdraftCurrency):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrAccountClosed):
+			http.Error(w, "Счет закрыт", http.StatusConflict)
+		default:
+			h.logger.Errorf("Ошибка установки лимита овердрафта по счету %d: %v", accountID, err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Администратор %d установил лимит овердрафта %s по счету %d", adminID, req.Limit, accountID)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newAccountRes(acc)); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// GetCharges возвращает проценты, начисленные по овердрафту счета пользователя
+func (h *OverdraftHandler) GetCharges(w http.ResponseWriter, r *http.Request) {
+	userID, accountID, ok := h.userAndAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	charges, err := h.overdraftService.GetCharges(r.Context(), accountID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) {
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("Ошибка получения процентов по овердрафту счета %d: %v", accountID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.OverdraftChargesRes{Charges: charges}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *OverdraftHandler) userAndAccountID(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Warnf("Неверный ID счета: %v", err)
+		http.Error(w, "Неверный ID счета", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, accountID, true
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type P2PHandler struct {
+	p2pService *services.P2PService
+	logger     *logrus.Logger
+}
+
+func NewP2PHandler(p2pService *services.P2PService, logger *logrus.Logger) *P2PHandler {
+	return &P2PHandler{
+		p2pService: p2pService,
+		logger:     logger,
+	}
+}
+
+func (h *P2PHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.P2PTransferReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.p2pService.Transfer(r.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRecipientRequired):
+			http.Error(w, "Укажите email или телефон получателя", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidPhone):
+			http.Error(w, "Неверный формат номера телефона", http.StatusBadRequest)
+		case errors.Is(err, services.ErrRecipientNotFound), errors.Is(err, services.ErrRecipientNoAccount):
+			h.logger.Warnf("Получатель перевода не найден: %v", err)
+			http.Error(w, "Получатель не найден или не может принять перевод", http.StatusNotFound)
+		case errors.Is(err, services.ErrSelfTransfer):
+			http.Error(w, "Для перевода себе используйте перевод между своими счетами", http.StatusBadRequest)
+		case errors.Is(err, services.ErrAccountFrozen):
+			http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+		case errors.Is(err, services.ErrAccountClosed):
+			http.Error(w, "Счет закрыт", http.StatusConflict)
+		case errors.Is(err, services.ErrAccountLocked):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, services.ErrInsufficientFunds):
+			h.logger.Warnf("Недостаточно средств: %v", err)
+			http.Error(w, "Недостаточно средств", http.StatusBadRequest)
+		case errors.Is(err, services.ErrNegativeAmount):
+			http.Error(w, "Сумма перевода должна быть положительной", http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка перевода: %v", err)
+			http.Error(w, "Не удалось перевести", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Пользователь %d перевел %s %s получателю %s", userID, req.Amount, resp.Currency, resp.RecipientName)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
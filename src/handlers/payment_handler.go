@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+	logger         *logrus.Logger
+}
+
+func NewPaymentHandler(paymentService *services.PaymentService, logger *logrus.Logger) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+		logger:         logger,
+	}
+}
+
+func (h *PaymentHandler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
+	merchantID, err := middlewares.GetMerchantID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения ID мерчанта: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.PaymentReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	if req.CardID == 0 || (req.CVV == "" && req.PIN == "") || req.Amount == "" {
+		h.logger.Warn("Отсутствуют обязательные поля")
+		http.Error(w, "Все поля обязательны", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.paymentService.ProcessPayment(r.Context(), merchantID, req)
+	if payment != nil {
+		w.Header().Set("X-Payment-ID", strconv.FormatInt(payment.ID, 10))
+	}
+	if err != nil {
+		h.writePaymentError(w, req.CardID, err)
+		return
+	}
+
+	h.logger.WithField("merchant_id", merchantID).Infof("Платеж %d по карте %d: %s", payment.ID, req.CardID, payment.Status)
+
+	resp := types.PaymentRes{
+		Success:     true,
+		PaymentID:   strconv.FormatInt(payment.ID, 10),
+		Status:      payment.Status,
+		Description: "Платеж обработан",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if payment.Status == models.PaymentPendingConfirmation {
+		resp.Success = false
+		resp.Description = "Платеж ожидает подтверждения держателем карты"
+		w.WriteHeader(http.StatusAccepted)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *PaymentHandler) ConfirmPayment(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID платежа: %v", err)
+		http.Error(w, "Неверный ID платежа", http.StatusBadRequest)
+		return
+	}
+
+	var req types.ConfirmPaymentReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	if req.Code == "" {
+		h.logger.Warn("Нет кода подтверждения")
+		http.Error(w, "Код подтверждения обязателен", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.paymentService.ConfirmPayment(r.Context(), paymentID, userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPaymentNotFound):
+			h.logger.Warnf("Платеж %d не найден: %v", paymentID, err)
+			http.Error(w, "Платеж не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrPaymentNotPending):
+			http.Error(w, "Платеж не ожидает подтверждения", http.StatusConflict)
+		case errors.Is(err, services.ErrPaymentExpired):
+			http.Error(w, "Истек срок подтверждения платежа", http.StatusGone)
+		case errors.Is(err, services.ErrPaymentConfirmationFailed):
+			h.logger.Warnf("Неверный код подтверждения платежа %d", paymentID)
+			http.Error(w, "Неверный код подтверждения", http.StatusForbidden)
+		default:
+			h.writePaymentError(w, 0, err)
+		}
+		return
+	}
+
+	resp := types.PaymentRes{
+		Success:     true,
+		PaymentID:   strconv.FormatInt(payment.ID, 10),
+		Status:      payment.Status,
+		Description: "Платеж подтвержден",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID платежа: %v", err)
+		http.Error(w, "Неверный ID платежа", http.StatusBadRequest)
+		return
+	}
+
+	payment, history, err := h.paymentService.GetPayment(r.Context(), paymentID, userID)
+	h.writePaymentDetails(w, paymentID, payment, history, err)
+}
+
+func (h *PaymentHandler) GetMerchantPayment(w http.ResponseWriter, r *http.Request) {
+	merchantID, err := middlewares.GetMerchantID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения ID мерчанта: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID платежа: %v", err)
+		http.Error(w, "Неверный ID платежа", http.StatusBadRequest)
+		return
+	}
+
+	payment, history, err := h.paymentService.GetMerchantPayment(r.Context(), paymentID, merchantID)
+	h.writePaymentDetails(w, paymentID, payment, history, err)
+}
+
+func (h *PaymentHandler) GetCardPayments(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	cardID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID карты: %v", err)
+		http.Error(w, "Неверный ID карты", http.StatusBadRequest)
+		return
+	}
+
+	payments, err := h.paymentService.GetCardPayments(r.Context(), cardID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCardNotFound):
+			h.logger.Warnf("Карта %d не найдена", cardID)
+			http.Error(w, "Карта не найдена", http.StatusNotFound)
+		case errors.Is(err, services.ErrCardAccessDenied):
+			h.logger.Warnf("Доступ к карте %d запрещен для пользователя %d", cardID, userID)
+			http.Error(w, "Доступ запрещен", http.StatusForbidden)
+		default:
+			h.logger.Errorf("Ошибка получения платежей по карте: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := types.PaymentListRes{Payments: make([]types.PaymentDetailsRes, 0, len(payments))}
+	for _, payment := range payments {
+		resp.Payments = append(resp.Payments, toPaymentDetailsRes(payment, nil))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *PaymentHandler) writePaymentDetails(w http.ResponseWriter, paymentID int64, payment *models.Payment, history []*models.PaymentStatusChange, err error) {
+	if err != nil {
+		if errors.Is(err, services.ErrPaymentNotFound) {
+			h.logger.Warnf("Платеж %d не найден", paymentID)
+			http.Error(w, "Платеж не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("Ошибка получения платежа: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toPaymentDetailsRes(payment, history)); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func toPaymentDetailsRes(payment *models.Payment, history []*models.PaymentStatusChange) types.PaymentDetailsRes {
+	res := types.PaymentDetailsRes{
+		PaymentID:     strconv.FormatInt(payment.ID, 10),
+		MerchantID:    payment.MerchantID,
+		CardID:        payment.CardID,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Status:        payment.Status,
+		FailureReason: payment.FailureReason,
+		TransactionID: payment.TransactionID,
+		CreatedAt:     payment.CreatedAt,
+		UpdatedAt:     payment.UpdatedAt,
+	}
+	for _, change := range history {
+		res.History = append(res.History, types.PaymentStatusChangeRes{
+			Status:    change.Status,
+			Reason:    change.Reason,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+	return res
+}
+
+func (h *PaymentHandler) writePaymentError(w http.ResponseWriter, cardID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAmount):
+		h.logger.Warnf("Неверная сумма платежа: %v", err)
+		http.Error(w, "Неверная сумма платежа", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountFrozen):
+		http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+	case errors.Is(err, services.ErrAccountClosed):
+		http.Error(w, "Счет закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountLocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInsufficientFunds):
+		h.logger.Warnf("Недостаточно средств: %v", err)
+		http.Error(w, "Недостаточно средств", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCardInactive):
+		h.logger.Warnf("Платеж по неактивной карте %d", cardID)
+		http.Error(w, "Карта не активна", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCardExpired):
+		h.logger.Warnf("Платеж по просроченной карте %d", cardID)
+		http.Error(w, "Карта просрочена", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCardLimitExceeded):
+		h.logger.Warnf("Превышен лимит по карте %d", cardID)
+		http.Error(w, "Превышен лимит платежа по карте", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCardBlocked):
+		h.logger.Warnf("Платеж по заблокированной карте %d", cardID)
+		http.Error(w, "Карта заблокирована", http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidPIN), errors.Is(err, services.ErrInvalidCVV), errors.Is(err, services.ErrPINNotSet):
+		h.logger.Warnf("Неверные данные карты %d: %v", cardID, err)
+		http.Error(w, "Неверные данные карты", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCurrencyMismatch):
+		h.logger.Warnf("Валюта платежа не совпадает с валютой счета карты %d", cardID)
+		http.Error(w, "Валюта платежа не поддерживается картой", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCardMerchantMismatch):
+		h.logger.Warnf("Карта %d привязана к другому мерчанту", cardID)
+		http.Error(w, "Карта не принимается этим мерчантом", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Ошибка проверки данных карты: %v", err)
+		http.Error(w, "Ошибка проверки данных карты", http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type PaymentRequestHandler struct {
+	requestService *services.PaymentRequestService
+	logger         *logrus.Logger
+}
+
+func NewPaymentRequestHandler(requestService *services.PaymentRequestService, logger *logrus.Logger) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		requestService: requestService,
+		logger:         logger,
+	}
+}
+
+func (h *PaymentRequestHandler) CreateRequest(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.CreatePaymentRequestReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.requestService.CreateRequest(r.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPaymentRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrNegativeAmount):
+			http.Error(w, "Сумма должна быть положительной", http.StatusBadRequest)
+		case errors.Is(err, services.ErrRecipientRequired):
+			http.Error(w, "Укажите email или телефон плательщика", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidPhone):
+			http.Error(w, "Неверный формат номера телефона", http.StatusBadRequest)
+		case errors.Is(err, services.ErrRecipientNotFound):
+			h.logger.Warnf("Плательщик запроса не найден: %v", err)
+			http.Error(w, "Плательщик не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrAccountNotFound):
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+		default:
+			h.logger.Errorf("Ошибка создания запроса на оплату: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Пользователь %d запросил %s %s у пользователя %d", userID,
+		res.Request.Amount.StringFixed(2), res.Request.Currency, res.Request.PayerID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// GetIncoming возвращает запросы, которые пользователь должен оплатить
+func (h *PaymentRequestHandler) GetIncoming(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.requestService.GetIncoming)
+}
+
+// GetOutgoing возвращает запросы, выставленные пользователем
+func (h *PaymentRequestHandler) GetOutgoing(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.requestService.GetOutgoing)
+}
+
+func (h *PaymentRequestHandler) GetRequest(w http.ResponseWriter, r *http.Request) {
+	userID, requestID, ok := h.userAndRequestID(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.requestService.GetRequest(r.Context(), requestID, userID)
+	if err != nil {
+		h.writeError(w, requestID, err)
+		return
+	}
+	h.writeJSON(w, res)
+}
+
+// Pay оплачивает входящий запрос со счета from_account_id
+func (h *PaymentRequestHandler) Pay(w http.ResponseWriter, r *http.Request) {
+	userID, requestID, ok := h.userAndRequestID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.PayPaymentRequestReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.requestService.Pay(r.Context(), requestID, userID, req)
+	if err != nil {
+		h.writeError(w, requestID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d оплатил запрос %d", userID, requestID)
+	h.writeJSON(w, res)
+}
+
+func (h *PaymentRequestHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	userID, requestID, ok := h.userAndRequestID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.DeclinePaymentRequestReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.logger.Errorf("Ошибка декодирования: %v", err)
+			http.Error(w, "Неверный формат", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := h.requestService.Decline(r.Context(), requestID, userID, req)
+	if err != nil {
+		h.writeError(w, requestID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d отклонил запрос %d", userID, requestID)
+	h.writeJSON(w, res)
+}
+
+func (h *PaymentRequestHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, requestID, ok := h.userAndRequestID(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.requestService.Cancel(r.Context(), requestID, userID)
+	if err != nil {
+		h.writeError(w, requestID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d отозвал запрос %d", userID, requestID)
+	h.writeJSON(w, res)
+}
+
+func (h *PaymentRequestHandler) list(w http.ResponseWriter, r *http.Request,
+	get func(ctx context.Context, userID int64) ([]types.PaymentRequestRes, error)) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	requests, err := get(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения запросов на оплату: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, types.PaymentRequestListRes{Requests: requests})
+}
+
+func (h *PaymentRequestHandler) userAndRequestID(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	requestID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID запроса: %v", err)
+		http.Error(w, "Неверный ID запроса", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, requestID, true
+}
+
+func (h *PaymentRequestHandler) writeError(w http.ResponseWriter, requestID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrPaymentRequestNotFound):
+		h.logger.Warnf("Запрос на оплату %d не найден", requestID)
+		http.Error(w, "Запрос на оплату не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrPaymentRequestNotPending):
+		http.Error(w, "Запрос уже оплачен, отклонен или истек", http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidPaymentRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrSameAccount):
+		http.Error(w, "Нельзя переводить на тот же счет", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCurrencyMismatch):
+		http.Error(w, "Валюта счета не совпадает с валютой запроса", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountFrozen):
+		http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+	case errors.Is(err, services.ErrAccountClosed):
+		http.Error(w, "Счет закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountLocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInsufficientFunds):
+		h.logger.Warnf("Недостаточно средств для оплаты запроса %d", requestID)
+		http.Error(w, "Недостаточно средств", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountNotFound):
+		http.Error(w, "Счет не найден", http.StatusNotFound)
+	default:
+		h.logger.Errorf("Ошибка обработки запроса на оплату %d: %v", requestID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
+
+func (h *PaymentRequestHandler) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type ProfileHandler struct {
+	profileService *services.ProfileService
+	logger         *logrus.Logger
+}
+
+func NewProfileHandler(profileService *services.ProfileService, logger *logrus.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.profileService.GetProfile(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения профиля: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeProfile(w, user)
+}
+
+func (h *ProfileHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.UpdateProfileReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.profileService.UpdateName(r.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidName) {
+			http.Error(w, "Имя и фамилия обязательны", http.StatusBadRequest)
+			return
+		}
+		h.logger.Errorf("Ошибка обновления профиля: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeProfile(w, user)
+}
+
+func (h *ProfileHandler) RequestPhoneVerification(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.PhoneVerificationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.profileService.RequestPhoneVerification(r.Context(), userID, req.Phone); err != nil {
+		if errors.Is(err, services.ErrInvalidPhone) {
+			http.Error(w, "Неверный формат номера телефона", http.StatusBadRequest)
+			return
+		}
+		h.logger.Errorf("Ошибка отправки кода подтверждения: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *ProfileHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.VerifyPhoneReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.profileService.VerifyPhone(r.Context(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoPhoneVerification):
+			http.Error(w, "Подтверждение номера не запрашивалось", http.StatusBadRequest)
+		case errors.Is(err, services.ErrPhoneCodeExpired):
+			http.Error(w, "Истек срок действия кода", http.StatusGone)
+		case errors.Is(err, services.ErrPhoneCodeAttemptsExceed):
+			h.logger.Warnf("Превышено число попыток подтверждения телефона пользователем %d", userID)
+			http.Error(w, "Превышено число попыток, запросите новый код", http.StatusTooManyRequests)
+		case errors.Is(err, services.ErrPhoneCodeInvalid):
+			h.logger.Warnf("Неверный код подтверждения телефона от пользователя %d", userID)
+			http.Error(w, "Неверный код подтверждения", http.StatusForbidden)
+		case errors.Is(err, repository.ErrPhoneTaken):
+			http.Error(w, "Номер уже подтвержден другим пользователем", http.StatusConflict)
+		default:
+			h.logger.Errorf("Ошибка подтверждения телефона: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.writeProfile(w, user)
+}
+
+func (h *ProfileHandler) writeProfile(w http.ResponseWriter, user *models.User) {
+	resp := types.ProfileRes{
+		ID:            user.ID,
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		PhoneVerified: user.PhoneVerifiedAt != nil,
+	}
+	if user.Phone != nil {
+		resp.Phone = *user.Phone
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type SavingsGoalHandler struct {
+	goalService *services.SavingsGoalService
+	logger      *logrus.Logger
+}
+
+func NewSavingsGoalHandler(goalService *services.SavingsGoalService, logger *logrus.Logger) *SavingsGoalHandler {
+	return &SavingsGoalHandler{
+		goalService: goalService,
+		logger:      logger,
+	}
+}
+
+func (h *SavingsGoalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.CreateSavingsGoalReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.goalService.CreateGoal(r.Context(), userID, req)
+	if err != nil {
+		h.writeError(w, 0, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d создал цель %d на %s", userID, res.Goal.ID, res.Goal.TargetAmount.StringFixed(2))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *SavingsGoalHandler) GetGoals(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	goals, err := h.goalService.GetUserGoals(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения целей: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.SavingsGoalListRes{Goals: goals}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *SavingsGoalHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	userID, goalID, ok := h.userAndGoalID(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.goalService.GetGoal(r.Context(), goalID, userID)
+	if err != nil {
+		h.writeError(w, goalID, err)
+		return
+	}
+	h.writeGoal(w, res)
+}
+
+// UpdateRules заменяет правила автоматического пополнения цели
+func (h *SavingsGoalHandler) UpdateRules(w http.ResponseWriter, r *http.Request) {
+	userID, goalID, ok := h.userAndGoalID(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.UpdateSavingsGoalRulesReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.goalService.UpdateRules(r.Context(), goalID, userID, req)
+	if err != nil {
+		h.writeError(w, goalID, err)
+		return
+	}
+	h.writeGoal(w, res)
+}
+
+// CloseGoal закрывает цель, накопленное переводится на основной счет
+func (h *SavingsGoalHandler) CloseGoal(w http.ResponseWriter, r *http.Request) {
+	userID, goalID, ok := h.userAndGoalID(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.goalService.CloseGoal(r.Context(), goalID, userID)
+	if err != nil {
+		h.writeError(w, goalID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d закрыл цель %d", userID, goalID)
+	h.writeGoal(w, res)
+}
+
+func (h *SavingsGoalHandler) userAndGoalID(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	goalID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID цели: %v", err)
+		http.Error(w, "Неверный ID цели", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, goalID, true
+}
+
+func (h *SavingsGoalHandler) writeGoal(w http.ResponseWriter, res *types.SavingsGoalRes) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *SavingsGoalHandler) writeError(w http.ResponseWriter, goalID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrSavingsGoalNotFound):
+		h.logger.Warnf("Цель %d не найдена", goalID)
+		http.Error(w, "Цель не найдена", http.StatusNotFound)
+	case errors.Is(err, services.ErrSavingsGoalClosed):
+		http.Error(w, "Цель уже закрыта", http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidSavingsGoal), errors.Is(err, services.ErrInvalidSavingsRule),
+		errors.Is(err, services.ErrTooManySavingsGoals), errors.Is(err, services.ErrSavingsGoalMainAccount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountNotFound):
+		http.Error(w, "Счет не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrAccountFrozen):
+		http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+	case errors.Is(err, services.ErrAccountClosed):
+		http.Error(w, "Счет закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountLocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrAccountNotEmpty):
+		http.Error(w, "Не удалось перевести накопленное на основной счет", http.StatusConflict)
+	case errors.Is(err, services.ErrInsufficientFunds):
+		http.Error(w, "Недостаточно средств", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Ошибка операции по цели %d: %v", goalID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/sbp"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type SBPHandler struct {
+	sbpService *services.SBPService
+	logger     *logrus.Logger
+}
+
+func NewSBPHandler(sbpService *services.SBPService, logger *logrus.Logger) *SBPHandler {
+	return &SBPHandler{
+		sbpService: sbpService,
+		logger:     logger,
+	}
+}
+
+func (h *SBPHandler) CreateQR(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.CreateSBPQRReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	qr, err := h.sbpService.CreateQR(r.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSBPQR):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrNegativeAmount):
+			http.Error(w, "Сумма должна быть положительной", http.StatusBadRequest)
+		case errors.Is(err, services.ErrAccountNotFound):
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrCurrencyMismatch):
+			http.Error(w, "Платежная ссылка СБП выпускается только на счет в рублях", http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка создания платежной ссылки: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Пользователь %d выпустил платежную ссылку СБП %s", userID, qr.QRID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(types.SBPQRRes{QRCode: qr, Payload: h.sbpService.Payload(qr)}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *SBPHandler) GetQRs(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	codes, err := h.sbpService.GetUserQRs(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения платежных ссылок: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	resp := types.SBPQRListRes{QRCodes: make([]types.SBPQRRes, 0, len(codes))}
+	for _, qr := range codes {
+		resp.QRCodes = append(resp.QRCodes, types.SBPQRRes{QRCode: qr, Payload: h.sbpService.Payload(qr)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *SBPHandler) GetQR(w http.ResponseWriter, r *http.Request) {
+	userID, qrID, ok := h.userAndQRID(w, r)
+	if !ok {
+		return
+	}
+
+	qr, err := h.sbpService.GetQR(r.Context(), qrID, userID)
+	if err != nil {
+		h.writeQRError(w, qrID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.SBPQRRes{QRCode: qr, Payload: h.sbpService.Payload(qr)}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+// GetQRImage отдает QR-код с платежной ссылкой в PNG. Размер модуля в
+// пикселях можно задать параметром scale
+func (h *SBPHandler) GetQRImage(w http.ResponseWriter, r *http.Request) {
+	userID, qrID, ok := h.userAndQRID(w, r)
+	if !ok {
+		return
+	}
+
+	scale := 0
+	if raw := r.URL.Query().Get("scale"); raw != "" {
+		scale, _ = strconv.Atoi(raw)
+		if scale <= 0 {
+			http.Error(w, "Неверный параметр scale", http.StatusBadRequest)
+			return
+		}
+	}
+
+	image, err := h.sbpService.RenderPNG(r.Context(), qrID, userID, scale)
+	if err != nil {
+		h.writeQRError(w, qrID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	if _, err := w.Write(image); err != nil {
+		h.logger.Errorf("Ошибка отправки QR-кода: %v", err)
+	}
+}
+
+// ParsePayload разбирает отсканированную ссылку и возвращает заполненный
+// перевод для подтверждения пользователем
+func (h *SBPHandler) ParsePayload(w http.ResponseWriter, r *http.Request) {
+	var req types.SBPPayloadReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.sbpService.PreviewPayment(r.Context(), req.Payload)
+	if err != nil {
+		h.writePaymentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *SBPHandler) Pay(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.SBPPayReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.sbpService.Pay(r.Context(), userID, req)
+	if err != nil {
+		h.writePaymentError(w, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d оплатил по СБП %s %s", userID, res.Amount.StringFixed(2), res.Currency)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *SBPHandler) userAndQRID(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	qrID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID платежной ссылки: %v", err)
+		http.Error(w, "Неверный ID платежной ссылки", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, qrID, true
+}
+
+func (h *SBPHandler) writeQRError(w http.ResponseWriter, qrID int64, err error) {
+	if errors.Is(err, services.ErrSBPQRNotFound) {
+		h.logger.Warnf("Платежная ссылка %d не найдена", qrID)
+		http.Error(w, "Платежная ссылка не найдена", http.StatusNotFound)
+		return
+	}
+	h.logger.Errorf("Ошибка получения платежной ссылки %d: %v", qrID, err)
+	http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+}
+
+func (h *SBPHandler) writePaymentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sbp.ErrInvalidPayload):
+		h.logger.Warnf("Неверная платежная ссылка: %v", err)
+		http.Error(w, "Неверная платежная ссылка", http.StatusBadRequest)
+	case errors.Is(err, services.ErrSBPForeignBank):
+		http.Error(w, "Оплата по ссылкам других банков пока не поддерживается", http.StatusUnprocessableEntity)
+	case errors.Is(err, services.ErrSBPQRNotFound):
+		http.Error(w, "Платежная ссылка не найдена", http.StatusNotFound)
+	case errors.Is(err, services.ErrSBPQRInactive):
+		http.Error(w, "Платежная ссылка уже оплачена или истекла", http.StatusConflict)
+	case errors.Is(err, services.ErrSBPAmountMismatch):
+		http.Error(w, "Сумма не совпадает с суммой в платежной ссылке", http.StatusBadRequest)
+	case errors.Is(err, services.ErrNegativeAmount):
+		http.Error(w, "Укажите положительную сумму перевода", http.StatusBadRequest)
+	case errors.Is(err, services.ErrSameAccount):
+		http.Error(w, "Нельзя переводить на тот же счет", http.StatusBadRequest)
+	case errors.Is(err, services.ErrCurrencyMismatch):
+		http.Error(w, "Счета в разных валютах", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountFrozen):
+		http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+	case errors.Is(err, services.ErrAccountClosed):
+		http.Error(w, "Счет закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountLocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInsufficientFunds):
+		h.logger.Warnf("Недостаточно средств: %v", err)
+		http.Error(w, "Недостаточно средств", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountNotFound):
+		http.Error(w, "Счет не найден", http.StatusNotFound)
+	default:
+		h.logger.Errorf("Ошибка оплаты по СБП: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type SettlementHandler struct {
+	settlementService *services.SettlementService
+	logger            *logrus.Logger
+}
+
+func NewSettlementHandler(settlementService *services.SettlementService, logger *logrus.Logger) *SettlementHandler {
+	return &SettlementHandler{
+		settlementService: settlementService,
+		logger:            logger,
+	}
+}
+
+func (h *SettlementHandler) GetBatches(w http.ResponseWriter, r *http.Request) {
+	var (
+		batches []*models.SettlementBatch
+		err     error
+	)
+
+	if raw := r.URL.Query().Get("merchant_id"); raw != "" {
+		merchantID, parseErr := strconv.ParseInt(raw, 10, 64)
+		if parseErr != nil {
+			http.Error(w, "Неверный ID мерчанта", http.StatusBadRequest)
+			return
+		}
+		batches, err = h.settlementService.GetMerchantBatches(r.Context(), merchantID)
+	} else {
+		batches, err = h.settlementService.GetBatches(r.Context())
+	}
+	if err != nil {
+		h.logger.Errorf("Ошибка получения расчетов: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeBatches(w, batches)
+}
+
+func (h *SettlementHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	h.writeReport(w, r, 0)
+}
+
+func (h *SettlementHandler) RunSettlement(w http.ResponseWriter, r *http.Request) {
+	if err := h.settlementService.SettleDaily(r.Context()); err != nil {
+		h.logger.Errorf("Ошибка расчета с мерчантами: %v", err)
+		http.Error(w, "Расчет выполнен с ошибками", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SettlementHandler) GetMerchantBatches(w http.ResponseWriter, r *http.Request) {
+	merchantID, err := middlewares.GetMerchantID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения ID мерчанта: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	batches, err := h.settlementService.GetMerchantBatches(r.Context(), merchantID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения расчетов мерчанта %d: %v", merchantID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeBatches(w, batches)
+}
+
+func (h *SettlementHandler) GetMerchantReport(w http.ResponseWriter, r *http.Request) {
+	merchantID, err := middlewares.GetMerchantID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения ID мерчанта: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	h.writeReport(w, r, merchantID)
+}
+
+// writeReport отдает отчет в CSV (format=csv или Accept: text/csv), иначе в JSON
+func (h *SettlementHandler) writeReport(w http.ResponseWriter, r *http.Request, merchantID int64) {
+	batchID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID расчета: %v", err)
+		http.Error(w, "Неверный ID расчета", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.settlementService.GetReport(r.Context(), batchID, merchantID)
+	if err != nil {
+		if errors.Is(err, services.ErrSettlementNotFound) {
+			h.logger.Warnf("Расчет %d не найден", batchID)
+			http.Error(w, "Расчет не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("Ошибка формирования отчета по расчету %d: %v", batchID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="settlement-%d.csv"`, batchID))
+		if err := services.WriteSettlementReportCSV(w, report); err != nil {
+			h.logger.Errorf("Ошибка записи CSV: %v", err)
+		}
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="settlement-%d.json"`, batchID))
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			h.logger.Errorf("Ошибка кодирования: %v", err)
+		}
+	default:
+		http.Error(w, "Неизвестный формат отчета", http.StatusBadRequest)
+	}
+}
+
+func (h *SettlementHandler) writeBatches(w http.ResponseWriter, batches []*models.SettlementBatch) {
+	if batches == nil {
+		batches = []*models.SettlementBatch{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.SettlementListRes{Batches: batches}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
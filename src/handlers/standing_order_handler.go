@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+type StandingOrderHandler struct {
+	orderService *services.StandingOrderService
+	logger       *logrus.Logger
+}
+
+func NewStandingOrderHandler(orderService *services.StandingOrderService, logger *logrus.Logger) *StandingOrderHandler {
+	return &StandingOrderHandler{
+		orderService: orderService,
+		logger:       logger,
+	}
+}
+
+func (h *StandingOrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	var req types.CreateStandingOrderReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Errorf("Ошибка декодирования: %v", err)
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.orderService.CreateOrder(r.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSchedule):
+			h.logger.Warnf("Неверное расписание поручения: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrNegativeAmount):
+			http.Error(w, "Сумма перевода должна быть положительной", http.StatusBadRequest)
+		case errors.Is(err, services.ErrAccountNotFound):
+			http.Error(w, "Счет не найден", http.StatusNotFound)
+		case errors.Is(err, services.ErrSameAccount):
+			http.Error(w, "Нельзя делать перевод на тот же счет", http.StatusBadRequest)
+		case errors.Is(err, services.ErrCurrencyMismatch):
+			http.Error(w, "Валюты счетов не совпадают", http.StatusBadRequest)
+		case errors.Is(err, services.ErrRecipientRequired):
+			http.Error(w, "Укажите to_account_id либо email или телефон получателя", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidPhone):
+			http.Error(w, "Неверный формат номера телефона", http.StatusBadRequest)
+		case errors.Is(err, services.ErrRecipientNotFound), errors.Is(err, services.ErrRecipientNoAccount):
+			h.logger.Warnf("Получатель поручения не найден: %v", err)
+			http.Error(w, "Получатель не найден или не может принять перевод", http.StatusNotFound)
+		case errors.Is(err, services.ErrSelfTransfer):
+			http.Error(w, "Для перевода себе укажите to_account_id", http.StatusBadRequest)
+		default:
+			h.logger.Errorf("Ошибка создания поручения: %v", err)
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.Infof("Пользователь %d создал поручение %d (%s)", userID, order.ID, order.ScheduleType)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *StandingOrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	orders, err := h.orderService.GetUserOrders(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения поручений: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	if orders == nil {
+		orders = []*models.StandingOrder{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.StandingOrderListRes{Orders: orders}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *StandingOrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	orderID, ok := h.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	order, executions, err := h.orderService.GetOrder(r.Context(), orderID, userID)
+	if err != nil {
+		h.writeOrderError(w, orderID, err)
+		return
+	}
+	if executions == nil {
+		executions = []*models.StandingOrderExecution{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.StandingOrderDetailsRes{Order: order, Executions: executions}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *StandingOrderHandler) PauseOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.orderService.PauseOrder)
+}
+
+func (h *StandingOrderHandler) ResumeOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.orderService.ResumeOrder)
+}
+
+func (h *StandingOrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.orderService.CancelOrder)
+}
+
+func (h *StandingOrderHandler) changeStatus(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, orderID int64, userID int64) (*models.StandingOrder, error)) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	orderID, ok := h.parseOrderID(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := change(r.Context(), orderID, userID)
+	if err != nil {
+		h.writeOrderError(w, orderID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d перевел поручение %d в статус %s", userID, orderID, order.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *StandingOrderHandler) parseOrderID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID поручения: %v", err)
+		http.Error(w, "Неверный ID поручения", http.StatusBadRequest)
+		return 0, false
+	}
+	return orderID, true
+}
+
+func (h *StandingOrderHandler) writeOrderError(w http.ResponseWriter, orderID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrStandingOrderNotFound):
+		h.logger.Warnf("Поручение %d не найдено", orderID)
+		http.Error(w, "Поручение не найдено", http.StatusNotFound)
+	case errors.Is(err, services.ErrStandingOrderTransition):
+		h.logger.Warnf("Недопустимая смена статуса поручения %d", orderID)
+		http.Error(w, "Недопустимая смена статуса поручения", http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidSchedule):
+		http.Error(w, "У поручения не осталось дат исполнения", http.StatusConflict)
+	default:
+		h.logger.Errorf("Ошибка обработки поручения %d: %v", orderID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/config"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/services"
+	"sf-finances/src/statement"
+)
+
+type StatementHandler struct {
+	statementService *services.StatementService
+	statementCfg     config.StatementConfig
+	logger           *logrus.Logger
+}
+
+func NewStatementHandler(statementService *services.StatementService, statementCfg config.StatementConfig, logger *logrus.Logger) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+		statementCfg:     statementCfg,
+		logger:           logger,
+	}
+}
+
+// GetStatement отдает выписку по счету за период from..to (YYYY-MM-DD,
+// включительно). Формат выбирается параметром format (json, csv, ofx,
+// camt053) или заголовком Accept
+func (h *StatementHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userID, accountID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	format, err := statement.Negotiate(query.Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		http.Error(w, "Поддерживаются форматы json, csv, ofx и camt053", http.StatusNotAcceptable)
+		return
+	}
+
+	now := time.Now()
+	from, to, err := services.ParseStatementPeriod(query.Get("from"), query.Get("to"), h.statementCfg, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	st, err := h.statementService.GetStatement(r.Context(), accountID, userID, from, to)
+	if err != nil {
+		h.writeStatementError(w, accountID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", statement.ContentType(format))
+	w.Header().Set("Vary", "Accept")
+	if format != statement.FormatJSON {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement_%d_%s.%s"`,
+			accountID, from.In(h.statementCfg.Location).Format("20060102"), statement.FileExtension(format)))
+	}
+	if err := statement.Write(w, format, st, h.statementService.Options(now)); err != nil {
+		h.logger.Errorf("Ошибка записи выписки: %v", err)
+	}
+}
+
+// GetStatementPDF отдает печатную форму выписки за период from..to
+func (h *StatementHandler) GetStatementPDF(w http.ResponseWriter, r *http.Request) {
+	userID, accountID, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := services.ParseStatementPeriod(query.Get("from"), query.Get("to"), h.statementCfg, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.statementService.RenderPDF(r.Context(), accountID, userID, from, to)
+	if err != nil {
+		h.writeStatementError(w, accountID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d сформировал PDF-выписку по счету %d", userID, accountID)
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement_%d_%s.pdf"`,
+		accountID, from.In(h.statementCfg.Location).Format("20060102")))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if _, err := w.Write(data); err != nil {
+		h.logger.Errorf("Ошибка записи выписки: %v", err)
+	}
+}
+
+func (h *StatementHandler) parseRequest(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	accountID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID счета: %v", err)
+		http.Error(w, "Неверный ID счета", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, accountID, true
+}
+
+func (h *StatementHandler) writeStatementError(w http.ResponseWriter, accountID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		http.Error(w, "Счет не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidPeriod):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Ошибка формирования выписки по счету %d: %v", accountID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
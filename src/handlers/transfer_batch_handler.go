@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/middlewares"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+	"sf-finances/src/types"
+)
+
+var (
+	errBadBatchFormat = errors.New("неверный формат пакета")
+	errBadFromAccount = errors.New("неверный from_account_id")
+)
+
+type TransferBatchHandler struct {
+	batchService  *services.TransferBatchService
+	maxUploadSize int64
+	logger        *logrus.Logger
+}
+
+func NewTransferBatchHandler(batchService *services.TransferBatchService, maxUploadSize int64, logger *logrus.Logger) *TransferBatchHandler {
+	return &TransferBatchHandler{
+		batchService:  batchService,
+		maxUploadSize: maxUploadSize,
+		logger:        logger,
+	}
+}
+
+// CreateBatch принимает пакет в JSON, либо CSV в теле запроса (text/csv,
+// счет списания в параметре from_account_id), либо CSV-файл в поле file
+// формы multipart/form-data вместе с полем from_account_id
+func (h *TransferBatchHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	req, err := h.decodeBatch(r)
+	if err != nil {
+		h.writeBatchError(w, userID, err)
+		return
+	}
+
+	batch, err := h.batchService.CreateBatch(r.Context(), userID, req)
+	if err != nil {
+		h.writeBatchError(w, userID, err)
+		return
+	}
+
+	h.logger.Infof("Пользователь %d создал пакет переводов %d: %d строк на %s %s",
+		userID, batch.ID, batch.ItemCount, batch.TotalAmount, batch.Currency)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(batch); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *TransferBatchHandler) decodeBatch(r *http.Request) (types.CreateTransferBatchReq, error) {
+	var req types.CreateTransferBatchReq
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var csvBody io.Reader
+	var fromAccount string
+	switch mediaType {
+	case "text/csv":
+		csvBody = r.Body
+		fromAccount = r.URL.Query().Get("from_account_id")
+	case "multipart/form-data":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return req, errBadBatchFormat
+		}
+		defer file.Close()
+		csvBody = file
+		fromAccount = r.FormValue("from_account_id")
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, errBadBatchFormat
+		}
+		return req, nil
+	}
+
+	fromAccountID, err := strconv.ParseInt(fromAccount, 10, 64)
+	if err != nil {
+		return req, errBadFromAccount
+	}
+
+	items, err := services.ParseTransferBatchCSV(csvBody)
+	if err != nil {
+		return req, err
+	}
+
+	req.FromAccountID = fromAccountID
+	req.Items = items
+	return req, nil
+}
+
+func (h *TransferBatchHandler) GetBatches(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	batches, err := h.batchService.GetUserBatches(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Ошибка получения пакетов переводов: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	if batches == nil {
+		batches = []*models.TransferBatch{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.TransferBatchListRes{Batches: batches}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *TransferBatchHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	userID, err := middlewares.GetUserID(r.Context())
+	if err != nil {
+		h.logger.Errorf("Ошибка получения userID: %v", err)
+		http.Error(w, "Ошибка авторизации", http.StatusUnauthorized)
+		return
+	}
+
+	batchID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.logger.Errorf("Неверный ID пакета: %v", err)
+		http.Error(w, "Неверный ID пакета", http.StatusBadRequest)
+		return
+	}
+
+	batch, items, err := h.batchService.GetBatch(r.Context(), batchID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrTransferBatchNotFound) {
+			h.logger.Warnf("Пакет переводов %d не найден", batchID)
+			http.Error(w, "Пакет переводов не найден", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("Ошибка получения пакета переводов %d: %v", batchID, err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	if items == nil {
+		items = []*models.TransferBatchItem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.TransferBatchDetailsRes{Batch: batch, Items: items}); err != nil {
+		h.logger.Errorf("Ошибка кодирования: %v", err)
+	}
+}
+
+func (h *TransferBatchHandler) writeBatchError(w http.ResponseWriter, userID int64, err error) {
+	var validationErr *services.TransferBatchValidationError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &validationErr):
+		h.logger.Warnf("Пакет переводов пользователя %d отклонен: %v", userID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(types.TransferBatchErrorRes{Errors: validationErr.Errors}); err != nil {
+			h.logger.Errorf("Ошибка кодирования: %v", err)
+		}
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, "Слишком большой файл", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errBadBatchFormat):
+		http.Error(w, "Неверный формат", http.StatusBadRequest)
+	case errors.Is(err, errBadFromAccount):
+		http.Error(w, "Неверный ID счета списания", http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidTransferBatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrAccountNotFound):
+		http.Error(w, "Счет не найден", http.StatusNotFound)
+	case errors.Is(err, services.ErrAccountFrozen):
+		http.Error(w, "Счет заморожен, операции по нему запрещены", http.StatusForbidden)
+	case errors.Is(err, services.ErrAccountClosed):
+		http.Error(w, "Счет закрыт", http.StatusConflict)
+	case errors.Is(err, services.ErrAccountLocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInsufficientFunds):
+		h.logger.Warnf("Недостаточно средств для пакета переводов пользователя %d", userID)
+		http.Error(w, "Недостаточно средств для всего пакета", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Ошибка создания пакета переводов: %v", err)
+		http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/services"
+)
+
+// AdminMiddleware пропускает только пользователей с ролью ADMIN.
+// Должен стоять после JWTMiddleware
+type AdminMiddleware struct {
+	authService services.UserService
+	logger      *logrus.Logger
+}
+
+func NewAdminMiddleware(authService services.UserService, logger *logrus.Logger) *AdminMiddleware {
+	return &AdminMiddleware{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+func (m *AdminMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := GetUserID(r.Context())
+		if err != nil {
+			http.Error(w, "Нужна авторизация", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := m.authService.IsAdmin(r.Context(), userID)
+		if err != nil {
+			m.logger.WithError(err).Error("Ошибка проверки роли пользователя")
+			http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+			return
+		}
+
+		if !isAdmin {
+			m.logger.Warnf("Пользователь %d пытался обратиться к админ-маршруту %s", userID, r.URL.Path)
+			http.Error(w, "Доступ запрещен", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/models"
+	"sf-finances/src/services"
+)
+
+// MerchantAuthMiddleware аутентифицирует мерчанта по API-ключу из заголовка
+// X-API-Key. Используется вместо JWT на маршрутах мерчантов
+type MerchantAuthMiddleware struct {
+	merchantService *services.MerchantService
+	logger          *logrus.Logger
+}
+
+func NewMerchantAuthMiddleware(merchantService *services.MerchantService, logger *logrus.Logger) *MerchantAuthMiddleware {
+	return &MerchantAuthMiddleware{
+		merchantService: merchantService,
+		logger:          logger,
+	}
+}
+
+// Require пропускает запрос, только если ключ дает область доступа scope
+func (m *MerchantAuthMiddleware) Require(scope models.MerchantScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := r.Header.Get("X-API-Key")
+			if apiKey == "" {
+				http.Error(w, "Нужен API-ключ", http.StatusUnauthorized)
+				return
+			}
+
+			merchantID, err := m.merchantService.AuthenticateAPIKey(r.Context(), apiKey, scope)
+			if err != nil {
+				switch {
+				case errors.Is(err, services.ErrAPIKeyScope):
+					m.logger.WithField("scope", scope).Warn("API-ключ без нужной области доступа")
+					http.Error(w, "Доступ запрещен", http.StatusForbidden)
+				case errors.Is(err, services.ErrInvalidAPIKey):
+					m.logger.Warn("Неверный API-ключ мерчанта")
+					http.Error(w, "Неверный API-ключ", http.StatusUnauthorized)
+				default:
+					m.logger.WithError(err).Error("Ошибка проверки API-ключа")
+					http.Error(w, "Ошибка сервера", http.StatusInternalServerError)
+				}
+				return
+			}
+
+			if header := r.Header.Get("X-Merchant-ID"); header != "" && header != strconv.FormatInt(merchantID, 10) {
+				m.logger.WithField("merchant_id", merchantID).Warn("X-Merchant-ID не совпадает с владельцем API-ключа")
+				http.Error(w, "Неверный ID мерчанта", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), MerchantIDKey, merchantID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/services"
+)
+
+const MerchantIDKey contextKey = "merchantID"
+
+const maxSignedBodySize = 1 << 20
+
+type MerchantSignatureMiddleware struct {
+	merchantAuthService *services.MerchantAuthService
+	logger              *logrus.Logger
+}
+
+func NewMerchantSignatureMiddleware(merchantAuthService *services.MerchantAuthService, logger *logrus.Logger) *MerchantSignatureMiddleware {
+	return &MerchantSignatureMiddleware{
+		merchantAuthService: merchantAuthService,
+		logger:              logger,
+	}
+}
+
+func (m *MerchantSignatureMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// После MerchantAuthMiddleware мерчант уже известен по API-ключу
+		merchantID, err := GetMerchantID(r.Context())
+		if err != nil {
+			merchantID, err = strconv.ParseInt(r.Header.Get("X-Merchant-ID"), 10, 64)
+			if err != nil {
+				http.Error(w, "Нужен ID мерчанта", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodySize))
+		if err != nil {
+			m.logger.WithError(err).Warn("Ошибка чтения тела запроса")
+			http.Error(w, "Неверный формат", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		err = m.merchantAuthService.VerifySignature(r.Context(), merchantID,
+			r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce"), r.Header.Get("X-Signature"), body)
+		if err != nil {
+			m.logger.WithError(err).WithField("merchant_id", merchantID).Warn("Ошибка проверки подписи мерчанта")
+			switch {
+			case errors.Is(err, services.ErrRequestExpired), errors.Is(err, services.ErrNonceReused):
+				http.Error(w, "Запрос устарел или уже обработан", http.StatusUnauthorized)
+			default:
+				http.Error(w, "Неверная подпись", http.StatusUnauthorized)
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), MerchantIDKey, merchantID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetMerchantID(ctx context.Context) (int64, error) {
+	merchantID, ok := ctx.Value(MerchantIDKey).(int64)
+	if !ok {
+		return 0, errors.New("ID мерчанта отсутствует в контексте")
+	}
+	return merchantID, nil
+}
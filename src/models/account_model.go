@@ -8,12 +8,36 @@ import (
 type Currency string
 const (
 	RUB Currency = "RUB"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+)
+
+type AccountStatus string
+const (
+	AccountActive AccountStatus = "ACTIVE"
+	// Замороженный администратором счет: операции по нему запрещены до разморозки
+	AccountFrozen AccountStatus = "FROZEN"
+	AccountClosed AccountStatus = "CLOSED"
 )
 
 type Account struct {
-	ID        int64           `db:"id"       json:"id"`
-	UserID    int64           `db:"user_id"  json:"user_id"`
-	Balance   decimal.Decimal `db:"balance"  json:"balance"`
-	Currency  Currency        `db:"currency" json:"currency"`
-	CreatedAt time.Time       `db:"created_at" json:"created_at"`
-}
\ No newline at end of file
+	ID           int64           `db:"id"            json:"id"`
+	UserID       int64           `db:"user_id"       json:"user_id"`
+	Number       string          `db:"number"        json:"number"`
+	Nickname     *string         `db:"nickname"      json:"nickname,omitempty"`
+	Balance      decimal.Decimal `db:"balance"       json:"balance"`
+	Currency     Currency        `db:"currency"      json:"currency"`
+	Status       AccountStatus   `db:"status"        json:"status"`
+	FreezeReason *string         `db:"freeze_reason" json:"freeze_reason,omitempty"`
+	FrozenBy     *int64          `db:"frozen_by"     json:"frozen_by,omitempty"`
+	FrozenAt     *time.Time      `db:"frozen_at"     json:"frozen_at,omitempty"`
+	ClosedAt     *time.Time      `db:"closed_at"     json:"closed_at,omitempty"`
+	// До этого момента списания со счета запрещены (срочный вклад)
+	LockedUntil *time.Time `db:"locked_until" json:"locked_until,omitempty"`
+	// Лимит овердрафта: насколько баланс может уйти в минус. Проценты за
+	// пользование начислены по OverdraftChargedThrough включительно
+	OverdraftLimit          decimal.Decimal `db:"overdraft_limit"           json:"overdraft_limit"`
+	OverdraftStartedAt      *time.Time      `db:"overdraft_started_at"      json:"overdraft_started_at,omitempty"`
+	OverdraftChargedThrough *time.Time      `db:"overdraft_charged_through" json:"overdraft_charged_through,omitempty"`
+	CreatedAt               time.Time       `db:"created_at"    json:"created_at"`
+}
@@ -1,12 +1,60 @@
 package models
 
-import "time"
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type CardProduct string
+const (
+	VisaDebit  CardProduct = "VISA_DEBIT"
+	VisaCredit CardProduct = "VISA_CREDIT"
+	MirDebit   CardProduct = "MIR_DEBIT"
+	MirCredit  CardProduct = "MIR_CREDIT"
+)
+
+type CardType string
+const (
+	PhysicalCard     CardType = "PHYSICAL"
+	VirtualSingleUse CardType = "VIRTUAL_SINGLE_USE"
+	MerchantLocked   CardType = "MERCHANT_LOCKED"
+)
+
+type CardStatus string
+const (
+	CardActive            CardStatus = "ACTIVE"
+	CardClosed            CardStatus = "CLOSED"
+	CardBlocked           CardStatus = "BLOCKED"
+	CardExpired           CardStatus = "EXPIRED"
+	CardPendingActivation CardStatus = "PENDING_ACTIVATION"
+)
 
 type Card struct {
-	ID         int64     `db:"id"        json:"id"`
-	UserID     int64     `db:"user_id"   json:"user_id"`
-	CardNumber []byte    `db:"card_number" json:"-"`
-	Expire     []byte    `db:"expire"      json:"-"`
-	CVVHash    string    `db:"cvv_hash"    json:"-"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
-}
\ No newline at end of file
+	ID               int64               `db:"id"        json:"id"`
+	UserID           int64               `db:"user_id"   json:"user_id"`
+	AccountID        int64               `db:"account_id" json:"account_id"`
+	Product          CardProduct         `db:"product"   json:"product"`
+	Type             CardType            `db:"type"      json:"type"`
+	Status           CardStatus          `db:"status"    json:"status"`
+	LockedMerchantID *int64              `db:"locked_merchant_id" json:"locked_merchant_id,omitempty"`
+	PaymentLimit     decimal.NullDecimal `db:"payment_limit" json:"payment_limit"`
+	ReissuedFromID   *int64              `db:"reissued_from_id" json:"reissued_from_id,omitempty"`
+	ExpiresAt        time.Time           `db:"expires_at"  json:"expires_at"`
+	CardNumber       []byte              `db:"card_number" json:"-"`
+	Expire           []byte              `db:"expire"      json:"-"`
+	CVVHash          string              `db:"cvv_hash"    json:"-"`
+	PANHash          string              `db:"pan_hash"    json:"-"`
+	PINHash          *string             `db:"pin_hash"    json:"-"`
+	PINCardID        *int64              `db:"pin_card_id" json:"-"`
+	PINAttempts      int                 `db:"pin_attempts" json:"-"`
+	CreatedAt        time.Time           `db:"created_at" json:"created_at"`
+}
+
+// PINResetCode - одноразовый код для сброса PIN, отправленный владельцу карты
+type PINResetCode struct {
+	CardID    int64     `db:"card_id"    json:"card_id"`
+	CodeHash  string    `db:"code_hash"  json:"-"`
+	Attempts  int       `db:"attempts"   json:"attempts"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type DepositType string
+const (
+	// Накопительный счет: пополнение и снятие без ограничений
+	DepositSavings DepositType = "SAVINGS"
+	// Срочный вклад: списания запрещены до MaturityDate, досрочное закрытие
+	// лишает процентов
+	DepositTerm DepositType = "TERM"
+)
+
+type DepositStatus string
+const (
+	DepositActive  DepositStatus = "ACTIVE"
+	DepositMatured DepositStatus = "MATURED"
+	DepositClosed  DepositStatus = "CLOSED"
+)
+
+// Deposit - вклад на отдельном счете AccountID. Проценты начисляются
+// ежедневно на остаток на конец дня и капитализируются раз в месяц.
+// AccruedThrough - последний день, за который проценты начислены
+type Deposit struct {
+	ID                int64           `db:"id"                 json:"id"`
+	UserID            int64           `db:"user_id"            json:"user_id"`
+	AccountID         int64           `db:"account_id"         json:"account_id"`
+	Type              DepositType     `db:"type"               json:"type"`
+	AnnualRate        decimal.Decimal `db:"annual_rate"        json:"annual_rate"`
+	TermMonths        *int            `db:"term_months"        json:"term_months,omitempty"`
+	OpenedOn          time.Time       `db:"opened_on"          json:"opened_on"`
+	MaturityDate      *time.Time      `db:"maturity_date"      json:"maturity_date,omitempty"`
+	AccruedThrough    time.Time       `db:"accrued_through"    json:"accrued_through"`
+	Status            DepositStatus   `db:"status"             json:"status"`
+	ForfeitedInterest decimal.Decimal `db:"forfeited_interest" json:"forfeited_interest"`
+	CreatedAt         time.Time       `db:"created_at"         json:"created_at"`
+	ClosedAt          *time.Time      `db:"closed_at"          json:"closed_at,omitempty"`
+}
+
+// DepositAccrual - проценты за один день. Amount хранится без округления,
+// до копеек округляется только сумма капитализации
+type DepositAccrual struct {
+	ID               int64           `db:"id"                json:"id"`
+	DepositID        int64           `db:"deposit_id"        json:"deposit_id"`
+	AccrualDate      time.Time       `db:"accrual_date"      json:"accrual_date"`
+	Balance          decimal.Decimal `db:"balance"           json:"balance"`
+	AnnualRate       decimal.Decimal `db:"annual_rate"       json:"annual_rate"`
+	Amount           decimal.Decimal `db:"amount"            json:"amount"`
+	CapitalizationID *int64          `db:"capitalization_id" json:"capitalization_id,omitempty"`
+	Forfeited        bool            `db:"forfeited"         json:"forfeited"`
+}
+
+// DepositCapitalization - выплата начисленных процентов на счет вклада
+// транзакцией TransactionID. Нулевая сумма закрывается без транзакции
+type DepositCapitalization struct {
+	ID            int64           `db:"id"             json:"id"`
+	DepositID     int64           `db:"deposit_id"     json:"deposit_id"`
+	Amount        decimal.Decimal `db:"amount"         json:"amount"`
+	TransactionID *int64          `db:"transaction_id" json:"transaction_id,omitempty"`
+	Forfeited     bool            `db:"forfeited"      json:"forfeited"`
+	PaidAt        *time.Time      `db:"paid_at"        json:"paid_at,omitempty"`
+	CreatedAt     time.Time       `db:"created_at"     json:"created_at"`
+}
+
+// DepositInterest - итоги по процентам вклада
+type DepositInterest struct {
+	// Начислено, но еще не капитализировано
+	Accrued decimal.Decimal `json:"accrued"`
+	// Выплачено на счет вклада
+	Paid decimal.Decimal `json:"paid"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type DisputeStatus string
+const (
+	DisputeOpened      DisputeStatus = "OPENED"
+	DisputeUnderReview DisputeStatus = "UNDER_REVIEW"
+	DisputeWon         DisputeStatus = "WON"
+	DisputeLost        DisputeStatus = "LOST"
+)
+
+type DisputeReason string
+const (
+	DisputeFraud           DisputeReason = "FRAUD"
+	DisputeNotReceived     DisputeReason = "NOT_RECEIVED"
+	DisputeDuplicate       DisputeReason = "DUPLICATE"
+	DisputeIncorrectAmount DisputeReason = "INCORRECT_AMOUNT"
+	DisputeCancelled       DisputeReason = "CANCELLED"
+)
+
+// Dispute - оспаривание пользователем списания по карте. CreditTransactionID -
+// временное зачисление на счет на время рассмотрения, ReversalTransactionID -
+// его списание, если спор проигран, ChargebackTransactionID - списание суммы
+// с мерчанта, если спор выигран по уже рассчитанному с ним платежу
+type Dispute struct {
+	ID                      int64           `db:"id"                        json:"id"`
+	PaymentID               int64           `db:"payment_id"                json:"payment_id"`
+	UserID                  int64           `db:"user_id"                   json:"user_id"`
+	AccountID               int64           `db:"account_id"                json:"account_id"`
+	Reason                  DisputeReason   `db:"reason"                    json:"reason"`
+	Comment                 *string         `db:"comment"                   json:"comment,omitempty"`
+	Amount                  decimal.Decimal `db:"amount"                    json:"amount"`
+	Status                  DisputeStatus   `db:"status"                    json:"status"`
+	CreditTransactionID     *int64          `db:"credit_transaction_id"     json:"credit_transaction_id,omitempty"`
+	ReversalTransactionID   *int64          `db:"reversal_transaction_id"   json:"reversal_transaction_id,omitempty"`
+	ChargebackTransactionID *int64          `db:"chargeback_transaction_id" json:"chargeback_transaction_id,omitempty"`
+	Resolution              *string         `db:"resolution"                json:"resolution,omitempty"`
+	ResolvedBy              *int64          `db:"resolved_by"               json:"resolved_by,omitempty"`
+	ResolvedAt              *time.Time      `db:"resolved_at"               json:"resolved_at,omitempty"`
+	CreatedAt               time.Time       `db:"created_at"                json:"created_at"`
+	UpdatedAt               time.Time       `db:"updated_at"                json:"updated_at"`
+}
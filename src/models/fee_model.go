@@ -0,0 +1,43 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// FeeOperation - вид операции, за которую может взиматься комиссия
+type FeeOperation string
+const (
+	FeeP2PTransfer FeeOperation = "P2P_TRANSFER"
+	FeeWithdrawal  FeeOperation = "WITHDRAWAL"
+	FeeCardPayment FeeOperation = "CARD_PAYMENT"
+)
+
+// Fee - операция, учтенная движком комиссий. Операции без комиссии тоже
+// сохраняются: по ним считаются бесплатные месячные лимиты. Комиссия
+// списывается отдельной транзакцией FeeTransactionID, связанной с
+// транзакцией операции TransactionID
+type Fee struct {
+	ID               int64           `db:"id"                 json:"id"`
+	UserID           int64           `db:"user_id"            json:"user_id"`
+	AccountID        int64           `db:"account_id"         json:"account_id"`
+	Operation        FeeOperation    `db:"operation"          json:"operation"`
+	Amount           decimal.Decimal `db:"amount"             json:"amount"`
+	Fee              decimal.Decimal `db:"fee"                json:"fee"`
+	TransactionID    int64           `db:"transaction_id"     json:"transaction_id"`
+	FeeTransactionID *int64          `db:"fee_transaction_id" json:"fee_transaction_id,omitempty"`
+	PostedAt         *time.Time      `db:"posted_at"          json:"posted_at,omitempty"`
+	CreatedAt        time.Time       `db:"created_at"         json:"created_at"`
+}
+
+// FeeQuote - расчет комиссии за операцию с учетом уже использованных в
+// этом месяце бесплатных лимитов
+type FeeQuote struct {
+	Operation FeeOperation    `json:"operation"`
+	Amount    decimal.Decimal `json:"amount"`
+	Fee       decimal.Decimal `json:"fee"`
+	// Сколько бесплатных операций и какой бесплатный объем остается в
+	// месяце без учета этой операции, если правило их задает
+	FreeCountLeft  *int             `json:"free_count_left,omitempty"`
+	FreeAmountLeft *decimal.Decimal `json:"free_amount_left,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type InterbankPaymentStatus string
+const (
+	InterbankPaymentPending  InterbankPaymentStatus = "PENDING"
+	InterbankPaymentSent     InterbankPaymentStatus = "SENT"
+	InterbankPaymentRejected InterbankPaymentStatus = "REJECTED"
+)
+
+// InterbankPayment - платежное поручение в другой банк. Сумма переводится
+// на транзитный счет банка при создании транзакцией DebitTransactionID,
+// поручение ждет отправки в статусе PENDING. При отказе сумма возвращается
+// транзакцией RefundTransactionID. Purpose хранит назначение платежа вместе
+// с отметкой об НДС
+type InterbankPayment struct {
+	ID                   int64                  `db:"id"                     json:"id"`
+	UserID               int64                  `db:"user_id"                json:"user_id"`
+	FromAccountID        int64                  `db:"from_account_id"        json:"from_account_id"`
+	Amount               decimal.Decimal        `db:"amount"                 json:"amount"`
+	RecipientName        string                 `db:"recipient_name"         json:"recipient_name"`
+	RecipientINN         *string                `db:"recipient_inn"          json:"recipient_inn,omitempty"`
+	RecipientKPP         *string                `db:"recipient_kpp"          json:"recipient_kpp,omitempty"`
+	RecipientBIC         string                 `db:"recipient_bic"          json:"recipient_bic"`
+	RecipientBankName    string                 `db:"recipient_bank_name"    json:"recipient_bank_name"`
+	CorrespondentAccount *string                `db:"correspondent_account"  json:"correspondent_account,omitempty"`
+	RecipientAccount     string                 `db:"recipient_account"      json:"recipient_account"`
+	Purpose              string                 `db:"purpose"                json:"purpose"`
+	VATRate              *int                   `db:"vat_rate"               json:"vat_rate,omitempty"`
+	VATAmount            *decimal.Decimal       `db:"vat_amount"             json:"vat_amount,omitempty"`
+	Status               InterbankPaymentStatus `db:"status"                 json:"status"`
+	Attempts             int                    `db:"attempts"               json:"attempts"`
+	GatewayReference     *string                `db:"gateway_reference"      json:"gateway_reference,omitempty"`
+	RejectReason         *string                `db:"reject_reason"          json:"reject_reason,omitempty"`
+	DebitTransactionID   int64                  `db:"debit_transaction_id"   json:"debit_transaction_id"`
+	RefundTransactionID  *int64                 `db:"refund_transaction_id"  json:"refund_transaction_id,omitempty"`
+	CreatedAt            time.Time              `db:"created_at"             json:"created_at"`
+	UpdatedAt            time.Time              `db:"updated_at"             json:"updated_at"`
+}
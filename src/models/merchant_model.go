@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type MerchantScope string
+const (
+	ScopePaymentsWrite   MerchantScope = "payments:write"
+	ScopePaymentsRead    MerchantScope = "payments:read"
+	ScopeSettlementsRead MerchantScope = "settlements:read"
+)
+
+// Merchant - торговая точка, принимающая оплату картами.
+// Выручка зачисляется на расчетный счет SettlementAccountID
+type Merchant struct {
+	ID                  int64     `db:"id"                    json:"id"`
+	Name                string    `db:"name"                  json:"name"`
+	MCC                 string    `db:"mcc"                   json:"mcc"`
+	SettlementAccountID int64     `db:"settlement_account_id" json:"settlement_account_id"`
+	CreatedAt           time.Time `db:"created_at"            json:"created_at"`
+}
+
+// MerchantAPIKey - API-ключ мерчанта. Хранится только ключевой хеш,
+// Prefix - открытая часть ключа для поиска
+type MerchantAPIKey struct {
+	ID         int64           `db:"id"           json:"id"`
+	MerchantID int64           `db:"merchant_id"  json:"merchant_id"`
+	Prefix     string          `db:"prefix"       json:"prefix"`
+	KeyHash    string          `db:"key_hash"     json:"-"`
+	Scopes     []MerchantScope `db:"scopes"       json:"scopes"`
+	ExpiresAt  *time.Time      `db:"expires_at"   json:"expires_at,omitempty"`
+	RevokedAt  *time.Time      `db:"revoked_at"   json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time      `db:"last_used_at" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time       `db:"created_at"   json:"created_at"`
+}
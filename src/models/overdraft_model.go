@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// OverdraftCharge - проценты за пользование овердрафтом за день ChargeDate
+// на отрицательный остаток Balance на конец дня. Списываются со счета
+// транзакцией TransactionID
+type OverdraftCharge struct {
+	ID            int64           `db:"id"             json:"id"`
+	AccountID     int64           `db:"account_id"     json:"account_id"`
+	ChargeDate    time.Time       `db:"charge_date"    json:"charge_date"`
+	Balance       decimal.Decimal `db:"balance"        json:"balance"`
+	AnnualRate    decimal.Decimal `db:"annual_rate"    json:"annual_rate"`
+	Amount        decimal.Decimal `db:"amount"         json:"amount"`
+	TransactionID *int64          `db:"transaction_id" json:"transaction_id,omitempty"`
+	PostedAt      *time.Time      `db:"posted_at"      json:"posted_at,omitempty"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type PaymentStatus string
+const (
+	PaymentCreated             PaymentStatus = "CREATED"
+	PaymentPendingConfirmation PaymentStatus = "PENDING_CONFIRMATION"
+	PaymentCaptured            PaymentStatus = "CAPTURED"
+	PaymentDeclined            PaymentStatus = "DECLINED"
+	PaymentExpired             PaymentStatus = "EXPIRED"
+)
+
+type Payment struct {
+	ID                int64           `db:"id"                  json:"id"`
+	MerchantID        int64           `db:"merchant_id"         json:"merchant_id"`
+	CardID            int64           `db:"card_id"             json:"card_id"`
+	Amount            decimal.Decimal `db:"amount"              json:"amount"`
+	Currency          Currency        `db:"currency"            json:"currency"`
+	Status            PaymentStatus   `db:"status"              json:"status"`
+	FailureReason     *string         `db:"failure_reason"      json:"failure_reason,omitempty"`
+	TransactionID     *int64          `db:"transaction_id"      json:"transaction_id,omitempty"`
+	SettlementBatchID *int64          `db:"settlement_batch_id" json:"settlement_batch_id,omitempty"`
+	CreatedAt         time.Time       `db:"created_at"          json:"created_at"`
+	UpdatedAt         time.Time       `db:"updated_at"          json:"updated_at"`
+}
+
+// PaymentStatusChange - запись истории статусов платежа
+type PaymentStatusChange struct {
+	ID        int64         `db:"id"         json:"id"`
+	PaymentID int64         `db:"payment_id" json:"payment_id"`
+	Status    PaymentStatus `db:"status"     json:"status"`
+	Reason    *string       `db:"reason"     json:"reason,omitempty"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+}
+
+// PaymentConfirmation - одноразовый код подтверждения платежа
+type PaymentConfirmation struct {
+	PaymentID   int64      `db:"payment_id"   json:"payment_id"`
+	CodeHash    string     `db:"code_hash"    json:"-"`
+	Attempts    int        `db:"attempts"     json:"attempts"`
+	ExpiresAt   time.Time  `db:"expires_at"   json:"expires_at"`
+	ConfirmedAt *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at"   json:"created_at"`
+}
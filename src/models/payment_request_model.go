@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type PaymentRequestStatus string
+const (
+	PaymentRequestPending   PaymentRequestStatus = "PENDING"
+	PaymentRequestPaid      PaymentRequestStatus = "PAID"
+	PaymentRequestDeclined  PaymentRequestStatus = "DECLINED"
+	PaymentRequestCancelled PaymentRequestStatus = "CANCELLED"
+	PaymentRequestExpired   PaymentRequestStatus = "EXPIRED"
+)
+
+// PaymentRequest - запрос денег у другого пользователя. Оплата зачисляется
+// на счет запросившего ToAccountID
+type PaymentRequest struct {
+	ID                int64                `db:"id"                   json:"id"`
+	RequesterID       int64                `db:"requester_id"         json:"requester_id"`
+	PayerID           int64                `db:"payer_id"             json:"payer_id"`
+	ToAccountID       int64                `db:"to_account_id"        json:"to_account_id"`
+	Amount            decimal.Decimal      `db:"amount"               json:"amount"`
+	Currency          Currency             `db:"currency"             json:"currency"`
+	Comment           *string              `db:"comment"              json:"comment,omitempty"`
+	Status            PaymentRequestStatus `db:"status"               json:"status"`
+	ExpiresAt         time.Time            `db:"expires_at"           json:"expires_at"`
+	PaidFromAccountID *int64               `db:"paid_from_account_id" json:"paid_from_account_id,omitempty"`
+	TransactionID     *int64               `db:"transaction_id"       json:"transaction_id,omitempty"`
+	DeclineReason     *string              `db:"decline_reason"       json:"decline_reason,omitempty"`
+	CreatedAt         time.Time            `db:"created_at"           json:"created_at"`
+	ResolvedAt        *time.Time           `db:"resolved_at"          json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type SavingsGoalStatus string
+const (
+	SavingsGoalActive SavingsGoalStatus = "ACTIVE"
+	SavingsGoalClosed SavingsGoalStatus = "CLOSED"
+)
+
+// SavingsRule - правило автоматического пополнения цели
+type SavingsRule string
+const (
+	// Округление каждой покупки по карте основного счета до RoundUpStep рублей
+	SavingsRoundUp SavingsRule = "ROUND_UP"
+	// DepositPercent процентов от поступлений на основной счет за месяц
+	SavingsDepositPercent SavingsRule = "DEPOSIT_PERCENT"
+)
+
+type SavingsContributionStatus string
+const (
+	ContributionPending SavingsContributionStatus = "PENDING"
+	ContributionDone    SavingsContributionStatus = "DONE"
+	ContributionSkipped SavingsContributionStatus = "SKIPPED"
+	// Перевод завершился сбоем и мог пройти: пополнение не повторяется
+	ContributionFailed SavingsContributionStatus = "FAILED"
+)
+
+// SavingsGoal - цель накоплений на отдельном счете AccountID, который
+// пополняется переводами с основного счета MainAccountID. Правила действуют
+// для операций после RoundUpSince и DepositPercentSince
+type SavingsGoal struct {
+	ID                  int64             `db:"id"                    json:"id"`
+	UserID              int64             `db:"user_id"               json:"user_id"`
+	MainAccountID       int64             `db:"main_account_id"       json:"main_account_id"`
+	AccountID           int64             `db:"account_id"            json:"account_id"`
+	Name                string            `db:"name"                  json:"name"`
+	TargetAmount        decimal.Decimal   `db:"target_amount"         json:"target_amount"`
+	TargetDate          *time.Time        `db:"target_date"           json:"target_date,omitempty"`
+	RoundUpStep         *int              `db:"round_up_step"         json:"round_up_step,omitempty"`
+	RoundUpSince        *time.Time        `db:"round_up_since"        json:"round_up_since,omitempty"`
+	DepositPercent      *decimal.Decimal  `db:"deposit_percent"       json:"deposit_percent,omitempty"`
+	DepositPercentSince *time.Time        `db:"deposit_percent_since" json:"deposit_percent_since,omitempty"`
+	Status              SavingsGoalStatus `db:"status"                json:"status"`
+	ReachedAt           *time.Time        `db:"reached_at"            json:"reached_at,omitempty"`
+	CreatedAt           time.Time         `db:"created_at"            json:"created_at"`
+	ClosedAt            *time.Time        `db:"closed_at"             json:"closed_at,omitempty"`
+}
+
+// SavingsContribution - автоматическое пополнение цели по правилу. Для
+// округления SourceTransactionID - списание по покупке, для процента от
+// поступлений Period - первый день месяца. Пополнение, которое не удалось
+// провести (например, не хватило средств), пропускается с причиной Reason
+type SavingsContribution struct {
+	ID                  int64                     `db:"id"                    json:"id"`
+	GoalID              int64                     `db:"goal_id"               json:"goal_id"`
+	Rule                SavingsRule               `db:"rule"                  json:"rule"`
+	SourceTransactionID *int64                    `db:"source_transaction_id" json:"source_transaction_id,omitempty"`
+	Period              *time.Time                `db:"period"                json:"period,omitempty"`
+	Amount              decimal.Decimal           `db:"amount"                json:"amount"`
+	Status              SavingsContributionStatus `db:"status"                json:"status"`
+	Reason              *string                   `db:"reason"                json:"reason,omitempty"`
+	CreatedAt           time.Time                 `db:"created_at"            json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type SBPQRType string
+const (
+	SBPQRStatic  SBPQRType = "STATIC"
+	SBPQRDynamic SBPQRType = "DYNAMIC"
+)
+
+type SBPQRStatus string
+const (
+	SBPQRActive  SBPQRStatus = "ACTIVE"
+	SBPQRPaid    SBPQRStatus = "PAID"
+	SBPQRExpired SBPQRStatus = "EXPIRED"
+)
+
+// SBPQRCode - платежная ссылка СБП на счет пользователя. Статическая ссылка
+// многоразовая, сумма в ней необязательна. Динамическая оплачивается один
+// раз до ExpiresAt
+type SBPQRCode struct {
+	ID        int64            `db:"id"         json:"id"`
+	QRID      string           `db:"qr_id"      json:"qr_id"`
+	UserID    int64            `db:"user_id"    json:"user_id"`
+	AccountID int64            `db:"account_id" json:"account_id"`
+	Type      SBPQRType        `db:"type"       json:"type"`
+	Amount    *decimal.Decimal `db:"amount"     json:"amount,omitempty"`
+	Purpose   *string          `db:"purpose"    json:"purpose,omitempty"`
+	Status    SBPQRStatus      `db:"status"     json:"status"`
+	ExpiresAt *time.Time       `db:"expires_at" json:"expires_at,omitempty"`
+	PaidAt    *time.Time       `db:"paid_at"    json:"paid_at,omitempty"`
+	CreatedAt time.Time        `db:"created_at" json:"created_at"`
+}
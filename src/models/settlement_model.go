@@ -0,0 +1,41 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// SettlementBatch - расчет с мерчантом за операционный день. Комиссия
+// эквайринга удерживается с каждого платежа, NetAmount зачисляется на
+// расчетный счет мерчанта транзакцией TransactionID, FeeAmount - на счет
+// доходов банка транзакцией FeeTransactionID
+type SettlementBatch struct {
+	ID               int64           `db:"id"                 json:"id"`
+	MerchantID       int64           `db:"merchant_id"        json:"merchant_id"`
+	BusinessDate     time.Time       `db:"business_date"      json:"business_date"`
+	Currency         Currency        `db:"currency"           json:"currency"`
+	FeeRate          decimal.Decimal `db:"fee_rate"           json:"fee_rate"`
+	PaymentCount     int             `db:"payment_count"      json:"payment_count"`
+	GrossAmount      decimal.Decimal `db:"gross_amount"       json:"gross_amount"`
+	FeeAmount        decimal.Decimal `db:"fee_amount"         json:"fee_amount"`
+	NetAmount        decimal.Decimal `db:"net_amount"         json:"net_amount"`
+	TransactionID    *int64          `db:"transaction_id"     json:"transaction_id,omitempty"`
+	FeeTransactionID *int64          `db:"fee_transaction_id" json:"fee_transaction_id,omitempty"`
+	CreatedAt        time.Time       `db:"created_at"         json:"created_at"`
+}
+
+// SettlementReportLine - платеж в отчете о расчете
+type SettlementReportLine struct {
+	PaymentID  int64           `json:"payment_id"`
+	CardID     int64           `json:"card_id"`
+	Amount     decimal.Decimal `json:"amount"`
+	Fee        decimal.Decimal `json:"fee"`
+	Net        decimal.Decimal `json:"net"`
+	CapturedAt time.Time       `json:"captured_at"`
+}
+
+type SettlementReport struct {
+	Batch    *SettlementBatch       `json:"batch"`
+	Merchant *Merchant              `json:"merchant"`
+	Lines    []SettlementReportLine `json:"lines"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type ScheduleType string
+const (
+	ScheduleOnce    ScheduleType = "ONCE"
+	ScheduleWeekly  ScheduleType = "WEEKLY"
+	ScheduleMonthly ScheduleType = "MONTHLY"
+	ScheduleCron    ScheduleType = "CRON"
+)
+
+type StandingOrderStatus string
+const (
+	StandingOrderActive    StandingOrderStatus = "ACTIVE"
+	StandingOrderPaused    StandingOrderStatus = "PAUSED"
+	StandingOrderCancelled StandingOrderStatus = "CANCELLED"
+	StandingOrderCompleted StandingOrderStatus = "COMPLETED"
+	StandingOrderFailed    StandingOrderStatus = "FAILED"
+)
+
+type ExecutionStatus string
+const (
+	ExecutionSucceeded ExecutionStatus = "SUCCEEDED"
+	ExecutionRetrying  ExecutionStatus = "RETRYING"
+	ExecutionFailed    ExecutionStatus = "FAILED"
+)
+
+// StandingOrder - регулярный или отложенный перевод. ScheduledFor - плановое
+// время текущего запуска, NextRunAt - когда его выполнить (при повторах
+// после нехватки средств позже ScheduledFor)
+type StandingOrder struct {
+	ID            int64               `db:"id"              json:"id"`
+	UserID        int64               `db:"user_id"         json:"user_id"`
+	FromAccountID int64               `db:"from_account_id" json:"from_account_id"`
+	ToAccountID   int64               `db:"to_account_id"   json:"to_account_id"`
+	Amount        decimal.Decimal     `db:"amount"          json:"amount"`
+	Description   *string             `db:"description"     json:"description,omitempty"`
+	ScheduleType  ScheduleType        `db:"schedule_type"   json:"schedule_type"`
+	RunAt         *time.Time          `db:"run_at"          json:"run_at,omitempty"`
+	Weekday       *int                `db:"weekday"         json:"weekday,omitempty"`
+	DayOfMonth    *int                `db:"day_of_month"    json:"day_of_month,omitempty"`
+	CronExpr      *string             `db:"cron_expr"       json:"cron_expr,omitempty"`
+	Status        StandingOrderStatus `db:"status"          json:"status"`
+	ScheduledFor  *time.Time          `db:"scheduled_for"   json:"scheduled_for,omitempty"`
+	NextRunAt     *time.Time          `db:"next_run_at"     json:"next_run_at,omitempty"`
+	RetryCount    int                 `db:"retry_count"     json:"retry_count"`
+	CreatedAt     time.Time           `db:"created_at"      json:"created_at"`
+	UpdatedAt     time.Time           `db:"updated_at"      json:"updated_at"`
+}
+
+// StandingOrderExecution - попытка выполнить перевод по поручению
+type StandingOrderExecution struct {
+	ID           int64           `db:"id"            json:"id"`
+	OrderID      int64           `db:"order_id"      json:"order_id"`
+	ScheduledFor time.Time       `db:"scheduled_for" json:"scheduled_for"`
+	Attempt      int             `db:"attempt"       json:"attempt"`
+	Status       ExecutionStatus `db:"status"        json:"status"`
+	Error        *string         `db:"error"         json:"error,omitempty"`
+	CreatedAt    time.Time       `db:"created_at"    json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Statement - выписка по счету за период [From, To). Transactions
+// упорядочены по времени проведения
+type Statement struct {
+	Account        *Account        `json:"account"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	TotalIn        decimal.Decimal `json:"total_in"`
+	TotalOut       decimal.Decimal `json:"total_out"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+	Transactions   []*Transaction  `json:"transactions"`
+}
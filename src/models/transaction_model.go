@@ -25,5 +25,9 @@ type Transaction struct {
 	Amount    decimal.Decimal `db:"amount" json:"amount"`
 	Type      TransactionType            `db:"type"        json:"type"`
 	Status    TransactionStatus          `db:"status"      json:"status"`
+	// Для переводов - счет второй стороны и назначение платежа
+	CounterpartyAccountID     *int64  `db:"counterparty_account_id"     json:"counterparty_account_id,omitempty"`
+	CounterpartyAccountNumber *string `db:"counterparty_account_number" json:"counterparty_account_number,omitempty"`
+	Description               *string `db:"description"                 json:"description,omitempty"`
 	CreatedAt time.Time       `db:"created_at"  json:"created_at"`
 }
\ No newline at end of file
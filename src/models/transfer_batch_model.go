@@ -0,0 +1,52 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type TransferBatchStatus string
+const (
+	TransferBatchProcessing TransferBatchStatus = "PROCESSING"
+	TransferBatchCompleted  TransferBatchStatus = "COMPLETED"
+	TransferBatchPartial    TransferBatchStatus = "PARTIALLY_COMPLETED"
+	TransferBatchFailed     TransferBatchStatus = "FAILED"
+)
+
+type TransferItemStatus string
+const (
+	TransferItemPending   TransferItemStatus = "PENDING"
+	TransferItemSucceeded TransferItemStatus = "SUCCEEDED"
+	TransferItemFailed    TransferItemStatus = "FAILED"
+)
+
+// TransferBatch - пакет переводов с одного счета (например, зарплатная
+// ведомость). Сумма всего пакета списывается при создании транзакцией
+// ReserveTransactionID, суммы неудавшихся переводов возвращаются одной
+// транзакцией RefundTransactionID
+type TransferBatch struct {
+	ID                   int64               `db:"id"                     json:"id"`
+	UserID               int64               `db:"user_id"                json:"user_id"`
+	FromAccountID        int64               `db:"from_account_id"        json:"from_account_id"`
+	Currency             Currency            `db:"currency"               json:"currency"`
+	TotalAmount          decimal.Decimal     `db:"total_amount"           json:"total_amount"`
+	ItemCount            int                 `db:"item_count"             json:"item_count"`
+	Status               TransferBatchStatus `db:"status"                 json:"status"`
+	ReserveTransactionID int64               `db:"reserve_transaction_id" json:"reserve_transaction_id"`
+	RefundTransactionID  *int64              `db:"refund_transaction_id"  json:"refund_transaction_id,omitempty"`
+	CreatedAt            time.Time           `db:"created_at"             json:"created_at"`
+	CompletedAt          *time.Time          `db:"completed_at"           json:"completed_at,omitempty"`
+}
+
+type TransferBatchItem struct {
+	ID            int64              `db:"id"             json:"id"`
+	BatchID       int64              `db:"batch_id"       json:"batch_id"`
+	Line          int                `db:"line"           json:"line"`
+	ToAccountID   int64              `db:"to_account_id"  json:"to_account_id"`
+	Amount        decimal.Decimal    `db:"amount"         json:"amount"`
+	Purpose       *string            `db:"purpose"        json:"purpose,omitempty"`
+	Status        TransferItemStatus `db:"status"         json:"status"`
+	Error         *string            `db:"error"          json:"error,omitempty"`
+	TransactionID *int64             `db:"transaction_id" json:"transaction_id,omitempty"`
+	ProcessedAt   *time.Time         `db:"processed_at"   json:"processed_at,omitempty"`
+}
@@ -2,9 +2,30 @@ package models
 
 import "time"
 
+type UserRole string
+const (
+	RoleUser  UserRole = "USER"
+	RoleAdmin UserRole = "ADMIN"
+)
+
 type User struct {
-	ID        int64     `db:"id" json:"id"`
-	Email     string    `db:"email" json:"email"`
-	Password  string    `db:"password_hash" json:"-"`
+	ID              int64      `db:"id" json:"id"`
+	Email           string     `db:"email" json:"email"`
+	Password        string     `db:"password_hash" json:"-"`
+	Role            UserRole   `db:"role" json:"role"`
+	FirstName       string     `db:"first_name" json:"first_name"`
+	LastName        string     `db:"last_name" json:"last_name"`
+	Phone           *string    `db:"phone" json:"phone,omitempty"`
+	PhoneVerifiedAt *time.Time `db:"phone_verified_at" json:"phone_verified_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+// PhoneVerification - код подтверждения номера телефона, отправленный пользователю
+type PhoneVerification struct {
+	UserID    int64     `db:"user_id"    json:"user_id"`
+	Phone     string    `db:"phone"      json:"phone"`
+	CodeHash  string    `db:"code_hash"  json:"-"`
+	Attempts  int       `db:"attempts"   json:"attempts"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
-}
\ No newline at end of file
+}
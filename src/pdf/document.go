@@ -0,0 +1,392 @@
+// Package pdf - минимальный генератор PDF 1.7 без внешних зависимостей:
+// страницы с текстом, линиями и прямоугольниками и встроенные шрифты
+// TrueType, в том числе с кириллицей.
+//
+// Координаты задаются в пунктах (1/72 дюйма) от левого нижнего угла страницы.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+type Document struct {
+	Title    string
+	Author   string
+	Created  time.Time
+	pages    []*Page
+	fonts    []*documentFont
+	fontRefs map[*Font]*documentFont
+}
+
+// documentFont - шрифт в документе вместе с использованными глифами,
+// по которым строятся таблица ширин и ToUnicode
+type documentFont struct {
+	font   *Font
+	name   string
+	usedBy map[uint16]rune
+}
+
+func NewDocument() *Document {
+	return &Document{fontRefs: map[*Font]*documentFont{}}
+}
+
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{doc: d, Width: width, Height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+func (d *Document) fontRef(f *Font) *documentFont {
+	ref, ok := d.fontRefs[f]
+	if !ok {
+		ref = &documentFont{font: f, name: fmt.Sprintf("F%d", len(d.fonts)+1), usedBy: map[uint16]rune{}}
+		d.fonts = append(d.fonts, ref)
+		d.fontRefs[f] = ref
+	}
+	return ref
+}
+
+type Page struct {
+	doc     *Document
+	Width   float64
+	Height  float64
+	content bytes.Buffer
+	font    *documentFont
+	size    float64
+}
+
+func (p *Page) SetFont(f *Font, size float64) {
+	p.font = p.doc.fontRef(f)
+	p.size = size
+}
+
+// SetFillColor задает цвет заливки и текста компонентами RGB от 0 до 1
+func (p *Page) SetFillColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", num(r), num(g), num(b))
+}
+
+func (p *Page) SetStrokeColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s RG\n", num(r), num(g), num(b))
+}
+
+func (p *Page) SetLineWidth(w float64) {
+	fmt.Fprintf(&p.content, "%s w\n", num(w))
+}
+
+// Text выводит строку текущим шрифтом, (x, y) - начало базовой линии.
+// Символы, которых нет в шрифте, выводятся пустым глифом
+func (p *Page) Text(x, y float64, s string) {
+	if p.font == nil {
+		panic("pdf: шрифт не задан")
+	}
+
+	var hex strings.Builder
+	for _, r := range s {
+		gid := p.font.font.GlyphID(r)
+		if _, ok := p.font.usedBy[gid]; !ok {
+			p.font.usedBy[gid] = r
+		}
+		fmt.Fprintf(&hex, "%04X", gid)
+	}
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td <%s> Tj ET\n", p.font.name, num(p.size), num(x), num(y), hex.String())
+}
+
+// TextRight выводит строку, выровненную по правому краю right
+func (p *Page) TextRight(right, y float64, s string) {
+	p.Text(right-p.TextWidth(s), y, s)
+}
+
+func (p *Page) TextCenter(center, y float64, s string) {
+	p.Text(center-p.TextWidth(s)/2, y, s)
+}
+
+// TextWidth возвращает ширину строки текущим шрифтом
+func (p *Page) TextWidth(s string) float64 {
+	return p.font.font.TextWidth(s, p.size)
+}
+
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", num(x1), num(y1), num(x2), num(y2))
+}
+
+// Rect рисует прямоугольник с левым нижним углом (x, y): с заливкой,
+// контуром или тем и другим
+func (p *Page) Rect(x, y, w, h float64, fill, stroke bool) {
+	op := "n"
+	switch {
+	case fill && stroke:
+		op = "B"
+	case fill:
+		op = "f"
+	case stroke:
+		op = "S"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(y), num(w), num(h), op)
+}
+
+// Circle рисует окружность четырьмя кривыми Безье
+func (p *Page) Circle(cx, cy, r float64) {
+	const k = 0.5523
+	kr := k * r
+	fmt.Fprintf(&p.content, "%s %s m\n", num(cx+r), num(cy))
+	fmt.Fprintf(&p.content, "%s %s %s %s %s %s c\n", num(cx+r), num(cy+kr), num(cx+kr), num(cy+r), num(cx), num(cy+r))
+	fmt.Fprintf(&p.content, "%s %s %s %s %s %s c\n", num(cx-kr), num(cy+r), num(cx-r), num(cy+kr), num(cx-r), num(cy))
+	fmt.Fprintf(&p.content, "%s %s %s %s %s %s c\n", num(cx-r), num(cy-kr), num(cx-kr), num(cy-r), num(cx), num(cy-r))
+	fmt.Fprintf(&p.content, "%s %s %s %s %s %s c S\n", num(cx+kr), num(cy-r), num(cx+r), num(cy-kr), num(cx+r), num(cy))
+}
+
+// Write выводит документ. Потоки сжимаются, из шрифтов встраиваются только
+// глифы, которые есть в тексте
+func (d *Document) Write(w io.Writer) error {
+	pw := &objectWriter{w: w}
+	pw.printf("%%PDF-1.7\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Номера объектов: 1 - каталог, 2 - дерево страниц, 3 - сведения о
+	// документе, далее по два объекта на страницу и по шесть на шрифт
+	const catalogID, pagesID, infoID = 1, 2, 3
+	nextID := 4
+	pageIDs := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = nextID
+		nextID += 2
+	}
+	fontIDs := make([]int, len(d.fonts))
+	for i := range d.fonts {
+		fontIDs[i] = nextID
+		nextID += 6
+	}
+
+	pw.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	kids := make([]string, len(pageIDs))
+	for i, id := range pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	pw.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageIDs)))
+
+	created := d.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	pw.object(infoID, fmt.Sprintf("<< /Title %s /Author %s /Producer (sf-finances) /CreationDate %s >>",
+		textString(d.Title), textString(d.Author), pdfString(pdfDate(created))))
+
+	var fontResources strings.Builder
+	for i, f := range d.fonts {
+		fmt.Fprintf(&fontResources, "/%s %d 0 R ", f.name, fontIDs[i])
+	}
+
+	for i, page := range d.pages {
+		pw.object(pageIDs[i], fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			pagesID, num(page.Width), num(page.Height), fontResources.String(), pageIDs[i]+1))
+		if err := pw.stream(pageIDs[i]+1, "", page.content.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	for i, f := range d.fonts {
+		if err := pw.font(fontIDs[i], f); err != nil {
+			return err
+		}
+	}
+
+	return pw.finish(nextID, catalogID, infoID)
+}
+
+// font записывает шрифт Type0 с кодировкой Identity-H: коды символов в
+// тексте совпадают с номерами глифов шрифта
+func (pw *objectWriter) font(id int, f *documentFont) error {
+	descendantID, descriptorID, fileID, toUnicodeID, cidSystemID := id+1, id+2, id+3, id+4, id+5
+	font := f.font
+
+	gids := make([]int, 0, len(f.usedBy))
+	for gid := range f.usedBy {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	data, err := font.subset(gids)
+	if err != nil {
+		return err
+	}
+	name := font.subsetName(gids)
+
+	pw.object(id, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, descendantID, toUnicodeID))
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, font.glyphWidth(uint16(gid)))
+	}
+	pw.object(descendantID, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo %d 0 R /FontDescriptor %d 0 R "+
+			"/DW %d /W [%s] /CIDToGIDMap /Identity >>",
+		name, cidSystemID, descriptorID, font.glyphWidth(0), widths.String()))
+
+	flags := 32 // Nonsymbolic
+	if font.italicAngle != 0 {
+		flags |= 64
+	}
+	pw.object(descriptorID, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] /ItalicAngle %s "+
+			"/Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, flags, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]),
+		font.scale(font.bbox[3]), num(font.italicAngle), font.scale(font.ascent), font.scale(font.descent),
+		font.scale(font.capHeight), fileID))
+
+	if err := pw.stream(fileID, fmt.Sprintf("/Length1 %d", len(data)), data); err != nil {
+		return err
+	}
+	if err := pw.stream(toUnicodeID, "", toUnicodeCMap(gids, f.usedBy)); err != nil {
+		return err
+	}
+
+	pw.object(cidSystemID, "<< /Registry (Adobe) /Ordering (Identity) /Supplement 0 >>")
+	return nil
+}
+
+// toUnicodeCMap сопоставляет глифы символам, чтобы текст из документа можно
+// было искать и копировать
+func toUnicodeCMap(gids []int, usedBy map[uint16]rune) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	// В одном блоке bfchar допускается не больше 100 записей
+	for start := 0; start < len(gids); start += 100 {
+		end := min(start+100, len(gids))
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			fmt.Fprintf(&b, "<%04X> <%04X>\n", gid, usedBy[uint16(gid)])
+		}
+		b.WriteString("endbfchar\n")
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// objectWriter пишет объекты и запоминает их смещения для таблицы xref.
+// Первая ошибка записи сохраняется и возвращается из finish
+type objectWriter struct {
+	w       io.Writer
+	offset  int
+	offsets map[int]int
+	err     error
+}
+
+func (pw *objectWriter) printf(format string, args ...any) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.offset += n
+	pw.err = err
+}
+
+func (pw *objectWriter) write(b []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(b)
+	pw.offset += n
+	pw.err = err
+}
+
+func (pw *objectWriter) object(id int, body string) {
+	pw.begin(id)
+	pw.printf("%s\nendobj\n", body)
+}
+
+func (pw *objectWriter) begin(id int) {
+	if pw.offsets == nil {
+		pw.offsets = map[int]int{}
+	}
+	pw.offsets[id] = pw.offset
+	pw.printf("%d 0 obj\n", id)
+}
+
+// stream записывает поток, сжатый Flate; extra - дополнительные ключи словаря
+func (pw *objectWriter) stream(id int, extra string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	pw.begin(id)
+	pw.printf("<< /Length %d /Filter /FlateDecode %s>>\nstream\n", compressed.Len(), extra)
+	pw.write(compressed.Bytes())
+	pw.printf("\nendstream\nendobj\n")
+	return pw.err
+}
+
+func (pw *objectWriter) finish(size, rootID, infoID int) error {
+	xrefOffset := pw.offset
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		pw.printf("%010d 00000 n \n", pw.offsets[id])
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, rootID, infoID, xrefOffset)
+	return pw.err
+}
+
+// num форматирует число без лишних нулей: PDF не принимает экспоненту
+func num(v float64) string {
+	s := fmt.Sprintf("%.3f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+func pdfString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	return "(" + r.Replace(s) + ")"
+}
+
+// textString кодирует строку в UTF-16BE с BOM для полей сведений о документе
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("D:%s%c%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
@@ -0,0 +1,194 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// Таблицы, которые остаются в подмножестве шрифта. Программы хинтинга (cvt,
+// fpgm, prep) переносятся, если они есть. Таблица post с именами глифов
+// не нужна и отбрасывается
+var subsetTables = []string{"OS/2", "cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "prep"}
+
+// Флаги компонентов составного глифа
+const (
+	glyphArgsAreWords  = 0x0001
+	glyphHaveScale     = 0x0008
+	glyphMoreComponent = 0x0020
+	glyphHaveXYScale   = 0x0040
+	glyphHave2x2       = 0x0080
+)
+
+// subset собирает шрифт, в котором остаются только глифы gids, их составные
+// части и глиф 0. Номера глифов не меняются: остальные глифы становятся
+// пустыми, поэтому коды в тексте документа остаются верными. Шрифт без
+// таблиц glyf и loca встраивается целиком
+func (f *Font) subset(gids []int) ([]byte, error) {
+	glyf, loca, head := f.tables["glyf"], f.tables["loca"], f.tables["head"]
+	if glyf == nil || loca == nil || len(head) < 54 {
+		return f.data, nil
+	}
+
+	numGlyphs := len(f.advances)
+	offsets, err := glyphOffsets(loca, fontReader(head).i16(50) == 1, numGlyphs, len(glyf))
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[int]bool{0: true}
+	queue := append([]int{0}, gids...)
+	for len(queue) > 0 {
+		gid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if gid < 0 || gid >= numGlyphs {
+			continue
+		}
+		keep[gid] = true
+		for _, c := range glyphComponents(glyf[offsets[gid]:offsets[gid+1]]) {
+			if !keep[c] {
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	// Глифы выравниваются на 4 байта, loca всегда в длинном формате
+	var newGlyf []byte
+	newLoca := make([]byte, 4*(numGlyphs+1))
+	for gid := 0; gid < numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(newLoca[4*gid:], uint32(len(newGlyf)))
+		if keep[gid] {
+			newGlyf = append(newGlyf, glyf[offsets[gid]:offsets[gid+1]]...)
+			for len(newGlyf)%4 != 0 {
+				newGlyf = append(newGlyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*numGlyphs:], uint32(len(newGlyf)))
+
+	newHead := append([]byte(nil), head...)
+	binary.BigEndian.PutUint32(newHead[8:], 0)
+	binary.BigEndian.PutUint16(newHead[50:], 1)
+
+	tables := map[string][]byte{"glyf": newGlyf, "loca": newLoca, "head": newHead}
+	for _, tag := range subsetTables {
+		if _, ok := tables[tag]; !ok && f.tables[tag] != nil {
+			tables[tag] = f.tables[tag]
+		}
+	}
+	return writeFont(tables), nil
+}
+
+// subsetName - имя подмножества шрифта: шесть заглавных букв по набору
+// глифов и "+" перед именем шрифта (ISO 32000-1, 9.6.4)
+func (f *Font) subsetName(gids []int) string {
+	h := fnv.New32a()
+	for _, gid := range gids {
+		h.Write([]byte{byte(gid >> 8), byte(gid)})
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag) + "+" + f.name
+}
+
+// glyphOffsets читает из loca смещения numGlyphs+1 глифов в таблице glyf
+func glyphOffsets(loca []byte, long bool, numGlyphs int, glyfLen int) ([]int, error) {
+	r := fontReader(loca)
+	offsets := make([]int, numGlyphs+1)
+	for i := range offsets {
+		if long {
+			offsets[i] = int(r.u32(4 * i))
+		} else {
+			offsets[i] = 2 * int(r.u16(2*i))
+		}
+		if offsets[i] > glyfLen || i > 0 && offsets[i] < offsets[i-1] {
+			return nil, fmt.Errorf("%w: повреждена таблица loca", ErrUnsupportedFont)
+		}
+	}
+	return offsets, nil
+}
+
+// glyphComponents возвращает глифы, из которых состоит составной глиф
+func glyphComponents(glyph []byte) []int {
+	r := fontReader(glyph)
+	if len(glyph) < 10 || r.i16(0) >= 0 {
+		return nil
+	}
+
+	var components []int
+	for pos := 10; pos+4 <= len(glyph); {
+		flags := r.u16(pos)
+		components = append(components, int(r.u16(pos+2)))
+		pos += 4
+		if flags&glyphArgsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&glyphHaveScale != 0:
+			pos += 2
+		case flags&glyphHaveXYScale != 0:
+			pos += 4
+		case flags&glyphHave2x2 != 0:
+			pos += 8
+		}
+		if flags&glyphMoreComponent == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// writeFont собирает файл TrueType из таблиц
+func writeFont(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	header := make([]byte, 12+16*numTables)
+	binary.BigEndian.PutUint32(header[0:], 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(16*numTables-searchRange))
+
+	var body []byte
+	for i, tag := range tags {
+		data := tables[tag]
+		rec := header[12+16*i:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], tableChecksum(data))
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(header)+len(body)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(data)))
+		body = append(body, data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	return append(header, body...)
+}
+
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,91 @@
+package pdf
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func loadTestFont(t *testing.T, name string) *Font {
+	t.Helper()
+	f, err := LoadFont(filepath.Join("..", "..", "data", "fonts", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestSubset(t *testing.T) {
+	tests := []struct {
+		font string
+		text string
+	}{
+		{"DejaVuSans.ttf", "Выписка по счету 40817810000000000001"},
+		{"DejaVuSans-Bold.ttf", "Итого: 1 234,56 ₽"},
+		{"DejaVuSans.ttf", "Ёё Йй àé"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.font+"/"+tt.text, func(t *testing.T) {
+			f := loadTestFont(t, tt.font)
+
+			var gids []int
+			for _, r := range tt.text {
+				gids = append(gids, int(f.GlyphID(r)))
+			}
+			data, err := f.subset(gids)
+			if err != nil {
+				t.Fatalf("subset: %v", err)
+			}
+			if len(data) >= len(f.data)/4 {
+				t.Errorf("размер подмножества %d, полный шрифт %d", len(data), len(f.data))
+			}
+
+			sub, err := ParseFont(data)
+			if err != nil {
+				t.Fatalf("ParseFont: %v", err)
+			}
+			if len(sub.advances) != len(f.advances) {
+				t.Errorf("глифов %d, ожидалось %d", len(sub.advances), len(f.advances))
+			}
+
+			offsets, err := glyphOffsets(sub.tables["loca"], true, len(sub.advances), len(sub.tables["glyf"]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range tt.text {
+				gid := f.GlyphID(r)
+				if sub.GlyphID(r) != gid {
+					t.Errorf("символ %q: глиф %d, ожидался %d", r, sub.GlyphID(r), gid)
+				}
+				if r != ' ' && offsets[gid] == offsets[gid+1] {
+					t.Errorf("символ %q: глиф %d пуст", r, gid)
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentEmbedsSubset(t *testing.T) {
+	regular := loadTestFont(t, "DejaVuSans.ttf")
+
+	doc := NewDocument()
+	for i := 0; i < 3; i++ {
+		page := doc.AddPage(A4Width, A4Height)
+		page.SetFont(regular, 9)
+		for row := 0; row < 40; row++ {
+			page.Text(40, 800-float64(row)*18, "12.10.2026 Перевод по номеру телефона 1 500,00")
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() > 100<<10 {
+		t.Errorf("размер документа %d байт", buf.Len())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("+"+regular.name)) {
+		t.Errorf("в документе нет имени подмножества шрифта")
+	}
+}
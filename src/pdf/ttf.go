@@ -0,0 +1,303 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"unicode/utf16"
+)
+
+var ErrUnsupportedFont = errors.New("неподдерживаемый шрифт")
+
+// Font - шрифт TrueType для встраивания в документ. Из файла читаются только
+// таблицы, нужные для вывода текста: метрики, ширины глифов и таблица
+// символов cmap
+type Font struct {
+	name        string
+	data        []byte
+	tables      map[string][]byte
+	unitsPerEm  int
+	ascent      int
+	descent     int
+	capHeight   int
+	bbox        [4]int
+	italicAngle float64
+	glyphs      map[rune]uint16
+	advances    []uint16
+}
+
+// LoadFont читает шрифт TrueType (.ttf). Шрифты OpenType с контурами CFF
+// (.otf) не поддерживаются
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+func ParseFont(data []byte) (*Font, error) {
+	r := fontReader(data)
+	if len(data) < 12 {
+		return nil, ErrUnsupportedFont
+	}
+	if version := r.u32(0); version != 0x00010000 && version != 0x74727565 {
+		return nil, fmt.Errorf("%w: нужен шрифт с контурами TrueType", ErrUnsupportedFont)
+	}
+
+	tables := map[string][]byte{}
+	numTables := int(r.u16(4))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, ErrUnsupportedFont
+		}
+		tag := string(data[rec : rec+4])
+		offset, length := int(r.u32(rec+8)), int(r.u32(rec+12))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("%w: таблица %s за пределами файла", ErrUnsupportedFont, tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: нет таблицы %s", ErrUnsupportedFont, tag)
+		}
+	}
+
+	f := &Font{data: data, tables: tables, name: "Font"}
+
+	head := fontReader(tables["head"])
+	f.unitsPerEm = int(head.u16(18))
+	f.bbox = [4]int{int(head.i16(36)), int(head.i16(38)), int(head.i16(40)), int(head.i16(42))}
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("%w: unitsPerEm = 0", ErrUnsupportedFont)
+	}
+
+	hhea := fontReader(tables["hhea"])
+	f.ascent = int(hhea.i16(4))
+	f.descent = int(hhea.i16(6))
+	f.capHeight = f.ascent
+	numHMetrics := int(hhea.u16(34))
+
+	numGlyphs := int(fontReader(tables["maxp"]).u16(4))
+	hmtx := fontReader(tables["hmtx"])
+	if numHMetrics == 0 || len(hmtx) < 4*numHMetrics {
+		return nil, fmt.Errorf("%w: повреждена таблица hmtx", ErrUnsupportedFont)
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for gid := range f.advances {
+		if gid < numHMetrics {
+			f.advances[gid] = hmtx.u16(4 * gid)
+		} else {
+			f.advances[gid] = f.advances[numHMetrics-1]
+		}
+	}
+
+	if os2 := fontReader(tables["OS/2"]); len(os2) >= 90 && os2.u16(0) >= 2 {
+		f.capHeight = int(os2.i16(88))
+	}
+	if post := fontReader(tables["post"]); len(post) >= 8 {
+		f.italicAngle = float64(int32(post.u32(4))) / 65536
+	}
+	if name := postScriptName(tables["name"]); name != "" {
+		f.name = name
+	}
+
+	glyphs, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs = glyphs
+
+	return f, nil
+}
+
+// GlyphID возвращает глиф символа; 0 - символа в шрифте нет
+func (f *Font) GlyphID(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// glyphWidth возвращает ширину глифа в тысячных долях кегля
+func (f *Font) glyphWidth(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return int(f.advances[gid]) * 1000 / f.unitsPerEm
+}
+
+// TextWidth возвращает ширину строки в пунктах при кегле size
+func (f *Font) TextWidth(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		total += f.glyphWidth(f.GlyphID(r))
+	}
+	return float64(total) * size / 1000
+}
+
+func (f *Font) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+func parseCmap(table []byte) (map[rune]uint16, error) {
+	r := fontReader(table)
+	if len(table) < 4 {
+		return nil, fmt.Errorf("%w: повреждена таблица cmap", ErrUnsupportedFont)
+	}
+
+	// Предпочтение - полной таблице Unicode (формат 12), затем BMP (формат 4)
+	var best []byte
+	bestRank := 0
+	numTables := int(r.u16(2))
+	for i := 0; i < numTables; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(table) {
+			break
+		}
+		platform, encoding, offset := r.u16(rec), r.u16(rec+2), int(r.u32(rec+4))
+		if offset+2 > len(table) {
+			continue
+		}
+		format := r.u16(offset)
+		rank := 0
+		switch {
+		case format == 12 && (platform == 3 && encoding == 10 || platform == 0):
+			rank = 2
+		case format == 4 && (platform == 3 && encoding == 1 || platform == 0):
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = table[offset:], rank
+		}
+	}
+
+	switch bestRank {
+	case 2:
+		return parseCmapFormat12(best)
+	case 1:
+		return parseCmapFormat4(best)
+	default:
+		return nil, fmt.Errorf("%w: нет таблицы символов Unicode", ErrUnsupportedFont)
+	}
+}
+
+func parseCmapFormat4(sub []byte) (map[rune]uint16, error) {
+	r := fontReader(sub)
+	if len(sub) < 14 {
+		return nil, fmt.Errorf("%w: повреждена таблица cmap", ErrUnsupportedFont)
+	}
+	segCount := int(r.u16(6)) / 2
+	endCodes := 14
+	startCodes := endCodes + 2*segCount + 2
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+	if idRangeOffsets+2*segCount > len(sub) {
+		return nil, fmt.Errorf("%w: повреждена таблица cmap", ErrUnsupportedFont)
+	}
+
+	glyphs := map[rune]uint16{}
+	for i := 0; i < segCount; i++ {
+		end, start := int(r.u16(endCodes+2*i)), int(r.u16(startCodes+2*i))
+		delta := r.u16(idDeltas + 2*i)
+		rangeOffsetPos := idRangeOffsets + 2*i
+		rangeOffset := int(r.u16(rangeOffsetPos))
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var gid uint16
+			if rangeOffset == 0 {
+				gid = uint16(c) + delta
+			} else {
+				addr := rangeOffsetPos + rangeOffset + 2*(c-start)
+				if addr+2 > len(sub) {
+					continue
+				}
+				if gid = r.u16(addr); gid != 0 {
+					gid += delta
+				}
+			}
+			if gid != 0 {
+				glyphs[rune(c)] = gid
+			}
+		}
+	}
+	return glyphs, nil
+}
+
+func parseCmapFormat12(sub []byte) (map[rune]uint16, error) {
+	r := fontReader(sub)
+	if len(sub) < 16 {
+		return nil, fmt.Errorf("%w: повреждена таблица cmap", ErrUnsupportedFont)
+	}
+	numGroups := int(r.u32(12))
+	if 16+12*numGroups > len(sub) {
+		return nil, fmt.Errorf("%w: повреждена таблица cmap", ErrUnsupportedFont)
+	}
+
+	glyphs := map[rune]uint16{}
+	for i := 0; i < numGroups; i++ {
+		group := 16 + 12*i
+		start, end, startGlyph := r.u32(group), r.u32(group+4), r.u32(group+8)
+		// Документы выписок обходятся символами BMP
+		for c := start; c <= end && c <= 0xFFFF; c++ {
+			glyphs[rune(c)] = uint16(startGlyph + c - start)
+		}
+	}
+	return glyphs, nil
+}
+
+// postScriptName читает имя шрифта (nameID 6) из таблицы name
+func postScriptName(table []byte) string {
+	r := fontReader(table)
+	if len(table) < 6 {
+		return ""
+	}
+	count, stringOffset := int(r.u16(2)), int(r.u16(4))
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		if rec+12 > len(table) {
+			break
+		}
+		platform, nameID := r.u16(rec), r.u16(rec+6)
+		length, offset := int(r.u16(rec+8)), int(r.u16(rec+10))
+		start := stringOffset + offset
+		if nameID != 6 || start+length > len(table) {
+			continue
+		}
+
+		raw := table[start : start+length]
+		switch platform {
+		case 1:
+			return string(raw)
+		case 0, 3:
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			return string(utf16.Decode(units))
+		}
+	}
+	return ""
+}
+
+// fontReader читает числа big-endian; чтение за границей таблицы дает 0
+type fontReader []byte
+
+func (r fontReader) u16(off int) uint16 {
+	if off < 0 || off+2 > len(r) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(r[off:])
+}
+
+func (r fontReader) i16(off int) int16 {
+	return int16(r.u16(off))
+}
+
+func (r fontReader) u32(off int) uint32 {
+	if off < 0 || off+4 > len(r) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(r[off:])
+}
@@ -0,0 +1,45 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QuietZone - обязательная светлая рамка вокруг кода в модулях
+const QuietZone = 4
+
+// Image рисует код в черно-белое изображение: каждый модуль - квадрат
+// scale x scale пикселей, вокруг рамка QuietZone модулей
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			px, py := (x+QuietZone)*scale, (y+QuietZone)*scale
+			for dy := 0; dy < scale; dy++ {
+				row := img.Pix[(py+dy)*img.Stride+px : (py+dy)*img.Stride+px+scale]
+				for i := range row {
+					row[i] = 1
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG возвращает изображение кода в формате PNG
+func (c *Code) PNG(scale int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image(scale)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
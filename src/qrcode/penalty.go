@@ -0,0 +1,93 @@
+package qrcode
+
+// Веса штрафов за неудачные сочетания модулей
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// Узор 1:1:3:1:1, похожий на поисковый, с четырьмя светлыми модулями с
+// одной из сторон
+var (
+	finderLikeBefore = []bool{false, false, false, false, true, false, true, true, true, false, true}
+	finderLikeAfter  = []bool{true, false, true, true, true, false, true, false, false, false, false}
+)
+
+// penalty оценивает маскированный код: чем меньше, тем легче его считать
+func (c *Code) penalty() int {
+	result := 0
+	line := make([]bool, c.Size)
+
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			line[x] = c.modules[y][x]
+		}
+		result += linePenalty(line)
+	}
+	for x := 0; x < c.Size; x++ {
+		for y := 0; y < c.Size; y++ {
+			line[y] = c.modules[y][x]
+		}
+		result += linePenalty(line)
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					result += penaltyN2
+				}
+			}
+		}
+	}
+
+	// Отклонение доли темных модулей от 50% с шагом 5%
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyN4
+	return result
+}
+
+// linePenalty считает штрафы за серии одного цвета и узоры, похожие на
+// поисковые, в строке или столбце
+func linePenalty(line []bool) int {
+	result := 0
+
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += penaltyN1 + run - 5
+		}
+		run = 1
+	}
+
+	for i := 0; i+len(finderLikeBefore) <= len(line); i++ {
+		if matchAt(line, i, finderLikeBefore) {
+			result += penaltyN3
+		}
+		if matchAt(line, i, finderLikeAfter) {
+			result += penaltyN3
+		}
+	}
+	return result
+}
+
+func matchAt(line []bool, offset int, pattern []bool) bool {
+	for j, p := range pattern {
+		if line[offset+j] != p {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Кодовые слова данных версии 1-M из опубликованных примеров кодирования
+// (ISO/IEC 18004, приложение I и "HELLO WORLD") и коррекция к ним
+func TestReedSolomonRemainder(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		ecc  []byte
+	}{
+		{
+			"01234567, 1-M",
+			[]byte{16, 32, 12, 86, 97, 128, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17},
+			[]byte{165, 36, 212, 193, 237, 54, 199, 135, 44, 85},
+		},
+		{
+			"HELLO WORLD, 1-M",
+			[]byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			[]byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reedSolomonRemainder(tt.data, reedSolomonDivisor(len(tt.ecc)))
+			if !slices.Equal(got, tt.ecc) {
+				t.Errorf("коррекция %v, ожидалось %v", got, tt.ecc)
+			}
+		})
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		level   Level
+		version int
+		wantErr error
+	}{
+		{"предел версии 1-L", 17, LevelL, 1, nil},
+		{"на байт больше 1-L", 18, LevelL, 2, nil},
+		{"предел версии 1-H", 7, LevelH, 1, nil},
+		{"предел версии 6-M", 106, LevelM, 6, nil},
+		{"версия 7 с информацией о версии", 107, LevelM, 7, nil},
+		{"предел версии 7-M", 122, LevelM, 7, nil},
+		{"предел версии 40-L", 2953, LevelL, 40, nil},
+		{"сверх версии 40-L", 2954, LevelL, 0, ErrDataTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Encode([]byte(strings.Repeat("a", tt.size)), tt.level)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.Version != tt.version {
+				t.Errorf("версия %d, ожидалась %d", c.Version, tt.version)
+			}
+			if c.Size != 17+4*tt.version {
+				t.Errorf("размер %d", c.Size)
+			}
+		})
+	}
+}
+
+// Обе копии информации о формате должны совпадать, нести уровень коррекции
+// и делиться на порождающий многочлен кода БЧХ
+func TestEncodeFormatBits(t *testing.T) {
+	for _, level := range []Level{LevelL, LevelM, LevelQ, LevelH} {
+		c, err := Encode([]byte("https://qr.nspk.ru/AS1000670LSS7DN18SJQDNP4B05KLJL2?type=01&bank=100000000111"), level)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var first, second int
+		for i := 0; i <= 5; i++ {
+			first |= dark(c, 8, i) << i
+		}
+		first |= dark(c, 8, 7)<<6 | dark(c, 8, 8)<<7 | dark(c, 7, 8)<<8
+		for i := 9; i < 15; i++ {
+			first |= dark(c, 14-i, 8) << i
+		}
+		for i := 0; i < 8; i++ {
+			second |= dark(c, c.Size-1-i, 8) << i
+		}
+		for i := 8; i < 15; i++ {
+			second |= dark(c, 8, c.Size-15+i) << i
+		}
+
+		if first != second {
+			t.Errorf("уровень %d: копии формата %015b и %015b", level, first, second)
+		}
+		bits := first ^ 0x5412
+		if bits>>13 != level.formatBits() {
+			t.Errorf("уровень %d: в формате уровень %d", level, bits>>13)
+		}
+		rem := bits
+		for i := 14; i >= 10; i-- {
+			if rem>>i&1 == 1 {
+				rem ^= 0x537 << (i - 10)
+			}
+		}
+		if rem != 0 {
+			t.Errorf("уровень %d: формат %015b не является кодом БЧХ", level, bits)
+		}
+		if !c.Dark(8, c.Size-8) {
+			t.Errorf("уровень %d: нет темного модуля", level)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	c, err := Encode([]byte("test"), LevelM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.PNG(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := img.Bounds().Dx(); w%4 != 0 || w <= c.Size*4 {
+		t.Errorf("ширина %d при размере кода %d", w, c.Size)
+	}
+}
+
+func dark(c *Code, x, y int) int {
+	if c.Dark(x, y) {
+		return 1
+	}
+	return 0
+}
@@ -2,12 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 	"sf-finances/src/models"
 )
 
+var ErrAccountStatusConflict = errors.New("состояние счета уже изменено")
+
 type AccountRepository struct {
 	db *pgxpool.Pool
 }
@@ -16,41 +21,77 @@ func NewAccountRepository(db *pgxpool.Pool) *AccountRepository {
 	return &AccountRepository{db: db}
 }
 
-func (r *AccountRepository) CreateAccount(ctx context.Context, userID int64, currency models.Currency) (*models.Account, error) {
-	query := `
-		INSERT INTO accounts (user_id, currency)
-		VALUES ($1, $2)
-		RETURNING id, user_id, balance, currency, created_at
-	`
+const accountColumns = `id, user_id, number, nickname, balance, currency, status, freeze_reason, frozen_by, frozen_at,
+	closed_at, locked_until, overdraft_limit, overdraft_started_at, overdraft_charged_through, created_at`
+
+func scanAccount(row pgx.Row) (*models.Account, error) {
 	var acc models.Account
-	err := r.db.QueryRow(ctx, query, userID, currency).Scan(
-		&acc.ID, &acc.UserID, &acc.Balance, &acc.Currency, &acc.CreatedAt,
-	)
+	var number *string
+	err := row.Scan(&acc.ID, &acc.UserID, &number, &acc.Nickname, &acc.Balance, &acc.Currency, &acc.Status,
+		&acc.FreezeReason, &acc.FrozenBy, &acc.FrozenAt, &acc.ClosedAt, &acc.LockedUntil, &acc.OverdraftLimit,
+		&acc.OverdraftStartedAt, &acc.OverdraftChargedThrough, &acc.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
+	if number != nil {
+		acc.Number = *number
+	}
 	return &acc, nil
 }
 
+func scanAccounts(rows pgx.Rows) ([]*models.Account, error) {
+	var accounts []*models.Account
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// NextAccountID резервирует ID для нового счета. Номер счета строится из ID,
+// поэтому он нужен до вставки
+func (r *AccountRepository) NextAccountID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(ctx, `SELECT nextval(pg_get_serial_sequence('accounts', 'id'))`).Scan(&id)
+	return id, err
+}
+
+func (r *AccountRepository) CreateAccount(ctx context.Context, acc *models.Account) (*models.Account, error) {
+	query := `
+		INSERT INTO accounts (id, user_id, number, currency, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + accountColumns
+	return scanAccount(r.db.QueryRow(ctx, query, acc.ID, acc.UserID, acc.Number, acc.Currency, models.AccountActive))
+}
+
 func (r *AccountRepository) GetAccountByID(ctx context.Context, id int64) (*models.Account, error) {
 	query := `
-		SELECT id, user_id, balance, currency, created_at
+		SELECT ` + accountColumns + `
 		FROM accounts
 		WHERE id = $1
 	`
-	var acc models.Account
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&acc.ID, &acc.UserID, &acc.Balance, &acc.Currency, &acc.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &acc, nil
+	return scanAccount(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *AccountRepository) GetAccountByNumber(ctx context.Context, number string) (*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE number = $1
+	`
+	return scanAccount(r.db.QueryRow(ctx, query, number))
 }
 
 func (r *AccountRepository) GetAccountsByUserID(ctx context.Context, userID int64) ([]*models.Account, error) {
 	query := `
-		SELECT id, user_id, balance, currency, created_at
+		SELECT ` + accountColumns + `
 		FROM accounts
 		WHERE user_id = $1
 		ORDER BY id
@@ -61,19 +102,170 @@ func (r *AccountRepository) GetAccountsByUserID(ctx context.Context, userID int6
 	}
 	defer rows.Close()
 
-	var accounts []*models.Account
-	for rows.Next() {
-		var acc models.Account
-		if err := rows.Scan(&acc.ID, &acc.UserID, &acc.Balance, &acc.Currency, &acc.CreatedAt); err != nil {
-			return nil, err
-		}
-		accounts = append(accounts, &acc)
+	return scanAccounts(rows)
+}
+
+// GetAccountsWithoutNumber возвращает счета, открытые до появления номеров
+func (r *AccountRepository) GetAccountsWithoutNumber(ctx context.Context) ([]*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE number IS NULL
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	return scanAccounts(rows)
+}
+
+// SetNumber присваивает номер счету, у которого его еще нет
+func (r *AccountRepository) SetNumber(ctx context.Context, id int64, number string) error {
+	query := `
+		UPDATE accounts
+		SET number = $2
+		WHERE id = $1 AND number IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, id, number)
+	return err
+}
+
+// GetDefaultAccount возвращает основной счет пользователя в валюте currency -
+// самый старый из незакрытых
+func (r *AccountRepository) GetDefaultAccount(ctx context.Context, userID int64, currency models.Currency) (*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE user_id = $1 AND currency = $2 AND status <> $3
+		ORDER BY created_at, id
+		LIMIT 1
+	`
+	return scanAccount(r.db.QueryRow(ctx, query, userID, currency, models.AccountClosed))
+}
+
+// SetNickname задает пользовательское название счета, nil его убирает
+func (r *AccountRepository) SetNickname(ctx context.Context, id int64, nickname *string) error {
+	query := `
+		UPDATE accounts
+		SET nickname = $2
+		WHERE id = $1 AND status <> $3
+	`
+	return r.execStatus(ctx, query, id, nickname, models.AccountClosed)
+}
+
+// Freeze замораживает действующий счет по решению администратора adminID
+func (r *AccountRepository) Freeze(ctx context.Context, id int64, adminID int64, reason string) error {
+	query := `
+		UPDATE accounts
+		SET status = $3, freeze_reason = $4, frozen_by = $5, frozen_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	return r.execStatus(ctx, query, id, models.AccountActive, models.AccountFrozen, reason, adminID)
+}
 
-	if err = rows.Err(); err != nil {
+// Unfreeze возвращает замороженный счет в действующие
+func (r *AccountRepository) Unfreeze(ctx context.Context, id int64) error {
+	query := `
+		UPDATE accounts
+		SET status = $3, freeze_reason = NULL, frozen_by = NULL, frozen_at = NULL
+		WHERE id = $1 AND status = $2
+	`
+	return r.execStatus(ctx, query, id, models.AccountFrozen, models.AccountActive)
+}
+
+// Close закрывает действующий счет с нулевым балансом. Если баланс успел
+// измениться, возвращает ErrAccountStatusConflict
+func (r *AccountRepository) Close(ctx context.Context, id int64) error {
+	query := `
+		UPDATE accounts
+		SET status = $3, closed_at = NOW()
+		WHERE id = $1 AND status = $2 AND balance = 0
+	`
+	return r.execStatus(ctx, query, id, models.AccountActive, models.AccountClosed)
+}
+
+// SetLockedUntil запрещает списания со счета до until, nil снимает запрет
+func (r *AccountRepository) SetLockedUntil(ctx context.Context, id int64, until *time.Time) error {
+	query := `
+		UPDATE accounts
+		SET locked_until = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, until)
+	return err
+}
+
+// SetOverdraftLimit задает лимит овердрафта незакрытому счету. Проценты за
+// пользование начисляются за дни после chargedThrough, если счету еще не
+// открывали овердрафт
+func (r *AccountRepository) SetOverdraftLimit(ctx context.Context, id int64, limit decimal.Decimal, chargedThrough time.Time) error {
+	query := `
+		UPDATE accounts
+		SET overdraft_limit = $2, overdraft_charged_through = COALESCE(overdraft_charged_through, $3)
+		WHERE id = $1 AND status <> $4
+	`
+	return r.execStatus(ctx, query, id, limit, chargedThrough, models.AccountClosed)
+}
+
+// StartOverdrafts отмечает счета с овердрафтом, баланс которых ушел в минус,
+// и возвращает их. Каждый выход в минус возвращается один раз
+func (r *AccountRepository) StartOverdrafts(ctx context.Context) ([]*models.Account, error) {
+	query := `
+		UPDATE accounts
+		SET overdraft_started_at = NOW()
+		WHERE balance < 0 AND overdraft_started_at IS NULL AND overdraft_charged_through IS NOT NULL
+		RETURNING ` + accountColumns
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
 		return nil, err
 	}
-	return accounts, nil
+	defer rows.Close()
+
+	return scanAccounts(rows)
+}
+
+// EndRepaidOverdrafts снимает отметку с погашенных овердрафтов, чтобы
+// следующий выход в минус снова был замечен
+func (r *AccountRepository) EndRepaidOverdrafts(ctx context.Context) error {
+	query := `
+		UPDATE accounts
+		SET overdraft_started_at = NULL
+		WHERE balance >= 0 AND overdraft_started_at IS NOT NULL
+	`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
+
+// GetOverdraftAccountsDue возвращает счета с овердрафтом, проценты по
+// которым еще не начислены по день through включительно
+func (r *AccountRepository) GetOverdraftAccountsDue(ctx context.Context, through time.Time) ([]*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE overdraft_charged_through < $1 AND status <> $2
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, through, models.AccountClosed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAccounts(rows)
+}
+
+func (r *AccountRepository) execStatus(ctx context.Context, query string, args ...any) error {
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAccountStatusConflict
+	}
+	return nil
 }
 
 func (r *AccountRepository) UpdateBalance(ctx context.Context, id int64, amount decimal.Decimal) error {
@@ -86,6 +278,19 @@ func (r *AccountRepository) UpdateBalance(ctx context.Context, id int64, amount
 	return err
 }
 
+// Withdraw списывает сумму, только если на счете достаточно средств с
+// учетом лимита овердрафта. При нехватке средств возвращает pgx.ErrNoRows
+func (r *AccountRepository) Withdraw(ctx context.Context, id int64, amount decimal.Decimal) error {
+	query := `
+		UPDATE accounts
+		SET balance = balance - $1
+		WHERE id = $2 AND balance + overdraft_limit >= $1
+		RETURNING balance
+	`
+	var newBalance decimal.Decimal
+	return r.db.QueryRow(ctx, query, amount, id).Scan(&newBalance)
+}
+
 func (r *AccountRepository) TransferBetweenAccounts(ctx context.Context, fromID, toID int64, amount decimal.Decimal) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -93,11 +298,11 @@ func (r *AccountRepository) TransferBetweenAccounts(ctx context.Context, fromID,
 	}
 	defer tx.Rollback(ctx)
 
-	// Списание со счета отправителя
+	// Списание со счета отправителя в пределах остатка и овердрафта
 	updateFromQuery := `
 		UPDATE accounts
 		SET balance = balance - $1
-		WHERE id = $2 AND balance >= $1
+		WHERE id = $2 AND balance + overdraft_limit >= $1
 		RETURNING balance
 	`
 	var newBalance decimal.Decimal
@@ -117,5 +322,30 @@ func (r *AccountRepository) TransferBetweenAccounts(ctx context.Context, fromID,
 		return err
 	}
 
+	return tx.Commit(ctx)
+}
+
+// TransferUnchecked переводит без проверки остатка. Нужен для операций со
+// счетами банка: счета расходов уходят в минус, проценты по овердрафту
+// списываются и сверх лимита
+func (r *AccountRepository) TransferUnchecked(ctx context.Context, fromID, toID int64, amount decimal.Decimal) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE accounts
+		SET balance = balance + $1
+		WHERE id = $2
+	`
+	if _, err := tx.Exec(ctx, query, amount.Neg(), fromID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query, amount, toID); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
\ No newline at end of file
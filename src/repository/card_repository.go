@@ -2,11 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"sf-finances/src/models"
 )
 
+var ErrDuplicatePAN = errors.New("карта с таким номером уже существует")
+
 type CardRepository struct {
 	db *pgxpool.Pool
 }
@@ -15,47 +21,73 @@ func NewCardRepository(db *pgxpool.Pool) *CardRepository {
 	return &CardRepository{db: db}
 }
 
-func (r *CardRepository) CreateCard(ctx context.Context, userID int64, encryptedNumber, encryptedExpire []byte, cvvHash string) (*models.Card, error) {
-	query := `
-		INSERT INTO cards
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, user_id, created_at
-	`
+const cardColumns = `id, user_id, account_id, product, type, status, locked_merchant_id,
+	payment_limit, reissued_from_id, expires_at,
+	card_number, expire, cvv_hash, pan_hash, pin_hash, pin_card_id, pin_attempts, created_at`
+
+func scanCard(row pgx.Row) (*models.Card, error) {
 	var card models.Card
-	err := r.db.QueryRow(ctx, query, userID, encryptedNumber, encryptedExpire, cvvHash).Scan(
-		&card.ID, &card.UserID, &card.CreatedAt,
+	err := row.Scan(
+		&card.ID, &card.UserID, &card.AccountID, &card.Product, &card.Type, &card.Status, &card.LockedMerchantID,
+		&card.PaymentLimit, &card.ReissuedFromID, &card.ExpiresAt,
+		&card.CardNumber, &card.Expire, &card.CVVHash, &card.PANHash, &card.PINHash, &card.PINCardID, &card.PINAttempts,
+		&card.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	return &card, nil
+}
 
-	card.CardNumber = encryptedNumber
-	card.Expire = encryptedExpire
-	card.CVVHash = cvvHash
+func scanCards(rows pgx.Rows) ([]*models.Card, error) {
+	var cards []*models.Card
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
 
-	return &card, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cards, nil
 }
 
-func (r *CardRepository) GetCardByID(ctx context.Context, cardID int64) (*models.Card, error) {
+func (r *CardRepository) CreateCard(ctx context.Context, card *models.Card) (*models.Card, error) {
 	query := `
-		SELECT id, user_id, card_number, expire, cvv_hash, created_at
-		FROM cards 
-		WHERE id = $1
-	`
-	var card models.Card
-	err := r.db.QueryRow(ctx, query, cardID).Scan(
-		&card.ID, &card.UserID, &card.CardNumber, &card.Expire, &card.CVVHash, &card.CreatedAt,
-	)
+		INSERT INTO cards (user_id, account_id, product, type, status, locked_merchant_id,
+			payment_limit, reissued_from_id, expires_at, card_number, expire, cvv_hash, pan_hash, pin_hash, pin_card_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING ` + cardColumns
+	created, err := scanCard(r.db.QueryRow(ctx, query, card.UserID, card.AccountID, card.Product, card.Type, card.Status,
+		card.LockedMerchantID, card.PaymentLimit, card.ReissuedFromID, card.ExpiresAt,
+		card.CardNumber, card.Expire, card.CVVHash, card.PANHash, card.PINHash, card.PINCardID))
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDuplicatePAN
+		}
 		return nil, err
 	}
 
-	return &card, nil
+	return created, nil
+}
+
+func (r *CardRepository) GetCardByID(ctx context.Context, cardID int64) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards 
+		WHERE id = $1
+	`
+	return scanCard(r.db.QueryRow(ctx, query, cardID))
 }
 
 func (r *CardRepository) GetCardsByUserID(ctx context.Context, userID int64) ([]*models.Card, error) {
 	query := `
-		SELECT id, user_id, created_at
+		SELECT ` + cardColumns + `
 		FROM cards 
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -66,20 +98,165 @@ func (r *CardRepository) GetCardsByUserID(ctx context.Context, userID int64) ([]
 	}
 	defer rows.Close()
 
-	var cards []*models.Card
-	for rows.Next() {
-		var card models.Card
-		if err := rows.Scan(&card.ID, &card.UserID, &card.CreatedAt); err != nil {
-			return nil, err
-		}
-		cards = append(cards, &card)
+	return scanCards(rows)
+}
+
+// ClaimForMerchant привязывает карту к мерчанту, если она еще не привязана.
+// Возвращает false, если карта уже привязана к другому мерчанту
+func (r *CardRepository) ClaimForMerchant(ctx context.Context, cardID, merchantID int64) (bool, error) {
+	query := `
+		UPDATE cards
+		SET locked_merchant_id = $2
+		WHERE id = $1 AND (locked_merchant_id IS NULL OR locked_merchant_id = $2)
+	`
+	tag, err := r.db.Exec(ctx, query, cardID, merchantID)
+	if err != nil {
+		return false, err
 	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *CardRepository) ReleaseMerchant(ctx context.Context, cardID int64) error {
+	query := `
+		UPDATE cards
+		SET locked_merchant_id = NULL
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, cardID)
+	return err
+}
 
-	if err = rows.Err(); err != nil {
+// UpdateStatus меняет статус карты, только если текущий статус равен from
+func (r *CardRepository) UpdateStatus(ctx context.Context, cardID int64, from, to models.CardStatus) (bool, error) {
+	query := `
+		UPDATE cards
+		SET status = $3
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := r.db.Exec(ctx, query, cardID, from, to)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// MarkExpired переводит в статус EXPIRED все карты с истекшим сроком действия
+func (r *CardRepository) MarkExpired(ctx context.Context, now time.Time) ([]*models.Card, error) {
+	query := `
+		UPDATE cards
+		SET status = $2
+		WHERE expires_at < $1 AND status IN ($3, $4, $5)
+		RETURNING ` + cardColumns
+	rows, err := r.db.Query(ctx, query, now, models.CardExpired,
+		models.CardActive, models.CardBlocked, models.CardPendingActivation)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return cards, nil
+	return scanCards(rows)
+}
+
+// GetCardsForReissue возвращает активные карты, истекающие до before,
+// для которых еще не выпущена замена
+func (r *CardRepository) GetCardsForReissue(ctx context.Context, before time.Time, cardTypes []models.CardType) ([]*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards c
+		WHERE c.status = $1 AND c.expires_at < $2 AND c.type = ANY($3)
+			AND NOT EXISTS (SELECT 1 FROM cards r WHERE r.reissued_from_id = c.id)
+		ORDER BY c.expires_at
+	`
+	rows, err := r.db.Query(ctx, query, models.CardActive, before, cardTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCards(rows)
+}
+
+// Activate сохраняет перешифрованные данные карты и CVV и делает карту активной
+func (r *CardRepository) Activate(ctx context.Context, cardID int64, encryptedNumber, encryptedExpire []byte, cvvHash string) error {
+	query := `
+		UPDATE cards
+		SET card_number = $2, expire = $3, cvv_hash = $4, status = $5
+		WHERE id = $1 AND status = $6
+	`
+	tag, err := r.db.Exec(ctx, query, cardID, encryptedNumber, encryptedExpire, cvvHash,
+		models.CardActive, models.CardPendingActivation)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CardRepository) SetPIN(ctx context.Context, cardID int64, pinHash string) error {
+	query := `
+		UPDATE cards
+		SET pin_hash = $2, pin_card_id = id, pin_attempts = 0
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, cardID, pinHash)
+	return err
+}
+
+// ResetPIN задает новый PIN и снимает блокировку карты по неверному PIN
+func (r *CardRepository) ResetPIN(ctx context.Context, cardID int64, pinHash string) error {
+	query := `
+		UPDATE cards
+		SET pin_hash = $2,
+			pin_card_id = id,
+			pin_attempts = 0,
+			status = CASE WHEN status = $3 THEN $4 ELSE status END
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, cardID, pinHash, models.CardBlocked, models.CardActive)
+	return err
+}
+
+func (r *CardRepository) ResetPINAttempts(ctx context.Context, cardID int64) error {
+	query := `
+		UPDATE cards
+		SET pin_attempts = 0
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, cardID)
+	return err
+}
+
+// RegisterPINFailure увеличивает счетчик неверных PIN и блокирует карту
+// при достижении maxAttempts. Возвращает true, если карта заблокирована
+func (r *CardRepository) RegisterPINFailure(ctx context.Context, cardID int64, maxAttempts int) (bool, error) {
+	query := `
+		UPDATE cards
+		SET pin_attempts = pin_attempts + 1,
+			status = CASE WHEN pin_attempts + 1 >= $2 AND status = $4 THEN $3 ELSE status END
+		WHERE id = $1
+		RETURNING status
+	`
+	var status models.CardStatus
+	err := r.db.QueryRow(ctx, query, cardID, maxAttempts, models.CardBlocked, models.CardActive).Scan(&status)
+	if err != nil {
+		return false, err
+	}
+	return status == models.CardBlocked, nil
+}
+
+func (r *CardRepository) ExistsByPANHash(ctx context.Context, panHash string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM cards WHERE pan_hash = $1)
+	`
+	var exists bool
+	err := r.db.QueryRow(ctx, query, panHash).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
 }
 
 func (r *CardRepository) IsCardExistsForUser(ctx context.Context, cardID int64, userID int64) (bool, error) {
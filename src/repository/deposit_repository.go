@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+var (
+	ErrDepositStatusConflict = errors.New("статус вклада уже изменен")
+	ErrCapitalizationPaying  = errors.New("капитализация уже выплачивается")
+)
+
+type DepositRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDepositRepository(db *pgxpool.Pool) *DepositRepository {
+	return &DepositRepository{db: db}
+}
+
+const depositColumns = `id, user_id, account_id, type, annual_rate, term_months, opened_on, maturity_date,
+	accrued_through, status, forfeited_interest, created_at, closed_at`
+
+const capitalizationColumns = `id, deposit_id, amount, transaction_id, forfeited, paid_at, created_at`
+
+func scanDeposit(row pgx.Row) (*models.Deposit, error) {
+	var d models.Deposit
+	err := row.Scan(&d.ID, &d.UserID, &d.AccountID, &d.Type, &d.AnnualRate, &d.TermMonths, &d.OpenedOn, &d.MaturityDate,
+		&d.AccruedThrough, &d.Status, &d.ForfeitedInterest, &d.CreatedAt, &d.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func scanDeposits(rows pgx.Rows) ([]*models.Deposit, error) {
+	var deposits []*models.Deposit
+	for rows.Next() {
+		d, err := scanDeposit(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deposits, nil
+}
+
+func scanCapitalization(row pgx.Row) (*models.DepositCapitalization, error) {
+	var c models.DepositCapitalization
+	err := row.Scan(&c.ID, &c.DepositID, &c.Amount, &c.TransactionID, &c.Forfeited, &c.PaidAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *DepositRepository) Create(ctx context.Context, d *models.Deposit) (*models.Deposit, error) {
+	query := `
+		INSERT INTO deposits (user_id, account_id, type, annual_rate, term_months, opened_on, maturity_date,
+			accrued_through, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + depositColumns
+	return scanDeposit(r.db.QueryRow(ctx, query, d.UserID, d.AccountID, d.Type, d.AnnualRate, d.TermMonths,
+		d.OpenedOn, d.MaturityDate, d.AccruedThrough, d.Status))
+}
+
+func (r *DepositRepository) GetByID(ctx context.Context, id int64) (*models.Deposit, error) {
+	query := `
+		SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE id = $1
+	`
+	return scanDeposit(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *DepositRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.Deposit, error) {
+	query := `
+		SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeposits(rows)
+}
+
+// GetActive возвращает вклады, по которым начисляются проценты
+func (r *DepositRepository) GetActive(ctx context.Context) ([]*models.Deposit, error) {
+	query := `
+		SELECT ` + depositColumns + `
+		FROM deposits
+		WHERE status = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, models.DepositActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeposits(rows)
+}
+
+// AddAccrual записывает проценты за день и сдвигает accrued_through.
+// Повторное начисление за тот же день ничего не меняет
+func (r *DepositRepository) AddAccrual(ctx context.Context, a *models.DepositAccrual) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `
+		INSERT INTO deposit_accruals (deposit_id, accrual_date, balance, annual_rate, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (deposit_id, accrual_date) DO NOTHING
+	`
+	_, err = tx.Exec(ctx, insertQuery, a.DepositID, a.AccrualDate, a.Balance, a.AnnualRate, a.Amount)
+	if err != nil {
+		return err
+	}
+
+	updateQuery := `
+		UPDATE deposits
+		SET accrued_through = $2
+		WHERE id = $1 AND accrued_through < $2
+	`
+	if _, err := tx.Exec(ctx, updateQuery, a.DepositID, a.AccrualDate); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Capitalize в одной транзакции собирает некапитализированные проценты за
+// дни до before в выплату. Сумма округляется до копеек. Если начислений нет,
+// возвращает pgx.ErrNoRows
+func (r *DepositRepository) Capitalize(ctx context.Context, depositID int64, before time.Time) (*models.DepositCapitalization, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var capitalizationID int64
+	insertQuery := `
+		INSERT INTO deposit_capitalizations (deposit_id, amount)
+		VALUES ($1, 0)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, insertQuery, depositID).Scan(&capitalizationID); err != nil {
+		return nil, err
+	}
+
+	claimQuery := `
+		UPDATE deposit_accruals
+		SET capitalization_id = $1
+		WHERE deposit_id = $2 AND capitalization_id IS NULL AND NOT forfeited AND accrual_date < $3
+	`
+	tag, err := tx.Exec(ctx, claimQuery, capitalizationID, depositID, before)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	totalQuery := `
+		UPDATE deposit_capitalizations
+		SET amount = (SELECT ROUND(SUM(amount), 2) FROM deposit_accruals WHERE capitalization_id = $1)
+		WHERE id = $1
+		RETURNING ` + capitalizationColumns
+	c, err := scanCapitalization(tx.QueryRow(ctx, totalQuery, capitalizationID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetUnpaidCapitalizations возвращает невыплаченные капитализации, которые
+// сейчас никто не выплачивает, в том числе оставшиеся после прошлых сбоев
+func (r *DepositRepository) GetUnpaidCapitalizations(ctx context.Context) ([]*models.DepositCapitalization, error) {
+	query := `
+		SELECT ` + capitalizationColumns + `
+		FROM deposit_capitalizations
+		WHERE paid_at IS NULL AND paying_at IS NULL AND NOT forfeited
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var capitalizations []*models.DepositCapitalization
+	for rows.Next() {
+		c, err := scanCapitalization(rows)
+		if err != nil {
+			return nil, err
+		}
+		capitalizations = append(capitalizations, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return capitalizations, nil
+}
+
+// ClaimCapitalization отмечает капитализацию выплачиваемой, чтобы ее не
+// выплатили дважды. Если ее уже выплачивают, возвращает ErrCapitalizationPaying
+func (r *DepositRepository) ClaimCapitalization(ctx context.Context, id int64) error {
+	query := `
+		UPDATE deposit_capitalizations
+		SET paying_at = NOW()
+		WHERE id = $1 AND paid_at IS NULL AND paying_at IS NULL AND NOT forfeited
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCapitalizationPaying
+	}
+	return nil
+}
+
+// ReleaseCapitalization возвращает капитализацию, которую не удалось
+// выплатить, в очередь
+func (r *DepositRepository) ReleaseCapitalization(ctx context.Context, id int64) error {
+	query := `
+		UPDATE deposit_capitalizations
+		SET paying_at = NULL
+		WHERE id = $1 AND paid_at IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// MarkCapitalizationPaid отмечает выплату капитализации транзакцией txID
+// (nil для нулевой суммы)
+func (r *DepositRepository) MarkCapitalizationPaid(ctx context.Context, id int64, txID *int64) error {
+	query := `
+		UPDATE deposit_capitalizations
+		SET transaction_id = $2, paid_at = NOW()
+		WHERE id = $1 AND paid_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id, txID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDepositStatusConflict
+	}
+	return nil
+}
+
+// Forfeit аннулирует все проценты вклада: начисленные и выплаченные.
+// Возвращает уже выплаченную сумму, которую нужно вернуть со счета вклада
+func (r *DepositRepository) Forfeit(ctx context.Context, depositID int64) (decimal.Decimal, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	defer tx.Rollback(ctx)
+
+	var accrued decimal.Decimal
+	accrualsQuery := `
+		WITH forfeited AS (
+			UPDATE deposit_accruals
+			SET forfeited = TRUE
+			WHERE deposit_id = $1 AND capitalization_id IS NULL AND NOT forfeited
+			RETURNING amount
+		)
+		SELECT COALESCE(ROUND(SUM(amount), 2), 0) FROM forfeited
+	`
+	if err := tx.QueryRow(ctx, accrualsQuery, depositID).Scan(&accrued); err != nil {
+		return decimal.Zero, err
+	}
+
+	var unpaid, paid decimal.Decimal
+	capitalizationsQuery := `
+		WITH forfeited AS (
+			UPDATE deposit_capitalizations
+			SET forfeited = TRUE
+			WHERE deposit_id = $1 AND NOT forfeited
+			RETURNING amount, transaction_id
+		)
+		SELECT COALESCE(SUM(amount) FILTER (WHERE transaction_id IS NULL), 0),
+			COALESCE(SUM(amount) FILTER (WHERE transaction_id IS NOT NULL), 0)
+		FROM forfeited
+	`
+	if err := tx.QueryRow(ctx, capitalizationsQuery, depositID).Scan(&unpaid, &paid); err != nil {
+		return decimal.Zero, err
+	}
+
+	updateQuery := `
+		UPDATE deposits
+		SET forfeited_interest = forfeited_interest + $2
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, updateQuery, depositID, accrued.Add(unpaid).Add(paid)); err != nil {
+		return decimal.Zero, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return decimal.Zero, err
+	}
+	return paid, nil
+}
+
+// GetInterest считает начисленные и выплаченные проценты вклада
+func (r *DepositRepository) GetInterest(ctx context.Context, depositID int64) (*models.DepositInterest, error) {
+	query := `
+		SELECT
+			(SELECT COALESCE(SUM(amount), 0) FROM deposit_accruals
+				WHERE deposit_id = $1 AND capitalization_id IS NULL AND NOT forfeited),
+			(SELECT COALESCE(SUM(amount), 0) FROM deposit_capitalizations
+				WHERE deposit_id = $1 AND transaction_id IS NOT NULL AND NOT forfeited)
+	`
+	var interest models.DepositInterest
+	if err := r.db.QueryRow(ctx, query, depositID).Scan(&interest.Accrued, &interest.Paid); err != nil {
+		return nil, err
+	}
+	interest.Accrued = interest.Accrued.Round(2)
+	return &interest, nil
+}
+
+// UpdateStatus меняет статус вклада, только если он все еще from. При
+// закрытии запоминает время закрытия
+func (r *DepositRepository) UpdateStatus(ctx context.Context, id int64, from, to models.DepositStatus) error {
+	var closedAt *time.Time
+	if to == models.DepositClosed {
+		now := time.Now()
+		closedAt = &now
+	}
+
+	query := `
+		UPDATE deposits
+		SET status = $3, closed_at = COALESCE($4, closed_at)
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := r.db.Exec(ctx, query, id, from, to, closedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDepositStatusConflict
+	}
+	return nil
+}
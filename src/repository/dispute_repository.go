@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+var (
+	ErrDisputeExists         = errors.New("по платежу уже открыт спор")
+	ErrDisputeStatusConflict = errors.New("статус спора уже изменен")
+)
+
+type DisputeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDisputeRepository(db *pgxpool.Pool) *DisputeRepository {
+	return &DisputeRepository{db: db}
+}
+
+const disputeColumns = `id, payment_id, user_id, account_id, reason, comment, amount, status,
+	credit_transaction_id, reversal_transaction_id, chargeback_transaction_id, resolution, resolved_by, resolved_at,
+	created_at, updated_at`
+
+func scanDispute(row pgx.Row) (*models.Dispute, error) {
+	var d models.Dispute
+	err := row.Scan(&d.ID, &d.PaymentID, &d.UserID, &d.AccountID, &d.Reason, &d.Comment, &d.Amount, &d.Status,
+		&d.CreditTransactionID, &d.ReversalTransactionID, &d.ChargebackTransactionID, &d.Resolution, &d.ResolvedBy,
+		&d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func scanDisputes(rows pgx.Rows) ([]*models.Dispute, error) {
+	var disputes []*models.Dispute
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return disputes, nil
+}
+
+// Create сохраняет спор. На payment_id стоит уникальный индекс, поэтому
+// повторный спор по тому же платежу возвращает ErrDisputeExists
+func (r *DisputeRepository) Create(ctx context.Context, d *models.Dispute) (*models.Dispute, error) {
+	query := `
+		INSERT INTO disputes (payment_id, user_id, account_id, reason, comment, amount, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + disputeColumns
+	created, err := scanDispute(r.db.QueryRow(ctx, query, d.PaymentID, d.UserID, d.AccountID, d.Reason,
+		d.Comment, d.Amount, d.Status))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDisputeExists
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *DisputeRepository) GetByID(ctx context.Context, id int64) (*models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE id = $1
+	`
+	return scanDispute(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *DisputeRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDisputes(rows)
+}
+
+// GetByStatuses возвращает споры в указанных статусах, старые первыми
+func (r *DisputeRepository) GetByStatuses(ctx context.Context, statuses []models.DisputeStatus) ([]*models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE status = ANY($1)
+		ORDER BY created_at
+	`
+	rows, err := r.db.Query(ctx, query, statuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDisputes(rows)
+}
+
+// UpdateStatus меняет статус спора, только если текущий статус равен from.
+// Иначе возвращает ErrDisputeStatusConflict
+func (r *DisputeRepository) UpdateStatus(ctx context.Context, id int64, from, to models.DisputeStatus) error {
+	query := `
+		UPDATE disputes
+		SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := r.db.Exec(ctx, query, id, from, to)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDisputeStatusConflict
+	}
+	return nil
+}
+
+// Resolve закрывает спор решением администратора adminID. Как и UpdateStatus,
+// срабатывает только из статуса from
+func (r *DisputeRepository) Resolve(ctx context.Context, id int64, from, to models.DisputeStatus, adminID int64, resolution *string) error {
+	query := `
+		UPDATE disputes
+		SET status = $3, resolved_by = $4, resolution = $5, resolved_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := r.db.Exec(ctx, query, id, from, to, adminID, resolution)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDisputeStatusConflict
+	}
+	return nil
+}
+
+func (r *DisputeRepository) SetCreditTransactionID(ctx context.Context, id int64, transactionID int64) error {
+	query := `
+		UPDATE disputes
+		SET credit_transaction_id = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, transactionID)
+	return err
+}
+
+func (r *DisputeRepository) SetReversalTransactionID(ctx context.Context, id int64, transactionID int64) error {
+	query := `
+		UPDATE disputes
+		SET reversal_transaction_id = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, transactionID)
+	return err
+}
+
+func (r *DisputeRepository) SetChargebackTransactionID(ctx context.Context, id int64, transactionID int64) error {
+	query := `
+		UPDATE disputes
+		SET chargeback_transaction_id = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, transactionID)
+	return err
+}
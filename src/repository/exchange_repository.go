@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrDocumentImported = errors.New("документ уже загружен")
+
+// ExchangeRepository хранит платежные поручения, загруженные из файлов
+// обмена с 1С. Поручение определяется счетом плательщика, номером и датой
+type ExchangeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExchangeRepository(db *pgxpool.Pool) *ExchangeRepository {
+	return &ExchangeRepository{db: db}
+}
+
+// ClaimDocument записывает поручение до его исполнения, чтобы повторная
+// загрузка файла не исполнила его еще раз. Если поручение уже загружено,
+// возвращает ErrDocumentImported
+func (r *ExchangeRepository) ClaimDocument(ctx context.Context, userID int64, payerAccount, number string,
+	date time.Time) (int64, error) {
+	query := `
+		INSERT INTO imported_payment_orders (user_id, payer_account, number, document_date)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (payer_account, number, document_date) DO NOTHING
+		RETURNING id
+	`
+	var id int64
+	err := r.db.QueryRow(ctx, query, userID, payerAccount, number, date).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrDocumentImported
+	}
+	return id, err
+}
+
+// ReleaseDocument снимает запись о поручении, которое не удалось исполнить,
+// чтобы его можно было загрузить снова
+func (r *ExchangeRepository) ReleaseDocument(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM imported_payment_orders
+		WHERE id = $1 AND transaction_id IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *ExchangeRepository) SetTransactionID(ctx context.Context, id int64, transactionID int64) error {
+	query := `
+		UPDATE imported_payment_orders
+		SET transaction_id = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, transactionID)
+	return err
+}
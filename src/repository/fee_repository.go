@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+var ErrFeePosted = errors.New("комиссия уже списана")
+
+type FeeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFeeRepository(db *pgxpool.Pool) *FeeRepository {
+	return &FeeRepository{db: db}
+}
+
+const feeColumns = `id, user_id, account_id, operation, amount, fee, transaction_id, fee_transaction_id, posted_at, created_at`
+
+func scanFee(row pgx.Row) (*models.Fee, error) {
+	var f models.Fee
+	err := row.Scan(&f.ID, &f.UserID, &f.AccountID, &f.Operation, &f.Amount, &f.Fee, &f.TransactionID,
+		&f.FeeTransactionID, &f.PostedAt, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func scanFees(rows pgx.Rows) ([]*models.Fee, error) {
+	var fees []*models.Fee
+	for rows.Next() {
+		f, err := scanFee(rows)
+		if err != nil {
+			return nil, err
+		}
+		fees = append(fees, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return fees, nil
+}
+
+// Create сохраняет операцию. Операция без комиссии сразу считается списанной
+func (r *FeeRepository) Create(ctx context.Context, fee *models.Fee) error {
+	query := `
+		INSERT INTO fees (user_id, account_id, operation, amount, fee, transaction_id, posted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $7 THEN NOW() END)
+		RETURNING ` + feeColumns
+	row := r.db.QueryRow(ctx, query, fee.UserID, fee.AccountID, fee.Operation, fee.Amount, fee.Fee, fee.TransactionID,
+		fee.Fee.IsZero())
+	created, err := scanFee(row)
+	if err != nil {
+		return err
+	}
+	*fee = *created
+	return nil
+}
+
+// GetMonthlyUsage возвращает число и сумму операций вида operation
+// пользователя начиная с since
+func (r *FeeRepository) GetMonthlyUsage(ctx context.Context, userID int64, operation models.FeeOperation,
+	since time.Time) (int, decimal.Decimal, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		FROM fees
+		WHERE user_id = $1 AND operation = $2 AND created_at >= $3
+	`
+	var count int
+	var amount decimal.Decimal
+	err := r.db.QueryRow(ctx, query, userID, operation, since).Scan(&count, &amount)
+	return count, amount, err
+}
+
+// GetUnposted возвращает комиссии, которые еще не списаны со счетов
+func (r *FeeRepository) GetUnposted(ctx context.Context) ([]*models.Fee, error) {
+	query := `
+		SELECT ` + feeColumns + `
+		FROM fees
+		WHERE posted_at IS NULL
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFees(rows)
+}
+
+// Claim отмечает комиссию списываемой, чтобы ее не списали дважды. Если
+// комиссию уже списывают, возвращает ErrFeePosted
+func (r *FeeRepository) Claim(ctx context.Context, id int64) error {
+	query := `
+		UPDATE fees
+		SET posted_at = NOW()
+		WHERE id = $1 AND posted_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFeePosted
+	}
+	return nil
+}
+
+// Release возвращает комиссию, которую не удалось списать, в очередь
+func (r *FeeRepository) Release(ctx context.Context, id int64) error {
+	query := `
+		UPDATE fees
+		SET posted_at = NULL
+		WHERE id = $1 AND fee_transaction_id IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *FeeRepository) SetFeeTransactionID(ctx context.Context, id int64, feeTxID int64) error {
+	query := `
+		UPDATE fees
+		SET fee_transaction_id = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, feeTxID)
+	return err
+}
+
+func (r *FeeRepository) GetByTransactionID(ctx context.Context, txID int64) (*models.Fee, error) {
+	query := `
+		SELECT ` + feeColumns + `
+		FROM fees
+		WHERE transaction_id = $1
+	`
+	return scanFee(r.db.QueryRow(ctx, query, txID))
+}
+
+// Delete удаляет учет отмененной операции, чтобы она не занимала
+// бесплатный месячный лимит
+func (r *FeeRepository) Delete(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM fees
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// GetByAccountID возвращает взятые по счету комиссии, операции без
+// комиссии не включаются
+func (r *FeeRepository) GetByAccountID(ctx context.Context, accountID int64) ([]*models.Fee, error) {
+	query := `
+		SELECT ` + feeColumns + `
+		FROM fees
+		WHERE account_id = $1 AND fee > 0
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFees(rows)
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+var ErrInterbankPaymentStatusConflict = errors.New("статус платежного поручения уже изменен")
+
+type InterbankPaymentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewInterbankPaymentRepository(db *pgxpool.Pool) *InterbankPaymentRepository {
+	return &InterbankPaymentRepository{db: db}
+}
+
+const interbankPaymentColumns = `id, user_id, from_account_id, amount, recipient_name, recipient_inn, recipient_kpp,
+	recipient_bic, recipient_bank_name, correspondent_account, recipient_account, purpose, vat_rate, vat_amount,
+	status, attempts, gateway_reference, reject_reason, debit_transaction_id, refund_transaction_id,
+	created_at, updated_at`
+
+func scanInterbankPayment(row pgx.Row) (*models.InterbankPayment, error) {
+	var p models.InterbankPayment
+	err := row.Scan(&p.ID, &p.UserID, &p.FromAccountID, &p.Amount, &p.RecipientName, &p.RecipientINN,
+		&p.RecipientKPP, &p.RecipientBIC, &p.RecipientBankName, &p.CorrespondentAccount, &p.RecipientAccount,
+		&p.Purpose, &p.VATRate, &p.VATAmount, &p.Status, &p.Attempts, &p.GatewayReference, &p.RejectReason,
+		&p.DebitTransactionID, &p.RefundTransactionID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func scanInterbankPayments(rows pgx.Rows) ([]*models.InterbankPayment, error) {
+	var payments []*models.InterbankPayment
+	for rows.Next() {
+		p, err := scanInterbankPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *InterbankPaymentRepository) Create(ctx context.Context, p *models.InterbankPayment) (*models.InterbankPayment, error) {
+	query := `
+		INSERT INTO interbank_payments (user_id, from_account_id, amount, recipient_name, recipient_inn,
+			recipient_kpp, recipient_bic, recipient_bank_name, correspondent_account, recipient_account, purpose,
+			vat_rate, vat_amount, status, debit_transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING ` + interbankPaymentColumns
+	return scanInterbankPayment(r.db.QueryRow(ctx, query, p.UserID, p.FromAccountID, p.Amount, p.RecipientName,
+		p.RecipientINN, p.RecipientKPP, p.RecipientBIC, p.RecipientBankName, p.CorrespondentAccount,
+		p.RecipientAccount, p.Purpose, p.VATRate, p.VATAmount, p.Status, p.DebitTransactionID))
+}
+
+func (r *InterbankPaymentRepository) GetByID(ctx context.Context, id int64) (*models.InterbankPayment, error) {
+	query := `
+		SELECT ` + interbankPaymentColumns + `
+		FROM interbank_payments
+		WHERE id = $1
+	`
+	return scanInterbankPayment(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *InterbankPaymentRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.InterbankPayment, error) {
+	query := `
+		SELECT ` + interbankPaymentColumns + `
+		FROM interbank_payments
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInterbankPayments(rows)
+}
+
+// ClaimPending выбирает поручения, ожидающие отправки, увеличивает счетчик
+// попыток и блокирует их на lease
+func (r *InterbankPaymentRepository) ClaimPending(ctx context.Context, now time.Time, lease time.Duration) ([]*models.InterbankPayment, error) {
+	query := `
+		UPDATE interbank_payments
+		SET locked_until = $2, attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id
+			FROM interbank_payments
+			WHERE status = $3 AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + interbankPaymentColumns
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), models.InterbankPaymentPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInterbankPayments(rows)
+}
+
+// MarkSent отмечает поручение принятым шлюзом
+func (r *InterbankPaymentRepository) MarkSent(ctx context.Context, id int64, reference string) error {
+	query := `
+		UPDATE interbank_payments
+		SET status = $3, gateway_reference = $4, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	return r.updateStatus(ctx, query, id, models.InterbankPaymentPending, models.InterbankPaymentSent, reference)
+}
+
+// MarkRejected отклоняет поручение с записью причины и транзакции возврата
+func (r *InterbankPaymentRepository) MarkRejected(ctx context.Context, id int64, reason string, refundTransactionID int64) error {
+	query := `
+		UPDATE interbank_payments
+		SET status = $3, reject_reason = $4, refund_transaction_id = $5, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	return r.updateStatus(ctx, query, id, models.InterbankPaymentPending, models.InterbankPaymentRejected, reason,
+		refundTransactionID)
+}
+
+// Release снимает блокировку, чтобы поручение отправилось при следующем запуске
+func (r *InterbankPaymentRepository) Release(ctx context.Context, id int64) error {
+	query := `
+		UPDATE interbank_payments
+		SET locked_until = NULL
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *InterbankPaymentRepository) updateStatus(ctx context.Context, query string, id int64,
+	from, to models.InterbankPaymentStatus, args ...any) error {
+	tag, err := r.db.Exec(ctx, query, append([]any{id, from, to}, args...)...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInterbankPaymentStatusConflict
+	}
+	return nil
+}
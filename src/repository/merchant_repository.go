@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+type MerchantRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMerchantRepository(db *pgxpool.Pool) *MerchantRepository {
+	return &MerchantRepository{db: db}
+}
+
+const merchantColumns = `id, name, mcc, settlement_account_id, created_at`
+
+const merchantAPIKeyColumns = `id, merchant_id, prefix, key_hash, scopes, expires_at, revoked_at, last_used_at, created_at`
+
+func scanMerchant(row pgx.Row) (*models.Merchant, error) {
+	var m models.Merchant
+	err := row.Scan(&m.ID, &m.Name, &m.MCC, &m.SettlementAccountID, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func scanMerchantAPIKey(row pgx.Row) (*models.MerchantAPIKey, error) {
+	var k models.MerchantAPIKey
+	err := row.Scan(&k.ID, &k.MerchantID, &k.Prefix, &k.KeyHash, &k.Scopes, &k.ExpiresAt, &k.RevokedAt,
+		&k.LastUsedAt, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Create сохраняет мерчанта. Секрет подписи шифруется ключом pgpKey,
+// так как для проверки HMAC он нужен в открытом виде
+func (r *MerchantRepository) Create(ctx context.Context, m *models.Merchant, signingSecret string, pgpKey string) (*models.Merchant, error) {
+	query := `
+		INSERT INTO merchants (name, mcc, settlement_account_id, signing_secret)
+		VALUES ($1, $2, $3, pgp_sym_encrypt($4, $5))
+		RETURNING ` + merchantColumns
+	return scanMerchant(r.db.QueryRow(ctx, query, m.Name, m.MCC, m.SettlementAccountID, signingSecret, pgpKey))
+}
+
+func (r *MerchantRepository) GetByID(ctx context.Context, id int64) (*models.Merchant, error) {
+	query := `
+		SELECT ` + merchantColumns + `
+		FROM merchants
+		WHERE id = $1
+	`
+	return scanMerchant(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *MerchantRepository) GetAll(ctx context.Context) ([]*models.Merchant, error) {
+	query := `
+		SELECT ` + merchantColumns + `
+		FROM merchants
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merchants []*models.Merchant
+	for rows.Next() {
+		m, err := scanMerchant(rows)
+		if err != nil {
+			return nil, err
+		}
+		merchants = append(merchants, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return merchants, nil
+}
+
+func (r *MerchantRepository) GetSigningSecret(ctx context.Context, id int64, pgpKey string) (string, error) {
+	query := `
+		SELECT pgp_sym_decrypt(signing_secret, $2)
+		FROM merchants
+		WHERE id = $1
+	`
+	var secret string
+	err := r.db.QueryRow(ctx, query, id, pgpKey).Scan(&secret)
+	return secret, err
+}
+
+func (r *MerchantRepository) UpdateSigningSecret(ctx context.Context, id int64, signingSecret string, pgpKey string) error {
+	query := `
+		UPDATE merchants
+		SET signing_secret = pgp_sym_encrypt($2, $3)
+		WHERE id = $1
+	`
+	tag, err := r.db.Exec(ctx, query, id, signingSecret, pgpKey)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *MerchantRepository) CreateAPIKey(ctx context.Context, k *models.MerchantAPIKey) (*models.MerchantAPIKey, error) {
+	query := `
+		INSERT INTO merchant_api_keys (merchant_id, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + merchantAPIKeyColumns
+	return scanMerchantAPIKey(r.db.QueryRow(ctx, query, k.MerchantID, k.Prefix, k.KeyHash, k.Scopes))
+}
+
+func (r *MerchantRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.MerchantAPIKey, error) {
+	query := `
+		SELECT ` + merchantAPIKeyColumns + `
+		FROM merchant_api_keys
+		WHERE prefix = $1
+	`
+	return scanMerchantAPIKey(r.db.QueryRow(ctx, query, prefix))
+}
+
+func (r *MerchantRepository) GetAPIKey(ctx context.Context, merchantID int64, keyID int64) (*models.MerchantAPIKey, error) {
+	query := `
+		SELECT ` + merchantAPIKeyColumns + `
+		FROM merchant_api_keys
+		WHERE id = $1 AND merchant_id = $2
+	`
+	return scanMerchantAPIKey(r.db.QueryRow(ctx, query, keyID, merchantID))
+}
+
+func (r *MerchantRepository) GetAPIKeys(ctx context.Context, merchantID int64) ([]*models.MerchantAPIKey, error) {
+	query := `
+		SELECT ` + merchantAPIKeyColumns + `
+		FROM merchant_api_keys
+		WHERE merchant_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.MerchantAPIKey
+	for rows.Next() {
+		k, err := scanMerchantAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ExpireAPIKey ограничивает срок действия ключа моментом expiresAt,
+// если ключ еще не отозван и не истекает раньше
+func (r *MerchantRepository) ExpireAPIKey(ctx context.Context, keyID int64, expiresAt time.Time) error {
+	query := `
+		UPDATE merchant_api_keys
+		SET expires_at = $2
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+	`
+	_, err := r.db.Exec(ctx, query, keyID, expiresAt)
+	return err
+}
+
+// RevokeAPIKey немедленно отзывает ключ. Возвращает false, если ключ уже отозван
+func (r *MerchantRepository) RevokeAPIKey(ctx context.Context, merchantID int64, keyID int64) (bool, error) {
+	query := `
+		UPDATE merchant_api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND merchant_id = $2 AND revoked_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, keyID, merchantID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *MerchantRepository) TouchAPIKey(ctx context.Context, keyID int64) error {
+	query := `
+		UPDATE merchant_api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, keyID)
+	return err
+}
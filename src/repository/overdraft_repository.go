@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+var ErrOverdraftChargePosted = errors.New("проценты по овердрафту уже списаны")
+
+type OverdraftRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOverdraftRepository(db *pgxpool.Pool) *OverdraftRepository {
+	return &OverdraftRepository{db: db}
+}
+
+const overdraftChargeColumns = `id, account_id, charge_date, balance, annual_rate, amount, transaction_id, posted_at`
+
+func scanOverdraftCharge(row pgx.Row) (*models.OverdraftCharge, error) {
+	var c models.OverdraftCharge
+	err := row.Scan(&c.ID, &c.AccountID, &c.ChargeDate, &c.Balance, &c.AnnualRate, &c.Amount, &c.TransactionID, &c.PostedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanOverdraftCharges(rows pgx.Rows) ([]*models.OverdraftCharge, error) {
+	var charges []*models.OverdraftCharge
+	for rows.Next() {
+		c, err := scanOverdraftCharge(rows)
+		if err != nil {
+			return nil, err
+		}
+		charges = append(charges, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return charges, nil
+}
+
+// CloseDay отмечает, что проценты за день day по счету начислены, и
+// записывает начисление charge, если за день есть что списать (nil - нечего).
+// Повторный вызов за тот же день ничего не меняет
+func (r *OverdraftRepository) CloseDay(ctx context.Context, accountID int64, day time.Time, charge *models.OverdraftCharge) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	updateQuery := `
+		UPDATE accounts
+		SET overdraft_charged_through = $2
+		WHERE id = $1 AND overdraft_charged_through < $2
+	`
+	tag, err := tx.Exec(ctx, updateQuery, accountID, day)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	if charge != nil {
+		insertQuery := `
+			INSERT INTO overdraft_charges (account_id, charge_date, balance, annual_rate, amount)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err := tx.Exec(ctx, insertQuery, accountID, day, charge.Balance, charge.AnnualRate, charge.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetUnposted возвращает начисления, которые еще не списаны со счетов,
+// в том числе оставшиеся после прошлых сбоев
+func (r *OverdraftRepository) GetUnposted(ctx context.Context) ([]*models.OverdraftCharge, error) {
+	query := `
+		SELECT ` + overdraftChargeColumns + `
+		FROM overdraft_charges
+		WHERE posted_at IS NULL
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOverdraftCharges(rows)
+}
+
+// Claim отмечает начисление списываемым, чтобы его не списали дважды. Если
+// начисление уже списывают, возвращает ErrOverdraftChargePosted
+func (r *OverdraftRepository) Claim(ctx context.Context, id int64) error {
+	query := `
+		UPDATE overdraft_charges
+		SET posted_at = NOW()
+		WHERE id = $1 AND posted_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOverdraftChargePosted
+	}
+	return nil
+}
+
+// Release возвращает начисление, которое не удалось списать, в очередь
+func (r *OverdraftRepository) Release(ctx context.Context, id int64) error {
+	query := `
+		UPDATE overdraft_charges
+		SET posted_at = NULL
+		WHERE id = $1 AND transaction_id IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *OverdraftRepository) SetTransactionID(ctx context.Context, id int64, txID int64) error {
+	query := `
+		UPDATE overdraft_charges
+		SET transaction_id = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, txID)
+	return err
+}
+
+func (r *OverdraftRepository) GetByAccountID(ctx context.Context, accountID int64) ([]*models.OverdraftCharge, error) {
+	query := `
+		SELECT ` + overdraftChargeColumns + `
+		FROM overdraft_charges
+		WHERE account_id = $1
+		ORDER BY charge_date DESC
+	`
+	rows, err := r.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOverdraftCharges(rows)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+type PaymentConfirmationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentConfirmationRepository(db *pgxpool.Pool) *PaymentConfirmationRepository {
+	return &PaymentConfirmationRepository{db: db}
+}
+
+func (r *PaymentConfirmationRepository) Create(ctx context.Context, c *models.PaymentConfirmation) error {
+	query := `
+		INSERT INTO payment_confirmations (payment_id, code_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`
+	_, err := r.db.Exec(ctx, query, c.PaymentID, c.CodeHash, c.ExpiresAt)
+	return err
+}
+
+func (r *PaymentConfirmationRepository) GetByPaymentID(ctx context.Context, paymentID int64) (*models.PaymentConfirmation, error) {
+	query := `
+		SELECT payment_id, code_hash, attempts, expires_at, confirmed_at, created_at
+		FROM payment_confirmations
+		WHERE payment_id = $1
+	`
+	var c models.PaymentConfirmation
+	err := r.db.QueryRow(ctx, query, paymentID).Scan(&c.PaymentID, &c.CodeHash, &c.Attempts, &c.ExpiresAt,
+		&c.ConfirmedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// RegisterFailedAttempt увеличивает счетчик неверных кодов и возвращает новое значение
+func (r *PaymentConfirmationRepository) RegisterFailedAttempt(ctx context.Context, paymentID int64) (int, error) {
+	query := `
+		UPDATE payment_confirmations
+		SET attempts = attempts + 1
+		WHERE payment_id = $1
+		RETURNING attempts
+	`
+	var attempts int
+	err := r.db.QueryRow(ctx, query, paymentID).Scan(&attempts)
+	return attempts, err
+}
+
+// MarkConfirmed отмечает код использованным. Возвращает false, если платеж
+// уже подтвержден параллельным запросом или больше не ожидает подтверждения
+func (r *PaymentConfirmationRepository) MarkConfirmed(ctx context.Context, paymentID int64) (bool, error) {
+	query := `
+		UPDATE payment_confirmations c
+		SET confirmed_at = NOW()
+		FROM payments p
+		WHERE c.payment_id = $1 AND c.confirmed_at IS NULL AND p.id = c.payment_id AND p.status = $2
+	`
+	tag, err := r.db.Exec(ctx, query, paymentID, models.PaymentPendingConfirmation)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+var ErrPaymentStatusConflict = errors.New("статус платежа уже изменен")
+
+type PaymentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentRepository(db *pgxpool.Pool) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+const paymentColumns = `id, merchant_id, card_id, amount, currency, status, failure_reason, transaction_id,
+	settlement_batch_id, created_at, updated_at`
+
+func scanPayment(row pgx.Row) (*models.Payment, error) {
+	var p models.Payment
+	err := row.Scan(&p.ID, &p.MerchantID, &p.CardID, &p.Amount, &p.Currency, &p.Status,
+		&p.FailureReason, &p.TransactionID, &p.SettlementBatchID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func scanPayments(rows pgx.Rows) ([]*models.Payment, error) {
+	var payments []*models.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func insertPaymentStatusChange(ctx context.Context, tx pgx.Tx, paymentID int64, status models.PaymentStatus, reason *string) error {
+	query := `
+		INSERT INTO payment_status_history (payment_id, status, reason)
+		VALUES ($1, $2, $3)
+	`
+	_, err := tx.Exec(ctx, query, paymentID, status, reason)
+	return err
+}
+
+func (r *PaymentRepository) Create(ctx context.Context, p *models.Payment) (*models.Payment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO payments (merchant_id, card_id, amount, currency, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + paymentColumns
+	created, err := scanPayment(tx.QueryRow(ctx, query, p.MerchantID, p.CardID, p.Amount, p.Currency, p.Status))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertPaymentStatusChange(ctx, tx, created.ID, created.Status, nil); err != nil {
+		return nil, err
+	}
+
+	return created, tx.Commit(ctx)
+}
+
+// UpdateStatus меняет статус платежа, только если текущий статус равен from,
+// и записывает изменение в историю. Иначе возвращает ErrPaymentStatusConflict
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, id int64, from, to models.PaymentStatus, reason *string) error {
+	return r.updateStatus(ctx, id, from, to, reason, nil)
+}
+
+// Capture переводит платеж в статус CAPTURED вместе с транзакцией списания,
+// чтобы захваченный платеж не остался без нее
+func (r *PaymentRepository) Capture(ctx context.Context, id int64, from models.PaymentStatus, transactionID int64) error {
+	return r.updateStatus(ctx, id, from, models.PaymentCaptured, nil, &transactionID)
+}
+
+func (r *PaymentRepository) updateStatus(ctx context.Context, id int64, from, to models.PaymentStatus, reason *string,
+	transactionID *int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE payments
+		SET status = $3, failure_reason = COALESCE($4, failure_reason),
+			transaction_id = COALESCE($5, transaction_id), updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := tx.Exec(ctx, query, id, from, to, reason, transactionID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPaymentStatusConflict
+	}
+
+	if err := insertPaymentStatusChange(ctx, tx, id, to, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PaymentRepository) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE id = $1
+	`
+	return scanPayment(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *PaymentRepository) GetByCardID(ctx context.Context, cardID int64) ([]*models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE card_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
+
+func (r *PaymentRepository) GetStatusHistory(ctx context.Context, paymentID int64) ([]*models.PaymentStatusChange, error) {
+	query := `
+		SELECT id, payment_id, status, reason, created_at
+		FROM payment_status_history
+		WHERE payment_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(ctx, query, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.PaymentStatusChange
+	for rows.Next() {
+		var c models.PaymentStatusChange
+		if err := rows.Scan(&c.ID, &c.PaymentID, &c.Status, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, &c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ExpireConfirmations переводит в статус EXPIRED платежи, код по которым
+// не введен до now, и возвращает их ID. Платеж с уже использованным кодом
+// списывается ConfirmPayment и не отменяется
+func (r *PaymentRepository) ExpireConfirmations(ctx context.Context, now time.Time, reason string) ([]int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE payments p
+		SET status = $3, failure_reason = $4, updated_at = NOW()
+		FROM payment_confirmations c
+		WHERE c.payment_id = p.id AND p.status = $1 AND c.expires_at < $2 AND c.confirmed_at IS NULL
+		RETURNING p.id
+	`
+	rows, err := tx.Query(ctx, query, models.PaymentPendingConfirmation, now, models.PaymentExpired, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := insertPaymentStatusChange(ctx, tx, id, models.PaymentExpired, &reason); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, tx.Commit(ctx)
+}
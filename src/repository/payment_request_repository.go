@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+var ErrPaymentRequestStatusConflict = errors.New("статус запроса на оплату уже изменен")
+
+type PaymentRequestRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentRequestRepository(db *pgxpool.Pool) *PaymentRequestRepository {
+	return &PaymentRequestRepository{db: db}
+}
+
+const paymentRequestColumns = `id, requester_id, payer_id, to_account_id, amount, currency, comment, status,
+	expires_at, paid_from_account_id, transaction_id, decline_reason, created_at, resolved_at`
+
+func scanPaymentRequest(row pgx.Row) (*models.PaymentRequest, error) {
+	var p models.PaymentRequest
+	err := row.Scan(&p.ID, &p.RequesterID, &p.PayerID, &p.ToAccountID, &p.Amount, &p.Currency, &p.Comment, &p.Status,
+		&p.ExpiresAt, &p.PaidFromAccountID, &p.TransactionID, &p.DeclineReason, &p.CreatedAt, &p.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func scanPaymentRequests(rows pgx.Rows) ([]*models.PaymentRequest, error) {
+	var requests []*models.PaymentRequest
+	for rows.Next() {
+		p, err := scanPaymentRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (r *PaymentRequestRepository) Create(ctx context.Context, p *models.PaymentRequest) (*models.PaymentRequest, error) {
+	query := `
+		INSERT INTO payment_requests (requester_id, payer_id, to_account_id, amount, currency, comment, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + paymentRequestColumns
+	return scanPaymentRequest(r.db.QueryRow(ctx, query, p.RequesterID, p.PayerID, p.ToAccountID, p.Amount, p.Currency,
+		p.Comment, p.Status, p.ExpiresAt))
+}
+
+func (r *PaymentRequestRepository) GetByID(ctx context.Context, id int64) (*models.PaymentRequest, error) {
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM payment_requests
+		WHERE id = $1
+	`
+	return scanPaymentRequest(r.db.QueryRow(ctx, query, id))
+}
+
+// GetIncoming возвращает запросы, выставленные пользователю
+func (r *PaymentRequestRepository) GetIncoming(ctx context.Context, payerID int64) ([]*models.PaymentRequest, error) {
+	return r.list(ctx, "payer_id", payerID)
+}
+
+// GetOutgoing возвращает запросы, выставленные пользователем
+func (r *PaymentRequestRepository) GetOutgoing(ctx context.Context, requesterID int64) ([]*models.PaymentRequest, error) {
+	return r.list(ctx, "requester_id", requesterID)
+}
+
+func (r *PaymentRequestRepository) list(ctx context.Context, column string, userID int64) ([]*models.PaymentRequest, error) {
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM payment_requests
+		WHERE ` + column + ` = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPaymentRequests(rows)
+}
+
+// MarkPaid закрывает действующий запрос как оплаченный со счета fromAccountID
+func (r *PaymentRequestRepository) MarkPaid(ctx context.Context, id int64, fromAccountID int64) error {
+	query := `
+		UPDATE payment_requests
+		SET status = $3, paid_from_account_id = $4, resolved_at = NOW()
+		WHERE id = $1 AND status = $2 AND expires_at > NOW()
+	`
+	return r.exec(ctx, query, id, models.PaymentRequestPending, models.PaymentRequestPaid, fromAccountID)
+}
+
+func (r *PaymentRequestRepository) SetTransactionID(ctx context.Context, id int64, transactionID int64) error {
+	query := `
+		UPDATE payment_requests
+		SET transaction_id = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, transactionID)
+	return err
+}
+
+// Reopen возвращает запрос к оплате, если перевод по нему не прошел
+func (r *PaymentRequestRepository) Reopen(ctx context.Context, id int64) error {
+	query := `
+		UPDATE payment_requests
+		SET status = $3, paid_from_account_id = NULL, resolved_at = NULL
+		WHERE id = $1 AND status = $2
+	`
+	return r.exec(ctx, query, id, models.PaymentRequestPaid, models.PaymentRequestPending)
+}
+
+// Resolve закрывает ожидающий запрос со статусом to (отклонен или отменен)
+func (r *PaymentRequestRepository) Resolve(ctx context.Context, id int64, to models.PaymentRequestStatus, reason *string) error {
+	query := `
+		UPDATE payment_requests
+		SET status = $3, decline_reason = $4, resolved_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	return r.exec(ctx, query, id, models.PaymentRequestPending, to, reason)
+}
+
+// ExpireDue закрывает запросы, срок которых истек к now, и возвращает их
+func (r *PaymentRequestRepository) ExpireDue(ctx context.Context, now time.Time) ([]*models.PaymentRequest, error) {
+	query := `
+		UPDATE payment_requests
+		SET status = $2, resolved_at = $1
+		WHERE status = $3 AND expires_at <= $1
+		RETURNING ` + paymentRequestColumns
+	rows, err := r.db.Query(ctx, query, now, models.PaymentRequestExpired, models.PaymentRequestPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPaymentRequests(rows)
+}
+
+func (r *PaymentRequestRepository) exec(ctx context.Context, query string, args ...any) error {
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPaymentRequestStatusConflict
+	}
+	return nil
+}
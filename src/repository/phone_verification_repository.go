@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+type PhoneVerificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPhoneVerificationRepository(db *pgxpool.Pool) *PhoneVerificationRepository {
+	return &PhoneVerificationRepository{db: db}
+}
+
+// Upsert сохраняет новый код для пользователя, заменяя предыдущий
+func (r *PhoneVerificationRepository) Upsert(ctx context.Context, v *models.PhoneVerification) error {
+	query := `
+		INSERT INTO phone_verifications (user_id, phone, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET phone = EXCLUDED.phone, code_hash = EXCLUDED.code_hash, attempts = 0,
+			expires_at = EXCLUDED.expires_at, created_at = NOW()
+	`
+	_, err := r.db.Exec(ctx, query, v.UserID, v.Phone, v.CodeHash, v.ExpiresAt)
+	return err
+}
+
+func (r *PhoneVerificationRepository) GetByUserID(ctx context.Context, userID int64) (*models.PhoneVerification, error) {
+	query := `
+		SELECT user_id, phone, code_hash, attempts, expires_at, created_at
+		FROM phone_verifications
+		WHERE user_id = $1
+	`
+	var v models.PhoneVerification
+	err := r.db.QueryRow(ctx, query, userID).Scan(&v.UserID, &v.Phone, &v.CodeHash, &v.Attempts,
+		&v.ExpiresAt, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// RegisterFailedAttempt увеличивает счетчик неверных кодов и возвращает новое значение
+func (r *PhoneVerificationRepository) RegisterFailedAttempt(ctx context.Context, userID int64) (int, error) {
+	query := `
+		UPDATE phone_verifications
+		SET attempts = attempts + 1
+		WHERE user_id = $1
+		RETURNING attempts
+	`
+	var attempts int
+	err := r.db.QueryRow(ctx, query, userID).Scan(&attempts)
+	return attempts, err
+}
+
+func (r *PhoneVerificationRepository) Delete(ctx context.Context, userID int64) error {
+	query := `
+		DELETE FROM phone_verifications
+		WHERE user_id = $1
+	`
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
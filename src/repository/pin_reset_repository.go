@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+type PINResetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPINResetRepository(db *pgxpool.Pool) *PINResetRepository {
+	return &PINResetRepository{db: db}
+}
+
+// Upsert сохраняет новый код для карты, заменяя предыдущий
+func (r *PINResetRepository) Upsert(ctx context.Context, c *models.PINResetCode) error {
+	query := `
+		INSERT INTO pin_reset_codes (card_id, code_hash, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (card_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, attempts = 0, expires_at = EXCLUDED.expires_at, created_at = NOW()
+	`
+	_, err := r.db.Exec(ctx, query, c.CardID, c.CodeHash, c.ExpiresAt)
+	return err
+}
+
+func (r *PINResetRepository) GetByCardID(ctx context.Context, cardID int64) (*models.PINResetCode, error) {
+	query := `
+		SELECT card_id, code_hash, attempts, expires_at, created_at
+		FROM pin_reset_codes
+		WHERE card_id = $1
+	`
+	var c models.PINResetCode
+	err := r.db.QueryRow(ctx, query, cardID).Scan(&c.CardID, &c.CodeHash, &c.Attempts, &c.ExpiresAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// RegisterFailedAttempt увеличивает счетчик неверных попыток и возвращает новое значение
+func (r *PINResetRepository) RegisterFailedAttempt(ctx context.Context, cardID int64) (int, error) {
+	query := `
+		UPDATE pin_reset_codes
+		SET attempts = attempts + 1
+		WHERE card_id = $1
+		RETURNING attempts
+	`
+	var attempts int
+	err := r.db.QueryRow(ctx, query, cardID).Scan(&attempts)
+	return attempts, err
+}
+
+// Consume удаляет использованный код. Возвращает false, если код уже
+// использован параллельным запросом или заменен новым
+func (r *PINResetRepository) Consume(ctx context.Context, cardID int64, codeHash string) (bool, error) {
+	query := `
+		DELETE FROM pin_reset_codes
+		WHERE card_id = $1 AND code_hash = $2
+	`
+	tag, err := r.db.Exec(ctx, query, cardID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
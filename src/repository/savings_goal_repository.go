@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+var ErrSavingsGoalStatusConflict = errors.New("статус цели уже изменен")
+
+type SavingsGoalRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSavingsGoalRepository(db *pgxpool.Pool) *SavingsGoalRepository {
+	return &SavingsGoalRepository{db: db}
+}
+
+const savingsGoalColumns = `id, user_id, main_account_id, account_id, name, target_amount, target_date, round_up_step,
+	round_up_since, deposit_percent, deposit_percent_since, status, reached_at, created_at, closed_at`
+
+const contributionColumns = `id, goal_id, rule, source_transaction_id, period, amount, status, reason, created_at`
+
+func scanSavingsGoal(row pgx.Row) (*models.SavingsGoal, error) {
+	var g models.SavingsGoal
+	err := row.Scan(&g.ID, &g.UserID, &g.MainAccountID, &g.AccountID, &g.Name, &g.TargetAmount, &g.TargetDate,
+		&g.RoundUpStep, &g.RoundUpSince, &g.DepositPercent, &g.DepositPercentSince, &g.Status, &g.ReachedAt,
+		&g.CreatedAt, &g.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func scanSavingsGoals(rows pgx.Rows) ([]*models.SavingsGoal, error) {
+	var goals []*models.SavingsGoal
+	for rows.Next() {
+		g, err := scanSavingsGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+func scanContribution(row pgx.Row) (*models.SavingsContribution, error) {
+	var c models.SavingsContribution
+	err := row.Scan(&c.ID, &c.GoalID, &c.Rule, &c.SourceTransactionID, &c.Period, &c.Amount, &c.Status, &c.Reason,
+		&c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *SavingsGoalRepository) Create(ctx context.Context, g *models.SavingsGoal) (*models.SavingsGoal, error) {
+	query := `
+		INSERT INTO savings_goals (user_id, main_account_id, account_id, name, target_amount, target_date,
+			round_up_step, round_up_since, deposit_percent, deposit_percent_since, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + savingsGoalColumns
+	return scanSavingsGoal(r.db.QueryRow(ctx, query, g.UserID, g.MainAccountID, g.AccountID, g.Name, g.TargetAmount,
+		g.TargetDate, g.RoundUpStep, g.RoundUpSince, g.DepositPercent, g.DepositPercentSince, g.Status))
+}
+
+func (r *SavingsGoalRepository) GetByID(ctx context.Context, id int64) (*models.SavingsGoal, error) {
+	query := `
+		SELECT ` + savingsGoalColumns + `
+		FROM savings_goals
+		WHERE id = $1
+	`
+	return scanSavingsGoal(r.db.QueryRow(ctx, query, id))
+}
+
+// GetByAccountID возвращает цель, счетом которой является accountID
+func (r *SavingsGoalRepository) GetByAccountID(ctx context.Context, accountID int64) (*models.SavingsGoal, error) {
+	query := `
+		SELECT ` + savingsGoalColumns + `
+		FROM savings_goals
+		WHERE account_id = $1
+	`
+	return scanSavingsGoal(r.db.QueryRow(ctx, query, accountID))
+}
+
+func (r *SavingsGoalRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.SavingsGoal, error) {
+	query := `
+		SELECT ` + savingsGoalColumns + `
+		FROM savings_goals
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSavingsGoals(rows)
+}
+
+// GetActive возвращает цели, по которым применяются правила пополнения
+func (r *SavingsGoalRepository) GetActive(ctx context.Context) ([]*models.SavingsGoal, error) {
+	query := `
+		SELECT ` + savingsGoalColumns + `
+		FROM savings_goals
+		WHERE status = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, models.SavingsGoalActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSavingsGoals(rows)
+}
+
+func (r *SavingsGoalRepository) CountActive(ctx context.Context, userID int64) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM savings_goals
+		WHERE user_id = $1 AND status = $2
+	`
+	var count int
+	err := r.db.QueryRow(ctx, query, userID, models.SavingsGoalActive).Scan(&count)
+	return count, err
+}
+
+// UpdateRules сохраняет правила пополнения действующей цели
+func (r *SavingsGoalRepository) UpdateRules(ctx context.Context, g *models.SavingsGoal) error {
+	query := `
+		UPDATE savings_goals
+		SET round_up_step = $2, round_up_since = $3, deposit_percent = $4, deposit_percent_since = $5
+		WHERE id = $1 AND status = $6
+	`
+	tag, err := r.db.Exec(ctx, query, g.ID, g.RoundUpStep, g.RoundUpSince, g.DepositPercent, g.DepositPercentSince,
+		models.SavingsGoalActive)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSavingsGoalStatusConflict
+	}
+	return nil
+}
+
+// MarkReached отмечает достижение цели. Возвращает false, если цель уже
+// была отмечена достигнутой
+func (r *SavingsGoalRepository) MarkReached(ctx context.Context, id int64) (bool, error) {
+	query := `
+		UPDATE savings_goals
+		SET reached_at = NOW()
+		WHERE id = $1 AND reached_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *SavingsGoalRepository) Close(ctx context.Context, id int64) error {
+	query := `
+		UPDATE savings_goals
+		SET status = $2, closed_at = NOW()
+		WHERE id = $1 AND status = $3
+	`
+	tag, err := r.db.Exec(ctx, query, id, models.SavingsGoalClosed, models.SavingsGoalActive)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSavingsGoalStatusConflict
+	}
+	return nil
+}
+
+// GetUnroundedCardPayments возвращает списания по покупкам картами счета
+// accountID начиная с since, которые еще не округлялись в пользу цели
+func (r *SavingsGoalRepository) GetUnroundedCardPayments(ctx context.Context, goalID int64, accountID int64,
+	since time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT t.id, t.amount, t.created_at
+		FROM payments p
+		JOIN transactions t ON t.id = p.transaction_id
+		WHERE t.account_id = $1 AND t.created_at >= $2
+			AND NOT EXISTS (
+				SELECT 1 FROM savings_contributions c
+				WHERE c.goal_id = $3 AND c.rule = $4 AND c.source_transaction_id = t.id
+			)
+		ORDER BY t.id
+	`
+	rows, err := r.db.Query(ctx, query, accountID, since, goalID, models.SavingsRoundUp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		tx := models.Transaction{AccountID: accountID}
+		if err := rows.Scan(&tx.ID, &tx.Amount, &tx.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, &tx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// CreateContribution сохраняет пополнение до перевода, чтобы одно и то же
+// пополнение не провели дважды. Если такое пополнение уже есть (та же
+// покупка или тот же месяц), возвращает pgx.ErrNoRows
+func (r *SavingsGoalRepository) CreateContribution(ctx context.Context, c *models.SavingsContribution) (*models.SavingsContribution, error) {
+	query := `
+		INSERT INTO savings_contributions (goal_id, rule, source_transaction_id, period, amount, status, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
+		RETURNING ` + contributionColumns
+	return scanContribution(r.db.QueryRow(ctx, query, c.GoalID, c.Rule, c.SourceTransactionID, c.Period, c.Amount,
+		c.Status, c.Reason))
+}
+
+// FinishContribution фиксирует результат перевода по пополнению
+func (r *SavingsGoalRepository) FinishContribution(ctx context.Context, id int64, status models.SavingsContributionStatus,
+	reason *string) error {
+	query := `
+		UPDATE savings_contributions
+		SET status = $2, reason = $3
+		WHERE id = $1 AND status = $4
+	`
+	_, err := r.db.Exec(ctx, query, id, status, reason, models.ContributionPending)
+	return err
+}
+
+// GetContributions возвращает последние limit автоматических пополнений цели
+func (r *SavingsGoalRepository) GetContributions(ctx context.Context, goalID int64, limit int) ([]*models.SavingsContribution, error) {
+	query := `
+		SELECT ` + contributionColumns + `
+		FROM savings_contributions
+		WHERE goal_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, goalID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contributions []*models.SavingsContribution
+	for rows.Next() {
+		c, err := scanContribution(rows)
+		if err != nil {
+			return nil, err
+		}
+		contributions = append(contributions, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return contributions, nil
+}
+
+// GetContributedTotal возвращает сумму проведенных автоматических пополнений цели
+func (r *SavingsGoalRepository) GetContributedTotal(ctx context.Context, goalID int64) (decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM savings_contributions
+		WHERE goal_id = $1 AND status = $2
+	`
+	var total decimal.Decimal
+	err := r.db.QueryRow(ctx, query, goalID, models.ContributionDone).Scan(&total)
+	return total, err
+}
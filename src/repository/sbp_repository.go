@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+var ErrSBPQRStatusConflict = errors.New("статус платежной ссылки уже изменен")
+
+type SBPRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSBPRepository(db *pgxpool.Pool) *SBPRepository {
+	return &SBPRepository{db: db}
+}
+
+const sbpQRColumns = `id, qr_id, user_id, account_id, type, amount, purpose, status, expires_at, paid_at, created_at`
+
+func scanSBPQRCode(row pgx.Row) (*models.SBPQRCode, error) {
+	var q models.SBPQRCode
+	err := row.Scan(&q.ID, &q.QRID, &q.UserID, &q.AccountID, &q.Type, &q.Amount, &q.Purpose, &q.Status,
+		&q.ExpiresAt, &q.PaidAt, &q.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (r *SBPRepository) Create(ctx context.Context, q *models.SBPQRCode) (*models.SBPQRCode, error) {
+	query := `
+		INSERT INTO sbp_qr_codes (qr_id, user_id, account_id, type, amount, purpose, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + sbpQRColumns
+	return scanSBPQRCode(r.db.QueryRow(ctx, query, q.QRID, q.UserID, q.AccountID, q.Type, q.Amount, q.Purpose,
+		q.Status, q.ExpiresAt))
+}
+
+func (r *SBPRepository) GetByID(ctx context.Context, id int64) (*models.SBPQRCode, error) {
+	query := `
+		SELECT ` + sbpQRColumns + `
+		FROM sbp_qr_codes
+		WHERE id = $1
+	`
+	return scanSBPQRCode(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *SBPRepository) GetByQRID(ctx context.Context, qrID string) (*models.SBPQRCode, error) {
+	query := `
+		SELECT ` + sbpQRColumns + `
+		FROM sbp_qr_codes
+		WHERE qr_id = $1
+	`
+	return scanSBPQRCode(r.db.QueryRow(ctx, query, qrID))
+}
+
+func (r *SBPRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.SBPQRCode, error) {
+	query := `
+		SELECT ` + sbpQRColumns + `
+		FROM sbp_qr_codes
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []*models.SBPQRCode
+	for rows.Next() {
+		q, err := scanSBPQRCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, q)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// MarkPaid отмечает динамическую ссылку оплаченной, только если она еще
+// активна и не истекла. Иначе возвращает ErrSBPQRStatusConflict
+func (r *SBPRepository) MarkPaid(ctx context.Context, id int64) error {
+	query := `
+		UPDATE sbp_qr_codes
+		SET status = $3, paid_at = NOW()
+		WHERE id = $1 AND status = $2 AND (expires_at IS NULL OR expires_at > NOW())
+	`
+	tag, err := r.db.Exec(ctx, query, id, models.SBPQRActive, models.SBPQRPaid)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSBPQRStatusConflict
+	}
+	return nil
+}
+
+// Reopen возвращает ссылку в активное состояние, если перевод по ней не прошел
+func (r *SBPRepository) Reopen(ctx context.Context, id int64) error {
+	query := `
+		UPDATE sbp_qr_codes
+		SET status = $3, paid_at = NULL
+		WHERE id = $1 AND status = $2
+	`
+	_, err := r.db.Exec(ctx, query, id, models.SBPQRPaid, models.SBPQRActive)
+	return err
+}
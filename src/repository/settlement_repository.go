@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+var ErrSettlementPaying = errors.New("пакет уже выплачивается")
+
+type SettlementRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSettlementRepository(db *pgxpool.Pool) *SettlementRepository {
+	return &SettlementRepository{db: db}
+}
+
+// SettlementKey - мерчант и валюта, по которым есть нерассчитанные платежи
+type SettlementKey struct {
+	MerchantID int64
+	Currency   models.Currency
+}
+
+const settlementColumns = `id, merchant_id, business_date, currency, fee_rate, payment_count,
+	gross_amount, fee_amount, net_amount, transaction_id, fee_transaction_id, created_at`
+
+func scanSettlementBatch(row pgx.Row) (*models.SettlementBatch, error) {
+	var b models.SettlementBatch
+	err := row.Scan(&b.ID, &b.MerchantID, &b.BusinessDate, &b.Currency, &b.FeeRate, &b.PaymentCount,
+		&b.GrossAmount, &b.FeeAmount, &b.NetAmount, &b.TransactionID, &b.FeeTransactionID, &b.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func scanSettlementBatches(rows pgx.Rows) ([]*models.SettlementBatch, error) {
+	var batches []*models.SettlementBatch
+	for rows.Next() {
+		b, err := scanSettlementBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// GetPendingKeys возвращает мерчантов и валюты со списанными, но еще
+// не рассчитанными и не оспоренными платежами, созданными до cutoff
+func (r *SettlementRepository) GetPendingKeys(ctx context.Context, cutoff time.Time) ([]SettlementKey, error) {
+	query := `
+		SELECT DISTINCT merchant_id, currency
+		FROM payments
+		WHERE status = $1 AND settlement_batch_id IS NULL AND created_at < $2
+			AND NOT EXISTS (SELECT 1 FROM disputes d WHERE d.payment_id = payments.id AND d.status <> $3)
+		ORDER BY merchant_id, currency
+	`
+	rows, err := r.db.Query(ctx, query, models.PaymentCaptured, cutoff, models.DisputeLost)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []SettlementKey
+	for rows.Next() {
+		var k SettlementKey
+		if err := rows.Scan(&k.MerchantID, &k.Currency); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateBatch в одной транзакции создает пакет, привязывает к нему
+// нерассчитанные платежи мерчанта до cutoff и считает итоги. Платежи с
+// открытым или выигранным держателем спором не рассчитываются. Комиссия
+// округляется до копеек отдельно по каждому платежу. Если платежей нет,
+// возвращает pgx.ErrNoRows
+func (r *SettlementRepository) CreateBatch(ctx context.Context, key SettlementKey, businessDate time.Time,
+	cutoff time.Time, feeRate decimal.Decimal) (*models.SettlementBatch, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var batchID int64
+	insertQuery := `
+		INSERT INTO settlement_batches (merchant_id, business_date, currency, fee_rate)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err = tx.QueryRow(ctx, insertQuery, key.MerchantID, businessDate, key.Currency, feeRate).Scan(&batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	claimQuery := `
+		UPDATE payments
+		SET settlement_batch_id = $1, updated_at = NOW()
+		WHERE merchant_id = $2 AND currency = $3 AND status = $4
+			AND settlement_batch_id IS NULL AND created_at < $5
+			AND NOT EXISTS (SELECT 1 FROM disputes d WHERE d.payment_id = payments.id AND d.status <> $6)
+	`
+	tag, err := tx.Exec(ctx, claimQuery, batchID, key.MerchantID, key.Currency, models.PaymentCaptured, cutoff,
+		models.DisputeLost)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	totalsQuery := `
+		UPDATE settlement_batches b
+		SET payment_count = t.payment_count,
+			gross_amount = t.gross_amount,
+			fee_amount = t.fee_amount,
+			net_amount = t.gross_amount - t.fee_amount
+		FROM (
+			SELECT COUNT(*) AS payment_count,
+				SUM(amount) AS gross_amount,
+				SUM(ROUND(amount * $2, 2)) AS fee_amount
+			FROM payments
+			WHERE settlement_batch_id = $1
+		) t
+		WHERE b.id = $1
+		RETURNING b.id, b.merchant_id, b.business_date, b.currency, b.fee_rate, b.payment_count,
+			b.gross_amount, b.fee_amount, b.net_amount, b.transaction_id, b.fee_transaction_id, b.created_at
+	`
+	batch, err := scanSettlementBatch(tx.QueryRow(ctx, totalsQuery, batchID, feeRate))
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, tx.Commit(ctx)
+}
+
+// Пакет не выплачен, пока не зачислена сумма мерчанту или комиссия на счет доходов
+const settlementUnpaid = `payment_count > 0 AND
+	(transaction_id IS NULL OR (fee_amount > 0 AND fee_transaction_id IS NULL))`
+
+// GetUnpaidBatches возвращает невыплаченные пакеты, которые сейчас никто не выплачивает
+func (r *SettlementRepository) GetUnpaidBatches(ctx context.Context) ([]*models.SettlementBatch, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlement_batches
+		WHERE paying_at IS NULL AND ` + settlementUnpaid + `
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSettlementBatches(rows)
+}
+
+func (r *SettlementRepository) SetTransactionID(ctx context.Context, id int64, transactionID int64) error {
+	query := `
+		UPDATE settlement_batches
+		SET transaction_id = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, transactionID)
+	return err
+}
+
+func (r *SettlementRepository) SetFeeTransactionID(ctx context.Context, id int64, feeTxID int64) error {
+	query := `
+		UPDATE settlement_batches
+		SET fee_transaction_id = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, feeTxID)
+	return err
+}
+
+// Claim отмечает пакет выплачиваемым, чтобы его не выплатили дважды. Если
+// пакет уже выплачивают или он выплачен, возвращает ErrSettlementPaying
+func (r *SettlementRepository) Claim(ctx context.Context, id int64) error {
+	query := `
+		UPDATE settlement_batches
+		SET paying_at = NOW()
+		WHERE id = $1 AND paying_at IS NULL AND ` + settlementUnpaid + `
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSettlementPaying
+	}
+	return nil
+}
+
+// Release возвращает пакет, который не удалось выплатить, в очередь
+func (r *SettlementRepository) Release(ctx context.Context, id int64) error {
+	query := `
+		UPDATE settlement_batches
+		SET paying_at = NULL
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *SettlementRepository) GetByID(ctx context.Context, id int64) (*models.SettlementBatch, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlement_batches
+		WHERE id = $1
+	`
+	return scanSettlementBatch(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *SettlementRepository) GetByMerchantID(ctx context.Context, merchantID int64) ([]*models.SettlementBatch, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlement_batches
+		WHERE merchant_id = $1
+		ORDER BY business_date DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSettlementBatches(rows)
+}
+
+func (r *SettlementRepository) GetAll(ctx context.Context) ([]*models.SettlementBatch, error) {
+	query := `
+		SELECT ` + settlementColumns + `
+		FROM settlement_batches
+		ORDER BY business_date DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSettlementBatches(rows)
+}
+
+func (r *SettlementRepository) GetBatchPayments(ctx context.Context, batchID int64) ([]*models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE settlement_batch_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(ctx, query, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPayments(rows)
+}
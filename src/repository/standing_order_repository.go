@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+var ErrStandingOrderStatusConflict = errors.New("статус поручения уже изменен")
+
+type StandingOrderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewStandingOrderRepository(db *pgxpool.Pool) *StandingOrderRepository {
+	return &StandingOrderRepository{db: db}
+}
+
+const standingOrderColumns = `id, user_id, from_account_id, to_account_id, amount, description, schedule_type,
+	run_at, weekday, day_of_month, cron_expr, status, scheduled_for, next_run_at, retry_count, created_at, updated_at`
+
+func scanStandingOrder(row pgx.Row) (*models.StandingOrder, error) {
+	var o models.StandingOrder
+	err := row.Scan(&o.ID, &o.UserID, &o.FromAccountID, &o.ToAccountID, &o.Amount, &o.Description, &o.ScheduleType,
+		&o.RunAt, &o.Weekday, &o.DayOfMonth, &o.CronExpr, &o.Status, &o.ScheduledFor, &o.NextRunAt, &o.RetryCount,
+		&o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func scanStandingOrders(rows pgx.Rows) ([]*models.StandingOrder, error) {
+	var orders []*models.StandingOrder
+	for rows.Next() {
+		o, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *StandingOrderRepository) Create(ctx context.Context, o *models.StandingOrder) (*models.StandingOrder, error) {
+	query := `
+		INSERT INTO standing_orders (user_id, from_account_id, to_account_id, amount, description, schedule_type,
+			run_at, weekday, day_of_month, cron_expr, status, scheduled_for, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING ` + standingOrderColumns
+	return scanStandingOrder(r.db.QueryRow(ctx, query, o.UserID, o.FromAccountID, o.ToAccountID, o.Amount,
+		o.Description, o.ScheduleType, o.RunAt, o.Weekday, o.DayOfMonth, o.CronExpr, o.Status, o.ScheduledFor,
+		o.NextRunAt))
+}
+
+func (r *StandingOrderRepository) GetByID(ctx context.Context, id int64) (*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE id = $1
+	`
+	return scanStandingOrder(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *StandingOrderRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStandingOrders(rows)
+}
+
+// ClaimDue выбирает активные поручения, срок которых наступил к now, и
+// блокирует их на lease, чтобы параллельный исполнитель их не взял
+func (r *StandingOrderRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) ([]*models.StandingOrder, error) {
+	query := `
+		UPDATE standing_orders
+		SET locked_until = $2
+		WHERE id IN (
+			SELECT id
+			FROM standing_orders
+			WHERE status = $3 AND next_run_at <= $1 AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY next_run_at
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + standingOrderColumns
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), models.StandingOrderActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStandingOrders(rows)
+}
+
+// CompleteRun записывает результат запуска и новое состояние поручения
+// в одной транзакции и снимает блокировку
+func (r *StandingOrderRepository) CompleteRun(ctx context.Context, o *models.StandingOrder, e *models.StandingOrderExecution) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `
+		INSERT INTO standing_order_executions (order_id, scheduled_for, attempt, status, error)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = tx.Exec(ctx, insertQuery, e.OrderID, e.ScheduledFor, e.Attempt, e.Status, e.Error)
+	if err != nil {
+		return err
+	}
+
+	// Поручение могли приостановить или отменить во время выполнения,
+	// такой статус сохраняем
+	updateQuery := `
+		UPDATE standing_orders
+		SET status = CASE WHEN status = $2 THEN $3 ELSE status END,
+			scheduled_for = $4, next_run_at = $5, retry_count = $6,
+			locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err = tx.Exec(ctx, updateQuery, o.ID, models.StandingOrderActive, o.Status, o.ScheduledFor, o.NextRunAt,
+		o.RetryCount)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateStatus меняет статус поручения, только если текущий статус равен from,
+// и сохраняет новое расписание. Иначе возвращает ErrStandingOrderStatusConflict
+func (r *StandingOrderRepository) UpdateStatus(ctx context.Context, o *models.StandingOrder, from models.StandingOrderStatus) error {
+	query := `
+		UPDATE standing_orders
+		SET status = $3, scheduled_for = $4, next_run_at = $5, retry_count = $6, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+	tag, err := r.db.Exec(ctx, query, o.ID, from, o.Status, o.ScheduledFor, o.NextRunAt, o.RetryCount)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStandingOrderStatusConflict
+	}
+	return nil
+}
+
+func (r *StandingOrderRepository) GetExecutions(ctx context.Context, orderID int64) ([]*models.StandingOrderExecution, error) {
+	query := `
+		SELECT id, order_id, scheduled_for, attempt, status, error, created_at
+		FROM standing_order_executions
+		WHERE order_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []*models.StandingOrderExecution
+	for rows.Next() {
+		var e models.StandingOrderExecution
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.ScheduledFor, &e.Attempt, &e.Status, &e.Error, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		executions = append(executions, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
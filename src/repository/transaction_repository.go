@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 	"sf-finances/src/models"
@@ -16,26 +18,74 @@ func NewTransactionRepository(db *pgxpool.Pool) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (r *TransactionRepository) CreateTransaction(ctx context.Context, accountID int64, amount decimal.Decimal,
-	txType models.TransactionType, status models.TransactionStatus) (*models.Transaction, error) {
-	query := `
-		INSERT INTO transactions
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, account_id, amount, type, status, created_at
-	`
+// Номер счета второй стороны берется подзапросом, чтобы список колонок
+// подходил и для SELECT, и для RETURNING
+const transactionColumns = `id, account_id, amount, type, status, counterparty_account_id,
+	(SELECT ca.number FROM accounts ca WHERE ca.id = counterparty_account_id), description, created_at`
+
+func scanTransaction(row pgx.Row) (*models.Transaction, error) {
 	var tx models.Transaction
-	err := r.db.QueryRow(ctx, query, accountID, amount, txType, status).Scan(
-		&tx.ID, &tx.AccountID, &tx.Amount, &tx.Type, &tx.Status, &tx.CreatedAt,
-	)
+	err := row.Scan(&tx.ID, &tx.AccountID, &tx.Amount, &tx.Type, &tx.Status, &tx.CounterpartyAccountID,
+		&tx.CounterpartyAccountNumber, &tx.Description, &tx.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &tx, nil
 }
 
+func scanTransactions(rows pgx.Rows) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *TransactionRepository) CreateTransaction(ctx context.Context, accountID int64, amount decimal.Decimal,
+	txType models.TransactionType, status models.TransactionStatus) (*models.Transaction, error) {
+	query := `
+		INSERT INTO transactions (account_id, amount, type, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + transactionColumns
+	return scanTransaction(r.db.QueryRow(ctx, query, accountID, amount, txType, status))
+}
+
+// CreateSystemTransaction записывает операцию банка по счету клиента:
+// возврат, зачисление или списание по решению банка, перевод со счета банка.
+// Такие операции не считаются поступлениями клиента
+func (r *TransactionRepository) CreateSystemTransaction(ctx context.Context, accountID int64, counterpartyAccountID *int64,
+	amount decimal.Decimal, txType models.TransactionType, description *string) (*models.Transaction, error) {
+	query := `
+		INSERT INTO transactions (account_id, amount, type, status, counterparty_account_id, description, system)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+		RETURNING ` + transactionColumns
+	return scanTransaction(r.db.QueryRow(ctx, query, accountID, amount, txType, models.COMPLETED,
+		counterpartyAccountID, description))
+}
+
+// CreateTransferTransaction записывает одну сторону перевода со ссылкой на
+// счет второй стороны
+func (r *TransactionRepository) CreateTransferTransaction(ctx context.Context, accountID, counterpartyAccountID int64,
+	amount decimal.Decimal, txType models.TransactionType, description *string) (*models.Transaction, error) {
+	query := `
+		INSERT INTO transactions (account_id, amount, type, status, counterparty_account_id, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + transactionColumns
+	return scanTransaction(r.db.QueryRow(ctx, query, accountID, amount, txType, models.COMPLETED,
+		counterpartyAccountID, description))
+}
+
 func (r *TransactionRepository) GetTransactionsByAccountID(ctx context.Context, accountID int64) ([]*models.Transaction, error) {
 	query := `
-		SELECT id, account_id, amount, type, status, created_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE account_id = $1
 		ORDER BY created_at DESC
@@ -46,24 +96,13 @@ func (r *TransactionRepository) GetTransactionsByAccountID(ctx context.Context,
 	}
 	defer rows.Close()
 
-	var transactions []*models.Transaction
-	for rows.Next() {
-		var tx models.Transaction
-		if err := rows.Scan(&tx.ID, &tx.AccountID, &tx.Amount, &tx.Type, &tx.Status, &tx.CreatedAt); err != nil {
-			return nil, err
-		}
-		transactions = append(transactions, &tx)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-	return transactions, nil
+	return scanTransactions(rows)
 }
 
 func (r *TransactionRepository) GetTransactionsByUserID(ctx context.Context, userID int64) ([]*models.Transaction, error) {
 	query := `
-		SELECT t.id, t.account_id, t.amount, t.type, t.status, t.created_at
+		SELECT t.id, t.account_id, t.amount, t.type, t.status, t.counterparty_account_id,
+			(SELECT ca.number FROM accounts ca WHERE ca.id = t.counterparty_account_id), t.description, t.created_at
 		FROM transactions t
 		JOIN accounts a ON t.account_id = a.id
 		WHERE a.user_id = $1
@@ -75,17 +114,53 @@ func (r *TransactionRepository) GetTransactionsByUserID(ctx context.Context, use
 	}
 	defer rows.Close()
 
-	var transactions []*models.Transaction
-	for rows.Next() {
-		var tx models.Transaction
-		if err := rows.Scan(&tx.ID, &tx.AccountID, &tx.Amount, &tx.Type, &tx.Status, &tx.CreatedAt); err != nil {
-			return nil, err
-		}
-		transactions = append(transactions, &tx)
-	}
+	return scanTransactions(rows)
+}
 
-	if err = rows.Err(); err != nil {
+// GetCompletedByPeriod возвращает проведенные операции по счету за [from, to)
+// в порядке проведения
+func (r *TransactionRepository) GetCompletedByPeriod(ctx context.Context, accountID int64, from, to time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE account_id = $1 AND status = $2 AND created_at >= $3 AND created_at < $4
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(ctx, query, accountID, models.COMPLETED, from, to)
+	if err != nil {
 		return nil, err
 	}
-	return transactions, nil
-}
\ No newline at end of file
+	defer rows.Close()
+
+	return scanTransactions(rows)
+}
+
+// GetNetTurnoverSince возвращает изменение баланса счета проведенными
+// операциями начиная с since: зачисления со знаком плюс, списания - минус
+func (r *TransactionRepository) GetNetTurnoverSince(ctx context.Context, accountID int64, since time.Time) (decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN type = $3 THEN amount ELSE -amount END), 0)
+		FROM transactions
+		WHERE account_id = $1 AND status = $2 AND created_at >= $4
+	`
+	var net decimal.Decimal
+	err := r.db.QueryRow(ctx, query, accountID, models.COMPLETED, models.DEPOSIT, since).Scan(&net)
+	return net, err
+}
+
+// GetIncomingTotal возвращает сумму поступлений на счет за [from, to):
+// пополнений и переводов от других пользователей. Переводы с других счетов
+// того же пользователя и операции банка (возвраты, проценты) не учитываются
+func (r *TransactionRepository) GetIncomingTotal(ctx context.Context, accountID int64, from, to time.Time) (decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(t.amount), 0)
+		FROM transactions t
+		JOIN accounts a ON a.id = t.account_id
+		LEFT JOIN accounts ca ON ca.id = t.counterparty_account_id
+		WHERE t.account_id = $1 AND t.type = $2 AND t.status = $3 AND t.created_at >= $4 AND t.created_at < $5
+			AND NOT t.system AND (ca.user_id IS NULL OR ca.user_id <> a.user_id)
+	`
+	var total decimal.Decimal
+	err := r.db.QueryRow(ctx, query, accountID, models.DEPOSIT, models.COMPLETED, from, to).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sf-finances/src/models"
+)
+
+type TransferBatchRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTransferBatchRepository(db *pgxpool.Pool) *TransferBatchRepository {
+	return &TransferBatchRepository{db: db}
+}
+
+const transferBatchColumns = `id, user_id, from_account_id, currency, total_amount, item_count, status,
+	reserve_transaction_id, refund_transaction_id, created_at, completed_at`
+
+const transferBatchItemColumns = `id, batch_id, line, to_account_id, amount, purpose, status, error,
+	transaction_id, processed_at`
+
+func scanTransferBatch(row pgx.Row) (*models.TransferBatch, error) {
+	var b models.TransferBatch
+	err := row.Scan(&b.ID, &b.UserID, &b.FromAccountID, &b.Currency, &b.TotalAmount, &b.ItemCount, &b.Status,
+		&b.ReserveTransactionID, &b.RefundTransactionID, &b.CreatedAt, &b.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func scanTransferBatches(rows pgx.Rows) ([]*models.TransferBatch, error) {
+	var batches []*models.TransferBatch
+	for rows.Next() {
+		b, err := scanTransferBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// Create сохраняет пакет вместе со всеми переводами в одной транзакции
+func (r *TransferBatchRepository) Create(ctx context.Context, b *models.TransferBatch, items []*models.TransferBatchItem) (*models.TransferBatch, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	batchQuery := `
+		INSERT INTO transfer_batches (user_id, from_account_id, currency, total_amount, item_count, status,
+			reserve_transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + transferBatchColumns
+	created, err := scanTransferBatch(tx.QueryRow(ctx, batchQuery, b.UserID, b.FromAccountID, b.Currency,
+		b.TotalAmount, b.ItemCount, b.Status, b.ReserveTransactionID))
+	if err != nil {
+		return nil, err
+	}
+
+	itemQuery := `
+		INSERT INTO transfer_batch_items (batch_id, line, to_account_id, amount, purpose, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	for _, item := range items {
+		_, err := tx.Exec(ctx, itemQuery, created.ID, item.Line, item.ToAccountID, item.Amount, item.Purpose, item.Status)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return created, tx.Commit(ctx)
+}
+
+func (r *TransferBatchRepository) GetByID(ctx context.Context, id int64) (*models.TransferBatch, error) {
+	query := `
+		SELECT ` + transferBatchColumns + `
+		FROM transfer_batches
+		WHERE id = $1
+	`
+	return scanTransferBatch(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *TransferBatchRepository) GetByUserID(ctx context.Context, userID int64) ([]*models.TransferBatch, error) {
+	query := `
+		SELECT ` + transferBatchColumns + `
+		FROM transfer_batches
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTransferBatches(rows)
+}
+
+// ClaimProcessing выбирает пакеты в обработке и блокирует их на lease, чтобы
+// параллельный исполнитель их не взял
+func (r *TransferBatchRepository) ClaimProcessing(ctx context.Context, now time.Time, lease time.Duration) ([]*models.TransferBatch, error) {
+	query := `
+		UPDATE transfer_batches
+		SET locked_until = $2
+		WHERE id IN (
+			SELECT id
+			FROM transfer_batches
+			WHERE status = $3 AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + transferBatchColumns
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), models.TransferBatchProcessing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTransferBatches(rows)
+}
+
+func (r *TransferBatchRepository) GetItems(ctx context.Context, batchID int64) ([]*models.TransferBatchItem, error) {
+	query := `
+		SELECT ` + transferBatchItemColumns + `
+		FROM transfer_batch_items
+		WHERE batch_id = $1
+		ORDER BY line
+	`
+	rows, err := r.db.Query(ctx, query, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.TransferBatchItem
+	for rows.Next() {
+		var i models.TransferBatchItem
+		err := rows.Scan(&i.ID, &i.BatchID, &i.Line, &i.ToAccountID, &i.Amount, &i.Purpose, &i.Status, &i.Error,
+			&i.TransactionID, &i.ProcessedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *TransferBatchRepository) SetItemResult(ctx context.Context, item *models.TransferBatchItem) error {
+	query := `
+		UPDATE transfer_batch_items
+		SET status = $2, error = $3, transaction_id = $4, processed_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, item.ID, item.Status, item.Error, item.TransactionID)
+	return err
+}
+
+func (r *TransferBatchRepository) SetRefundTransactionID(ctx context.Context, id int64, refundTxID int64) error {
+	query := `
+		UPDATE transfer_batches
+		SET refund_transaction_id = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, refundTxID)
+	return err
+}
+
+// Complete фиксирует итоговый статус пакета и снимает блокировку
+func (r *TransferBatchRepository) Complete(ctx context.Context, b *models.TransferBatch) error {
+	query := `
+		UPDATE transfer_batches
+		SET status = $2, refund_transaction_id = $3, completed_at = NOW(), locked_until = NULL
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, b.ID, b.Status, b.RefundTransactionID)
+	return err
+}
@@ -5,16 +5,23 @@ import (
 	"errors"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"sf-finances/src/models"
 )
 
-var ErrUserNotFound = errors.New("пользователь не найден")
+var (
+	ErrUserNotFound = errors.New("пользователь не найден")
+	ErrPhoneTaken   = errors.New("номер телефона уже подтвержден другим пользователем")
+)
 
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) (int64, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByID(ctx context.Context, id int64) (*models.User, error)
+	GetByVerifiedPhone(ctx context.Context, phone string) (*models.User, error)
+	UpdateName(ctx context.Context, id int64, firstName, lastName string) error
+	SetVerifiedPhone(ctx context.Context, id int64, phone string) error
 }
 
 type UserRepositoryPgx struct {
@@ -25,14 +32,26 @@ func NewUserRepository(pool *pgxpool.Pool) UserRepository {
 	return &UserRepositoryPgx{pool: pool}
 }
 
+const userColumns = `id, email, password_hash, role, first_name, last_name, phone, phone_verified_at, created_at`
+
+func scanUser(row pgx.Row) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.FirstName, &user.LastName,
+		&user.Phone, &user.PhoneVerifiedAt, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 func (r *UserRepositoryPgx) Create(ctx context.Context, user *models.User) (int64, error) {
 	var id int64
 
 	err := r.pool.QueryRow(ctx,
-		`INSERT INTO users
-				VALUES ($1, $2) 
+		`INSERT INTO users (email, password_hash, first_name, last_name)
+				VALUES ($1, $2, $3, $4) 
 				RETURNING id`,
-		user.Email, user.Password).Scan(&id)
+		user.Email, user.Password, user.FirstName, user.LastName).Scan(&id)
 
 	if err != nil {
 		return 0, err
@@ -42,13 +61,11 @@ func (r *UserRepositoryPgx) Create(ctx context.Context, user *models.User) (int6
 }
 
 func (r *UserRepositoryPgx) GetByEmail(ctx context.Context, email string) (*models.User, error) {
-	user := &models.User{}
-
-	err := r.pool.QueryRow(ctx,
-		`SELECT id, email, password_hash, created_at 
+	user, err := scanUser(r.pool.QueryRow(ctx,
+		`SELECT `+userColumns+`
          FROM users 
          WHERE email = $1`,
-		email).Scan(&user.ID, &user.Email, &user.Password, &user.CreatedAt)
+		email))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -61,17 +78,63 @@ func (r *UserRepositoryPgx) GetByEmail(ctx context.Context, email string) (*mode
 }
 
 func (r *UserRepositoryPgx) GetByID(ctx context.Context, id int64) (*models.User, error) {
-	user := &models.User{}
-
-	err := r.pool.QueryRow(ctx,
-		`SELECT id, email, password_hash, created_at 
+	user, err := scanUser(r.pool.QueryRow(ctx,
+		`SELECT `+userColumns+`
          FROM users 
          WHERE id = $1`,
-		id).Scan(&user.ID, &user.Email, &user.Password, &user.CreatedAt)
+		id))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetByVerifiedPhone ищет пользователя только среди подтвержденных номеров
+func (r *UserRepositoryPgx) GetByVerifiedPhone(ctx context.Context, phone string) (*models.User, error) {
+	user, err := scanUser(r.pool.QueryRow(ctx,
+		`SELECT `+userColumns+`
+         FROM users 
+         WHERE phone = $1 AND phone_verified_at IS NOT NULL`,
+		phone))
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 
 	return user, nil
-}
\ No newline at end of file
+}
+
+func (r *UserRepositoryPgx) UpdateName(ctx context.Context, id int64, firstName, lastName string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE users
+         SET first_name = $2, last_name = $3
+         WHERE id = $1`,
+		id, firstName, lastName)
+
+	return err
+}
+
+// SetVerifiedPhone сохраняет подтвержденный номер. На подтвержденные номера
+// стоит уникальный индекс, занятый номер возвращает ErrPhoneTaken
+func (r *UserRepositoryPgx) SetVerifiedPhone(ctx context.Context, id int64, phone string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE users
+         SET phone = $2, phone_verified_at = NOW()
+         WHERE id = $1`,
+		id, phone)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrPhoneTaken
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+// Package sbp формирует и разбирает платежные ссылки Системы быстрых
+// платежей вида https://qr.nspk.ru/<ID>?type=01&bank=...&sum=...&cur=RUB&crc=...
+package sbp
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidPayload = errors.New("неверная платежная ссылка СБП")
+
+// QRType - тип платежной ссылки
+type QRType string
+
+const (
+	// Static - многоразовая ссылка, сумма может быть не задана
+	Static QRType = "01"
+	// Dynamic - одноразовая ссылка с суммой и сроком действия
+	Dynamic QRType = "02"
+)
+
+// QRIDLength - длина идентификатора платежной ссылки
+const QRIDLength = 32
+
+const qrIDAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Payload - содержимое платежной ссылки. Amount в рублях, в ссылке сумма
+// передается в копейках
+type Payload struct {
+	BaseURL  string
+	QRID     string
+	Type     QRType
+	BankID   string
+	Amount   *decimal.Decimal
+	Currency string
+	Purpose  string
+}
+
+// NewQRID генерирует идентификатор ссылки: второй символ обозначает тип
+// (S - статическая, D - динамическая), остальные случайны
+func NewQRID(qrType QRType) (string, error) {
+	buf := make([]byte, QRIDLength)
+	size := big.NewInt(int64(len(qrIDAlphabet)))
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = qrIDAlphabet[n.Int64()]
+	}
+	buf[0] = 'A'
+	buf[1] = 'S'
+	if qrType == Dynamic {
+		buf[1] = 'D'
+	}
+	return string(buf), nil
+}
+
+// String собирает ссылку. Контрольная сумма crc считается по всей строке
+// до параметра crc
+func (p *Payload) String() string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSuffix(p.BaseURL, "/"))
+	sb.WriteByte('/')
+	sb.WriteString(p.QRID)
+	sb.WriteString("?type=")
+	sb.WriteString(string(p.Type))
+	sb.WriteString("&bank=")
+	sb.WriteString(p.BankID)
+	if p.Amount != nil {
+		sb.WriteString("&sum=")
+		sb.WriteString(p.Amount.Shift(2).Round(0).String())
+	}
+	sb.WriteString("&cur=")
+	sb.WriteString(p.Currency)
+	if p.Purpose != "" {
+		sb.WriteString("&purpose=")
+		sb.WriteString(url.QueryEscape(p.Purpose))
+	}
+
+	body := sb.String()
+	return fmt.Sprintf("%s&crc=%04X", body, crc16(body))
+}
+
+// Parse разбирает ссылку. Ссылки НСПК контрольной суммы не содержат, она
+// проверяется, только если параметр crc есть
+func Parse(raw string) (*Payload, error) {
+	raw = strings.TrimSpace(raw)
+	body, crc, ok := strings.Cut(raw, "&crc=")
+	if ok {
+		want, err := strconv.ParseUint(crc, 16, 16)
+		if err != nil || len(crc) != 4 {
+			return nil, fmt.Errorf("%w: неверная контрольная сумма", ErrInvalidPayload)
+		}
+		if uint16(want) != crc16(body) {
+			return nil, fmt.Errorf("%w: не сходится контрольная сумма", ErrInvalidPayload)
+		}
+	}
+
+	u, err := url.Parse(body)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%w: ожидается ссылка https", ErrInvalidPayload)
+	}
+
+	p := &Payload{
+		BaseURL:  u.Scheme + "://" + u.Host + "/",
+		QRID:     strings.Trim(u.Path, "/"),
+		Type:     QRType(u.Query().Get("type")),
+		BankID:   u.Query().Get("bank"),
+		Currency: u.Query().Get("cur"),
+		Purpose:  u.Query().Get("purpose"),
+	}
+	if len(p.QRID) != QRIDLength {
+		return nil, fmt.Errorf("%w: неверный идентификатор", ErrInvalidPayload)
+	}
+	if p.Type != Static && p.Type != Dynamic {
+		return nil, fmt.Errorf("%w: неизвестный тип %q", ErrInvalidPayload, p.Type)
+	}
+	if p.BankID == "" {
+		return nil, fmt.Errorf("%w: не указан банк получателя", ErrInvalidPayload)
+	}
+
+	if sum := u.Query().Get("sum"); sum != "" {
+		kopecks, err := strconv.ParseInt(sum, 10, 64)
+		if err != nil || kopecks <= 0 {
+			return nil, fmt.Errorf("%w: неверная сумма", ErrInvalidPayload)
+		}
+		amount := decimal.New(kopecks, -2)
+		p.Amount = &amount
+	} else if p.Type == Dynamic {
+		return nil, fmt.Errorf("%w: в динамической ссылке нет суммы", ErrInvalidPayload)
+	}
+	return p, nil
+}
+
+// crc16 - CRC-16/CCITT-FALSE (полином 0x1021, начальное значение 0xFFFF)
+func crc16(s string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,122 @@
+package sbp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNewQRID(t *testing.T) {
+	tests := []struct {
+		qrType QRType
+		prefix string
+	}{
+		{Static, "AS"},
+		{Dynamic, "AD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.qrType), func(t *testing.T) {
+			seen := map[string]bool{}
+			for i := 0; i < 100; i++ {
+				id, err := NewQRID(tt.qrType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(id) != QRIDLength || !strings.HasPrefix(id, tt.prefix) {
+					t.Fatalf("идентификатор %q", id)
+				}
+				if strings.Trim(id, qrIDAlphabet) != "" {
+					t.Fatalf("идентификатор %q вне алфавита", id)
+				}
+				if seen[id] {
+					t.Fatalf("повтор идентификатора %q", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestCRC16(t *testing.T) {
+	// Контрольное значение CRC-16/CCITT-FALSE
+	if got := crc16("123456789"); got != 0x29B1 {
+		t.Errorf("crc16 = %04X, ожидалось 29B1", got)
+	}
+}
+
+func TestPayloadRoundTrip(t *testing.T) {
+	amount := decimal.RequireFromString("1500.50")
+	tests := []struct {
+		name string
+		p    Payload
+	}{
+		{"статическая без суммы", Payload{Type: Static}},
+		{"статическая с суммой", Payload{Type: Static, Amount: &amount}},
+		{"динамическая с назначением", Payload{Type: Dynamic, Amount: &amount, Purpose: "Оплата заказа №15 & доставка"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qrID, err := NewQRID(tt.p.Type)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := tt.p
+			p.BaseURL, p.QRID, p.BankID, p.Currency = "https://qr.nspk.ru/", qrID, "100000000111", "RUB"
+
+			got, err := Parse(p.String())
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", p.String(), err)
+			}
+			if got.BaseURL != p.BaseURL || got.QRID != p.QRID || got.Type != p.Type || got.BankID != p.BankID ||
+				got.Currency != p.Currency || got.Purpose != p.Purpose {
+				t.Errorf("разобрано %+v, ожидалось %+v", got, p)
+			}
+			if (got.Amount == nil) != (p.Amount == nil) || got.Amount != nil && !got.Amount.Equal(*p.Amount) {
+				t.Errorf("сумма %v, ожидалась %v", got.Amount, p.Amount)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	const qrID = "AD1000670LSS7DN18SJQDNP4B05KLJL2"
+	tests := []struct {
+		name    string
+		raw     string
+		amount  string
+		wantErr bool
+	}{
+		{"ссылка НСПК без crc", "https://qr.nspk.ru/" + qrID + "?type=02&bank=100000000111&sum=10000&cur=RUB", "100", false},
+		{"пробелы по краям", "  https://qr.nspk.ru/" + qrID + "?type=02&bank=100000000111&sum=1&cur=RUB\n", "0.01", false},
+		{"неверная crc", "https://qr.nspk.ru/" + qrID + "?type=02&bank=100000000111&sum=10000&cur=RUB&crc=0000", "", true},
+		{"crc не из 4 знаков", "https://qr.nspk.ru/" + qrID + "?type=02&bank=100000000111&sum=10000&cur=RUB&crc=ABC", "", true},
+		{"http", "http://qr.nspk.ru/" + qrID + "?type=02&bank=100000000111&sum=10000&cur=RUB", "", true},
+		{"короткий идентификатор", "https://qr.nspk.ru/AD1000?type=02&bank=100000000111&sum=10000&cur=RUB", "", true},
+		{"неизвестный тип", "https://qr.nspk.ru/" + qrID + "?type=03&bank=100000000111&sum=10000&cur=RUB", "", true},
+		{"без банка", "https://qr.nspk.ru/" + qrID + "?type=02&sum=10000&cur=RUB", "", true},
+		{"динамическая без суммы", "https://qr.nspk.ru/" + qrID + "?type=02&bank=100000000111&cur=RUB", "", true},
+		{"нулевая сумма", "https://qr.nspk.ru/" + qrID + "?type=01&bank=100000000111&sum=0&cur=RUB", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPayload) {
+					t.Errorf("ошибка %v, ожидалась ErrInvalidPayload", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Amount == nil || !p.Amount.Equal(decimal.RequireFromString(tt.amount)) {
+				t.Errorf("сумма %v, ожидалась %s", p.Amount, tt.amount)
+			}
+		})
+	}
+}
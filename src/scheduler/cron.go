@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("неверное cron-выражение")
+
+// Cron - расписание в формате cron из пяти полей:
+// минута, час, день месяца, месяц, день недели (0 или 7 - воскресенье).
+// Поддерживаются *, списки через запятую, диапазоны a-b и шаг /n
+type Cron struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// Как в классическом cron: если ограничены и день месяца, и день недели,
+	// подходит любой из них
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{0, 59}, // минута
+	{0, 23}, // час
+	{1, 31}, // день месяца
+	{1, 12}, // месяц
+	{0, 7},  // день недели
+}
+
+// cronSearchLimit ограничивает поиск следующего запуска для выражений
+// вроде "0 0 31 2 *", которые никогда не срабатывают
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w: нужно 5 полей", ErrInvalidCron)
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: поле %d: %v", ErrInvalidCron, i+1, err)
+		}
+		sets[i] = set
+	}
+
+	// 7 и 0 - оба воскресенье
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &Cron{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("неверный шаг %q", stepPart)
+			}
+			step = n
+		}
+
+		from, to := bounds.min, bounds.max
+		if rangePart != "*" {
+			lo, hi, isRange := strings.Cut(rangePart, "-")
+			n, err := strconv.Atoi(lo)
+			if err != nil {
+				return 0, fmt.Errorf("неверное значение %q", lo)
+			}
+			from, to = n, n
+			if isRange {
+				if to, err = strconv.Atoi(hi); err != nil {
+					return 0, fmt.Errorf("неверное значение %q", hi)
+				}
+			} else if hasStep {
+				to = bounds.max
+			}
+		}
+
+		if from < bounds.min || to > bounds.max || from > to {
+			return 0, fmt.Errorf("значение вне диапазона %d-%d", bounds.min, bounds.max)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// Next возвращает первый момент строго после after, подходящий под расписание,
+// в часовом поясе after. Если такого момента нет, возвращает нулевое время
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) matchDay(t time.Time) bool {
+	dayMatch := c.days&(1<<uint(t.Day())) != 0
+	weekdayMatch := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatch
+	case c.anyWeekday:
+		return dayMatch
+	default:
+		return dayMatch || weekdayMatch
+	}
+}
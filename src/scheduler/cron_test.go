@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-b * * * *",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidCron) {
+				t.Errorf("ошибка %v, ожидалась ErrInvalidCron", err)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	at := func(s string) time.Time {
+		layout := "2006-01-02 15:04"
+		if len(s) > len(layout) {
+			layout += ":05"
+		}
+		v, err := time.ParseInLocation(layout, s, msk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after string
+		want  string
+	}{
+		{"каждую минуту", "* * * * *", "2026-10-19 10:15", "2026-10-19 10:16"},
+		{"строго после after", "15 10 * * *", "2026-10-19 10:15", "2026-10-20 10:15"},
+		{"ежедневно в 03:00", "0 3 * * *", "2026-10-19 10:15", "2026-10-20 03:00"},
+		{"шаг", "*/20 * * * *", "2026-10-19 10:41", "2026-10-19 11:00"},
+		{"шаг от значения", "5/20 * * * *", "2026-10-19 10:26", "2026-10-19 10:45"},
+		{"список и диапазон", "0 9-11,18 * * *", "2026-10-19 11:30", "2026-10-19 18:00"},
+		{"первое число месяца", "0 0 1 * *", "2026-12-15 00:00", "2027-01-01 00:00"},
+		{"31 число пропускает короткие месяцы", "0 0 31 * *", "2026-04-01 00:00", "2026-05-31 00:00"},
+		{"29 февраля", "0 12 29 2 *", "2026-03-01 00:00", "2028-02-29 12:00"},
+		{"воскресенье как 0", "0 10 * * 0", "2026-10-19 10:15", "2026-10-25 10:00"},
+		{"воскресенье как 7", "0 10 * * 7", "2026-10-19 10:15", "2026-10-25 10:00"},
+		{"будни", "30 8 * * 1-5", "2026-10-23 09:00", "2026-10-26 08:30"},
+		// Ограничены и день месяца, и день недели - подходит любой
+		{"день месяца или день недели", "0 0 25 * 3", "2026-10-19 00:00", "2026-10-21 00:00"},
+		{"секунды отбрасываются", "* * * * *", "2026-10-19 10:15:59", "2026-10-19 10:16"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Next(at(tt.after)); !got.Equal(at(tt.want)) {
+				t.Errorf("Next = %s, ожидалось %s", got.Format("2006-01-02 15:04 Mon"), tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	c, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next = %s, ожидалось нулевое время", got)
+	}
+}
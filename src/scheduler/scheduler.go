@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler запускает фоновые задачи с заданным интервалом.
+// Каждая задача выполняется сразу при старте, затем по таймеру
+type Scheduler struct {
+	jobs   []Job
+	logger *logrus.Logger
+	wg     sync.WaitGroup
+}
+
+func New(logger *logrus.Logger) *Scheduler {
+	return &Scheduler{logger: logger}
+}
+
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait ждет завершения всех задач после отмены контекста
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		s.logger.WithError(err).WithField("job", job.Name).Error("Ошибка выполнения задачи")
+		return
+	}
+	s.logger.WithField("job", job.Name).Debugf("Задача выполнена за %s", time.Since(start))
+}
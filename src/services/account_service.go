@@ -3,8 +3,15 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/shopspring/decimal"
+	"sf-finances/src/banking"
+	"sf-finances/src/config"
 	"sf-finances/src/models"
 	"sf-finances/src/repository"
 )
@@ -13,22 +20,113 @@ var (
 	ErrInsufficientFunds = errors.New("не хватает средств")
 	ErrSameAccount       = errors.New("нельзя делать перевод на тот же счет")
 	ErrNegativeAmount    = errors.New("сумма должна быть положительной")
+	ErrAccountNotFound   = errors.New("счет не найден")
+	ErrInvalidPeriod     = errors.New("начало периода должно быть раньше конца")
+	ErrAccountFrozen     = errors.New("счет заморожен")
+	ErrAccountClosed     = errors.New("счет закрыт")
+	ErrAccountNotFrozen  = errors.New("счет не заморожен")
+	ErrAccountNotEmpty   = errors.New("на счете остались средства")
+	ErrAccountLocked     = errors.New("списания со счета запрещены")
+	ErrInvalidNickname   = errors.New("неверное название счета")
+	ErrFreezeReason      = errors.New("укажите причину заморозки")
+	// Средства уже переведены, но записи перевода или комиссии не сохранены.
+	// Такой перевод нельзя повторять или отменять автоматически
+	ErrTransferIncomplete = errors.New("перевод проведен, но не записан полностью")
 )
 
+// Максимальная длина пользовательского названия счета
+const maxNicknameLength = 50
+
 type AccountService struct {
 	accountRepo     *repository.AccountRepository
 	transactionRepo *repository.TransactionRepository
+	feeService      *FeeService
+	bankingCfg      config.BankingConfig
 }
 
-func NewAccountService(accountRepo *repository.AccountRepository, transactionRepo *repository.TransactionRepository) *AccountService {
+func NewAccountService(accountRepo *repository.AccountRepository, transactionRepo *repository.TransactionRepository,
+	feeService *FeeService, bankingCfg config.BankingConfig) *AccountService {
 	return &AccountService{
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
+		feeService:      feeService,
+		bankingCfg:      bankingCfg,
 	}
 }
 
 func (s *AccountService) CreateAccount(ctx context.Context, userID int64, currency models.Currency) (*models.Account, error) {
-	return s.accountRepo.CreateAccount(ctx, userID, currency)
+	id, err := s.accountRepo.NextAccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := s.accountNumber(id, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.accountRepo.CreateAccount(ctx, &models.Account{
+		ID:       id,
+		UserID:   userID,
+		Number:   number,
+		Currency: currency,
+	})
+}
+
+// accountNumber строит номер счета: порядковым номером служит ID счета
+func (s *AccountService) accountNumber(id int64, currency models.Currency) (string, error) {
+	return banking.NewAccountNumber(s.bankingCfg.BIC, s.bankingCfg.BalanceAccount, currency, s.bankingCfg.Branch, id)
+}
+
+// AssignMissingNumbers присваивает номера счетам, открытым до их появления.
+// Возвращает число обновленных счетов
+func (s *AccountService) AssignMissingNumbers(ctx context.Context) (int, error) {
+	accounts, err := s.accountRepo.GetAccountsWithoutNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, acc := range accounts {
+		number, err := s.accountNumber(acc.ID, acc.Currency)
+		if err != nil {
+			return i, fmt.Errorf("счет %d: %w", acc.ID, err)
+		}
+		if err := s.accountRepo.SetNumber(ctx, acc.ID, number); err != nil {
+			return i, err
+		}
+	}
+	return len(accounts), nil
+}
+
+// GetAccountByNumber находит счет банка по номеру без проверки владельца.
+// Номер с неверным контрольным ключом отклоняется с banking.ErrInvalidAccountNumber
+func (s *AccountService) GetAccountByNumber(ctx context.Context, number string) (*models.Account, error) {
+	number = strings.TrimSpace(number)
+	if err := banking.ValidateAccountNumber(s.bankingCfg.BIC, number); err != nil {
+		return nil, err
+	}
+
+	acc, err := s.accountRepo.GetAccountByNumber(ctx, number)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return acc, nil
+}
+
+// GetUserAccountByNumber находит счет пользователя по номеру. Чужой счет
+// неотличим от несуществующего
+func (s *AccountService) GetUserAccountByNumber(ctx context.Context, number string, userID int64) (*models.Account, error) {
+	acc, err := s.GetAccountByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+	return acc, nil
 }
 
 func (s *AccountService) GetAccountByID(ctx context.Context, id int64, userID int64) (*models.Account, error) {
@@ -44,6 +142,11 @@ func (s *AccountService) GetAccountByID(ctx context.Context, id int64, userID in
 	return acc, nil
 }
 
+// GetAccount возвращает счет без проверки владельца (для системных операций)
+func (s *AccountService) GetAccount(ctx context.Context, id int64) (*models.Account, error) {
+	return s.accountRepo.GetAccountByID(ctx, id)
+}
+
 func (s *AccountService) GetAccountsByUserID(ctx context.Context, userID int64) ([]*models.Account, error) {
 	return s.accountRepo.GetAccountsByUserID(ctx, userID)
 }
@@ -57,8 +160,25 @@ func (s *AccountService) UpdateBalance(ctx context.Context, id int64, userID int
 	if err != nil {
 		return err
 	}
+	if err := checkActive(acc); err != nil {
+		return err
+	}
+	if amount.IsNegative() {
+		if err := checkUnlocked(acc, time.Now()); err != nil {
+			return err
+		}
+	}
 
-	if amount.LessThan(decimal.Zero) && acc.Balance.Add(amount).LessThan(decimal.Zero) {
+	var quote *models.FeeQuote
+	fee := decimal.Zero
+	if amount.IsNegative() {
+		quote, fee, err = s.quoteFee(ctx, acc, models.FeeWithdrawal, amount.Abs())
+		if err != nil {
+			return err
+		}
+	}
+
+	if amount.LessThan(decimal.Zero) && availableBalance(acc).Add(amount).LessThan(fee) {
 		return ErrInsufficientFunds
 	}
 
@@ -67,54 +187,701 @@ func (s *AccountService) UpdateBalance(ctx context.Context, id int64, userID int
 		txType = models.DEPOSIT
 	}
 
-	err = s.accountRepo.UpdateBalance(ctx, id, amount)
+	// Списание идет условным UPDATE: параллельные снятия не выйдут за лимит
+	// овердрафта, даже если проверка выше прочитала устаревший баланс
+	if amount.IsNegative() {
+		err = s.accountRepo.Withdraw(ctx, id, amount.Abs())
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInsufficientFunds
+		}
+	} else {
+		err = s.accountRepo.UpdateBalance(ctx, id, amount)
+	}
 	if err != nil {
 		return err
 	}
 
 	absAmount := amount.Abs()
-	_, err = s.transactionRepo.CreateTransaction(ctx, id, absAmount, txType, models.COMPLETED)
+	tx, err := s.transactionRepo.CreateTransaction(ctx, id, absAmount, txType, models.COMPLETED)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.chargeFee(ctx, acc, quote, tx.ID)
 }
 
-func (s *AccountService) Transfer(ctx context.Context, fromID, toID int64, userID int64, amount decimal.Decimal) error {
-	if fromID == toID {
-		return ErrSameAccount
+// Debit списывает средства со счета без проверки владельца (для системных операций)
+func (s *AccountService) Debit(ctx context.Context, accountID int64, amount decimal.Decimal) (*models.Transaction, error) {
+	return s.DebitWithFee(ctx, accountID, amount, "")
+}
+
+// DebitWithFee списывает средства со счета без проверки владельца и берет
+// комиссию по тарифу операции operation отдельной транзакцией (пустой
+// operation - без комиссии). Возвращает списание суммы операции
+func (s *AccountService) DebitWithFee(ctx context.Context, accountID int64, amount decimal.Decimal,
+	operation models.FeeOperation) (*models.Transaction, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+	acc, err := s.activeAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnlocked(acc, time.Now()); err != nil {
+		return nil, err
+	}
+
+	quote, fee, err := s.quoteFee(ctx, acc, operation, amount)
+	if err != nil {
+		return nil, err
+	}
+	if availableBalance(acc).LessThan(amount.Add(fee)) {
+		return nil, ErrInsufficientFunds
+	}
+
+	err = s.accountRepo.Withdraw(ctx, accountID, amount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInsufficientFunds
+		}
+		return nil, err
+	}
+
+	tx, err := s.transactionRepo.CreateTransaction(ctx, accountID, amount, models.WITHDRAWAL, models.COMPLETED)
+	if err != nil {
+		return nil, err
+	}
+	return tx, s.chargeFee(ctx, acc, quote, tx.ID)
+}
+
+// CancelDebit возвращает на счет списание tx, проведенное DebitWithFee,
+// вместе с комиссией по нему
+func (s *AccountService) CancelDebit(ctx context.Context, tx *models.Transaction) error {
+	if _, err := s.Credit(ctx, tx.AccountID, tx.Amount); err != nil {
+		return err
 	}
+	return s.cancelFee(ctx, tx)
+}
 
+// Credit зачисляет средства на счет без проверки владельца (для системных
+// операций). Как и прочие зачисления банка, проходит на замороженный счет
+func (s *AccountService) Credit(ctx context.Context, accountID int64, amount decimal.Decimal) (*models.Transaction, error) {
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return ErrNegativeAmount
+		return nil, ErrNegativeAmount
+	}
+	if _, err := s.openAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountRepo.UpdateBalance(ctx, accountID, amount); err != nil {
+		return nil, err
 	}
 
+	return s.transactionRepo.CreateSystemTransaction(ctx, accountID, nil, amount, models.DEPOSIT, nil)
+}
+
+// Transfer переводит средства со счета пользователя на любой счет банка по
+// его ID. Переводы по email, телефону и номеру счета идут через P2PService и
+// TransferByNumber
+func (s *AccountService) Transfer(ctx context.Context, fromID, toID int64, userID int64, amount decimal.Decimal) error {
 	fromAcc, err := s.GetAccountByID(ctx, fromID, userID)
 	if err != nil {
 		return err
 	}
 
-	if fromAcc.Balance.LessThan(amount) {
-		return ErrInsufficientFunds
+	toAcc, err := s.account(ctx, toID)
+	if err != nil {
+		return err
 	}
 
-	_, err = s.accountRepo.GetAccountByID(ctx, toID)
+	return s.transfer(ctx, fromAcc, toAcc, amount, nil)
+}
+
+// TransferByNumber переводит со счета пользователя на любой счет банка по
+// его номеру
+func (s *AccountService) TransferByNumber(ctx context.Context, fromID int64, userID int64, toNumber string,
+	amount decimal.Decimal, description *string) error {
+	fromAcc, err := s.GetAccountByID(ctx, fromID, userID)
 	if err != nil {
 		return err
 	}
 
-	err = s.accountRepo.TransferBetweenAccounts(ctx, fromID, toID, amount)
+	toAcc, err := s.GetAccountByNumber(ctx, toNumber)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.transactionRepo.CreateTransaction(ctx, fromID, amount, models.DEPOSIT, models.COMPLETED)
+	return s.transfer(ctx, fromAcc, toAcc, amount, description)
+}
+
+// transferFromUser переводит со счета пользователя userID на любой счет.
+// Получатель должен быть проверен вызывающим кодом. Возвращает списание со
+// счета пользователя
+func (s *AccountService) transferFromUser(ctx context.Context, fromID int64, userID int64, toID int64,
+	amount decimal.Decimal, description *string) (*models.Transaction, error) {
+	fromAcc, err := s.GetAccountByID(ctx, fromID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	toAcc, err := s.accountRepo.GetAccountByID(ctx, toID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	return s.executeTransfer(ctx, fromAcc, toAcc, amount, false, description)
+}
+
+// transfer переводит средства без проверки владельцев счетов. description
+// сохраняется в обеих транзакциях перевода как назначение платежа
+func (s *AccountService) transfer(ctx context.Context, fromAcc, toAcc *models.Account, amount decimal.Decimal,
+	description *string) error {
+	_, err := s.executeTransfer(ctx, fromAcc, toAcc, amount, false, description)
+	return err
+}
+
+// transferReserved переводит получателю сумму, заранее списанную со счета
+// fromID в резерв (например, пакетом переводов). Резерв возвращается на счет
+// и уходит получателю тем же переводом с комиссией и назначением платежа.
+// Возвращает списание со счета отправителя
+func (s *AccountService) transferReserved(ctx context.Context, fromID, toID int64, amount decimal.Decimal,
+	description *string) (*models.Transaction, error) {
+	fromAcc, err := s.account(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	toAcc, err := s.account(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	return s.executeTransfer(ctx, fromAcc, toAcc, amount, true, description)
+}
+
+// executeTransfer проводит перевод. Для reserved сумма перевода уже списана
+// со счета отправителя, и с него берется только комиссия. Ошибки после
+// движения средств оборачиваются в ErrTransferIncomplete
+func (s *AccountService) executeTransfer(ctx context.Context, fromAcc, toAcc *models.Account, amount decimal.Decimal,
+	reserved bool, description *string) (*models.Transaction, error) {
+	if fromAcc.ID == toAcc.ID {
+		return nil, ErrSameAccount
+	}
+
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+
+	if fromAcc.Currency != toAcc.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	if err := checkActive(fromAcc); err != nil {
+		return nil, err
+	}
+	if err := checkUnlocked(fromAcc, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkActive(toAcc); err != nil {
+		return nil, err
+	}
+
+	// Комиссия берется только за переводы другим пользователям
+	var quote *models.FeeQuote
+	fee := decimal.Zero
+	if fromAcc.UserID != toAcc.UserID {
+		var err error
+		quote, fee, err = s.quoteFee(ctx, fromAcc, models.FeeP2PTransfer, amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	required := amount.Add(fee)
+	if reserved {
+		required = fee
+	}
+	if availableBalance(fromAcc).LessThan(required) {
+		return nil, ErrInsufficientFunds
+	}
+
+	if reserved {
+		// Резерв уже списан с отправителя: зачисляется только получателю
+		if err := s.accountRepo.UpdateBalance(ctx, toAcc.ID, amount); err != nil {
+			return nil, err
+		}
+	} else {
+		err := s.accountRepo.TransferBetweenAccounts(ctx, fromAcc.ID, toAcc.ID, amount)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrInsufficientFunds
+			}
+			return nil, err
+		}
+	}
+
+	tx, err := s.recordTransfer(ctx, fromAcc, toAcc, amount, reserved, description)
+	if err == nil {
+		err = s.chargeFee(ctx, fromAcc, quote, tx.ID)
+	}
+	if err != nil {
+		return tx, fmt.Errorf("%w: %w", ErrTransferIncomplete, err)
+	}
+	return tx, nil
+}
+
+// recordTransfer записывает обе стороны проведенного перевода, а для
+// перевода из резерва - и возврат резерва на счет отправителя
+func (s *AccountService) recordTransfer(ctx context.Context, fromAcc, toAcc *models.Account, amount decimal.Decimal,
+	reserved bool, description *string) (*models.Transaction, error) {
+	if reserved {
+		_, err := s.transactionRepo.CreateSystemTransaction(ctx, fromAcc.ID, nil, amount, models.DEPOSIT, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.transactionRepo.CreateTransferTransaction(ctx, fromAcc.ID, toAcc.ID, amount, models.WITHDRAWAL, description)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.transactionRepo.CreateTransferTransaction(ctx, toAcc.ID, fromAcc.ID, amount, models.DEPOSIT, description)
+	if err != nil {
+		return tx, err
+	}
+	return tx, nil
+}
+
+// transferRejected сообщает, что перевод отклонен проверками до движения
+// средств и его можно считать невыполненным
+func transferRejected(err error) bool {
+	for _, target := range []error{ErrInsufficientFunds, ErrSameAccount, ErrNegativeAmount, ErrAccountNotFound,
+		ErrAccountFrozen, ErrAccountClosed, ErrAccountLocked, ErrCurrencyMismatch} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteFee считает комиссию за операцию по счету. Если операция не
+// тарифицируется, возвращает nil и нулевую комиссию
+func (s *AccountService) quoteFee(ctx context.Context, acc *models.Account, operation models.FeeOperation,
+	amount decimal.Decimal) (*models.FeeQuote, decimal.Decimal, error) {
+	quote, err := s.feeService.Quote(ctx, acc.UserID, acc.Currency, operation, amount)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	if quote == nil {
+		return nil, decimal.Zero, nil
+	}
+	return quote, quote.Fee, nil
+}
+
+// chargeFee учитывает проведенную операцию txID и списывает комиссию по
+// расчету quote. Комиссию, которую не удалось списать сразу, спишет PostFees
+func (s *AccountService) chargeFee(ctx context.Context, acc *models.Account, quote *models.FeeQuote, txID int64) error {
+	if quote == nil {
+		return nil
+	}
+
+	fee, err := s.feeService.record(ctx, acc, quote, txID)
+	if err != nil {
+		return err
+	}
+	if fee.Fee.IsPositive() {
+		// Операция уже проведена, ошибку списания комиссии не возвращаем:
+		// комиссия осталась в очереди PostFees
+		_ = s.postFee(ctx, acc, fee)
+	}
+	return nil
+}
+
+// cancelFee отменяет комиссию по операции tx: несписанная комиссия больше
+// не спишется, списанная возвращается со счета доходов
+func (s *AccountService) cancelFee(ctx context.Context, tx *models.Transaction) error {
+	feeRepo := s.feeService.feeRepo
+	fee, err := feeRepo.GetByTransactionID(ctx, tx.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if fee.Fee.IsPositive() {
+		err := feeRepo.Claim(ctx, fee.ID)
+		if errors.Is(err, repository.ErrFeePosted) {
+			err = s.refundFee(ctx, fee)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return feeRepo.Delete(ctx, fee.ID)
+}
+
+func (s *AccountService) refundFee(ctx context.Context, fee *models.Fee) error {
+	// Комиссию могли списать после того, как ее прочитали
+	fee, err := s.feeService.feeRepo.GetByTransactionID(ctx, fee.TransactionID)
 	if err != nil {
 		return err
 	}
+	if fee.FeeTransactionID == nil {
+		return fmt.Errorf("комиссия %d еще списывается", fee.ID)
+	}
 
-	_, err = s.transactionRepo.CreateTransaction(ctx, toID, amount, models.WITHDRAWAL, models.COMPLETED)
+	incomeAcc, err := s.GetAccountByNumber(ctx, s.feeService.feeCfg.IncomeAccount)
+	if err != nil {
+		return fmt.Errorf("счет доходов по комиссиям: %w", err)
+	}
+	description := fmt.Sprintf("Возврат комиссии за %s по операции №%d", feeOperationNames[fee.Operation], fee.TransactionID)
+	_, err = s.transferFromBank(ctx, incomeAcc, fee.AccountID, fee.Fee, &description)
 	return err
 }
 
+// PostFees списывает комиссии, которые не удалось списать вместе с операцией
+func (s *AccountService) PostFees(ctx context.Context) error {
+	fees, err := s.feeService.feeRepo.GetUnposted(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, fee := range fees {
+		acc, err := s.account(ctx, fee.AccountID)
+		if err == nil {
+			err = s.postFee(ctx, acc, fee)
+		}
+		if err != nil && !errors.Is(err, repository.ErrFeePosted) {
+			errs = append(errs, fmt.Errorf("комиссия %d: %w", fee.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postFee переводит комиссию со счета на счет доходов банка. Списание
+// проходит и сверх лимита овердрафта: достаточность средств на сумму
+// операции вместе с комиссией проверена при ее проведении
+func (s *AccountService) postFee(ctx context.Context, acc *models.Account, fee *models.Fee) error {
+	feeRepo := s.feeService.feeRepo
+	if err := feeRepo.Claim(ctx, fee.ID); err != nil {
+		return err
+	}
+
+	tx, err := s.postFeeTransfer(ctx, acc, fee)
+	if err != nil {
+		if releaseErr := feeRepo.Release(ctx, fee.ID); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+	fee.FeeTransactionID = &tx.ID
+	return feeRepo.SetFeeTransactionID(ctx, fee.ID, tx.ID)
+}
+
+func (s *AccountService) postFeeTransfer(ctx context.Context, acc *models.Account, fee *models.Fee) (*models.Transaction, error) {
+	incomeAcc, err := s.GetAccountByNumber(ctx, s.feeService.feeCfg.IncomeAccount)
+	if err != nil {
+		return nil, fmt.Errorf("счет доходов по комиссиям: %w", err)
+	}
+
+	description := fmt.Sprintf("Комиссия за %s по операции №%d", feeOperationNames[fee.Operation], fee.TransactionID)
+	return s.transferToBank(ctx, acc, incomeAcc, fee.Fee, &description)
+}
+
+// checkActive запрещает движение средств по замороженному или закрытому счету
+func checkActive(acc *models.Account) error {
+	switch acc.Status {
+	case models.AccountFrozen:
+		return fmt.Errorf("%w: счет %d", ErrAccountFrozen, acc.ID)
+	case models.AccountClosed:
+		return fmt.Errorf("%w: счет %d", ErrAccountClosed, acc.ID)
+	}
+	return nil
+}
+
+// checkOpen запрещает зачисления на закрытый счет. Зачисления банка
+// (возвраты, проценты) проходят и на замороженный счет
+func checkOpen(acc *models.Account) error {
+	if acc.Status == models.AccountClosed {
+		return fmt.Errorf("%w: счет %d", ErrAccountClosed, acc.ID)
+	}
+	return nil
+}
+
+// availableBalance - сколько можно списать со счета с учетом овердрафта
+func availableBalance(acc *models.Account) decimal.Decimal {
+	return acc.Balance.Add(acc.OverdraftLimit)
+}
+
+// checkUnlocked запрещает списания со счета, заблокированного до окончания
+// срока вклада
+func checkUnlocked(acc *models.Account, now time.Time) error {
+	if acc.LockedUntil != nil && now.Before(*acc.LockedUntil) {
+		return fmt.Errorf("%w до %s", ErrAccountLocked, acc.LockedUntil.Format(time.DateOnly))
+	}
+	return nil
+}
+
+func (s *AccountService) activeAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	acc, err := s.account(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActive(acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// openAccount возвращает незакрытый счет для системных операций
+func (s *AccountService) openAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	acc, err := s.account(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOpen(acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// BalanceAt восстанавливает баланс счета на момент at по операциям после него
+func (s *AccountService) BalanceAt(ctx context.Context, acc *models.Account, at time.Time) (decimal.Decimal, error) {
+	since, err := s.transactionRepo.GetNetTurnoverSince(ctx, acc.ID, at)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return acc.Balance.Sub(since), nil
+}
+
+// transferFromBank переводит со счета банка (например, счета расходов на
+// проценты) на счет клиента. Остаток на счете банка не проверяется, счет
+// клиента может быть заморожен, но не закрыт. Возвращает зачисление на счет
+// клиента
+func (s *AccountService) transferFromBank(ctx context.Context, bankAcc *models.Account, toID int64, amount decimal.Decimal,
+	description *string) (*models.Transaction, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+
+	toAcc, err := s.openAccount(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	if bankAcc.Currency != toAcc.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	if err := s.accountRepo.TransferUnchecked(ctx, bankAcc.ID, toAcc.ID, amount); err != nil {
+		return nil, err
+	}
+
+	_, err = s.transactionRepo.CreateTransferTransaction(ctx, bankAcc.ID, toAcc.ID, amount, models.WITHDRAWAL, description)
+	if err != nil {
+		return nil, err
+	}
+	return s.transactionRepo.CreateSystemTransaction(ctx, toAcc.ID, &bankAcc.ID, amount, models.DEPOSIT, description)
+}
+
+// payToBank переводит со счета клиента на счет банка в пределах доступного
+// остатка (например, на транзитный счет платежей в другие банки). Возвращает
+// списание со счета клиента
+func (s *AccountService) payToBank(ctx context.Context, fromID int64, bankAcc *models.Account, amount decimal.Decimal,
+	description *string) (*models.Transaction, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+
+	fromAcc, err := s.activeAccount(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnlocked(fromAcc, time.Now()); err != nil {
+		return nil, err
+	}
+	if fromAcc.Currency != bankAcc.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+	if availableBalance(fromAcc).LessThan(amount) {
+		return nil, ErrInsufficientFunds
+	}
+
+	err = s.accountRepo.TransferBetweenAccounts(ctx, fromAcc.ID, bankAcc.ID, amount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInsufficientFunds
+		}
+		return nil, err
+	}
+
+	tx, err := s.transactionRepo.CreateTransferTransaction(ctx, fromAcc.ID, bankAcc.ID, amount, models.WITHDRAWAL, description)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.transactionRepo.CreateTransferTransaction(ctx, bankAcc.ID, fromAcc.ID, amount, models.DEPOSIT, description)
+	return tx, err
+}
+
+// transferToBank списывает со счета клиента в пользу счета банка (например,
+// проценты за овердрафт). Списание проходит и сверх лимита овердрафта.
+// Возвращает списание со счета клиента
+func (s *AccountService) transferToBank(ctx context.Context, fromAcc *models.Account, bankAcc *models.Account,
+	amount decimal.Decimal, description *string) (*models.Transaction, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+	if fromAcc.Currency != bankAcc.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	if err := s.accountRepo.TransferUnchecked(ctx, fromAcc.ID, bankAcc.ID, amount); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.transactionRepo.CreateTransferTransaction(ctx, fromAcc.ID, bankAcc.ID, amount, models.WITHDRAWAL, description)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.transactionRepo.CreateTransferTransaction(ctx, bankAcc.ID, fromAcc.ID, amount, models.DEPOSIT, description)
+	return tx, err
+}
+
+// SetLockedUntil запрещает списания со счета до until, nil снимает запрет
+func (s *AccountService) SetLockedUntil(ctx context.Context, accountID int64, until *time.Time) error {
+	return s.accountRepo.SetLockedUntil(ctx, accountID, until)
+}
+
+// SetNickname задает название счета пользователя. Пустая строка убирает название
+func (s *AccountService) SetNickname(ctx context.Context, id int64, userID int64, nickname string) (*models.Account, error) {
+	acc, err := s.userAccount(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var value *string
+	if nickname = strings.TrimSpace(nickname); nickname != "" {
+		if utf8.RuneCountInString(nickname) > maxNicknameLength {
+			return nil, fmt.Errorf("%w: длиннее %d символов", ErrInvalidNickname, maxNicknameLength)
+		}
+		value = &nickname
+	}
+
+	if err := s.accountRepo.SetNickname(ctx, acc.ID, value); err != nil {
+		if errors.Is(err, repository.ErrAccountStatusConflict) {
+			return nil, ErrAccountClosed
+		}
+		return nil, err
+	}
+	return s.accountRepo.GetAccountByID(ctx, acc.ID)
+}
+
+// Freeze замораживает счет по решению администратора: операции клиента по
+// нему отклоняются с ErrAccountFrozen. Операции банка (возвраты, проценты,
+// комиссии, списание временных зачислений) по счету проходят
+func (s *AccountService) Freeze(ctx context.Context, id int64, adminID int64, reason string) (*models.Account, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrFreezeReason
+	}
+
+	acc, err := s.account(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Status == models.AccountClosed {
+		return nil, ErrAccountClosed
+	}
+	if acc.Status == models.AccountFrozen {
+		return nil, ErrAccountFrozen
+	}
+
+	if err := s.accountRepo.Freeze(ctx, acc.ID, adminID, reason); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.GetAccountByID(ctx, acc.ID)
+}
+
+// Unfreeze снимает заморозку со счета
+func (s *AccountService) Unfreeze(ctx context.Context, id int64) (*models.Account, error) {
+	acc, err := s.account(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountRepo.Unfreeze(ctx, acc.ID); err != nil {
+		if errors.Is(err, repository.ErrAccountStatusConflict) {
+			return nil, ErrAccountNotFrozen
+		}
+		return nil, err
+	}
+	return s.accountRepo.GetAccountByID(ctx, acc.ID)
+}
+
+// Close закрывает счет пользователя. Остаток переводится на другой его счет
+// sweepToID, без него закрыть можно только счет с нулевым балансом
+func (s *AccountService) Close(ctx context.Context, id int64, userID int64, sweepToID *int64) (*models.Account, error) {
+	acc, err := s.userAccount(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActive(acc); err != nil {
+		return nil, err
+	}
+
+	if acc.Balance.IsNegative() {
+		return nil, fmt.Errorf("%w: на счете задолженность %s", ErrAccountNotEmpty, acc.Balance.Neg().StringFixed(2))
+	}
+	if acc.Balance.IsPositive() {
+		if sweepToID == nil {
+			return nil, ErrAccountNotEmpty
+		}
+		toAcc, err := s.userAccount(ctx, *sweepToID, userID)
+		if err != nil {
+			return nil, err
+		}
+		description := fmt.Sprintf("Перевод остатка при закрытии счета %s", acc.Number)
+		if err := s.transfer(ctx, acc, toAcc, acc.Balance, &description); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.accountRepo.Close(ctx, acc.ID); err != nil {
+		if errors.Is(err, repository.ErrAccountStatusConflict) {
+			return nil, fmt.Errorf("%w: баланс или статус счета изменились, повторите закрытие", ErrAccountNotEmpty)
+		}
+		return nil, err
+	}
+	return s.accountRepo.GetAccountByID(ctx, acc.ID)
+}
+
+func (s *AccountService) account(ctx context.Context, id int64) (*models.Account, error) {
+	acc, err := s.accountRepo.GetAccountByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return acc, nil
+}
+
+// userAccount возвращает счет пользователя. Чужой счет неотличим от
+// несуществующего
+func (s *AccountService) userAccount(ctx context.Context, id int64, userID int64) (*models.Account, error) {
+	acc, err := s.account(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+	return acc, nil
+}
+
 func (s *AccountService) GetTransactionsByAccountID(ctx context.Context, accountID int64, userID int64) ([]*models.Transaction, error) {
 	_, err := s.GetAccountByID(ctx, accountID, userID)
 	if err != nil {
@@ -126,4 +893,53 @@ func (s *AccountService) GetTransactionsByAccountID(ctx context.Context, account
 
 func (s *AccountService) GetTransactionsByUserID(ctx context.Context, userID int64) ([]*models.Transaction, error) {
 	return s.transactionRepo.GetTransactionsByUserID(ctx, userID)
-}
\ No newline at end of file
+}
+
+// GetStatement собирает выписку по счету пользователя за [from, to).
+// Остатки на границах периода восстанавливаются от текущего баланса
+func (s *AccountService) GetStatement(ctx context.Context, accountID int64, userID int64, from, to time.Time) (*models.Statement, error) {
+	acc, err := s.accountRepo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	if !from.Before(to) {
+		return nil, ErrInvalidPeriod
+	}
+
+	transactions, err := s.transactionRepo.GetCompletedByPeriod(ctx, acc.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	sinceFrom, err := s.transactionRepo.GetNetTurnoverSince(ctx, acc.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.Statement{
+		Account:        acc,
+		From:           from,
+		To:             to,
+		OpeningBalance: acc.Balance.Sub(sinceFrom),
+		TotalIn:        decimal.Zero,
+		TotalOut:       decimal.Zero,
+		Transactions:   transactions,
+	}
+	for _, tx := range transactions {
+		if tx.Type == models.DEPOSIT {
+			statement.TotalIn = statement.TotalIn.Add(tx.Amount)
+		} else {
+			statement.TotalOut = statement.TotalOut.Add(tx.Amount)
+		}
+	}
+	statement.ClosingBalance = statement.OpeningBalance.Add(statement.TotalIn).Sub(statement.TotalOut)
+
+	return statement, nil
+}
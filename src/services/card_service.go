@@ -5,52 +5,136 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
+	"sf-finances/src/config"
 	"sf-finances/src/models"
 	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrUnknownCardProduct   = errors.New("неизвестный продукт карты")
+	ErrUnknownCardType      = errors.New("неизвестный тип карты")
+	ErrPANGeneration        = errors.New("не удалось сгенерировать уникальный номер карты")
+	ErrCardInactive         = errors.New("карта не активна")
+	ErrCardMerchantMismatch = errors.New("карта привязана к другому мерчанту")
+	ErrCardNotFound         = errors.New("карта не найдена")
+	ErrCardAccessDenied     = errors.New("доступ запрещен: карта не принадлежит пользователю")
+	ErrCardBlocked          = errors.New("карта заблокирована")
+	ErrInvalidCVV           = errors.New("неверный CVV код")
+	ErrInvalidPINFormat     = errors.New("PIN должен состоять из 4 цифр")
+	ErrInvalidPIN           = errors.New("неверный PIN")
+	ErrPINNotSet            = errors.New("PIN не установлен")
+	ErrCardExpired          = errors.New("карта просрочена")
+	ErrCardNotActivated     = errors.New("карта не активирована")
+	ErrCardAlreadyActive    = errors.New("карта не ожидает активации")
+	ErrInvalidPaymentLimit  = errors.New("лимит платежа должен быть положительным")
+	ErrPINResetNotRequested = errors.New("сброс PIN не запрошен")
+	ErrPINResetCodeExpired  = errors.New("истек срок действия кода")
+	ErrPINResetCodeInvalid  = errors.New("неверный код подтверждения")
+	ErrPINResetAttempts     = errors.New("превышено число попыток ввода кода")
 )
 
 type CardService struct {
-	cardRepo      *repository.CardRepository
-	db            *pgxpool.Pool
-	encryptionKey []byte
+	cardRepo       *repository.CardRepository
+	pinResetRepo   *repository.PINResetRepository
+	accountService *AccountService
+	notifier       Notifier
+	db             *pgxpool.Pool
+	encryptionKey  []byte
+	panHashKey     []byte
+	pinKey         []byte
+	servicePGPKey  string
+	cardCfg        config.CardConfig
 }
 
-func NewCardService(cardRepo *repository.CardRepository, db *pgxpool.Pool, encryptionKey string) *CardService {
+func NewCardService(cardRepo *repository.CardRepository, pinResetRepo *repository.PINResetRepository,
+	accountService *AccountService, notifier Notifier, db *pgxpool.Pool, cryptoCfg config.CryptoConfig,
+	cardCfg config.CardConfig) *CardService {
 	return &CardService{
-		cardRepo:      cardRepo,
-		db:            db,
-		encryptionKey: []byte(encryptionKey),
+		cardRepo:       cardRepo,
+		pinResetRepo:   pinResetRepo,
+		accountService: accountService,
+		notifier:       notifier,
+		db:             db,
+		encryptionKey:  []byte(cryptoCfg.HMACKey),
+		panHashKey:     []byte(cryptoCfg.PANHashKey),
+		pinKey:         []byte(cryptoCfg.PINKey),
+		servicePGPKey:  cryptoCfg.PGPKey,
+		cardCfg:        cardCfg,
+	}
+}
+
+// randomInt возвращает равномерно распределенное число из [0, max)
+func randomInt(max uint64) (uint64, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).SetUint64(max))
+	if err != nil {
+		return 0, err
 	}
+	return n.Uint64(), nil
 }
 
-func (s *CardService) generateCardNumber() (string, error) {
-	bin := "400000"
+func randomDigits(count int) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < count; i++ {
+		digit, err := randomInt(10)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(byte('0' + digit))
+	}
+	return sb.String(), nil
+}
+
+func (s *CardService) generateBIN(product models.CardProduct) (string, error) {
+	ranges := s.cardCfg.BINRanges[product]
+	if len(ranges) == 0 {
+		return "", ErrUnknownCardProduct
+	}
+
+	idx, err := randomInt(uint64(len(ranges)))
+	if err != nil {
+		return "", err
+	}
+	binRange := ranges[idx]
+
+	offset, err := randomInt(binRange.To - binRange.From + 1)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", binRange.From+offset), nil
+}
 
-	var digits string
-	randomDigits := make([]byte, 9)
-	_, err := rand.Read(randomDigits)
+func (s *CardService) generateCardNumber(product models.CardProduct) (string, error) {
+	bin, err := s.generateBIN(product)
 	if err != nil {
 		return "", err
 	}
 
-	for _, b := range randomDigits {
-		digit := int(b) % 10
-		digits += strconv.Itoa(digit)
+	digits, err := randomDigits(15 - len(bin))
+	if err != nil {
+		return "", err
 	}
 
 	number := bin + digits
+	return number + strconv.Itoa(luhnCheckDigit(number)), nil
+}
 
+func luhnCheckDigit(number string) int {
 	sum := 0
-	alternate := false
+	alternate := true
 
 	for i := len(number) - 1; i >= 0; i-- {
 		digit, _ := strconv.Atoi(string(number[i]))
@@ -66,27 +150,32 @@ func (s *CardService) generateCardNumber() (string, error) {
 		alternate = !alternate
 	}
 
-	checkDigit := (10 - (sum % 10)) % 10
-	fullNumber := number + strconv.Itoa(checkDigit)
-
-	return fullNumber, nil
+	return (10 - (sum % 10)) % 10
 }
 
-func (s *CardService) generateExpirationDate() string {
-	now := time.Now()
-	expiryDate := now.AddDate(3, 0, 0) // Карта действительна 3 года
-	return fmt.Sprintf("%02d/%d", expiryDate.Month(), expiryDate.Year()%100)
+// generateExpirationDate возвращает срок действия в виде MM/YY и момент,
+// с которого карта считается просроченной (начало следующего месяца)
+func (s *CardService) generateExpirationDate(cardType models.CardType) (string, time.Time) {
+	now := time.Now().UTC()
+	expiryDate := now.AddDate(0, s.cardCfg.ValidityMonths[cardType], 0)
+	expiresAt := time.Date(expiryDate.Year(), expiryDate.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	return fmt.Sprintf("%02d/%d", expiryDate.Month(), expiryDate.Year()%100), expiresAt
 }
 
 func (s *CardService) generateCVV() (string, error) {
-	b := make([]byte, 2)
-	_, err := rand.Read(b)
+	n, err := randomInt(900)
 	if err != nil {
 		return "", err
 	}
 
-	cvv := 100 + (int(b[0])<<8|int(b[1]))%900
-	return fmt.Sprintf("%03d", cvv), nil
+	return fmt.Sprintf("%03d", 100+n), nil
+}
+
+// hashPAN - ключевой хеш номера карты для поиска дубликатов без расшифровки
+func (s *CardService) hashPAN(cardNumber string) string {
+	h := hmac.New(sha256.New, s.panHashKey)
+	h.Write([]byte(cardNumber))
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 func (s *CardService) encryptWithPGP(ctx context.Context, data string, key string) ([]byte, error) {
@@ -116,18 +205,251 @@ func (s *CardService) validateCVV(cvv string, hash string) bool {
 	return err == nil
 }
 
-func (s *CardService) CreateCard(ctx context.Context, userID int64, pgpKey string) (*models.Card, map[string]string, error) {
-	cardNumber, err := s.generateCardNumber()
+// hashPIN хеширует PIN через bcrypt от HMAC с ключом сервиса и ID карты,
+// чтобы одинаковые PIN разных карт не давали одинаковых хешей
+func (s *CardService) hashPIN(cardID int64, pin string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(s.pinDigest(cardID, pin)), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, nil, fmt.Errorf("ошибка генерации номера карты: %w", err)
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (s *CardService) validatePIN(cardID int64, pin string, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(s.pinDigest(cardID, pin)))
+	return err == nil
+}
+
+func (s *CardService) pinDigest(cardID int64, pin string) string {
+	h := hmac.New(sha256.New, s.pinKey)
+	h.Write([]byte(fmt.Sprintf("%d:%s", cardID, pin)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// pinCardID возвращает ID карты, с которым хеширован PIN. У перевыпущенной
+// карты это ID карты, для которой PIN задавался
+func pinCardID(card *models.Card) int64 {
+	if card.PINCardID != nil {
+		return *card.PINCardID
+	}
+	return card.ID
+}
+
+func isValidPINFormat(pin string) bool {
+	if len(pin) != 4 {
+		return false
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// issueCard генерирует уникальный номер и срок действия, шифрует их ключом pgpKey
+// и сохраняет карту по шаблону. CVV-хеш должен быть заполнен в шаблоне заранее
+func (s *CardService) issueCard(ctx context.Context, template models.Card, pgpKey string) (*models.Card, string, string, error) {
+	expireDate, expiresAt := s.generateExpirationDate(template.Type)
+	template.ExpiresAt = expiresAt
+
+	encryptedExpire, err := s.encryptWithPGP(ctx, expireDate, pgpKey)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("ошибка шифрования срока действия: %w", err)
+	}
+	template.Expire = encryptedExpire
+
+	// Каждая попытка - новый номер, занятый номер расходует одну попытку
+	for attempt := 0; attempt < s.cardCfg.MaxPANAttempts; attempt++ {
+		cardNumber, err := s.generateCardNumber(template.Product)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("ошибка генерации номера карты: %w", err)
+		}
+
+		panHash := s.hashPAN(cardNumber)
+		exists, err := s.cardRepo.ExistsByPANHash(ctx, panHash)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if exists {
+			continue
+		}
+
+		encryptedNumber, err := s.encryptWithPGP(ctx, cardNumber, pgpKey)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("ошибка шифрования номера карты: %w", err)
+		}
+
+		template.CardNumber = encryptedNumber
+		template.PANHash = panHash
+
+		card, err := s.cardRepo.CreateCard(ctx, &template)
+		if errors.Is(err, repository.ErrDuplicatePAN) {
+			// Номер успели занять параллельным запросом - генерируем заново
+			continue
+		}
+		if err != nil {
+			return nil, "", "", fmt.Errorf("ошибка создания карты в БД: %w", err)
+		}
+
+		return card, cardNumber, expireDate, nil
+	}
+
+	return nil, "", "", ErrPANGeneration
+}
+
+func (s *CardService) CreateCard(ctx context.Context, userID int64, req types.CreateCardReq) (*models.Card, map[string]string, error) {
+	product := req.Product
+	if product == "" {
+		product = s.cardCfg.DefaultProduct
+	}
+	if _, ok := s.cardCfg.BINRanges[product]; !ok {
+		return nil, nil, ErrUnknownCardProduct
+	}
+
+	cardType := req.Type
+	if cardType == "" {
+		cardType = models.PhysicalCard
+	}
+	if _, ok := s.cardCfg.ValidityMonths[cardType]; !ok {
+		return nil, nil, ErrUnknownCardType
+	}
+
+	if req.PaymentLimit != nil && req.PaymentLimit.LessThanOrEqual(decimal.Zero) {
+		return nil, nil, ErrInvalidPaymentLimit
+	}
+
+	if _, err := s.accountService.GetAccountByID(ctx, req.AccountID, userID); err != nil {
+		return nil, nil, err
 	}
 
-	expireDate := s.generateExpirationDate()
 	cvv, err := s.generateCVV()
 	if err != nil {
 		return nil, nil, fmt.Errorf("ошибка генерации CVV: %w", err)
 	}
 
+	cvvHash, err := s.hashCVV(cvv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка хеширования CVV: %w", err)
+	}
+
+	template := models.Card{
+		UserID:    userID,
+		AccountID: req.AccountID,
+		Product:   product,
+		Type:      cardType,
+		Status:    models.CardActive,
+		CVVHash:   cvvHash,
+	}
+	if req.PaymentLimit != nil {
+		template.PaymentLimit = decimal.NewNullDecimal(*req.PaymentLimit)
+	}
+
+	card, cardNumber, expireDate, err := s.issueCard(ctx, template, req.PGPKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	message := fmt.Sprintf("%d:%s:%s:%s", card.ID, cardNumber, expireDate, cvv)
+	signature := s.generateHMAC(message)
+
+	cardDetails := map[string]string{
+		"number":    cardNumber,
+		"expire":    expireDate,
+		"cvv":       cvv,
+		"signature": signature,
+	}
+
+	return card, cardDetails, nil
+}
+
+// ProcessExpiringCards помечает просроченные карты и перевыпускает карты,
+// срок которых истекает в ближайшие ReissueBeforeDays дней
+func (s *CardService) ProcessExpiringCards(ctx context.Context) error {
+	now := time.Now()
+
+	expired, err := s.cardRepo.MarkExpired(ctx, now)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления просроченных карт: %w", err)
+	}
+	var errs []error
+	for _, card := range expired {
+		err := s.notifier.Notify(ctx, card.UserID, "Срок действия карты истек",
+			fmt.Sprintf("Срок действия карты %d истек, карта больше не принимается к оплате", card.ID))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("уведомление по карте %d: %w", card.ID, err))
+		}
+	}
+
+	before := now.AddDate(0, 0, s.cardCfg.ReissueBeforeDays)
+	cards, err := s.cardRepo.GetCardsForReissue(ctx, before, s.cardCfg.ReissueTypes)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("ошибка получения карт для перевыпуска: %w", err))
+		return errors.Join(errs...)
+	}
+
+	for _, card := range cards {
+		if err := s.reissueCard(ctx, card); err != nil {
+			errs = append(errs, fmt.Errorf("карта %d: %w", card.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reissueCard выпускает замену карты с тем же счетом, лимитами и PIN.
+// Ключ владельца сервису неизвестен, поэтому данные новой карты шифруются
+// ключом сервиса до активации владельцем, а CVV генерируется при активации
+func (s *CardService) reissueCard(ctx context.Context, card *models.Card) error {
+	reissuedFrom := card.ID
+	template := models.Card{
+		UserID:           card.UserID,
+		AccountID:        card.AccountID,
+		Product:          card.Product,
+		Type:             card.Type,
+		Status:           models.CardPendingActivation,
+		LockedMerchantID: card.LockedMerchantID,
+		PaymentLimit:     card.PaymentLimit,
+		ReissuedFromID:   &reissuedFrom,
+	}
+	if card.PINHash != nil {
+		pinFrom := pinCardID(card)
+		template.PINHash = card.PINHash
+		template.PINCardID = &pinFrom
+	}
+
+	newCard, _, _, err := s.issueCard(ctx, template, s.servicePGPKey)
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Notify(ctx, card.UserID, "Карта перевыпущена",
+		fmt.Sprintf("Срок действия карты %d истекает %s. Выпущена новая карта %d, активируйте ее в приложении",
+			card.ID, card.ExpiresAt.Format("2006-01-02"), newCard.ID))
+}
+
+// ActivateCard перешифровывает перевыпущенную карту ключом владельца,
+// генерирует CVV и закрывает карту, которую она заменяет
+func (s *CardService) ActivateCard(ctx context.Context, cardID int64, userID int64, pgpKey string) (*models.Card, map[string]string, error) {
+	card, err := s.getUserCard(ctx, cardID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if card.Status != models.CardPendingActivation {
+		return nil, nil, ErrCardAlreadyActive
+	}
+
+	cardNumber, err := s.decryptWithPGP(ctx, card.CardNumber, s.servicePGPKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка расшифровки номера карты: %w", err)
+	}
+
+	expireDate, err := s.decryptWithPGP(ctx, card.Expire, s.servicePGPKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка расшифровки срока действия: %w", err)
+	}
+
 	encryptedNumber, err := s.encryptWithPGP(ctx, cardNumber, pgpKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("ошибка шифрования номера карты: %w", err)
@@ -138,40 +460,48 @@ func (s *CardService) CreateCard(ctx context.Context, userID int64, pgpKey strin
 		return nil, nil, fmt.Errorf("ошибка шифрования срока действия: %w", err)
 	}
 
+	cvv, err := s.generateCVV()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка генерации CVV: %w", err)
+	}
+
 	cvvHash, err := s.hashCVV(cvv)
 	if err != nil {
 		return nil, nil, fmt.Errorf("ошибка хеширования CVV: %w", err)
 	}
 
-	card, err := s.cardRepo.CreateCard(ctx, userID, encryptedNumber, encryptedExpire, cvvHash)
+	err = s.cardRepo.Activate(ctx, card.ID, encryptedNumber, encryptedExpire, cvvHash)
 	if err != nil {
-		return nil, nil, fmt.Errorf("ошибка создания карты в БД: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrCardAlreadyActive
+		}
+		return nil, nil, fmt.Errorf("ошибка активации карты: %w", err)
 	}
+	card.Status = models.CardActive
 
-	message := fmt.Sprintf("%d:%s:%s:%s", card.ID, cardNumber, expireDate, cvv)
-	signature := s.generateHMAC(message)
+	if card.ReissuedFromID != nil {
+		if _, err := s.cardRepo.UpdateStatus(ctx, *card.ReissuedFromID, models.CardActive, models.CardClosed); err != nil {
+			return nil, nil, fmt.Errorf("ошибка закрытия старой карты: %w", err)
+		}
+	}
 
 	cardDetails := map[string]string{
-		"number":    cardNumber,
-		"expire":    expireDate,
-		"cvv":       cvv,
-		"signature": signature,
+		"number": cardNumber,
+		"expire": expireDate,
+		"cvv":    cvv,
 	}
 
 	return card, cardDetails, nil
 }
 
 func (s *CardService) GetCardDetails(ctx context.Context, cardID int64, userID int64, pgpKey string) (map[string]string, error) {
-	card, err := s.cardRepo.GetCardByID(ctx, cardID)
+	card, err := s.getUserCard(ctx, cardID, userID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("карта не найдена")
-		}
-		return nil, fmt.Errorf("ошибка получения карты: %w", err)
+		return nil, err
 	}
 
-	if card.UserID != userID {
-		return nil, errors.New("доступ запрещен: карта не принадлежит пользователю")
+	if card.Status == models.CardPendingActivation {
+		return nil, ErrCardNotActivated
 	}
 
 	cardNumber, err := s.decryptWithPGP(ctx, card.CardNumber, pgpKey)
@@ -198,54 +528,244 @@ func (s *CardService) GetUserCards(ctx context.Context, userID int64) ([]*models
 	return s.cardRepo.GetCardsByUserID(ctx, userID)
 }
 
-func (s *CardService) VerifyCardPayment(ctx context.Context, cardID int64, cvv string, pgpKey string) (bool, error) {
+// VerifyCardPayment проверяет карту по PIN, если он передан, иначе по CVV
+func (s *CardService) VerifyCardPayment(ctx context.Context, cardID int64, cvv, pin string) (*models.Card, error) {
 	card, err := s.cardRepo.GetCardByID(ctx, cardID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return false, errors.New("карта не найдена")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCardNotFound
 		}
-		return false, fmt.Errorf("ошибка получения карты: %w", err)
+		return nil, fmt.Errorf("ошибка получения карты: %w", err)
+	}
+
+	switch card.Status {
+	case models.CardActive:
+	case models.CardBlocked:
+		return nil, ErrCardBlocked
+	case models.CardExpired:
+		return nil, ErrCardExpired
+	default:
+		return nil, ErrCardInactive
 	}
 
-	isValidCVV := s.validateCVV(cvv, card.CVVHash)
-	if !isValidCVV {
-		return false, errors.New("неверный CVV код")
+	if !time.Now().Before(card.ExpiresAt) {
+		return nil, ErrCardExpired
+	}
+
+	if pin != "" {
+		if err := s.checkPIN(ctx, card, pin); err != nil {
+			return nil, err
+		}
+	} else if !s.validateCVV(cvv, card.CVVHash) {
+		return nil, ErrInvalidCVV
+	}
+
+	return card, nil
+}
+
+// checkPIN сверяет PIN и ведет счетчик неудачных попыток.
+// После MaxPINAttempts ошибок подряд карта блокируется
+func (s *CardService) checkPIN(ctx context.Context, card *models.Card, pin string) error {
+	if card.PINHash == nil {
+		return ErrPINNotSet
 	}
 
-	expire, err := s.decryptWithPGP(ctx, card.Expire, pgpKey)
+	if isValidPINFormat(pin) && s.validatePIN(pinCardID(card), pin, *card.PINHash) {
+		if card.PINAttempts > 0 {
+			return s.cardRepo.ResetPINAttempts(ctx, card.ID)
+		}
+		return nil
+	}
+
+	blocked, err := s.cardRepo.RegisterPINFailure(ctx, card.ID, s.cardCfg.MaxPINAttempts)
 	if err != nil {
-		return false, fmt.Errorf("ошибка расшифровки срока действия: %w", err)
+		return err
+	}
+	if blocked {
+		return ErrCardBlocked
 	}
+	return ErrInvalidPIN
+}
 
-	cardNumber, err := s.decryptWithPGP(ctx, card.CardNumber, pgpKey)
+func (s *CardService) getUserCard(ctx context.Context, cardID int64, userID int64) (*models.Card, error) {
+	card, err := s.cardRepo.GetCardByID(ctx, cardID)
 	if err != nil {
-		return false, fmt.Errorf("ошибка расшифровки номера карты: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCardNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения карты: %w", err)
 	}
 
-	var month, year int
-	_, err = fmt.Sscanf(expire, "%d/%d", &month, &year)
+	if card.UserID != userID {
+		return nil, ErrCardAccessDenied
+	}
+
+	return card, nil
+}
+
+// SetPIN устанавливает PIN карты. Если PIN уже задан, нужен текущий PIN
+func (s *CardService) SetPIN(ctx context.Context, cardID int64, userID int64, req types.SetPINReq) error {
+	if !isValidPINFormat(req.PIN) {
+		return ErrInvalidPINFormat
+	}
+
+	card, err := s.getUserCard(ctx, cardID, userID)
 	if err != nil {
-		return false, fmt.Errorf("ошибка парсинга срока действия: %w", err)
+		return err
 	}
 
-	year += 2000
+	switch card.Status {
+	case models.CardActive:
+	case models.CardBlocked:
+		return ErrCardBlocked
+	default:
+		return ErrCardInactive
+	}
 
-	now := time.Now()
-	expiryDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	expiryDate = expiryDate.AddDate(0, 1, -1)
+	if card.PINHash != nil {
+		if err := s.checkPIN(ctx, card, req.CurrentPIN); err != nil {
+			return err
+		}
+	}
+
+	pinHash, err := s.hashPIN(card.ID, req.PIN)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования PIN: %w", err)
+	}
+
+	return s.cardRepo.SetPIN(ctx, card.ID, pinHash)
+}
+
+// RequestPINReset отправляет владельцу карты одноразовый код для сброса PIN
+func (s *CardService) RequestPINReset(ctx context.Context, cardID int64, userID int64) error {
+	card, err := s.getUserCard(ctx, cardID, userID)
+	if err != nil {
+		return err
+	}
+
+	if card.Status != models.CardActive && card.Status != models.CardBlocked {
+		return ErrCardInactive
+	}
+
+	code, err := randomDigits(s.cardCfg.PINResetCodeLength)
+	if err != nil {
+		return fmt.Errorf("ошибка генерации кода: %w", err)
+	}
+
+	err = s.pinResetRepo.Upsert(ctx, &models.PINResetCode{
+		CardID:    card.ID,
+		CodeHash:  s.pinResetCodeHash(card.ID, code),
+		ExpiresAt: time.Now().Add(s.cardCfg.PINResetCodeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Notify(ctx, card.UserID, "Сброс PIN",
+		fmt.Sprintf("Код для сброса PIN карты %d: %s. Никому не сообщайте его", card.ID, code))
+}
+
+// ResetPIN задает новый PIN по CVV и коду из RequestPINReset, сбрасывает
+// счетчик попыток и разблокирует карту, заблокированную из-за неверного PIN.
+// Неверный код и неверный CVV расходуют попытки кода
+func (s *CardService) ResetPIN(ctx context.Context, cardID int64, userID int64, req types.ResetPINReq) error {
+	if !isValidPINFormat(req.PIN) {
+		return ErrInvalidPINFormat
+	}
+
+	card, err := s.getUserCard(ctx, cardID, userID)
+	if err != nil {
+		return err
+	}
 
-	if now.After(expiryDate) {
-		return false, errors.New("карта просрочена")
+	if card.Status != models.CardActive && card.Status != models.CardBlocked {
+		return ErrCardInactive
 	}
 
-	message := fmt.Sprintf("%d:%s:%s:%s", cardID, cardNumber, expire, cvv)
-	hmacSignature := s.generateHMAC(message)
+	reset, err := s.pinResetRepo.GetByCardID(ctx, card.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPINResetNotRequested
+		}
+		return err
+	}
+
+	if time.Now().After(reset.ExpiresAt) {
+		return ErrPINResetCodeExpired
+	}
 
-	if len(hmacSignature) == 0 {
-		return false, errors.New("ошибка генерации HMAC-подписи")
+	if reset.Attempts >= s.cardCfg.MaxPINResetCodeAttempts {
+		return ErrPINResetAttempts
 	}
 
-	return true, nil
+	codeHash := s.pinResetCodeHash(card.ID, req.Code)
+	codeValid := hmac.Equal([]byte(codeHash), []byte(reset.CodeHash))
+	if !codeValid || !s.validateCVV(req.CVV, card.CVVHash) {
+		if _, err := s.pinResetRepo.RegisterFailedAttempt(ctx, card.ID); err != nil {
+			return err
+		}
+		if !codeValid {
+			return ErrPINResetCodeInvalid
+		}
+		return ErrInvalidCVV
+	}
+
+	ok, err := s.pinResetRepo.Consume(ctx, card.ID, codeHash)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPINResetCodeInvalid
+	}
+
+	pinHash, err := s.hashPIN(card.ID, req.PIN)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования PIN: %w", err)
+	}
+
+	return s.cardRepo.ResetPIN(ctx, card.ID, pinHash)
+}
+
+func (s *CardService) pinResetCodeHash(cardID int64, code string) string {
+	return s.generateHMAC("pin-reset:" + strconv.FormatInt(cardID, 10) + ":" + code)
+}
+
+// ClaimForPayment резервирует карту под платеж мерчанта: одноразовая карта
+// закрывается, карта с привязкой закрепляется за первым мерчантом.
+// Если списание не прошло, резерв снимается через ReleasePaymentClaim
+func (s *CardService) ClaimForPayment(ctx context.Context, card *models.Card, merchantID int64) error {
+	switch card.Type {
+	case models.VirtualSingleUse:
+		ok, err := s.cardRepo.UpdateStatus(ctx, card.ID, models.CardActive, models.CardClosed)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrCardInactive
+		}
+	case models.MerchantLocked:
+		ok, err := s.cardRepo.ClaimForMerchant(ctx, card.ID, merchantID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrCardMerchantMismatch
+		}
+	}
+	return nil
+}
+
+func (s *CardService) ReleasePaymentClaim(ctx context.Context, card *models.Card) error {
+	switch card.Type {
+	case models.VirtualSingleUse:
+		_, err := s.cardRepo.UpdateStatus(ctx, card.ID, models.CardClosed, models.CardActive)
+		return err
+	case models.MerchantLocked:
+		if card.LockedMerchantID == nil {
+			return s.cardRepo.ReleaseMerchant(ctx, card.ID)
+		}
+	}
+	return nil
 }
 
 func (s *CardService) generateHMAC(message string) string {
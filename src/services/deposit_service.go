@@ -0,0 +1,489 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidDeposit  = errors.New("неверные параметры вклада")
+	ErrDepositNotFound = errors.New("вклад не найден")
+	ErrDepositClosed   = errors.New("вклад уже закрыт")
+)
+
+// DepositService открывает вклады на отдельных счетах, ежедневно начисляет
+// по ним проценты на остаток на конец дня и раз в месяц капитализирует их
+// переводом со счета расходов банка
+type DepositService struct {
+	depositRepo    *repository.DepositRepository
+	accountService *AccountService
+	notifier       Notifier
+	depositCfg     config.DepositConfig
+	// Закрытие вклада не должно пересекаться с начислением процентов
+	mu sync.Mutex
+}
+
+func NewDepositService(depositRepo *repository.DepositRepository, accountService *AccountService, notifier Notifier,
+	depositCfg config.DepositConfig) *DepositService {
+	return &DepositService{
+		depositRepo:    depositRepo,
+		accountService: accountService,
+		notifier:       notifier,
+		depositCfg:     depositCfg,
+	}
+}
+
+// GetRates возвращает действующие ставки по вкладам
+func (s *DepositService) GetRates() []types.DepositRateRes {
+	rates := []types.DepositRateRes{{Type: models.DepositSavings, AnnualRate: s.depositCfg.SavingsRate}}
+
+	terms := make([]int, 0, len(s.depositCfg.TermRates))
+	for months := range s.depositCfg.TermRates {
+		terms = append(terms, months)
+	}
+	slices.Sort(terms)
+	for _, months := range terms {
+		rates = append(rates, types.DepositRateRes{
+			Type:       models.DepositTerm,
+			TermMonths: &months,
+			AnnualRate: s.depositCfg.TermRates[months],
+		})
+	}
+	return rates
+}
+
+// OpenDeposit открывает счет вклада и переводит на него первоначальный
+// взнос. Проценты начисляются со следующего дня. Списания со срочного
+// вклада запрещены до конца дня окончания срока
+func (s *DepositService) OpenDeposit(ctx context.Context, userID int64, req types.OpenDepositReq) (*types.DepositRes, error) {
+	deposit := &models.Deposit{
+		UserID: userID,
+		Type:   req.Type,
+		Status: models.DepositActive,
+	}
+
+	switch req.Type {
+	case models.DepositSavings:
+		deposit.AnnualRate = s.depositCfg.SavingsRate
+	case models.DepositTerm:
+		if req.TermMonths == nil {
+			return nil, fmt.Errorf("%w: не указан срок вклада", ErrInvalidDeposit)
+		}
+		rate, ok := s.depositCfg.TermRates[*req.TermMonths]
+		if !ok {
+			return nil, fmt.Errorf("%w: нет вклада на %d мес.", ErrInvalidDeposit, *req.TermMonths)
+		}
+		if req.Amount.LessThan(s.depositCfg.MinTermAmount) {
+			return nil, fmt.Errorf("%w: минимальная сумма вклада %s руб.", ErrInvalidDeposit,
+				s.depositCfg.MinTermAmount.StringFixed(2))
+		}
+		deposit.AnnualRate = rate
+		deposit.TermMonths = req.TermMonths
+	default:
+		return nil, fmt.Errorf("%w: неизвестный тип %q", ErrInvalidDeposit, req.Type)
+	}
+
+	if req.Amount.IsNegative() {
+		return nil, ErrNegativeAmount
+	}
+	if req.Amount.Exponent() < -2 {
+		return nil, fmt.Errorf("%w: в сумме больше двух знаков после запятой", ErrInvalidDeposit)
+	}
+
+	var fromAcc *models.Account
+	if req.Amount.IsPositive() {
+		var err error
+		fromAcc, err = s.accountService.userAccount(ctx, req.FromAccountID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if fromAcc.Currency != models.RUB {
+			return nil, ErrCurrencyMismatch
+		}
+	}
+
+	acc, err := s.accountService.CreateAccount(ctx, userID, models.RUB)
+	if err != nil {
+		return nil, err
+	}
+	deposit.AccountID = acc.ID
+
+	nickname := "Накопительный счет"
+	if deposit.TermMonths != nil {
+		nickname = fmt.Sprintf("Вклад на %d мес.", *deposit.TermMonths)
+	}
+	if _, err := s.accountService.SetNickname(ctx, acc.ID, userID, nickname); err != nil {
+		return nil, err
+	}
+
+	if fromAcc != nil {
+		description := "Пополнение вклада"
+		if err := s.accountService.transfer(ctx, fromAcc, acc, req.Amount, &description); err != nil {
+			// Пустой счет вклада больше не нужен
+			if _, closeErr := s.accountService.Close(ctx, acc.ID, userID, nil); closeErr != nil {
+				return nil, errors.Join(err, fmt.Errorf("закрытие счета вклада: %w", closeErr))
+			}
+			return nil, err
+		}
+	}
+
+	today := s.today()
+	deposit.OpenedOn = today
+	deposit.AccruedThrough = today
+	if deposit.TermMonths != nil {
+		maturity := addMonths(today, *deposit.TermMonths)
+		deposit.MaturityDate = &maturity
+
+		lockedUntil := s.endOfDay(maturity)
+		if err := s.accountService.SetLockedUntil(ctx, acc.ID, &lockedUntil); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := s.depositRepo.Create(ctx, deposit)
+	if err != nil {
+		return nil, err
+	}
+	return s.depositRes(ctx, created)
+}
+
+func (s *DepositService) GetDeposit(ctx context.Context, depositID int64, userID int64) (*types.DepositRes, error) {
+	deposit, err := s.get(ctx, depositID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.depositRes(ctx, deposit)
+}
+
+func (s *DepositService) GetUserDeposits(ctx context.Context, userID int64) ([]types.DepositRes, error) {
+	deposits, err := s.depositRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]types.DepositRes, 0, len(deposits))
+	for _, deposit := range deposits {
+		r, err := s.depositRes(ctx, deposit)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *r)
+	}
+	return res, nil
+}
+
+// CloseDeposit закрывает вклад и переводит средства на счет toAccountID.
+// Срочный вклад до окончания срока закрывается без процентов: начисленные
+// аннулируются, выплаченные возвращаются на счет расходов банка
+func (s *DepositService) CloseDeposit(ctx context.Context, depositID int64, userID int64, req types.CloseDepositReq) (*types.DepositRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deposit, err := s.get(ctx, depositID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if deposit.Status == models.DepositClosed {
+		return nil, ErrDepositClosed
+	}
+	if req.ToAccountID == deposit.AccountID {
+		return nil, ErrSameAccount
+	}
+	if _, err := s.accountService.userAccount(ctx, req.ToAccountID, userID); err != nil {
+		return nil, err
+	}
+
+	var forfeited decimal.Decimal
+	if deposit.Type == models.DepositTerm && deposit.Status == models.DepositActive {
+		forfeited, err = s.forfeit(ctx, deposit)
+	} else {
+		err = s.settle(ctx, deposit, s.today())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.accountService.Close(ctx, deposit.AccountID, userID, &req.ToAccountID); err != nil {
+		return nil, err
+	}
+
+	deposit, err = s.depositRepo.GetByID(ctx, deposit.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.depositRepo.UpdateStatus(ctx, deposit.ID, deposit.Status, models.DepositClosed); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Вклад №%d закрыт, средства переведены на ваш счет", deposit.ID)
+	if forfeited.IsPositive() {
+		message = fmt.Sprintf("Вклад №%d закрыт досрочно, выплаченные проценты %s руб. удержаны", deposit.ID,
+			forfeited.StringFixed(2))
+	}
+	if err := s.notifier.Notify(ctx, userID, "Вклад закрыт", message); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeposit(ctx, deposit.ID, userID)
+}
+
+// forfeit аннулирует проценты досрочно закрываемого срочного вклада и
+// снимает запрет списаний. Возвращает удержанные выплаченные проценты
+func (s *DepositService) forfeit(ctx context.Context, deposit *models.Deposit) (decimal.Decimal, error) {
+	paid, err := s.depositRepo.Forfeit(ctx, deposit.ID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := s.accountService.SetLockedUntil(ctx, deposit.AccountID, nil); err != nil {
+		return decimal.Zero, err
+	}
+	if !paid.IsPositive() {
+		return paid, nil
+	}
+
+	depositAcc, err := s.accountService.account(ctx, deposit.AccountID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	expenseAcc, err := s.expenseAccount(ctx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	description := fmt.Sprintf("Удержание процентов при досрочном закрытии вклада №%d", deposit.ID)
+	if err := s.accountService.transfer(ctx, depositAcc, expenseAcc, paid, &description); err != nil {
+		return decimal.Zero, err
+	}
+	return paid, nil
+}
+
+// settle начисляет проценты по вчерашний день и выплачивает все
+// начисленное перед закрытием вклада
+func (s *DepositService) settle(ctx context.Context, deposit *models.Deposit, today time.Time) error {
+	if deposit.Status == models.DepositActive {
+		if err := s.accrue(ctx, deposit, today); err != nil {
+			return err
+		}
+	}
+	if _, err := s.depositRepo.Capitalize(ctx, deposit.ID, today); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	return s.payCapitalizations(ctx, deposit.ID)
+}
+
+// AccrueInterest начисляет проценты по всем действующим вкладам за прошедшие
+// дни, капитализирует проценты за закончившиеся месяцы и по наступившим
+// срокам вкладов. Повторный запуск за тот же день ничего не начисляет
+func (s *DepositService) AccrueInterest(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	today := s.today()
+	deposits, err := s.depositRepo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, deposit := range deposits {
+		if err := s.accrue(ctx, deposit, today); err != nil {
+			errs = append(errs, fmt.Errorf("вклад %d: %w", deposit.ID, err))
+		}
+	}
+
+	// Выплачиваем и новые капитализации, и оставшиеся после прошлых сбоев
+	if err := s.payCapitalizations(ctx, 0); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// accrue начисляет проценты за дни после AccruedThrough до вчерашнего
+// включительно, но не позже окончания срока вклада
+func (s *DepositService) accrue(ctx context.Context, deposit *models.Deposit, today time.Time) error {
+	acc, err := s.accountService.account(ctx, deposit.AccountID)
+	if err != nil {
+		return err
+	}
+	if acc.Status == models.AccountClosed {
+		// Счет закрыли в обход вклада, начислять больше не на что
+		return s.depositRepo.UpdateStatus(ctx, deposit.ID, deposit.Status, models.DepositClosed)
+	}
+
+	for day := deposit.AccruedThrough.AddDate(0, 0, 1); day.Before(today); day = day.AddDate(0, 0, 1) {
+		if deposit.MaturityDate != nil && day.After(*deposit.MaturityDate) {
+			break
+		}
+
+		balance, err := s.accountService.BalanceAt(ctx, acc, s.endOfDay(day))
+		if err != nil {
+			return err
+		}
+		err = s.depositRepo.AddAccrual(ctx, &models.DepositAccrual{
+			DepositID:   deposit.ID,
+			AccrualDate: day,
+			Balance:     balance,
+			AnnualRate:  deposit.AnnualRate,
+			Amount:      dailyInterest(balance, deposit.AnnualRate, day),
+		})
+		if err != nil {
+			return err
+		}
+		deposit.AccruedThrough = day
+	}
+
+	matured := deposit.MaturityDate != nil && !deposit.AccruedThrough.Before(*deposit.MaturityDate)
+
+	// Капитализация за закончившиеся месяцы, по окончании срока - за все дни
+	before := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if matured {
+		before = deposit.MaturityDate.AddDate(0, 0, 1)
+	}
+	if _, err := s.depositRepo.Capitalize(ctx, deposit.ID, before); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	if !matured {
+		return nil
+	}
+	if err := s.depositRepo.UpdateStatus(ctx, deposit.ID, models.DepositActive, models.DepositMatured); err != nil {
+		return err
+	}
+	deposit.Status = models.DepositMatured
+
+	message := fmt.Sprintf("Срок вклада №%d закончился. Проценты зачислены на счет вклада, средства можно снять",
+		deposit.ID)
+	return s.notifier.Notify(ctx, deposit.UserID, "Срок вклада закончился", message)
+}
+
+// payCapitalizations выплачивает невыплаченные капитализации вклада
+// depositID, 0 - всех вкладов. Выплата на замороженный счет откладывается
+func (s *DepositService) payCapitalizations(ctx context.Context, depositID int64) error {
+	capitalizations, err := s.depositRepo.GetUnpaidCapitalizations(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, c := range capitalizations {
+		if depositID != 0 && c.DepositID != depositID {
+			continue
+		}
+		err := s.payCapitalization(ctx, c)
+		if err != nil && !errors.Is(err, ErrAccountFrozen) && !errors.Is(err, repository.ErrCapitalizationPaying) {
+			errs = append(errs, fmt.Errorf("капитализация %d: %w", c.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *DepositService) payCapitalization(ctx context.Context, c *models.DepositCapitalization) error {
+	if !c.Amount.IsPositive() {
+		return s.depositRepo.MarkCapitalizationPaid(ctx, c.ID, nil)
+	}
+
+	deposit, err := s.depositRepo.GetByID(ctx, c.DepositID)
+	if err != nil {
+		return err
+	}
+	expenseAcc, err := s.expenseAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.depositRepo.ClaimCapitalization(ctx, c.ID); err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Капитализация процентов по вкладу №%d", deposit.ID)
+	tx, err := s.accountService.transferFromBank(ctx, expenseAcc, deposit.AccountID, c.Amount, &description)
+	if err != nil {
+		if transferRejected(err) {
+			if releaseErr := s.depositRepo.ReleaseCapitalization(ctx, c.ID); releaseErr != nil {
+				return errors.Join(err, releaseErr)
+			}
+		}
+		return err
+	}
+	return s.depositRepo.MarkCapitalizationPaid(ctx, c.ID, &tx.ID)
+}
+
+func (s *DepositService) expenseAccount(ctx context.Context) (*models.Account, error) {
+	acc, err := s.accountService.GetAccountByNumber(ctx, s.depositCfg.InterestExpenseAccount)
+	if err != nil {
+		return nil, fmt.Errorf("счет расходов на проценты: %w", err)
+	}
+	return acc, nil
+}
+
+// addMonths прибавляет к дате месяцы, не перескакивая через короткий месяц:
+// 31 января + 1 месяц = 28 (29) февраля
+func addMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, date.Location())
+}
+
+// dailyInterest считает проценты за день на остаток balance по ставке rate %
+// годовых с учетом числа дней в году
+func dailyInterest(balance decimal.Decimal, rate decimal.Decimal, day time.Time) decimal.Decimal {
+	if !balance.IsPositive() {
+		return decimal.Zero
+	}
+	daysInYear := int64(time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay())
+	return balance.Mul(rate).Div(decimal.NewFromInt(100 * daysInYear)).Round(8)
+}
+
+func (s *DepositService) get(ctx context.Context, depositID int64, userID int64) (*models.Deposit, error) {
+	deposit, err := s.depositRepo.GetByID(ctx, depositID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDepositNotFound
+		}
+		return nil, err
+	}
+	if deposit.UserID != userID {
+		return nil, ErrDepositNotFound
+	}
+	return deposit, nil
+}
+
+func (s *DepositService) depositRes(ctx context.Context, deposit *models.Deposit) (*types.DepositRes, error) {
+	acc, err := s.accountService.account(ctx, deposit.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	interest, err := s.depositRepo.GetInterest(ctx, deposit.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.DepositRes{
+		Deposit:       deposit,
+		AccountNumber: acc.Number,
+		Balance:       acc.Balance,
+		Interest:      interest,
+	}, nil
+}
+
+// today возвращает текущую дату операционного дня. Даты вкладов хранятся
+// как полночь UTC, как их возвращает база
+func (s *DepositService) today() time.Time {
+	now := time.Now().In(s.depositCfg.Location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// endOfDay возвращает момент окончания дня date по часовому поясу банка
+func (s *DepositService) endOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, s.depositCfg.Location)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAddMonths(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name   string
+		date   time.Time
+		months int
+		want   time.Time
+	}{
+		{"обычный месяц", date(2026, 3, 15), 1, date(2026, 4, 15)},
+		{"31 января в невисокосный год", date(2026, 1, 31), 1, date(2026, 2, 28)},
+		{"31 января в високосный год", date(2028, 1, 31), 1, date(2028, 2, 29)},
+		{"31 марта на 30-дневный месяц", date(2026, 3, 31), 1, date(2026, 4, 30)},
+		{"переход через год", date(2026, 11, 30), 3, date(2027, 2, 28)},
+		{"год", date(2028, 2, 29), 12, date(2029, 2, 28)},
+		{"ноль месяцев", date(2026, 10, 19), 0, date(2026, 10, 19)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addMonths(tt.date, tt.months); !got.Equal(tt.want) {
+				t.Errorf("addMonths = %s, ожидалось %s", got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestDailyInterest(t *testing.T) {
+	d := decimal.RequireFromString
+	tests := []struct {
+		name    string
+		balance string
+		rate    string
+		day     time.Time
+		want    string
+	}{
+		{"365 дней в году", "365000", "10", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), "100"},
+		{"366 дней в високосном году", "366000", "10", time.Date(2028, 6, 1, 0, 0, 0, 0, time.UTC), "100"},
+		{"дробная часть до 8 знаков", "1000", "16", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), "0.43835616"},
+		{"нулевой остаток", "0", "16", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), "0"},
+		{"отрицательный остаток", "-500", "16", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dailyInterest(d(tt.balance), d(tt.rate), tt.day); !got.Equal(d(tt.want)) {
+				t.Errorf("проценты %s, ожидалось %s", got, tt.want)
+			}
+		})
+	}
+}
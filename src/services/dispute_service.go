@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrDisputeNotFound          = errors.New("спор не найден")
+	ErrInvalidDisputeReason     = errors.New("неизвестный код причины спора")
+	ErrInvalidDisputeOutcome    = errors.New("решение по спору должно быть WON или LOST")
+	ErrInvalidDisputeTransition = errors.New("недопустимая смена статуса спора")
+	ErrPaymentNotDisputable     = errors.New("оспорить можно только списанный платеж")
+	ErrDisputeWindowExpired     = errors.New("истек срок оспаривания платежа")
+)
+
+var disputeReasons = []models.DisputeReason{
+	models.DisputeFraud,
+	models.DisputeNotReceived,
+	models.DisputeDuplicate,
+	models.DisputeIncorrectAmount,
+	models.DisputeCancelled,
+}
+
+// disputeTransitions - допустимые переходы статусов спора. WON и LOST конечные
+var disputeTransitions = map[models.DisputeStatus][]models.DisputeStatus{
+	models.DisputeOpened:      {models.DisputeUnderReview, models.DisputeWon, models.DisputeLost},
+	models.DisputeUnderReview: {models.DisputeWon, models.DisputeLost},
+}
+
+type DisputeService struct {
+	disputeRepo     *repository.DisputeRepository
+	paymentService  *PaymentService
+	merchantService *MerchantService
+	accountService  *AccountService
+	notifier        Notifier
+	disputeCfg      config.DisputeConfig
+}
+
+func NewDisputeService(disputeRepo *repository.DisputeRepository, paymentService *PaymentService,
+	merchantService *MerchantService, accountService *AccountService, notifier Notifier,
+	disputeCfg config.DisputeConfig) *DisputeService {
+	return &DisputeService{
+		disputeRepo:     disputeRepo,
+		paymentService:  paymentService,
+		merchantService: merchantService,
+		accountService:  accountService,
+		notifier:        notifier,
+		disputeCfg:      disputeCfg,
+	}
+}
+
+// OpenDispute открывает спор по платежу и временно зачисляет сумму платежа
+// на счет карты со счета сумм до выяснения до решения администратора
+func (s *DisputeService) OpenDispute(ctx context.Context, paymentID int64, userID int64, req types.OpenDisputeReq) (*models.Dispute, error) {
+	if !slices.Contains(disputeReasons, req.ReasonCode) {
+		return nil, ErrInvalidDisputeReason
+	}
+
+	payment, card, err := s.paymentService.getUserPayment(ctx, paymentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != models.PaymentCaptured {
+		return nil, ErrPaymentNotDisputable
+	}
+
+	if time.Since(payment.CreatedAt) > s.disputeCfg.Window {
+		return nil, ErrDisputeWindowExpired
+	}
+
+	suspenseAcc, err := s.suspenseAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment *string
+	if req.Comment != "" {
+		comment = &req.Comment
+	}
+
+	dispute, err := s.disputeRepo.Create(ctx, &models.Dispute{
+		PaymentID: payment.ID,
+		UserID:    userID,
+		AccountID: card.AccountID,
+		Reason:    req.ReasonCode,
+		Comment:   comment,
+		Amount:    payment.Amount,
+		Status:    models.DisputeOpened,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Временное зачисление по спору №%d", dispute.ID)
+	tx, err := s.accountService.transferFromBank(ctx, suspenseAcc, dispute.AccountID, dispute.Amount, &description)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка временного зачисления по спору %d: %w", dispute.ID, err)
+	}
+
+	if err := s.disputeRepo.SetCreditTransactionID(ctx, dispute.ID, tx.ID); err != nil {
+		return nil, err
+	}
+	dispute.CreditTransactionID = &tx.ID
+
+	err = s.notifier.Notify(ctx, userID, "Спор открыт",
+		fmt.Sprintf("Спор %d по платежу %d открыт. На счет временно зачислено %s %s",
+			dispute.ID, payment.ID, dispute.Amount.StringFixed(2), payment.Currency))
+
+	return dispute, err
+}
+
+func (s *DisputeService) GetDispute(ctx context.Context, disputeID int64, userID int64) (*models.Dispute, error) {
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if dispute.UserID != userID {
+		return nil, ErrDisputeNotFound
+	}
+	return dispute, nil
+}
+
+func (s *DisputeService) GetUserDisputes(ctx context.Context, userID int64) ([]*models.Dispute, error) {
+	return s.disputeRepo.GetByUserID(ctx, userID)
+}
+
+// ListDisputes возвращает споры в статусе status, а без фильтра - все нерешенные
+func (s *DisputeService) ListDisputes(ctx context.Context, status models.DisputeStatus) ([]*models.Dispute, error) {
+	statuses := []models.DisputeStatus{models.DisputeOpened, models.DisputeUnderReview}
+	if status != "" {
+		statuses = []models.DisputeStatus{status}
+	}
+	return s.disputeRepo.GetByStatuses(ctx, statuses)
+}
+
+// StartReview берет открытый спор на рассмотрение
+func (s *DisputeService) StartReview(ctx context.Context, disputeID int64) (*models.Dispute, error) {
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(ctx, dispute, models.DisputeUnderReview); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// Resolve закрывает спор. При проигрыше временное зачисление списывается
+// со счета, при выигрыше остается у пользователя, а платеж, уже
+// рассчитанный с мерчантом, списывается с его расчетного счета
+func (s *DisputeService) Resolve(ctx context.Context, disputeID int64, adminID int64, req types.ResolveDisputeReq) (*models.Dispute, error) {
+	if req.Outcome != models.DisputeWon && req.Outcome != models.DisputeLost {
+		return nil, ErrInvalidDisputeOutcome
+	}
+
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(disputeTransitions[dispute.Status], req.Outcome) {
+		return nil, ErrInvalidDisputeTransition
+	}
+
+	var resolution *string
+	if req.Resolution != "" {
+		resolution = &req.Resolution
+	}
+
+	// Смена статуса идет первой: параллельное решение по тому же спору
+	// получит конфликт и не спишет зачисление повторно
+	err = s.disputeRepo.Resolve(ctx, dispute.ID, dispute.Status, req.Outcome, adminID, resolution)
+	if err != nil {
+		if errors.Is(err, repository.ErrDisputeStatusConflict) {
+			return nil, ErrInvalidDisputeTransition
+		}
+		return nil, err
+	}
+	dispute.Status = req.Outcome
+	dispute.Resolution = resolution
+	dispute.ResolvedBy = &adminID
+
+	message := fmt.Sprintf("Спор %d по платежу %d решен в вашу пользу", dispute.ID, dispute.PaymentID)
+	if req.Outcome == models.DisputeLost {
+		if dispute.CreditTransactionID != nil {
+			if err := s.reverseCredit(ctx, dispute); err != nil {
+				return nil, fmt.Errorf("ошибка списания временного зачисления по спору %d: %w", dispute.ID, err)
+			}
+		}
+		message = fmt.Sprintf("Спор %d по платежу %d отклонен, временное зачисление %s списано",
+			dispute.ID, dispute.PaymentID, dispute.Amount.StringFixed(2))
+	} else if err := s.chargeback(ctx, dispute); err != nil {
+		return nil, fmt.Errorf("ошибка списания с мерчанта по спору %d: %w", dispute.ID, err)
+	}
+
+	return dispute, s.notifier.Notify(ctx, dispute.UserID, "Решение по спору", message)
+}
+
+// reverseCredit возвращает временное зачисление со счета пользователя на
+// счет сумм до выяснения
+func (s *DisputeService) reverseCredit(ctx context.Context, dispute *models.Dispute) error {
+	suspenseAcc, err := s.suspenseAccount(ctx)
+	if err != nil {
+		return err
+	}
+	acc, err := s.accountService.account(ctx, dispute.AccountID)
+	if err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Списание временного зачисления по спору №%d", dispute.ID)
+	tx, err := s.accountService.transferToBank(ctx, acc, suspenseAcc, dispute.Amount, &description)
+	if err != nil {
+		return err
+	}
+	if err := s.disputeRepo.SetReversalTransactionID(ctx, dispute.ID, tx.ID); err != nil {
+		return err
+	}
+	dispute.ReversalTransactionID = &tx.ID
+	return nil
+}
+
+// chargeback списывает сумму выигранного спора с расчетного счета мерчанта
+// на счет сумм до выяснения. Платеж, еще не рассчитанный с мерчантом, в
+// расчет не попадет, и списывать нечего
+func (s *DisputeService) chargeback(ctx context.Context, dispute *models.Dispute) error {
+	payment, err := s.paymentService.paymentRepo.GetByID(ctx, dispute.PaymentID)
+	if err != nil {
+		return err
+	}
+	if payment.SettlementBatchID == nil {
+		return nil
+	}
+
+	merchant, err := s.merchantService.GetMerchant(ctx, payment.MerchantID)
+	if err != nil {
+		return err
+	}
+	suspenseAcc, err := s.suspenseAccount(ctx)
+	if err != nil {
+		return err
+	}
+	merchantAcc, err := s.accountService.account(ctx, merchant.SettlementAccountID)
+	if err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Возврат по спору №%d, платеж №%d", dispute.ID, payment.ID)
+	tx, err := s.accountService.transferToBank(ctx, merchantAcc, suspenseAcc, dispute.Amount, &description)
+	if err != nil {
+		return err
+	}
+	if err := s.disputeRepo.SetChargebackTransactionID(ctx, dispute.ID, tx.ID); err != nil {
+		return err
+	}
+	dispute.ChargebackTransactionID = &tx.ID
+	return nil
+}
+
+func (s *DisputeService) suspenseAccount(ctx context.Context) (*models.Account, error) {
+	acc, err := s.accountService.GetAccountByNumber(ctx, s.disputeCfg.SuspenseAccount)
+	if err != nil {
+		return nil, fmt.Errorf("счет сумм до выяснения: %w", err)
+	}
+	return acc, nil
+}
+
+func (s *DisputeService) transition(ctx context.Context, dispute *models.Dispute, to models.DisputeStatus) error {
+	if !slices.Contains(disputeTransitions[dispute.Status], to) {
+		return ErrInvalidDisputeTransition
+	}
+
+	err := s.disputeRepo.UpdateStatus(ctx, dispute.ID, dispute.Status, to)
+	if err != nil {
+		if errors.Is(err, repository.ErrDisputeStatusConflict) {
+			return ErrInvalidDisputeTransition
+		}
+		return err
+	}
+
+	dispute.Status = to
+	return nil
+}
+
+func (s *DisputeService) getDispute(ctx context.Context, disputeID int64) (*models.Dispute, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, disputeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, err
+	}
+	return dispute, nil
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"sf-finances/src/config"
+	"sf-finances/src/exchange"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+const documentBankOrder = "Банковский ордер"
+
+// ExchangeService обменивается с 1С файлами формата 1CClientBankExchange:
+// исполняет выгруженные из 1С платежные поручения и готовит выписки для
+// загрузки в 1С. Счета в файле обмена указываются 20-значными номерами
+type ExchangeService struct {
+	exchangeRepo   *repository.ExchangeRepository
+	accountService *AccountService
+	userRepo       repository.UserRepository
+	statementCfg   config.StatementConfig
+}
+
+func NewExchangeService(exchangeRepo *repository.ExchangeRepository, accountService *AccountService,
+	userRepo repository.UserRepository, statementCfg config.StatementConfig) *ExchangeService {
+	return &ExchangeService{
+		exchangeRepo:   exchangeRepo,
+		accountService: accountService,
+		userRepo:       userRepo,
+		statementCfg:   statementCfg,
+	}
+}
+
+// ImportPaymentOrders исполняет платежные поручения из файла обмена. Каждое
+// поручение исполняется независимо, ошибка в одном не останавливает
+// остальные. Документы других видов и уже загруженные поручения с тем же
+// счетом плательщика, номером и датой пропускаются
+func (s *ExchangeService) ImportPaymentOrders(ctx context.Context, userID int64, r io.Reader) (*types.ImportRes, error) {
+	file, err := exchange.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &types.ImportRes{Documents: []types.ImportDocumentRes{}}
+	for i, doc := range file.Documents {
+		result := types.ImportDocumentRes{
+			Index:  i + 1,
+			Kind:   doc.Kind,
+			Number: doc.Number(),
+			Date:   doc.Get(exchange.KeyDate),
+		}
+
+		if doc.Kind != exchange.DocumentPaymentOrder {
+			result.Status = types.ImportDocumentSkipped
+			result.Error = "вид документа не поддерживается"
+			res.Skipped++
+			res.Documents = append(res.Documents, result)
+			continue
+		}
+
+		result.Amount, err = doc.Amount()
+		if err == nil {
+			err = s.executePaymentOrder(ctx, userID, doc)
+		}
+		switch {
+		case errors.Is(err, repository.ErrDocumentImported):
+			result.Status = types.ImportDocumentSkipped
+			result.Error = err.Error()
+			res.Skipped++
+		case err != nil:
+			result.Status = types.ImportDocumentFailed
+			result.Error = err.Error()
+			res.Failed++
+		default:
+			result.Status = types.ImportDocumentSucceeded
+			res.Succeeded++
+		}
+		res.Documents = append(res.Documents, result)
+	}
+
+	return res, nil
+}
+
+func (s *ExchangeService) executePaymentOrder(ctx context.Context, userID int64, doc *exchange.Document) error {
+	amount, err := doc.Amount()
+	if err != nil {
+		return err
+	}
+	number := strings.TrimSpace(doc.Number())
+	if number == "" {
+		return errors.New("не указан номер документа")
+	}
+	date, err := doc.Date()
+	if err != nil {
+		return err
+	}
+
+	fromAcc, err := s.accountService.GetAccountByNumber(ctx, doc.PayerAccount())
+	if err != nil {
+		return fmt.Errorf("счет плательщика: %w", err)
+	}
+	if fromAcc.UserID != userID {
+		return fmt.Errorf("счет плательщика: %w", ErrAccountNotFound)
+	}
+
+	toAcc, err := s.accountService.GetAccountByNumber(ctx, doc.RecipientAccount())
+	if err != nil {
+		return fmt.Errorf("счет получателя: %w", err)
+	}
+
+	var purpose *string
+	if p := strings.TrimSpace(doc.Purpose()); p != "" {
+		purpose = &p
+	}
+
+	documentID, err := s.exchangeRepo.ClaimDocument(ctx, userID, fromAcc.Number, number, date)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.accountService.transferFromUser(ctx, fromAcc.ID, userID, toAcc.ID, amount, purpose)
+	if err != nil {
+		if transferRejected(err) {
+			if releaseErr := s.exchangeRepo.ReleaseDocument(ctx, documentID); releaseErr != nil {
+				return errors.Join(err, releaseErr)
+			}
+		}
+		return err
+	}
+	return s.exchangeRepo.SetTransactionID(ctx, documentID, tx.ID)
+}
+
+// ExportStatement готовит выписку по счету за [from, to) в формате обмена с
+// 1С. Переводы выгружаются платежными поручениями, прочие операции -
+// банковскими ордерами
+func (s *ExchangeService) ExportStatement(ctx context.Context, accountID int64, userID int64, from, to time.Time) (*exchange.File, error) {
+	statement, err := s.accountService.GetStatement(ctx, accountID, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	holder, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holderName := strings.TrimSpace(holder.FirstName + " " + holder.LastName)
+
+	loc := s.statementCfg.Location
+	dateFrom := exchange.FormatDate(from.In(loc))
+	dateTo := exchange.FormatDate(to.In(loc).AddDate(0, 0, -1))
+	account := statement.Account.Number
+
+	file := exchange.NewFile(s.statementCfg.ExchangeSender, time.Now().In(loc))
+	file.Header.Add(exchange.KeyDateFrom, dateFrom)
+	file.Header.Add(exchange.KeyDateTo, dateTo)
+	file.Header.Add(exchange.KeyAccount, account)
+	file.Header.Add(exchange.KeyDocumentKind, exchange.DocumentPaymentOrder)
+	file.Header.Add(exchange.KeyDocumentKind, documentBankOrder)
+
+	section := &exchange.AccountSection{}
+	section.Add(exchange.KeyDateFrom, dateFrom)
+	section.Add(exchange.KeyDateTo, dateTo)
+	section.Add(exchange.KeyAccount, account)
+	section.Add(exchange.KeyOpeningBalance, exchange.FormatAmount(statement.OpeningBalance))
+	section.Add(exchange.KeyTotalIn, exchange.FormatAmount(statement.TotalIn))
+	section.Add(exchange.KeyTotalOut, exchange.FormatAmount(statement.TotalOut))
+	section.Add(exchange.KeyClosingBalance, exchange.FormatAmount(statement.ClosingBalance))
+	file.Accounts = append(file.Accounts, section)
+
+	names := map[int64]string{}
+	for _, tx := range statement.Transactions {
+		doc := &exchange.Document{Kind: documentBankOrder}
+		counterparty, counterpartyName := "", ""
+		if tx.CounterpartyAccountID != nil {
+			doc.Kind = exchange.DocumentPaymentOrder
+			if tx.CounterpartyAccountNumber != nil {
+				counterparty = *tx.CounterpartyAccountNumber
+			}
+			counterpartyName, err = s.counterpartyName(ctx, *tx.CounterpartyAccountID, names)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		date := exchange.FormatDate(tx.CreatedAt.In(loc))
+		doc.Add(exchange.KeyNumber, strconv.FormatInt(tx.ID, 10))
+		doc.Add(exchange.KeyDate, date)
+		doc.Add(exchange.KeyAmount, exchange.FormatAmount(tx.Amount))
+		if tx.Type == models.DEPOSIT {
+			doc.Add(exchange.KeyPayerAccount, counterparty)
+			doc.Add(exchange.KeyPayer, counterpartyName)
+			doc.Add(exchange.KeyRecipientAccount, account)
+			doc.Add(exchange.KeyCreditedDate, date)
+			doc.Add(exchange.KeyRecipient, holderName)
+		} else {
+			doc.Add(exchange.KeyPayerAccount, account)
+			doc.Add(exchange.KeyDebitedDate, date)
+			doc.Add(exchange.KeyPayer, holderName)
+			doc.Add(exchange.KeyRecipientAccount, counterparty)
+			doc.Add(exchange.KeyRecipient, counterpartyName)
+		}
+		if tx.Description != nil {
+			doc.Add(exchange.KeyPurpose, *tx.Description)
+		}
+		file.Documents = append(file.Documents, doc)
+	}
+
+	return file, nil
+}
+
+// counterpartyName возвращает имя владельца счета второй стороны в том же
+// маскированном виде, что и при P2P-переводе
+func (s *ExchangeService) counterpartyName(ctx context.Context, accountID int64, cache map[int64]string) (string, error) {
+	if name, ok := cache[accountID]; ok {
+		return name, nil
+	}
+
+	name := ""
+	acc, err := s.accountService.GetAccount(ctx, accountID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return "", err
+	default:
+		user, err := s.userRepo.GetByID(ctx, acc.UserID)
+		if err != nil {
+			return "", err
+		}
+		name = recipientDisplayName(user)
+	}
+
+	cache[accountID] = name
+	return name, nil
+}
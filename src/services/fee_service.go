@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+)
+
+var ErrUnknownFeeOperation = errors.New("неизвестный вид операции")
+
+// Названия операций в назначении платежа комиссии
+var feeOperationNames = map[models.FeeOperation]string{
+	models.FeeP2PTransfer: "перевод",
+	models.FeeWithdrawal:  "снятие наличных",
+	models.FeeCardPayment: "оплату картой",
+}
+
+// FeeService считает комиссии по тарифам и ведет учет операций для
+// бесплатных месячных лимитов. Списывает комиссии AccountService
+type FeeService struct {
+	feeRepo     *repository.FeeRepository
+	accountRepo *repository.AccountRepository
+	feeCfg      config.FeeConfig
+}
+
+func NewFeeService(feeRepo *repository.FeeRepository, accountRepo *repository.AccountRepository,
+	feeCfg config.FeeConfig) *FeeService {
+	return &FeeService{
+		feeRepo:     feeRepo,
+		accountRepo: accountRepo,
+		feeCfg:      feeCfg,
+	}
+}
+
+// Preview показывает комиссию, которую пользователь заплатит за операцию
+// по рублевому счету, если совершит ее сейчас
+func (s *FeeService) Preview(ctx context.Context, userID int64, operation models.FeeOperation,
+	amount decimal.Decimal) (*models.FeeQuote, error) {
+	if _, ok := feeOperationNames[operation]; !ok {
+		return nil, ErrUnknownFeeOperation
+	}
+	if !amount.IsPositive() {
+		return nil, ErrNegativeAmount
+	}
+
+	quote, err := s.Quote(ctx, userID, models.RUB, operation, amount)
+	if err != nil {
+		return nil, err
+	}
+	if quote == nil {
+		quote = &models.FeeQuote{Operation: operation, Amount: amount, Fee: decimal.Zero}
+	}
+	return quote, nil
+}
+
+// Quote считает комиссию за операцию пользователя по счету в валюте
+// currency. Возвращает nil, если операция не тарифицируется
+func (s *FeeService) Quote(ctx context.Context, userID int64, currency models.Currency, operation models.FeeOperation,
+	amount decimal.Decimal) (*models.FeeQuote, error) {
+	rule, ok := s.feeCfg.Rules[operation]
+	if !ok || currency != models.RUB {
+		return nil, nil
+	}
+
+	usedCount, usedAmount, err := s.feeRepo.GetMonthlyUsage(ctx, userID, operation, s.monthStart())
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.FeeQuote{
+		Operation: operation,
+		Amount:    amount,
+		Fee:       calculateFee(rule, amount, usedCount, usedAmount),
+	}
+	if rule.FreeCount > 0 {
+		left := max(rule.FreeCount-usedCount, 0)
+		quote.FreeCountLeft = &left
+	}
+	if rule.FreeAmount.IsPositive() {
+		left := decimal.Max(rule.FreeAmount.Sub(usedAmount), decimal.Zero)
+		quote.FreeAmountLeft = &left
+	}
+	return quote, nil
+}
+
+// calculateFee применяет тариф к операции на сумму amount, если в месяце
+// уже было usedCount операций на сумму usedAmount
+func calculateFee(rule config.FeeRule, amount decimal.Decimal, usedCount int, usedAmount decimal.Decimal) decimal.Decimal {
+	if usedCount < rule.FreeCount {
+		return decimal.Zero
+	}
+
+	chargeable := amount
+	if rule.FreeAmount.IsPositive() {
+		left := decimal.Max(rule.FreeAmount.Sub(usedAmount), decimal.Zero)
+		chargeable = decimal.Max(amount.Sub(left), decimal.Zero)
+	}
+	if !chargeable.IsPositive() {
+		return decimal.Zero
+	}
+
+	fee := rule.Fixed.Add(chargeable.Mul(rule.Percent).Div(decimal.NewFromInt(100)))
+	fee = decimal.Max(fee, rule.Min)
+	if rule.Max.IsPositive() {
+		fee = decimal.Min(fee, rule.Max)
+	}
+	return fee.Round(2)
+}
+
+// record сохраняет операцию по счету acc с транзакцией txID по расчету quote
+func (s *FeeService) record(ctx context.Context, acc *models.Account, quote *models.FeeQuote, txID int64) (*models.Fee, error) {
+	fee := &models.Fee{
+		UserID:        acc.UserID,
+		AccountID:     acc.ID,
+		Operation:     quote.Operation,
+		Amount:        quote.Amount,
+		Fee:           quote.Fee,
+		TransactionID: txID,
+	}
+	if err := s.feeRepo.Create(ctx, fee); err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+// GetAccountFees возвращает комиссии, взятые по счету пользователя
+func (s *FeeService) GetAccountFees(ctx context.Context, accountID int64, userID int64) ([]*models.Fee, error) {
+	acc, err := s.accountRepo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	fees, err := s.feeRepo.GetByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if fees == nil {
+		fees = []*models.Fee{}
+	}
+	return fees, nil
+}
+
+// monthStart возвращает начало текущего месяца по часовому поясу банка
+func (s *FeeService) monthStart() time.Time {
+	now := time.Now().In(s.feeCfg.Location)
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, s.feeCfg.Location)
+}
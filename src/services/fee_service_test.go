@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+)
+
+func TestCalculateFee(t *testing.T) {
+	d := decimal.RequireFromString
+	tests := []struct {
+		name       string
+		rule       config.FeeRule
+		amount     string
+		usedCount  int
+		usedAmount string
+		want       string
+	}{
+		{"процент", config.FeeRule{Percent: d("1.5")}, "1000", 0, "0", "15"},
+		{"фиксированная часть и процент", config.FeeRule{Fixed: d("30"), Percent: d("1")}, "1000", 0, "0", "40"},
+		{"минимум", config.FeeRule{Percent: d("1"), Min: d("50")}, "1000", 0, "0", "50"},
+		{"максимум", config.FeeRule{Percent: d("1"), Max: d("500")}, "100000", 0, "0", "500"},
+		{"без максимума", config.FeeRule{Percent: d("1")}, "100000", 0, "0", "1000"},
+		{"округление до копеек", config.FeeRule{Percent: d("0.7")}, "123.45", 0, "0", "0.86"},
+		{"бесплатные операции не исчерпаны", config.FeeRule{Fixed: d("30"), FreeCount: 3}, "1000", 2, "0", "0"},
+		{"бесплатные операции исчерпаны", config.FeeRule{Fixed: d("30"), FreeCount: 3}, "1000", 3, "0", "30"},
+		{"сумма в бесплатном лимите", config.FeeRule{Percent: d("1"), FreeAmount: d("100000")}, "50000", 0, "40000", "0"},
+		// Комиссия берется только с части сверх бесплатного лимита
+		{"сумма частично сверх лимита", config.FeeRule{Percent: d("1"), FreeAmount: d("100000")}, "50000", 0, "80000", "300"},
+		{"лимит уже исчерпан", config.FeeRule{Percent: d("1"), FreeAmount: d("100000")}, "50000", 0, "120000", "500"},
+		{"минимум не берется с операции в лимите", config.FeeRule{Percent: d("1"), Min: d("50"), FreeAmount: d("100000")}, "50000", 0, "0", "0"},
+		{"пустой тариф", config.FeeRule{}, "1000", 0, "0", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateFee(tt.rule, d(tt.amount), tt.usedCount, d(tt.usedAmount))
+			if !got.Equal(d(tt.want)) {
+				t.Errorf("комиссия %s, ожидалось %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sf-finances/src/models"
+)
+
+// ErrGatewayRejected - платежная система окончательно отклонила поручение.
+// Прочие ошибки шлюза считаются временными, отправка повторяется
+var ErrGatewayRejected = errors.New("поручение отклонено платежной системой")
+
+// InterbankGateway отправляет платежные поручения в другие банки и
+// возвращает идентификатор, присвоенный поручению платежной системой
+type InterbankGateway interface {
+	Submit(ctx context.Context, p *models.InterbankPayment) (string, error)
+}
+
+// FakeInterbankGateway принимает все поручения и пишет их в лог вместо
+// реальной отправки
+type FakeInterbankGateway struct {
+	logger *logrus.Logger
+	seq    atomic.Int64
+}
+
+func NewFakeInterbankGateway(logger *logrus.Logger) *FakeInterbankGateway {
+	return &FakeInterbankGateway{logger: logger}
+}
+
+func (g *FakeInterbankGateway) Submit(ctx context.Context, p *models.InterbankPayment) (string, error) {
+	reference := fmt.Sprintf("FAKE-%s-%06d", time.Now().Format("20060102"), g.seq.Add(1))
+	g.logger.WithFields(logrus.Fields{
+		"payment_id": p.ID,
+		"bic":        p.RecipientBIC,
+		"account":    p.RecipientAccount,
+		"amount":     p.Amount.StringFixed(2),
+		"reference":  reference,
+	}).Info("Платежное поручение отправлено")
+	return reference, nil
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/banking"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidInterbankPayment  = errors.New("неверные реквизиты платежного поручения")
+	ErrInterbankPaymentNotFound = errors.New("платежное поручение не найдено")
+	ErrBankNotFound             = errors.New("банк не найден в справочнике БИК")
+)
+
+// Ограничения длины полей платежного поручения (Положение Банка России № 762-П)
+const (
+	maxRecipientNameLength = 160
+	maxPurposeLength       = 210
+)
+
+// InterbankPaymentValidationError перечисляет все ошибочные реквизиты
+// поручения, чтобы клиент мог исправить их за один раз
+type InterbankPaymentValidationError struct {
+	Errors []types.FieldError
+}
+
+func (e *InterbankPaymentValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		fields = append(fields, fe.Field)
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidInterbankPayment, strings.Join(fields, ", "))
+}
+
+func (e *InterbankPaymentValidationError) Unwrap() error {
+	return ErrInvalidInterbankPayment
+}
+
+// InterbankPaymentService принимает платежные поручения в другие банки.
+// Сумма переводится на транзитный счет банка при создании поручения, фоновый
+// исполнитель отправляет поручения через InterbankGateway и при отказе
+// возвращает деньги с транзитного счета
+type InterbankPaymentService struct {
+	paymentRepo    *repository.InterbankPaymentRepository
+	accountService *AccountService
+	directory      *banking.BICDirectory
+	gateway        InterbankGateway
+	notifier       Notifier
+	bankingCfg     config.BankingConfig
+	interbankCfg   config.InterbankConfig
+}
+
+func NewInterbankPaymentService(paymentRepo *repository.InterbankPaymentRepository, accountService *AccountService,
+	directory *banking.BICDirectory, gateway InterbankGateway, notifier Notifier, bankingCfg config.BankingConfig,
+	interbankCfg config.InterbankConfig) *InterbankPaymentService {
+	return &InterbankPaymentService{
+		paymentRepo:    paymentRepo,
+		accountService: accountService,
+		directory:      directory,
+		gateway:        gateway,
+		notifier:       notifier,
+		bankingCfg:     bankingCfg,
+		interbankCfg:   interbankCfg,
+	}
+}
+
+func (s *InterbankPaymentService) CreatePayment(ctx context.Context, userID int64, req types.CreateInterbankPaymentReq) (*models.InterbankPayment, error) {
+	fromAcc, err := s.accountService.GetAccount(ctx, req.FromAccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if fromAcc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+	if fromAcc.Currency != models.RUB {
+		return nil, ErrCurrencyMismatch
+	}
+
+	payment, err := s.validate(req)
+	if err != nil {
+		return nil, err
+	}
+	payment.UserID = userID
+	payment.FromAccountID = fromAcc.ID
+	payment.Status = models.InterbankPaymentPending
+
+	clearingAcc, err := s.clearingAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Платеж получателю %s в %s: %s", payment.RecipientName, payment.RecipientBankName,
+		payment.Purpose)
+	debit, err := s.accountService.payToBank(ctx, fromAcc.ID, clearingAcc, payment.Amount, &description)
+	if err != nil {
+		return nil, err
+	}
+	payment.DebitTransactionID = debit.ID
+
+	created, err := s.paymentRepo.Create(ctx, payment)
+	if err != nil {
+		refundDescription := "Возврат платежа в другой банк: " + description
+		_, refundErr := s.accountService.transferFromBank(ctx, clearingAcc, fromAcc.ID, payment.Amount, &refundDescription)
+		if refundErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("возврат списания: %w", refundErr))
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *InterbankPaymentService) clearingAccount(ctx context.Context) (*models.Account, error) {
+	acc, err := s.accountService.GetAccountByNumber(ctx, s.interbankCfg.ClearingAccount)
+	if err != nil {
+		return nil, fmt.Errorf("транзитный счет платежей в другие банки: %w", err)
+	}
+	return acc, nil
+}
+
+// validate проверяет реквизиты поручения по справочнику БИК и контрольным
+// суммам и собирает из них поручение без данных плательщика
+func (s *InterbankPaymentService) validate(req types.CreateInterbankPaymentReq) (*models.InterbankPayment, error) {
+	var errs []types.FieldError
+	fail := func(field string, err error) {
+		errs = append(errs, types.FieldError{Field: field, Error: err.Error()})
+	}
+
+	p := &models.InterbankPayment{
+		Amount:           req.Amount,
+		RecipientName:    strings.TrimSpace(req.RecipientName),
+		RecipientBIC:     strings.TrimSpace(req.RecipientBIC),
+		RecipientAccount: strings.TrimSpace(req.RecipientAccount),
+	}
+
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		fail("amount", ErrNegativeAmount)
+	} else if req.Amount.Exponent() < -2 {
+		fail("amount", errors.New("сумма не может содержать больше двух знаков после запятой"))
+	}
+
+	switch n := utf8.RuneCountInString(p.RecipientName); {
+	case n == 0:
+		fail("recipient_name", errors.New("не указан получатель"))
+	case n > maxRecipientNameLength:
+		fail("recipient_name", fmt.Errorf("наименование получателя длиннее %d символов", maxRecipientNameLength))
+	}
+
+	inn := strings.TrimSpace(req.RecipientINN)
+	if inn != "" {
+		if err := banking.ValidateINN(inn); err != nil {
+			fail("recipient_inn", err)
+		}
+		p.RecipientINN = &inn
+	}
+
+	kpp := strings.ToUpper(strings.TrimSpace(req.RecipientKPP))
+	if kpp != "" {
+		switch {
+		case !banking.IsLegalEntityINN(inn):
+			fail("recipient_kpp", errors.New("КПП указывается только для организации с 10-значным ИНН"))
+		default:
+			if err := banking.ValidateKPP(kpp); err != nil {
+				fail("recipient_kpp", err)
+			}
+		}
+		p.RecipientKPP = &kpp
+	}
+
+	if bank, err := s.lookupRecipientBank(p.RecipientBIC); err != nil {
+		fail("recipient_bic", err)
+	} else {
+		p.RecipientBankName = bank.Name
+
+		corrAccount, err := correspondentAccount(bank, strings.TrimSpace(req.CorrespondentAccount))
+		if err != nil {
+			fail("correspondent_account", err)
+		}
+		if corrAccount != "" {
+			p.CorrespondentAccount = &corrAccount
+		}
+
+		if err := banking.ValidateAccountNumber(bank.BIC, p.RecipientAccount); err != nil {
+			fail("recipient_account", err)
+		}
+	}
+
+	purpose := strings.TrimSpace(req.Purpose)
+	if purpose == "" {
+		fail("purpose", errors.New("не указано назначение платежа"))
+	}
+	if req.VATRate != nil {
+		if !slices.Contains(s.interbankCfg.VATRates, *req.VATRate) {
+			fail("vat_rate", fmt.Errorf("недопустимая ставка НДС %d%%", *req.VATRate))
+		} else {
+			vat := vatAmount(req.Amount, *req.VATRate)
+			p.VATRate = req.VATRate
+			p.VATAmount = &vat
+			purpose += fmt.Sprintf("\nВ т.ч. НДС %d%% - %s руб.", *req.VATRate, vat.StringFixed(2))
+		}
+	} else {
+		purpose += "\nНДС не облагается"
+	}
+	if utf8.RuneCountInString(purpose) > maxPurposeLength {
+		fail("purpose", fmt.Errorf("назначение платежа вместе с НДС длиннее %d символов", maxPurposeLength))
+	}
+	p.Purpose = purpose
+
+	if len(errs) > 0 {
+		return nil, &InterbankPaymentValidationError{Errors: errs}
+	}
+	return p, nil
+}
+
+func (s *InterbankPaymentService) lookupRecipientBank(bic string) (*banking.Bank, error) {
+	if err := banking.ValidateBIC(bic); err != nil {
+		return nil, err
+	}
+	if bic == s.bankingCfg.BIC {
+		return nil, errors.New("получатель в этом же банке, используйте перевод по номеру счета")
+	}
+
+	bank, ok := s.directory.Lookup(bic)
+	if !ok {
+		return nil, ErrBankNotFound
+	}
+	if bank.Restricted {
+		return nil, errors.New("банк получателя не принимает переводы")
+	}
+	return bank, nil
+}
+
+// correspondentAccount проверяет корсчет банка получателя. Если он не указан,
+// а у банка он один, подставляется корсчет из справочника
+func correspondentAccount(bank *banking.Bank, account string) (string, error) {
+	if account == "" {
+		switch len(bank.CorrespondentAccounts) {
+		case 0:
+			return "", nil
+		case 1:
+			return bank.CorrespondentAccounts[0], nil
+		default:
+			return "", errors.New("у банка несколько корреспондентских счетов, укажите нужный")
+		}
+	}
+
+	if err := banking.ValidateCorrespondentAccount(bank.BIC, account); err != nil {
+		return account, err
+	}
+	if !bank.HasCorrespondentAccount(account) {
+		return account, errors.New("корреспондентский счет не принадлежит банку получателя")
+	}
+	return account, nil
+}
+
+// vatAmount выделяет НДС из суммы, включающей налог
+func vatAmount(amount decimal.Decimal, rate int) decimal.Decimal {
+	r := decimal.NewFromInt(int64(rate))
+	return amount.Mul(r).Div(r.Add(decimal.NewFromInt(100))).Round(2)
+}
+
+// SubmitPending отправляет ожидающие поручения в шлюз. Отказ платежной
+// системы или исчерпание попыток отклоняет поручение с возвратом суммы,
+// временная ошибка оставляет его до следующего запуска
+func (s *InterbankPaymentService) SubmitPending(ctx context.Context) error {
+	payments, err := s.paymentRepo.ClaimPending(ctx, time.Now(), s.interbankCfg.Lease)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, p := range payments {
+		if err := s.submit(ctx, p); err != nil {
+			errs = append(errs, fmt.Errorf("поручение %d: %w", p.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *InterbankPaymentService) submit(ctx context.Context, p *models.InterbankPayment) error {
+	reference, err := s.gateway.Submit(ctx, p)
+	if err == nil {
+		if err := s.paymentRepo.MarkSent(ctx, p.ID, reference); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Платеж на %s руб. получателю %s отправлен в %s", p.Amount.StringFixed(2),
+			p.RecipientName, p.RecipientBankName)
+		return s.notifier.Notify(ctx, p.UserID, "Платеж отправлен", message)
+	}
+
+	if !errors.Is(err, ErrGatewayRejected) && p.Attempts < s.interbankCfg.MaxAttempts {
+		return errors.Join(err, s.paymentRepo.Release(ctx, p.ID))
+	}
+	return s.reject(ctx, p, err.Error())
+}
+
+func (s *InterbankPaymentService) reject(ctx context.Context, p *models.InterbankPayment, reason string) error {
+	clearingAcc, err := s.clearingAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Возврат проходит и на замороженный счет
+	description := fmt.Sprintf("Возврат платежа №%d получателю %s: %s", p.ID, p.RecipientName, reason)
+	refund, err := s.accountService.transferFromBank(ctx, clearingAcc, p.FromAccountID, p.Amount, &description)
+	if err != nil {
+		return fmt.Errorf("возврат суммы отклоненного поручения: %w", err)
+	}
+	if err := s.paymentRepo.MarkRejected(ctx, p.ID, reason, refund.ID); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Платеж на %s руб. получателю %s отклонен: %s. Сумма возвращена на счет",
+		p.Amount.StringFixed(2), p.RecipientName, reason)
+	return s.notifier.Notify(ctx, p.UserID, "Платеж отклонен", message)
+}
+
+func (s *InterbankPaymentService) GetPayment(ctx context.Context, paymentID int64, userID int64) (*models.InterbankPayment, error) {
+	p, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInterbankPaymentNotFound
+		}
+		return nil, err
+	}
+	if p.UserID != userID {
+		return nil, ErrInterbankPaymentNotFound
+	}
+	return p, nil
+}
+
+func (s *InterbankPaymentService) GetUserPayments(ctx context.Context, userID int64) ([]*models.InterbankPayment, error) {
+	return s.paymentRepo.GetByUserID(ctx, userID)
+}
+
+// LookupBank возвращает банк из справочника БИК
+func (s *InterbankPaymentService) LookupBank(bic string) (*banking.Bank, error) {
+	if err := banking.ValidateBIC(bic); err != nil {
+		return nil, err
+	}
+	bank, ok := s.directory.Lookup(bic)
+	if !ok {
+		return nil, ErrBankNotFound
+	}
+	return bank, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownMerchant  = errors.New("неизвестный мерчант")
+	ErrInvalidSignature = errors.New("неверная подпись запроса")
+	ErrRequestExpired   = errors.New("запрос вне допустимого окна времени")
+	ErrNonceReused      = errors.New("повторное использование nonce")
+)
+
+type MerchantRegistry interface {
+	GetSecret(ctx context.Context, merchantID int64) ([]byte, error)
+}
+
+type NonceStore interface {
+	// Use отмечает nonce использованным и возвращает false, если он уже встречался
+	Use(key string, expiresAt time.Time) bool
+}
+
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *MemoryNonceStore) Use(key string, expiresAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, exp := range s.nonces {
+		if now.After(exp) {
+			delete(s.nonces, k)
+		}
+	}
+
+	if _, ok := s.nonces[key]; ok {
+		return false
+	}
+	s.nonces[key] = expiresAt
+	return true
+}
+
+type MerchantAuthService struct {
+	registry MerchantRegistry
+	nonces   NonceStore
+	window   time.Duration
+}
+
+func NewMerchantAuthService(registry MerchantRegistry, nonces NonceStore, window time.Duration) *MerchantAuthService {
+	return &MerchantAuthService{
+		registry: registry,
+		nonces:   nonces,
+		window:   window,
+	}
+}
+
+// CanonicalBody приводит JSON тело запроса к каноническому виду:
+// ключи отсортированы, без пробелов, числа без изменения записи
+func CanonicalBody(body []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return []byte{}, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// SignPayload считает HMAC-SHA256 от строки "timestamp\nnonce\nbody"
+func SignPayload(secret []byte, timestamp, nonce string, canonicalBody []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(timestamp))
+	h.Write([]byte("\n"))
+	h.Write([]byte(nonce))
+	h.Write([]byte("\n"))
+	h.Write(canonicalBody)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *MerchantAuthService) VerifySignature(ctx context.Context, merchantID int64, timestamp, nonce, signature string, body []byte) error {
+	if timestamp == "" || nonce == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	requestTime := time.Unix(unixTime, 0)
+	if diff := time.Since(requestTime); diff > s.window || diff < -s.window {
+		return ErrRequestExpired
+	}
+
+	secret, err := s.registry.GetSecret(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	canonical, err := CanonicalBody(body)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected, err := hex.DecodeString(SignPayload(secret, timestamp, nonce, canonical))
+	if err != nil {
+		return err
+	}
+
+	provided, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, provided) {
+		return ErrInvalidSignature
+	}
+
+	// Nonce проверяем только после подписи, чтобы чужие запросы не занимали окно
+	nonceKey := strconv.FormatInt(merchantID, 10) + ":" + nonce
+	if !s.nonces.Use(nonceKey, requestTime.Add(s.window)) {
+		return ErrNonceReused
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrMerchantNotFound          = errors.New("мерчант не найден")
+	ErrInvalidMerchantName       = errors.New("название мерчанта не указано")
+	ErrInvalidMCC                = errors.New("MCC должен состоять из 4 цифр")
+	ErrSettlementAccountNotFound = errors.New("расчетный счет мерчанта не найден")
+	ErrInvalidScope              = errors.New("неизвестная область доступа API-ключа")
+	ErrAPIKeyNotFound            = errors.New("API-ключ не найден")
+	ErrInvalidAPIKey             = errors.New("неверный API-ключ")
+	ErrAPIKeyScope               = errors.New("API-ключ не дает доступа к операции")
+)
+
+const (
+	apiKeyPrefix       = "sk_"
+	apiKeyPrefixBytes  = 6
+	apiKeySecretBytes  = 32
+	signingSecretBytes = 32
+)
+
+var (
+	mccPattern     = regexp.MustCompile(`^\d{4}$`)
+	merchantScopes = []models.MerchantScope{
+		models.ScopePaymentsWrite,
+		models.ScopePaymentsRead,
+		models.ScopeSettlementsRead,
+	}
+)
+
+// MerchantService ведет мерчантов, их секреты подписи и API-ключи.
+// Также служит реестром секретов для MerchantAuthService
+type MerchantService struct {
+	merchantRepo   *repository.MerchantRepository
+	accountService *AccountService
+	keyHashKey     []byte
+	pgpKey         string
+	merchantCfg    config.MerchantConfig
+}
+
+func NewMerchantService(merchantRepo *repository.MerchantRepository, accountService *AccountService,
+	cryptoCfg config.CryptoConfig, merchantCfg config.MerchantConfig) *MerchantService {
+	return &MerchantService{
+		merchantRepo:   merchantRepo,
+		accountService: accountService,
+		keyHashKey:     []byte(cryptoCfg.HMACKey),
+		pgpKey:         cryptoCfg.PGPKey,
+		merchantCfg:    merchantCfg,
+	}
+}
+
+// CreateMerchant регистрирует мерчанта и возвращает его секрет подписи.
+// Секрет показывается один раз
+func (s *MerchantService) CreateMerchant(ctx context.Context, req types.CreateMerchantReq) (*models.Merchant, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, "", ErrInvalidMerchantName
+	}
+
+	if !mccPattern.MatchString(req.MCC) {
+		return nil, "", ErrInvalidMCC
+	}
+
+	if _, err := s.accountService.GetAccount(ctx, req.SettlementAccountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", ErrSettlementAccountNotFound
+		}
+		return nil, "", err
+	}
+
+	secret, err := randomHex(signingSecretBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка генерации секрета подписи: %w", err)
+	}
+
+	merchant, err := s.merchantRepo.Create(ctx, &models.Merchant{
+		Name:                name,
+		MCC:                 req.MCC,
+		SettlementAccountID: req.SettlementAccountID,
+	}, secret, s.pgpKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return merchant, secret, nil
+}
+
+func (s *MerchantService) GetMerchant(ctx context.Context, merchantID int64) (*models.Merchant, error) {
+	merchant, err := s.merchantRepo.GetByID(ctx, merchantID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return merchant, nil
+}
+
+func (s *MerchantService) GetMerchants(ctx context.Context) ([]*models.Merchant, error) {
+	return s.merchantRepo.GetAll(ctx)
+}
+
+// GetSecret возвращает секрет подписи мерчанта (реализация MerchantRegistry)
+func (s *MerchantService) GetSecret(ctx context.Context, merchantID int64) ([]byte, error) {
+	secret, err := s.merchantRepo.GetSigningSecret(ctx, merchantID, s.pgpKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUnknownMerchant
+		}
+		return nil, err
+	}
+	return []byte(secret), nil
+}
+
+// RotateSigningSecret выдает мерчанту новый секрет подписи. Старый перестает
+// действовать сразу
+func (s *MerchantService) RotateSigningSecret(ctx context.Context, merchantID int64) (string, error) {
+	secret, err := randomHex(signingSecretBytes)
+	if err != nil {
+		return "", fmt.Errorf("ошибка генерации секрета подписи: %w", err)
+	}
+
+	if err := s.merchantRepo.UpdateSigningSecret(ctx, merchantID, secret, s.pgpKey); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrMerchantNotFound
+		}
+		return "", err
+	}
+	return secret, nil
+}
+
+// CreateAPIKey выпускает API-ключ с указанными областями доступа.
+// Ключ целиком возвращается только здесь, в базе хранится его хеш
+func (s *MerchantService) CreateAPIKey(ctx context.Context, merchantID int64, scopes []models.MerchantScope) (*models.MerchantAPIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(merchantScopes, scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	if _, err := s.GetMerchant(ctx, merchantID); err != nil {
+		return nil, "", err
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка генерации API-ключа: %w", err)
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка генерации API-ключа: %w", err)
+	}
+
+	key, err := s.merchantRepo.CreateAPIKey(ctx, &models.MerchantAPIKey{
+		MerchantID: merchantID,
+		Prefix:     prefix,
+		KeyHash:    s.hashAPIKey(prefix, secret),
+		Scopes:     scopes,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, apiKeyPrefix + prefix + "_" + secret, nil
+}
+
+// RotateAPIKey выпускает замену ключа с теми же областями доступа.
+// Старый ключ действует еще KeyRotationGrace, чтобы мерчант успел переключиться
+func (s *MerchantService) RotateAPIKey(ctx context.Context, merchantID int64, keyID int64) (*models.MerchantAPIKey, string, error) {
+	old, err := s.merchantRepo.GetAPIKey(ctx, merchantID, keyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", ErrAPIKeyNotFound
+		}
+		return nil, "", err
+	}
+
+	if !s.isAPIKeyValid(old) {
+		return nil, "", ErrAPIKeyNotFound
+	}
+
+	key, raw, err := s.CreateAPIKey(ctx, merchantID, old.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.merchantRepo.ExpireAPIKey(ctx, old.ID, time.Now().Add(s.merchantCfg.KeyRotationGrace)); err != nil {
+		return nil, "", err
+	}
+
+	return key, raw, nil
+}
+
+func (s *MerchantService) RevokeAPIKey(ctx context.Context, merchantID int64, keyID int64) error {
+	ok, err := s.merchantRepo.RevokeAPIKey(ctx, merchantID, keyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *MerchantService) GetAPIKeys(ctx context.Context, merchantID int64) ([]*models.MerchantAPIKey, error) {
+	if _, err := s.GetMerchant(ctx, merchantID); err != nil {
+		return nil, err
+	}
+	return s.merchantRepo.GetAPIKeys(ctx, merchantID)
+}
+
+// AuthenticateAPIKey проверяет ключ вида sk_<prefix>_<secret> и наличие
+// у него области доступа scope. Возвращает ID мерчанта
+func (s *MerchantService) AuthenticateAPIKey(ctx context.Context, rawKey string, scope models.MerchantScope) (int64, error) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(rawKey, apiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(rawKey, apiKeyPrefix) || prefix == "" || secret == "" {
+		return 0, ErrInvalidAPIKey
+	}
+
+	key, err := s.merchantRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrInvalidAPIKey
+		}
+		return 0, err
+	}
+
+	if !hmac.Equal([]byte(s.hashAPIKey(prefix, secret)), []byte(key.KeyHash)) || !s.isAPIKeyValid(key) {
+		return 0, ErrInvalidAPIKey
+	}
+
+	if !slices.Contains(key.Scopes, scope) {
+		return 0, ErrAPIKeyScope
+	}
+
+	if err := s.merchantRepo.TouchAPIKey(ctx, key.ID); err != nil {
+		return 0, err
+	}
+
+	return key.MerchantID, nil
+}
+
+func (s *MerchantService) isAPIKeyValid(key *models.MerchantAPIKey) bool {
+	if key.RevokedAt != nil {
+		return false
+	}
+	return key.ExpiresAt == nil || time.Now().Before(*key.ExpiresAt)
+}
+
+func (s *MerchantService) hashAPIKey(prefix, secret string) string {
+	h := hmac.New(sha256.New, s.keyHashKey)
+	h.Write([]byte(prefix + ":" + secret))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier отправляет уведомления пользователю (email, SMS, push)
+type Notifier interface {
+	Notify(ctx context.Context, userID int64, subject, message string) error
+}
+
+// LogNotifier пишет уведомления в лог вместо реальной отправки. Одноразовые
+// коды в логе маскируются
+type LogNotifier struct {
+	logger *logrus.Logger
+}
+
+func NewLogNotifier(logger *logrus.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, userID int64, subject, message string) error {
+	n.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"subject": subject,
+	}).Info(maskCodes(message))
+	return nil
+}
+
+// SMSSender отправляет SMS на номер телефона через SMS-шлюз
+type SMSSender interface {
+	SendSMS(ctx context.Context, phone, message string) error
+}
+
+// LogSMSSender пишет SMS в лог вместо реальной отправки. Одноразовые коды в
+// логе маскируются
+type LogSMSSender struct {
+	logger *logrus.Logger
+}
+
+func NewLogSMSSender(logger *logrus.Logger) *LogSMSSender {
+	return &LogSMSSender{logger: logger}
+}
+
+func (s *LogSMSSender) SendSMS(ctx context.Context, phone, message string) error {
+	s.logger.WithField("phone", phone).Info(maskCodes(message))
+	return nil
+}
+
+// Одноразовый код в сообщении идет после двоеточия в фразе, начинающейся со
+// слова "код": "Код подтверждения платежа 15 на сумму 100.00 RUB: 123456"
+var codePattern = regexp.MustCompile(`(?i)(код[^:\n]*:\s*)\d+`)
+
+// maskCodes заменяет одноразовые коды в сообщении звездочками
+func maskCodes(message string) string {
+	return codePattern.ReplaceAllStringFunc(message, func(m string) string {
+		sub := codePattern.FindStringSubmatch(m)
+		return sub[1] + "******"
+	})
+}
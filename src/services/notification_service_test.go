@@ -0,0 +1,24 @@
+package services
+
+import "testing"
+
+func TestMaskCodes(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"Код подтверждения платежа 15 на сумму 1500.00 RUB: 123456", "Код подтверждения платежа 15 на сумму 1500.00 RUB: ******"},
+		{"Код подтверждения номера +79991234567: 4821", "Код подтверждения номера +79991234567: ******"},
+		{"Ваш код: 0042", "Ваш код: ******"},
+		{"Перевод 1500.00 RUB зачислен на счет 40817810500000000001", "Перевод 1500.00 RUB зачислен на счет 40817810500000000001"},
+		{"Срок действия карты 12 истекает 2026-11-01", "Срок действия карты 12 истекает 2026-11-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := maskCodes(tt.message); got != tt.want {
+				t.Errorf("maskCodes = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+)
+
+var (
+	ErrInvalidOverdraftLimit  = errors.New("неверный лимит овердрафта")
+	ErrOverdraftLimitTooLarge = errors.New("лимит овердрафта превышает максимальный")
+	ErrOverdraftCurrency      = errors.New("овердрафт доступен только для рублевых счетов")
+)
+
+// OverdraftService ведет овердрафты по текущим счетам: лимит задает
+// администратор, за каждый день с отрицательным остатком на конец дня
+// со счета списываются проценты в пользу счета доходов банка
+type OverdraftService struct {
+	overdraftRepo  *repository.OverdraftRepository
+	accountRepo    *repository.AccountRepository
+	accountService *AccountService
+	notifier       Notifier
+	overdraftCfg   config.OverdraftConfig
+	mu             sync.Mutex
+}
+
+func NewOverdraftService(overdraftRepo *repository.OverdraftRepository, accountRepo *repository.AccountRepository,
+	accountService *AccountService, notifier Notifier, overdraftCfg config.OverdraftConfig) *OverdraftService {
+	return &OverdraftService{
+		overdraftRepo:  overdraftRepo,
+		accountRepo:    accountRepo,
+		accountService: accountService,
+		notifier:       notifier,
+		overdraftCfg:   overdraftCfg,
+	}
+}
+
+// SetLimit задает лимит овердрафта по счету, 0 отключает овердрафт. Уже
+// использованный овердрафт при снижении лимита не списывается
+func (s *OverdraftService) SetLimit(ctx context.Context, accountID int64, limit decimal.Decimal) (*models.Account, error) {
+	if limit.IsNegative() || !limit.Equal(limit.Round(2)) {
+		return nil, ErrInvalidOverdraftLimit
+	}
+	if limit.GreaterThan(s.overdraftCfg.MaxLimit) {
+		return nil, fmt.Errorf("%w: %s", ErrOverdraftLimitTooLarge, s.overdraftCfg.MaxLimit)
+	}
+
+	acc, err := s.accountService.account(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Currency != models.RUB {
+		return nil, ErrOverdraftCurrency
+	}
+
+	// Проценты начисляются начиная с сегодняшнего дня
+	chargedThrough := s.today().AddDate(0, 0, -1)
+	if err := s.accountRepo.SetOverdraftLimit(ctx, accountID, limit, chargedThrough); err != nil {
+		if errors.Is(err, repository.ErrAccountStatusConflict) {
+			return nil, fmt.Errorf("%w: счет %d", ErrAccountClosed, accountID)
+		}
+		return nil, err
+	}
+	return s.accountService.account(ctx, accountID)
+}
+
+// GetCharges возвращает начисленные по счету проценты за овердрафт
+func (s *OverdraftService) GetCharges(ctx context.Context, accountID int64, userID int64) ([]*models.OverdraftCharge, error) {
+	if _, err := s.accountService.userAccount(ctx, accountID, userID); err != nil {
+		return nil, err
+	}
+
+	charges, err := s.overdraftRepo.GetByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if charges == nil {
+		charges = []*models.OverdraftCharge{}
+	}
+	return charges, nil
+}
+
+// ProcessOverdrafts уведомляет о выходе счетов в минус, начисляет проценты
+// за прошедшие дни с отрицательным остатком и списывает их. Повторный запуск
+// за тот же день ничего не начисляет
+func (s *OverdraftService) ProcessOverdrafts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	if err := s.notifyStarted(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.accountRepo.EndRepaidOverdrafts(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	yesterday := s.today().AddDate(0, 0, -1)
+	accounts, err := s.accountRepo.GetOverdraftAccountsDue(ctx, yesterday)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, acc := range accounts {
+		if err := s.charge(ctx, acc, yesterday); err != nil {
+			errs = append(errs, fmt.Errorf("счет %d: %w", acc.ID, err))
+		}
+	}
+
+	// Списываем и новые начисления, и оставшиеся после прошлых сбоев
+	if err := s.postCharges(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *OverdraftService) notifyStarted(ctx context.Context) error {
+	accounts, err := s.accountRepo.StartOverdrafts(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, acc := range accounts {
+		message := fmt.Sprintf("По счету %s использован овердрафт: баланс %s %s при лимите %s %s. "+
+			"На отрицательный остаток начисляются проценты по ставке %s%% годовых",
+			acc.Number, acc.Balance.StringFixed(2), acc.Currency, acc.OverdraftLimit.StringFixed(2), acc.Currency,
+			s.overdraftCfg.AnnualRate)
+		if err := s.notifier.Notify(ctx, acc.UserID, "Использован овердрафт", message); err != nil {
+			errs = append(errs, fmt.Errorf("счет %d: %w", acc.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// charge начисляет проценты за дни после OverdraftChargedThrough по through
+// включительно на отрицательный остаток на конец каждого дня
+func (s *OverdraftService) charge(ctx context.Context, acc *models.Account, through time.Time) error {
+	for day := acc.OverdraftChargedThrough.AddDate(0, 0, 1); !day.After(through); day = day.AddDate(0, 0, 1) {
+		balance, err := s.accountService.BalanceAt(ctx, acc, s.endOfDay(day))
+		if err != nil {
+			return err
+		}
+
+		var charge *models.OverdraftCharge
+		if amount := dailyInterest(balance.Neg(), s.overdraftCfg.AnnualRate, day).Round(2); amount.IsPositive() {
+			charge = &models.OverdraftCharge{
+				Balance:    balance,
+				AnnualRate: s.overdraftCfg.AnnualRate,
+				Amount:     amount,
+			}
+		}
+		if err := s.overdraftRepo.CloseDay(ctx, acc.ID, day, charge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *OverdraftService) postCharges(ctx context.Context) error {
+	charges, err := s.overdraftRepo.GetUnposted(ctx)
+	if err != nil {
+		return err
+	}
+	if len(charges) == 0 {
+		return nil
+	}
+
+	incomeAcc, err := s.accountService.GetAccountByNumber(ctx, s.overdraftCfg.InterestIncomeAccount)
+	if err != nil {
+		return fmt.Errorf("счет доходов по овердрафтам: %w", err)
+	}
+
+	var errs []error
+	for _, c := range charges {
+		if err := s.postCharge(ctx, incomeAcc, c); err != nil && !errors.Is(err, repository.ErrOverdraftChargePosted) {
+			errs = append(errs, fmt.Errorf("начисление %d: %w", c.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *OverdraftService) postCharge(ctx context.Context, incomeAcc *models.Account, c *models.OverdraftCharge) error {
+	acc, err := s.accountService.account(ctx, c.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.overdraftRepo.Claim(ctx, c.ID); err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Проценты за пользование овердрафтом за %s", c.ChargeDate.Format("02.01.2006"))
+	tx, err := s.accountService.transferToBank(ctx, acc, incomeAcc, c.Amount, &description)
+	if err != nil {
+		if transferRejected(err) {
+			if releaseErr := s.overdraftRepo.Release(ctx, c.ID); releaseErr != nil {
+				return errors.Join(err, releaseErr)
+			}
+		}
+		return err
+	}
+	return s.overdraftRepo.SetTransactionID(ctx, c.ID, tx.ID)
+}
+
+// today возвращает текущую дату операционного дня как полночь UTC
+func (s *OverdraftService) today() time.Time {
+	now := time.Now().In(s.overdraftCfg.Location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// endOfDay возвращает момент окончания дня date по часовому поясу банка
+func (s *OverdraftService) endOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, s.overdraftCfg.Location)
+}
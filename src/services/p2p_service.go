@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrRecipientRequired  = errors.New("укажите email или телефон получателя")
+	ErrRecipientNotFound  = errors.New("получатель не найден")
+	ErrRecipientNoAccount = errors.New("у получателя нет счета в валюте перевода")
+	ErrSelfTransfer       = errors.New("для перевода себе используйте перевод между своими счетами")
+)
+
+// P2PService переводит средства другому пользователю по email или
+// подтвержденному номеру телефона, не раскрывая номера его счетов
+type P2PService struct {
+	accountService *AccountService
+	accountRepo    *repository.AccountRepository
+	userRepo       repository.UserRepository
+}
+
+func NewP2PService(accountService *AccountService, accountRepo *repository.AccountRepository,
+	userRepo repository.UserRepository) *P2PService {
+	return &P2PService{
+		accountService: accountService,
+		accountRepo:    accountRepo,
+		userRepo:       userRepo,
+	}
+}
+
+// Transfer зачисляет перевод на основной счет получателя в валюте счета
+// списания и возвращает маскированное имя получателя для подтверждения
+func (s *P2PService) Transfer(ctx context.Context, userID int64, req types.P2PTransferReq) (*types.P2PTransferRes, error) {
+	fromAcc, err := s.accountService.GetAccountByID(ctx, req.FromAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, toAcc, err := s.resolveRecipientAccount(ctx, userID, req.Email, req.Phone, fromAcc.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountService.transfer(ctx, fromAcc, toAcc, req.Amount, nil); err != nil {
+		return nil, err
+	}
+
+	return &types.P2PTransferRes{
+		Status:        "success",
+		RecipientName: recipientDisplayName(recipient),
+		Amount:        req.Amount,
+		Currency:      fromAcc.Currency,
+	}, nil
+}
+
+// resolveRecipientAccount находит другого пользователя по email или телефону
+// и его основной счет в валюте currency
+func (s *P2PService) resolveRecipientAccount(ctx context.Context, userID int64, email, phone string,
+	currency models.Currency) (*models.User, *models.Account, error) {
+	recipient, err := s.resolveRecipient(ctx, email, phone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if recipient.ID == userID {
+		return nil, nil, ErrSelfTransfer
+	}
+
+	account, err := s.accountRepo.GetDefaultAccount(ctx, recipient.ID, currency)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrRecipientNoAccount
+		}
+		return nil, nil, err
+	}
+
+	return recipient, account, nil
+}
+
+func (s *P2PService) resolveRecipient(ctx context.Context, email, phone string) (*models.User, error) {
+	email = strings.TrimSpace(email)
+	if (email == "") == (phone == "") {
+		return nil, ErrRecipientRequired
+	}
+
+	var (
+		user *models.User
+		err  error
+	)
+	if email != "" {
+		user, err = s.userRepo.GetByEmail(ctx, email)
+	} else {
+		normalized, phoneErr := NormalizePhone(phone)
+		if phoneErr != nil {
+			return nil, phoneErr
+		}
+		user, err = s.userRepo.GetByVerifiedPhone(ctx, normalized)
+	}
+
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// recipientDisplayName маскирует имя получателя, а если имя не заполнено - email
+func recipientDisplayName(user *models.User) string {
+	if name := MaskName(user.FirstName, user.LastName); name != "" {
+		return name
+	}
+
+	local, domain, ok := strings.Cut(user.Email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return string([]rune(local)[0]) + "***@" + domain
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidPaymentRequest    = errors.New("неверные параметры запроса на оплату")
+	ErrPaymentRequestNotFound   = errors.New("запрос на оплату не найден")
+	ErrPaymentRequestNotPending = errors.New("запрос уже оплачен, отклонен или истек")
+)
+
+// Максимальная длина комментария и причины отказа в запросе на оплату
+const maxPaymentRequestTextLength = 200
+
+// PaymentRequestService выставляет запросы денег другим пользователям.
+// Плательщик оплачивает запрос переводом на счет запросившего или
+// отклоняет его, запросивший получает уведомление о результате
+type PaymentRequestService struct {
+	requestRepo    *repository.PaymentRequestRepository
+	accountService *AccountService
+	p2pService     *P2PService
+	userRepo       repository.UserRepository
+	notifier       Notifier
+	requestCfg     config.PaymentRequestConfig
+}
+
+func NewPaymentRequestService(requestRepo *repository.PaymentRequestRepository, accountService *AccountService,
+	p2pService *P2PService, userRepo repository.UserRepository, notifier Notifier,
+	requestCfg config.PaymentRequestConfig) *PaymentRequestService {
+	return &PaymentRequestService{
+		requestRepo:    requestRepo,
+		accountService: accountService,
+		p2pService:     p2pService,
+		userRepo:       userRepo,
+		notifier:       notifier,
+		requestCfg:     requestCfg,
+	}
+}
+
+func (s *PaymentRequestService) CreateRequest(ctx context.Context, userID int64, req types.CreatePaymentRequestReq) (*types.PaymentRequestRes, error) {
+	toAcc, err := s.accountService.GetAccountByID(ctx, req.ToAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+	if req.Amount.Exponent() < -2 {
+		return nil, fmt.Errorf("%w: в сумме больше двух знаков после запятой", ErrInvalidPaymentRequest)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.requestCfg.DefaultTTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("%w: срок действия уже истек", ErrInvalidPaymentRequest)
+		}
+		if req.ExpiresAt.Sub(now) > s.requestCfg.MaxTTL {
+			return nil, fmt.Errorf("%w: срок действия больше %d дней", ErrInvalidPaymentRequest,
+				int(s.requestCfg.MaxTTL.Hours()/24))
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	payer, err := s.p2pService.resolveRecipient(ctx, req.Email, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if payer.ID == userID {
+		return nil, fmt.Errorf("%w: нельзя запросить деньги у себя", ErrInvalidPaymentRequest)
+	}
+
+	comment, err := paymentRequestText(req.Comment, "комментарий")
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.requestRepo.Create(ctx, &models.PaymentRequest{
+		RequesterID: userID,
+		PayerID:     payer.ID,
+		ToAccountID: toAcc.ID,
+		Amount:      req.Amount,
+		Currency:    toAcc.Currency,
+		Comment:     comment,
+		Status:      models.PaymentRequestPending,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requester, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Запрос на перевод %s %s от %s", created.Amount.StringFixed(2), created.Currency,
+		recipientDisplayName(requester))
+	if comment != nil {
+		message += ": " + *comment
+	}
+	if err := s.notifier.Notify(ctx, payer.ID, "Запрос на оплату", message); err != nil {
+		return nil, err
+	}
+
+	return &types.PaymentRequestRes{Request: created, CounterpartyName: recipientDisplayName(payer)}, nil
+}
+
+// GetIncoming возвращает запросы, выставленные пользователю
+func (s *PaymentRequestService) GetIncoming(ctx context.Context, userID int64) ([]types.PaymentRequestRes, error) {
+	requests, err := s.requestRepo.GetIncoming(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withCounterparties(ctx, userID, requests)
+}
+
+// GetOutgoing возвращает запросы, выставленные пользователем
+func (s *PaymentRequestService) GetOutgoing(ctx context.Context, userID int64) ([]types.PaymentRequestRes, error) {
+	requests, err := s.requestRepo.GetOutgoing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withCounterparties(ctx, userID, requests)
+}
+
+// GetRequest возвращает запрос любой из его сторон
+func (s *PaymentRequestService) GetRequest(ctx context.Context, requestID int64, userID int64) (*types.PaymentRequestRes, error) {
+	request, err := s.get(ctx, requestID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.withCounterparties(ctx, userID, []*models.PaymentRequest{request})
+	if err != nil {
+		return nil, err
+	}
+	return &res[0], nil
+}
+
+// Pay оплачивает запрос переводом со счета плательщика. Запрос закрывается
+// до перевода, чтобы его нельзя было оплатить дважды, и возвращается к
+// оплате, только если перевод отклонен до движения средств
+func (s *PaymentRequestService) Pay(ctx context.Context, requestID int64, userID int64, req types.PayPaymentRequestReq) (*types.PaymentRequestRes, error) {
+	request, err := s.get(ctx, requestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if request.PayerID != userID {
+		return nil, ErrPaymentRequestNotFound
+	}
+
+	if err := s.requestRepo.MarkPaid(ctx, request.ID, req.FromAccountID); err != nil {
+		if errors.Is(err, repository.ErrPaymentRequestStatusConflict) {
+			return nil, ErrPaymentRequestNotPending
+		}
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Оплата запроса №%d", request.ID)
+	if request.Comment != nil {
+		description += ": " + *request.Comment
+	}
+	tx, err := s.accountService.transferFromUser(ctx, req.FromAccountID, userID, request.ToAccountID, request.Amount, &description)
+	if err != nil && transferRejected(err) {
+		if reopenErr := s.requestRepo.Reopen(ctx, request.ID); reopenErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("возврат запроса к оплате: %w", reopenErr))
+		}
+		return nil, err
+	}
+	// Средства могли уйти и при ошибке: запрос остается оплаченным
+	if tx != nil {
+		if err := s.requestRepo.SetTransactionID(ctx, request.ID, tx.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil && !errors.Is(err, ErrTransferIncomplete) {
+		return nil, err
+	}
+
+	payer, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Запрос на %s %s оплачен, плательщик %s", request.Amount.StringFixed(2),
+		request.Currency, recipientDisplayName(payer))
+	if err := s.notifier.Notify(ctx, request.RequesterID, "Запрос оплачен", message); err != nil {
+		return nil, err
+	}
+
+	return s.GetRequest(ctx, request.ID, userID)
+}
+
+// Decline отклоняет запрос по решению плательщика, причина необязательна
+func (s *PaymentRequestService) Decline(ctx context.Context, requestID int64, userID int64, req types.DeclinePaymentRequestReq) (*types.PaymentRequestRes, error) {
+	request, err := s.get(ctx, requestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if request.PayerID != userID {
+		return nil, ErrPaymentRequestNotFound
+	}
+
+	reason, err := paymentRequestText(req.Reason, "причина отказа")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.resolve(ctx, request, models.PaymentRequestDeclined, reason); err != nil {
+		return nil, err
+	}
+
+	payer, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Запрос на %s %s отклонен плательщиком %s", request.Amount.StringFixed(2),
+		request.Currency, recipientDisplayName(payer))
+	if reason != nil {
+		message += ": " + *reason
+	}
+	if err := s.notifier.Notify(ctx, request.RequesterID, "Запрос отклонен", message); err != nil {
+		return nil, err
+	}
+
+	return s.GetRequest(ctx, request.ID, userID)
+}
+
+// Cancel отзывает запрос до оплаты по решению запросившего
+func (s *PaymentRequestService) Cancel(ctx context.Context, requestID int64, userID int64) (*types.PaymentRequestRes, error) {
+	request, err := s.get(ctx, requestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if request.RequesterID != userID {
+		return nil, ErrPaymentRequestNotFound
+	}
+
+	if err := s.resolve(ctx, request, models.PaymentRequestCancelled, nil); err != nil {
+		return nil, err
+	}
+
+	requester, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Запрос на %s %s отозван, запросивший %s", request.Amount.StringFixed(2),
+		request.Currency, recipientDisplayName(requester))
+	if err := s.notifier.Notify(ctx, request.PayerID, "Запрос отозван", message); err != nil {
+		return nil, err
+	}
+
+	return s.GetRequest(ctx, request.ID, userID)
+}
+
+// ExpireRequests закрывает запросы с истекшим сроком и уведомляет
+// запросивших, что запрос не был оплачен
+func (s *PaymentRequestService) ExpireRequests(ctx context.Context) error {
+	expired, err := s.requestRepo.ExpireDue(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, request := range expired {
+		message := fmt.Sprintf("Запрос на %s %s не был оплачен и истек", request.Amount.StringFixed(2), request.Currency)
+		if err := s.notifier.Notify(ctx, request.RequesterID, "Запрос истек", message); err != nil {
+			errs = append(errs, fmt.Errorf("запрос %d: %w", request.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *PaymentRequestService) get(ctx context.Context, requestID int64, userID int64) (*models.PaymentRequest, error) {
+	request, err := s.requestRepo.GetByID(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentRequestNotFound
+		}
+		return nil, err
+	}
+	if request.RequesterID != userID && request.PayerID != userID {
+		return nil, ErrPaymentRequestNotFound
+	}
+	return request, nil
+}
+
+func (s *PaymentRequestService) resolve(ctx context.Context, request *models.PaymentRequest,
+	status models.PaymentRequestStatus, reason *string) error {
+	if err := s.requestRepo.Resolve(ctx, request.ID, status, reason); err != nil {
+		if errors.Is(err, repository.ErrPaymentRequestStatusConflict) {
+			return ErrPaymentRequestNotPending
+		}
+		return err
+	}
+	return nil
+}
+
+// withCounterparties дополняет запросы маскированными именами второй стороны
+func (s *PaymentRequestService) withCounterparties(ctx context.Context, userID int64,
+	requests []*models.PaymentRequest) ([]types.PaymentRequestRes, error) {
+	names := make(map[int64]string)
+	res := make([]types.PaymentRequestRes, 0, len(requests))
+	for _, request := range requests {
+		counterpartyID := request.PayerID
+		if counterpartyID == userID {
+			counterpartyID = request.RequesterID
+		}
+
+		name, ok := names[counterpartyID]
+		if !ok {
+			user, err := s.userRepo.GetByID(ctx, counterpartyID)
+			if err != nil {
+				return nil, err
+			}
+			name = recipientDisplayName(user)
+			names[counterpartyID] = name
+		}
+		res = append(res, types.PaymentRequestRes{Request: request, CounterpartyName: name})
+	}
+	return res, nil
+}
+
+// paymentRequestText обрезает пробелы и проверяет длину необязательного текста
+func paymentRequestText(text string, field string) (*string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(text) > maxPaymentRequestTextLength {
+		return nil, fmt.Errorf("%w: %s длиннее %d символов", ErrInvalidPaymentRequest, field, maxPaymentRequestTextLength)
+	}
+	return &text, nil
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidAmount             = errors.New("неверная сумма платежа")
+	ErrCardLimitExceeded         = errors.New("превышен лимит платежа по карте")
+	ErrCurrencyMismatch          = errors.New("валюта платежа не совпадает с валютой счета карты")
+	ErrPaymentNotFound           = errors.New("платеж не найден")
+	ErrPaymentNotPending         = errors.New("платеж не ожидает подтверждения")
+	ErrPaymentConfirmationFailed = errors.New("неверный код подтверждения")
+	ErrPaymentExpired            = errors.New("истек срок подтверждения платежа")
+	errConfirmationAttempts      = errors.New("превышено число попыток ввода кода")
+)
+
+type PaymentService struct {
+	cardService      *CardService
+	accountService   *AccountService
+	paymentRepo      *repository.PaymentRepository
+	confirmationRepo *repository.PaymentConfirmationRepository
+	riskChecker      RiskChecker
+	notifier         Notifier
+	codeKey          []byte
+	paymentCfg       config.PaymentConfig
+}
+
+func NewPaymentService(cardService *CardService, accountService *AccountService, paymentRepo *repository.PaymentRepository,
+	confirmationRepo *repository.PaymentConfirmationRepository, riskChecker RiskChecker, notifier Notifier,
+	cryptoCfg config.CryptoConfig, paymentCfg config.PaymentConfig) *PaymentService {
+	return &PaymentService{
+		cardService:      cardService,
+		accountService:   accountService,
+		paymentRepo:      paymentRepo,
+		confirmationRepo: confirmationRepo,
+		riskChecker:      riskChecker,
+		notifier:         notifier,
+		codeKey:          []byte(cryptoCfg.HMACKey),
+		paymentCfg:       paymentCfg,
+	}
+}
+
+// ProcessPayment сохраняет платеж мерчанта, проверяет карту и авторизует его.
+// Крупные и рискованные платежи ждут подтверждения кодом от держателя карты,
+// остальные сразу списываются со счета карты. Отклоненный платеж тоже
+// сохраняется и возвращается вместе с ошибкой
+func (s *PaymentService) ProcessPayment(ctx context.Context, merchantID int64, req types.PaymentReq) (*models.Payment, error) {
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrInvalidAmount
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = models.RUB
+	}
+
+	payment, err := s.paymentRepo.Create(ctx, &models.Payment{
+		MerchantID: merchantID,
+		CardID:     req.CardID,
+		Amount:     amount,
+		Currency:   currency,
+		Status:     models.PaymentCreated,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения платежа: %w", err)
+	}
+
+	card, err := s.authorize(ctx, payment, req)
+	if err != nil {
+		return s.decline(ctx, payment, err)
+	}
+
+	needsConfirmation, err := s.needsConfirmation(ctx, card, merchantID, amount)
+	if err != nil {
+		return s.decline(ctx, payment, err)
+	}
+	if needsConfirmation {
+		if err := s.requestConfirmation(ctx, payment, card); err != nil {
+			return s.decline(ctx, payment, err)
+		}
+		return payment, nil
+	}
+
+	if err := s.capture(ctx, payment, card); err != nil {
+		return s.decline(ctx, payment, err)
+	}
+
+	return payment, nil
+}
+
+// ConfirmPayment подтверждает платеж одноразовым кодом и списывает средства
+func (s *PaymentService) ConfirmPayment(ctx context.Context, paymentID int64, userID int64, code string) (*models.Payment, error) {
+	payment, card, err := s.getUserPayment(ctx, paymentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != models.PaymentPendingConfirmation {
+		return nil, ErrPaymentNotPending
+	}
+
+	confirmation, err := s.confirmationRepo.GetByPaymentID(ctx, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(confirmation.ExpiresAt) {
+		if err := s.expire(ctx, payment.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrPaymentExpired
+	}
+
+	if !hmac.Equal([]byte(s.hashCode(payment.ID, code)), []byte(confirmation.CodeHash)) {
+		attempts, err := s.confirmationRepo.RegisterFailedAttempt(ctx, payment.ID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= s.paymentCfg.MaxConfirmationAttempts {
+			if err := s.markDeclined(ctx, payment, errConfirmationAttempts); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrPaymentConfirmationFailed
+	}
+
+	// Код одноразовый: параллельное подтверждение не должно списать средства дважды
+	ok, err := s.confirmationRepo.MarkConfirmed(ctx, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPaymentNotPending
+	}
+
+	// За время ожидания карту могли заблокировать или закрыть
+	if card.Status != models.CardActive || !time.Now().Before(card.ExpiresAt) {
+		return s.decline(ctx, payment, ErrCardInactive)
+	}
+
+	if err := s.capture(ctx, payment, card); err != nil {
+		return s.decline(ctx, payment, err)
+	}
+
+	return payment, nil
+}
+
+// ExpirePendingPayments отменяет платежи, не подтвержденные за ConfirmationTTL
+func (s *PaymentService) ExpirePendingPayments(ctx context.Context) error {
+	_, err := s.paymentRepo.ExpireConfirmations(ctx, time.Now(), ErrPaymentExpired.Error())
+	return err
+}
+
+// GetPayment возвращает платеж и историю статусов держателю карты
+func (s *PaymentService) GetPayment(ctx context.Context, paymentID int64, userID int64) (*models.Payment, []*models.PaymentStatusChange, error) {
+	payment, _, err := s.getUserPayment(ctx, paymentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history, err := s.paymentRepo.GetStatusHistory(ctx, payment.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payment, history, nil
+}
+
+// GetMerchantPayment возвращает платеж и историю статусов мерчанту, который его создал
+func (s *PaymentService) GetMerchantPayment(ctx context.Context, paymentID int64, merchantID int64) (*models.Payment, []*models.PaymentStatusChange, error) {
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrPaymentNotFound
+		}
+		return nil, nil, err
+	}
+
+	if payment.MerchantID != merchantID {
+		return nil, nil, ErrPaymentNotFound
+	}
+
+	history, err := s.paymentRepo.GetStatusHistory(ctx, payment.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payment, history, nil
+}
+
+func (s *PaymentService) GetCardPayments(ctx context.Context, cardID int64, userID int64) ([]*models.Payment, error) {
+	if _, err := s.cardService.getUserCard(ctx, cardID, userID); err != nil {
+		return nil, err
+	}
+	return s.paymentRepo.GetByCardID(ctx, cardID)
+}
+
+func (s *PaymentService) getUserPayment(ctx context.Context, paymentID int64, userID int64) (*models.Payment, *models.Card, error) {
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrPaymentNotFound
+		}
+		return nil, nil, err
+	}
+
+	card, err := s.cardService.getUserCard(ctx, payment.CardID, userID)
+	if err != nil {
+		if errors.Is(err, ErrCardAccessDenied) || errors.Is(err, ErrCardNotFound) {
+			return nil, nil, ErrPaymentNotFound
+		}
+		return nil, nil, err
+	}
+
+	return payment, card, nil
+}
+
+// authorize проверяет данные карты, лимит, привязку к мерчанту и валюту счета
+func (s *PaymentService) authorize(ctx context.Context, payment *models.Payment, req types.PaymentReq) (*models.Card, error) {
+	card, err := s.cardService.VerifyCardPayment(ctx, req.CardID, req.CVV, req.PIN)
+	if err != nil {
+		return nil, err
+	}
+
+	if card.PaymentLimit.Valid && payment.Amount.GreaterThan(card.PaymentLimit.Decimal) {
+		return nil, ErrCardLimitExceeded
+	}
+
+	if card.LockedMerchantID != nil && *card.LockedMerchantID != payment.MerchantID {
+		return nil, ErrCardMerchantMismatch
+	}
+
+	account, err := s.accountService.GetAccountByID(ctx, card.AccountID, card.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Currency != payment.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	return card, nil
+}
+
+// decline переводит платеж в статус DECLINED с причиной cause и возвращает cause
+func (s *PaymentService) decline(ctx context.Context, payment *models.Payment, cause error) (*models.Payment, error) {
+	if err := s.markDeclined(ctx, payment, cause); err != nil {
+		return payment, errors.Join(cause, err)
+	}
+	return payment, cause
+}
+
+func (s *PaymentService) markDeclined(ctx context.Context, payment *models.Payment, cause error) error {
+	reason := cause.Error()
+	err := s.paymentRepo.UpdateStatus(ctx, payment.ID, payment.Status, models.PaymentDeclined, &reason)
+	if err != nil {
+		return err
+	}
+
+	payment.Status = models.PaymentDeclined
+	payment.FailureReason = &reason
+	return nil
+}
+
+func (s *PaymentService) expire(ctx context.Context, paymentID int64) error {
+	reason := ErrPaymentExpired.Error()
+	err := s.paymentRepo.UpdateStatus(ctx, paymentID, models.PaymentPendingConfirmation, models.PaymentExpired, &reason)
+	if errors.Is(err, repository.ErrPaymentStatusConflict) {
+		return nil
+	}
+	return err
+}
+
+func (s *PaymentService) needsConfirmation(ctx context.Context, card *models.Card, merchantID int64, amount decimal.Decimal) (bool, error) {
+	if amount.GreaterThanOrEqual(s.paymentCfg.ConfirmationThreshold) {
+		return true, nil
+	}
+	return s.riskChecker.IsRisky(ctx, card, merchantID, amount)
+}
+
+func (s *PaymentService) requestConfirmation(ctx context.Context, payment *models.Payment, card *models.Card) error {
+	code, err := randomDigits(s.paymentCfg.ConfirmationCodeLength)
+	if err != nil {
+		return fmt.Errorf("ошибка генерации кода подтверждения: %w", err)
+	}
+
+	err = s.confirmationRepo.Create(ctx, &models.PaymentConfirmation{
+		PaymentID: payment.ID,
+		CodeHash:  s.hashCode(payment.ID, code),
+		ExpiresAt: time.Now().Add(s.paymentCfg.ConfirmationTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения кода подтверждения: %w", err)
+	}
+
+	err = s.paymentRepo.UpdateStatus(ctx, payment.ID, payment.Status, models.PaymentPendingConfirmation, nil)
+	if err != nil {
+		return err
+	}
+	payment.Status = models.PaymentPendingConfirmation
+
+	return s.notifier.Notify(ctx, card.UserID, "Подтверждение платежа",
+		fmt.Sprintf("Код подтверждения платежа %d на сумму %s %s: %s",
+			payment.ID, payment.Amount.StringFixed(2), payment.Currency, code))
+}
+
+// capture резервирует карту под мерчанта, списывает средства со счета карты
+// и переводит платеж в статус CAPTURED. Ошибка означает, что платеж не
+// захвачен и списание отменено, поэтому платеж можно отклонить
+func (s *PaymentService) capture(ctx context.Context, payment *models.Payment, card *models.Card) error {
+	if err := s.cardService.ClaimForPayment(ctx, card, payment.MerchantID); err != nil {
+		return err
+	}
+
+	tx, err := s.accountService.DebitWithFee(ctx, card.AccountID, payment.Amount, models.FeeCardPayment)
+	if err != nil {
+		if releaseErr := s.cardService.ReleasePaymentClaim(ctx, card); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+
+	// Платеж мог сменить статус параллельно: списание возвращается, чтобы
+	// средства не остались списанными по незавершенному платежу
+	if err := s.paymentRepo.Capture(ctx, payment.ID, payment.Status, tx.ID); err != nil {
+		if cancelErr := s.accountService.CancelDebit(ctx, tx); cancelErr != nil {
+			return errors.Join(err, cancelErr)
+		}
+		if releaseErr := s.cardService.ReleasePaymentClaim(ctx, card); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+	payment.Status = models.PaymentCaptured
+	payment.TransactionID = &tx.ID
+	return nil
+}
+
+func (s *PaymentService) hashCode(paymentID int64, code string) string {
+	h := hmac.New(sha256.New, s.codeKey)
+	h.Write([]byte(strconv.FormatInt(paymentID, 10) + ":" + code))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidPhone            = errors.New("неверный формат номера телефона")
+	ErrInvalidName             = errors.New("имя и фамилия не должны быть пустыми")
+	ErrNoPhoneVerification     = errors.New("подтверждение номера не запрашивалось")
+	ErrPhoneCodeExpired        = errors.New("истек срок действия кода")
+	ErrPhoneCodeInvalid        = errors.New("неверный код подтверждения")
+	ErrPhoneCodeAttemptsExceed = errors.New("превышено число попыток ввода кода")
+)
+
+type ProfileService struct {
+	userRepo         repository.UserRepository
+	verificationRepo *repository.PhoneVerificationRepository
+	smsSender        SMSSender
+	codeKey          []byte
+	profileCfg       config.ProfileConfig
+}
+
+func NewProfileService(userRepo repository.UserRepository, verificationRepo *repository.PhoneVerificationRepository,
+	smsSender SMSSender, cryptoCfg config.CryptoConfig, profileCfg config.ProfileConfig) *ProfileService {
+	return &ProfileService{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		smsSender:        smsSender,
+		codeKey:          []byte(cryptoCfg.HMACKey),
+		profileCfg:       profileCfg,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userID int64) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+func (s *ProfileService) UpdateName(ctx context.Context, userID int64, req types.UpdateProfileReq) (*models.User, error) {
+	firstName := strings.TrimSpace(req.FirstName)
+	lastName := strings.TrimSpace(req.LastName)
+	if firstName == "" || lastName == "" {
+		return nil, ErrInvalidName
+	}
+
+	if err := s.userRepo.UpdateName(ctx, userID, firstName, lastName); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+// RequestPhoneVerification отправляет код подтверждения SMS на новый номер.
+// Номер сохраняется в профиле только после VerifyPhone: код знает лишь тот,
+// у кого этот номер
+func (s *ProfileService) RequestPhoneVerification(ctx context.Context, userID int64, phone string) error {
+	normalized, err := NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+
+	code, err := randomDigits(s.profileCfg.PhoneCodeLength)
+	if err != nil {
+		return fmt.Errorf("ошибка генерации кода: %w", err)
+	}
+
+	err = s.verificationRepo.Upsert(ctx, &models.PhoneVerification{
+		UserID:    userID,
+		Phone:     normalized,
+		CodeHash:  s.hashCode(userID, normalized, code),
+		ExpiresAt: time.Now().Add(s.profileCfg.PhoneCodeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.smsSender.SendSMS(ctx, normalized, fmt.Sprintf("Код подтверждения номера телефона: %s", code))
+}
+
+func (s *ProfileService) VerifyPhone(ctx context.Context, userID int64, code string) (*models.User, error) {
+	verification, err := s.verificationRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoPhoneVerification
+		}
+		return nil, err
+	}
+
+	if time.Now().After(verification.ExpiresAt) {
+		return nil, ErrPhoneCodeExpired
+	}
+
+	if verification.Attempts >= s.profileCfg.MaxPhoneCodeAttempts {
+		return nil, ErrPhoneCodeAttemptsExceed
+	}
+
+	expected := s.hashCode(userID, verification.Phone, code)
+	if !hmac.Equal([]byte(expected), []byte(verification.CodeHash)) {
+		if _, err := s.verificationRepo.RegisterFailedAttempt(ctx, userID); err != nil {
+			return nil, err
+		}
+		return nil, ErrPhoneCodeInvalid
+	}
+
+	if err := s.userRepo.SetVerifiedPhone(ctx, userID, verification.Phone); err != nil {
+		return nil, err
+	}
+
+	if err := s.verificationRepo.Delete(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+func (s *ProfileService) hashCode(userID int64, phone, code string) string {
+	h := hmac.New(sha256.New, s.codeKey)
+	h.Write([]byte(strconv.FormatInt(userID, 10) + ":" + phone + ":" + code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NormalizePhone приводит номер к виду +<код страны><номер>. Российские номера
+// допускаются в форматах 8XXXXXXXXXX, 7XXXXXXXXXX и 9XXXXXXXXX
+func NormalizePhone(phone string) (string, error) {
+	var digits strings.Builder
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0, r == ' ', r == '-', r == '(', r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	d := digits.String()
+	switch {
+	case !strings.HasPrefix(phone, "+") && len(d) == 11 && (d[0] == '8' || d[0] == '7'):
+		d = "7" + d[1:]
+	case !strings.HasPrefix(phone, "+") && len(d) == 10 && d[0] == '9':
+		d = "7" + d
+	case strings.HasPrefix(phone, "+") && len(d) >= 10 && len(d) <= 15:
+	default:
+		return "", ErrInvalidPhone
+	}
+
+	return "+" + d, nil
+}
+
+// MaskName возвращает имя и первую букву фамилии: "Иван П."
+func MaskName(firstName, lastName string) string {
+	if firstName == "" {
+		return ""
+	}
+	if lastName == "" {
+		return firstName
+	}
+	initial, _ := utf8.DecodeRuneInString(lastName)
+	return firstName + " " + string(initial) + "."
+}
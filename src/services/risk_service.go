@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// RiskChecker оценивает, нужно ли дополнительное подтверждение платежа
+type RiskChecker interface {
+	IsRisky(ctx context.Context, card *models.Card, merchantID int64, amount decimal.Decimal) (bool, error)
+}
+
+// NewCardRiskChecker считает рискованными платежи по недавно выпущенным картам
+type NewCardRiskChecker struct {
+	window time.Duration
+}
+
+func NewNewCardRiskChecker(window time.Duration) *NewCardRiskChecker {
+	return &NewCardRiskChecker{window: window}
+}
+
+func (c *NewCardRiskChecker) IsRisky(ctx context.Context, card *models.Card, merchantID int64, amount decimal.Decimal) (bool, error) {
+	return time.Since(card.CreatedAt) < c.window, nil
+}
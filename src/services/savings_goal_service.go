@@ -0,0 +1,491 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidSavingsGoal     = errors.New("неверные параметры цели")
+	ErrSavingsGoalNotFound    = errors.New("цель не найдена")
+	ErrSavingsGoalClosed      = errors.New("цель уже закрыта")
+	ErrTooManySavingsGoals    = errors.New("слишком много целей")
+	ErrInvalidSavingsRule     = errors.New("неверное правило пополнения")
+	ErrSavingsGoalMainAccount = errors.New("счет цели не может быть основным счетом другой цели")
+)
+
+// Сколько последних автоматических пополнений показывать в цели
+const goalContributionsLimit = 20
+
+// SavingsGoalService ведет цели накоплений: у каждой цели свой счет,
+// который пополняется с основного счета вручную обычными переводами или
+// автоматически по правилам - округлением покупок по карте и процентом от
+// поступлений за месяц
+type SavingsGoalService struct {
+	goalRepo        *repository.SavingsGoalRepository
+	transactionRepo *repository.TransactionRepository
+	accountService  *AccountService
+	notifier        Notifier
+	goalCfg         config.SavingsGoalConfig
+	// Закрытие цели не должно пересекаться с применением правил
+	mu sync.Mutex
+}
+
+func NewSavingsGoalService(goalRepo *repository.SavingsGoalRepository, transactionRepo *repository.TransactionRepository,
+	accountService *AccountService, notifier Notifier, goalCfg config.SavingsGoalConfig) *SavingsGoalService {
+	return &SavingsGoalService{
+		goalRepo:        goalRepo,
+		transactionRepo: transactionRepo,
+		accountService:  accountService,
+		notifier:        notifier,
+		goalCfg:         goalCfg,
+	}
+}
+
+// CreateGoal открывает счет цели с названием цели и сохраняет цель
+func (s *SavingsGoalService) CreateGoal(ctx context.Context, userID int64, req types.CreateSavingsGoalReq) (*types.SavingsGoalRes, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxNicknameLength {
+		return nil, fmt.Errorf("%w: название от 1 до %d символов", ErrInvalidSavingsGoal, maxNicknameLength)
+	}
+	if !req.TargetAmount.IsPositive() || !req.TargetAmount.Equal(req.TargetAmount.Round(2)) {
+		return nil, fmt.Errorf("%w: сумма цели должна быть положительной", ErrInvalidSavingsGoal)
+	}
+
+	var targetDate *time.Time
+	if req.TargetDate != "" {
+		date, err := time.Parse(time.DateOnly, req.TargetDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: дата цели в формате ГГГГ-ММ-ДД", ErrInvalidSavingsGoal)
+		}
+		if !date.After(s.today()) {
+			return nil, fmt.Errorf("%w: дата цели должна быть в будущем", ErrInvalidSavingsGoal)
+		}
+		targetDate = &date
+	}
+
+	mainAcc, err := s.accountService.userAccount(ctx, req.MainAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActive(mainAcc); err != nil {
+		return nil, err
+	}
+	if _, err := s.goalRepo.GetByAccountID(ctx, mainAcc.ID); err == nil {
+		return nil, ErrSavingsGoalMainAccount
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	goal := &models.SavingsGoal{
+		UserID:        userID,
+		MainAccountID: mainAcc.ID,
+		Name:          name,
+		TargetAmount:  req.TargetAmount,
+		TargetDate:    targetDate,
+		Status:        models.SavingsGoalActive,
+	}
+	if err := s.applyRules(goal, mainAcc, req.RoundUpStep, req.DepositPercent); err != nil {
+		return nil, err
+	}
+
+	active, err := s.goalRepo.CountActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= s.goalCfg.MaxActiveGoals {
+		return nil, fmt.Errorf("%w: не больше %d", ErrTooManySavingsGoals, s.goalCfg.MaxActiveGoals)
+	}
+
+	acc, err := s.accountService.CreateAccount(ctx, userID, mainAcc.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.accountService.SetNickname(ctx, acc.ID, userID, name); err != nil {
+		return nil, err
+	}
+	goal.AccountID = acc.ID
+
+	created, err := s.goalRepo.Create(ctx, goal)
+	if err != nil {
+		return nil, err
+	}
+	return s.goalRes(ctx, created, false)
+}
+
+// UpdateRules заменяет правила пополнения цели. Включенное правило действует
+// для операций после включения, уже включенное продолжает действовать
+func (s *SavingsGoalService) UpdateRules(ctx context.Context, goalID int64, userID int64,
+	req types.UpdateSavingsGoalRulesReq) (*types.SavingsGoalRes, error) {
+	goal, err := s.get(ctx, goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if goal.Status == models.SavingsGoalClosed {
+		return nil, ErrSavingsGoalClosed
+	}
+
+	mainAcc, err := s.accountService.account(ctx, goal.MainAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRules(goal, mainAcc, req.RoundUpStep, req.DepositPercent); err != nil {
+		return nil, err
+	}
+
+	if err := s.goalRepo.UpdateRules(ctx, goal); err != nil {
+		if errors.Is(err, repository.ErrSavingsGoalStatusConflict) {
+			return nil, ErrSavingsGoalClosed
+		}
+		return nil, err
+	}
+	return s.goalRes(ctx, goal, true)
+}
+
+// applyRules проверяет правила и записывает их в цель, отмечая время
+// включения новых правил
+func (s *SavingsGoalService) applyRules(goal *models.SavingsGoal, mainAcc *models.Account, roundUpStep *int,
+	depositPercent *decimal.Decimal) error {
+	now := time.Now()
+
+	if roundUpStep != nil {
+		if !slices.Contains(s.goalCfg.RoundUpSteps, *roundUpStep) {
+			return fmt.Errorf("%w: округлять можно до %v руб.", ErrInvalidSavingsRule, s.goalCfg.RoundUpSteps)
+		}
+		if mainAcc.Currency != models.RUB {
+			return fmt.Errorf("%w: округление доступно только для рублевых счетов", ErrInvalidSavingsRule)
+		}
+		if goal.RoundUpStep == nil {
+			goal.RoundUpSince = &now
+		}
+	} else {
+		goal.RoundUpSince = nil
+	}
+	goal.RoundUpStep = roundUpStep
+
+	if depositPercent != nil {
+		p := *depositPercent
+		if !p.IsPositive() || p.GreaterThan(s.goalCfg.MaxDepositPercent) || !p.Equal(p.Round(2)) {
+			return fmt.Errorf("%w: процент от поступлений от 0.01 до %s", ErrInvalidSavingsRule, s.goalCfg.MaxDepositPercent)
+		}
+		if goal.DepositPercent == nil {
+			goal.DepositPercentSince = &now
+		}
+	} else {
+		goal.DepositPercentSince = nil
+	}
+	goal.DepositPercent = depositPercent
+	return nil
+}
+
+func (s *SavingsGoalService) GetGoal(ctx context.Context, goalID int64, userID int64) (*types.SavingsGoalRes, error) {
+	goal, err := s.get(ctx, goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.goalRes(ctx, goal, true)
+}
+
+func (s *SavingsGoalService) GetUserGoals(ctx context.Context, userID int64) ([]types.SavingsGoalRes, error) {
+	goals, err := s.goalRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]types.SavingsGoalRes, 0, len(goals))
+	for _, goal := range goals {
+		r, err := s.goalRes(ctx, goal, false)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *r)
+	}
+	return res, nil
+}
+
+// CloseGoal закрывает цель и ее счет, переводя накопленное на основной счет
+func (s *SavingsGoalService) CloseGoal(ctx context.Context, goalID int64, userID int64) (*types.SavingsGoalRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, err := s.get(ctx, goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if goal.Status == models.SavingsGoalClosed {
+		return nil, ErrSavingsGoalClosed
+	}
+
+	acc, err := s.accountService.account(ctx, goal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Status != models.AccountClosed {
+		if _, err := s.accountService.Close(ctx, goal.AccountID, userID, &goal.MainAccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.goalRepo.Close(ctx, goal.ID); err != nil {
+		if errors.Is(err, repository.ErrSavingsGoalStatusConflict) {
+			return nil, ErrSavingsGoalClosed
+		}
+		return nil, err
+	}
+	return s.GetGoal(ctx, goal.ID, userID)
+}
+
+// ApplyRules пополняет действующие цели по правилам: округляет новые покупки
+// по карте и после окончания месяца откладывает процент от поступлений за
+// него. Каждое пополнение проводится один раз, не прошедшее из-за нехватки
+// средств пропускается
+func (s *SavingsGoalService) ApplyRules(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goals, err := s.goalRepo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, goal := range goals {
+		if err := s.applyGoalRules(ctx, goal); err != nil {
+			errs = append(errs, fmt.Errorf("цель %d: %w", goal.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *SavingsGoalService) applyGoalRules(ctx context.Context, goal *models.SavingsGoal) error {
+	acc, err := s.accountService.account(ctx, goal.AccountID)
+	if err != nil {
+		return err
+	}
+	if acc.Status == models.AccountClosed {
+		// Счет цели закрыли в обход цели, пополнять больше некуда
+		return s.goalRepo.Close(ctx, goal.ID)
+	}
+
+	if goal.RoundUpStep != nil {
+		if err := s.roundUp(ctx, goal); err != nil {
+			return err
+		}
+	}
+	if goal.DepositPercent != nil {
+		if err := s.setAsidePercent(ctx, goal); err != nil {
+			return err
+		}
+	}
+	return s.checkReached(ctx, goal)
+}
+
+// roundUp округляет покупки по картам основного счета, сделанные после
+// включения правила
+func (s *SavingsGoalService) roundUp(ctx context.Context, goal *models.SavingsGoal) error {
+	payments, err := s.goalRepo.GetUnroundedCardPayments(ctx, goal.ID, goal.MainAccountID, *goal.RoundUpSince)
+	if err != nil {
+		return err
+	}
+
+	step := decimal.NewFromInt(int64(*goal.RoundUpStep))
+	for _, tx := range payments {
+		txID := tx.ID
+		contribution := &models.SavingsContribution{
+			GoalID:              goal.ID,
+			Rule:                models.SavingsRoundUp,
+			SourceTransactionID: &txID,
+			Amount:              roundUpAmount(tx.Amount, step),
+		}
+		description := fmt.Sprintf("Округление покупки до %d руб. в цель «%s»", *goal.RoundUpStep, goal.Name)
+		if err := s.contribute(ctx, goal, contribution, description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roundUpAmount возвращает, сколько не хватает сумме до кратной step
+func roundUpAmount(amount decimal.Decimal, step decimal.Decimal) decimal.Decimal {
+	return amount.Div(step).Ceil().Mul(step).Sub(amount)
+}
+
+// setAsidePercent откладывает процент от поступлений на основной счет за
+// прошлый месяц. Если правило включили в прошлом месяце, учитываются
+// поступления после включения
+func (s *SavingsGoalService) setAsidePercent(ctx context.Context, goal *models.SavingsGoal) error {
+	now := time.Now().In(s.goalCfg.Location)
+	periodEnd := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, s.goalCfg.Location)
+	periodStart := periodEnd.AddDate(0, -1, 0)
+
+	from := periodStart
+	if goal.DepositPercentSince.After(from) {
+		from = *goal.DepositPercentSince
+	}
+	if !from.Before(periodEnd) {
+		return nil
+	}
+
+	incoming, err := s.transactionRepo.GetIncomingTotal(ctx, goal.MainAccountID, from, periodEnd)
+	if err != nil {
+		return err
+	}
+
+	// Период хранится как дата первого дня месяца, как и другие даты в базе
+	period := time.Date(periodStart.Year(), periodStart.Month(), 1, 0, 0, 0, 0, time.UTC)
+	contribution := &models.SavingsContribution{
+		GoalID: goal.ID,
+		Rule:   models.SavingsDepositPercent,
+		Period: &period,
+		Amount: incoming.Mul(*goal.DepositPercent).Div(decimal.NewFromInt(100)).Round(2),
+	}
+	description := fmt.Sprintf("%s%% поступлений за %s в цель «%s»", goal.DepositPercent, periodStart.Format("01.2006"),
+		goal.Name)
+	return s.contribute(ctx, goal, contribution, description)
+}
+
+// contribute проводит пополнение цели переводом с основного счета. Нулевое
+// пополнение только отмечается, чтобы не пересчитывать его повторно
+func (s *SavingsGoalService) contribute(ctx context.Context, goal *models.SavingsGoal, c *models.SavingsContribution,
+	description string) error {
+	c.Status = models.ContributionPending
+	if !c.Amount.IsPositive() {
+		c.Status = models.ContributionSkipped
+	}
+
+	created, err := s.goalRepo.CreateContribution(ctx, c)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if created.Status == models.ContributionSkipped {
+		return nil
+	}
+
+	_, err = s.accountService.transferFromUser(ctx, goal.MainAccountID, goal.UserID, goal.AccountID, c.Amount, &description)
+	switch {
+	case err == nil:
+		return s.goalRepo.FinishContribution(ctx, created.ID, models.ContributionDone, nil)
+	case transferRejected(err):
+		reason := err.Error()
+		return s.goalRepo.FinishContribution(ctx, created.ID, models.ContributionSkipped, &reason)
+	case errors.Is(err, ErrTransferIncomplete):
+		reason := err.Error()
+		return s.goalRepo.FinishContribution(ctx, created.ID, models.ContributionDone, &reason)
+	default:
+		// Перевод мог пройти: пополнение остается в статусе FAILED и не
+		// повторяется, чтобы не перевести средства дважды
+		reason := err.Error()
+		if finishErr := s.goalRepo.FinishContribution(ctx, created.ID, models.ContributionFailed, &reason); finishErr != nil {
+			return errors.Join(err, finishErr)
+		}
+		return err
+	}
+}
+
+// checkReached один раз уведомляет пользователя о достижении цели
+func (s *SavingsGoalService) checkReached(ctx context.Context, goal *models.SavingsGoal) error {
+	if goal.ReachedAt != nil {
+		return nil
+	}
+	acc, err := s.accountService.account(ctx, goal.AccountID)
+	if err != nil {
+		return err
+	}
+	if acc.Balance.LessThan(goal.TargetAmount) {
+		return nil
+	}
+
+	reached, err := s.goalRepo.MarkReached(ctx, goal.ID)
+	if err != nil || !reached {
+		return err
+	}
+
+	message := fmt.Sprintf("Цель «%s» достигнута: накоплено %s %s из %s %s", goal.Name, acc.Balance.StringFixed(2),
+		acc.Currency, goal.TargetAmount.StringFixed(2), acc.Currency)
+	return s.notifier.Notify(ctx, goal.UserID, "Цель достигнута", message)
+}
+
+func (s *SavingsGoalService) get(ctx context.Context, goalID int64, userID int64) (*models.SavingsGoal, error) {
+	goal, err := s.goalRepo.GetByID(ctx, goalID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSavingsGoalNotFound
+		}
+		return nil, err
+	}
+	if goal.UserID != userID {
+		return nil, ErrSavingsGoalNotFound
+	}
+	return goal, nil
+}
+
+// goalRes считает прогресс цели, withContributions добавляет последние
+// автоматические пополнения
+func (s *SavingsGoalService) goalRes(ctx context.Context, goal *models.SavingsGoal, withContributions bool) (*types.SavingsGoalRes, error) {
+	acc, err := s.accountService.account(ctx, goal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	contributed, err := s.goalRepo.GetContributedTotal(ctx, goal.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := decimal.Max(goal.TargetAmount.Sub(acc.Balance), decimal.Zero)
+	progress := acc.Balance.Mul(decimal.NewFromInt(100)).Div(goal.TargetAmount).Round(2)
+	res := &types.SavingsGoalRes{
+		Goal:            goal,
+		AccountNumber:   acc.Number,
+		Balance:         acc.Balance,
+		Remaining:       remaining,
+		ProgressPercent: decimal.Min(decimal.Max(progress, decimal.Zero), decimal.NewFromInt(100)),
+		AutoContributed: contributed,
+	}
+
+	if goal.Status == models.SavingsGoalActive && goal.TargetDate != nil && remaining.IsPositive() {
+		months := monthsUntil(s.today(), *goal.TargetDate)
+		needed := remaining.Div(decimal.NewFromInt(int64(months))).RoundUp(2)
+		res.MonthlyNeeded = &needed
+	}
+
+	if withContributions {
+		contributions, err := s.goalRepo.GetContributions(ctx, goal.ID, goalContributionsLimit)
+		if err != nil {
+			return nil, err
+		}
+		res.Contributions = contributions
+	}
+	return res, nil
+}
+
+// monthsUntil возвращает число месяцев до даты target с учетом неполного
+// последнего, не меньше одного
+func monthsUntil(today time.Time, target time.Time) int {
+	months := (target.Year()-today.Year())*12 + int(target.Month()-today.Month())
+	if target.Day() > today.Day() {
+		months++
+	}
+	return max(months, 1)
+}
+
+// today возвращает текущую дату как полночь UTC, как даты хранятся в базе
+func (s *SavingsGoalService) today() time.Time {
+	now := time.Now().In(s.goalCfg.Location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
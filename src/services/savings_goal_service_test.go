@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRoundUpAmount(t *testing.T) {
+	d := decimal.RequireFromString
+	tests := []struct {
+		amount string
+		step   string
+		want   string
+	}{
+		{"123.45", "10", "6.55"},
+		{"123.45", "100", "76.55"},
+		{"120", "10", "0"},
+		{"0.01", "50", "49.99"},
+		{"99.99", "1", "0.01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount+"/"+tt.step, func(t *testing.T) {
+			if got := roundUpAmount(d(tt.amount), d(tt.step)); !got.Equal(d(tt.want)) {
+				t.Errorf("округление %s, ожидалось %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthsUntil(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name   string
+		today  time.Time
+		target time.Time
+		want   int
+	}{
+		{"ровно месяц", date(2026, 10, 19), date(2026, 11, 19), 1},
+		{"неполный последний месяц", date(2026, 10, 19), date(2026, 12, 20), 3},
+		{"день цели раньше дня месяца", date(2026, 10, 19), date(2026, 12, 1), 2},
+		{"через год", date(2026, 10, 19), date(2027, 10, 19), 12},
+		{"в этом месяце", date(2026, 10, 19), date(2026, 10, 25), 1},
+		{"цель уже прошла", date(2026, 10, 19), date(2026, 9, 1), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := monthsUntil(tt.today, tt.target); got != tt.want {
+				t.Errorf("monthsUntil = %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+}
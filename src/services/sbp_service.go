@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/qrcode"
+	"sf-finances/src/repository"
+	"sf-finances/src/sbp"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidSBPQR      = errors.New("неверные параметры платежной ссылки")
+	ErrSBPQRNotFound     = errors.New("платежная ссылка не найдена")
+	ErrSBPQRInactive     = errors.New("платежная ссылка уже оплачена или истекла")
+	ErrSBPForeignBank    = errors.New("платежная ссылка другого банка не поддерживается")
+	ErrSBPAmountMismatch = errors.New("сумма не совпадает с суммой в платежной ссылке")
+)
+
+// Максимальная длина назначения платежа в ссылке СБП
+const maxSBPPurposeLength = 140
+
+// SBPService выпускает платежные ссылки СБП на счета пользователей и
+// проводит оплату по ним переводом между счетами банка
+type SBPService struct {
+	sbpRepo        *repository.SBPRepository
+	accountService *AccountService
+	userRepo       repository.UserRepository
+	sbpCfg         config.SBPConfig
+	statementCfg   config.StatementConfig
+}
+
+func NewSBPService(sbpRepo *repository.SBPRepository, accountService *AccountService, userRepo repository.UserRepository,
+	sbpCfg config.SBPConfig, statementCfg config.StatementConfig) *SBPService {
+	return &SBPService{
+		sbpRepo:        sbpRepo,
+		accountService: accountService,
+		userRepo:       userRepo,
+		sbpCfg:         sbpCfg,
+		statementCfg:   statementCfg,
+	}
+}
+
+func (s *SBPService) CreateQR(ctx context.Context, userID int64, req types.CreateSBPQRReq) (*models.SBPQRCode, error) {
+	acc, err := s.accountService.GetAccount(ctx, req.AccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+	if acc.Currency != models.RUB {
+		return nil, ErrCurrencyMismatch
+	}
+
+	qr := &models.SBPQRCode{
+		UserID:    userID,
+		AccountID: acc.ID,
+		Type:      req.Type,
+		Status:    models.SBPQRActive,
+	}
+
+	switch req.Type {
+	case models.SBPQRStatic:
+	case models.SBPQRDynamic:
+		if req.Amount == nil {
+			return nil, fmt.Errorf("%w: для динамической ссылки нужна сумма", ErrInvalidSBPQR)
+		}
+		expiresAt := time.Now().Add(s.sbpCfg.DynamicTTL)
+		qr.ExpiresAt = &expiresAt
+	default:
+		return nil, fmt.Errorf("%w: неизвестный тип %q", ErrInvalidSBPQR, req.Type)
+	}
+
+	if req.Amount != nil {
+		if req.Amount.LessThanOrEqual(decimal.Zero) {
+			return nil, ErrNegativeAmount
+		}
+		if req.Amount.Exponent() < -2 {
+			return nil, fmt.Errorf("%w: в сумме больше двух знаков после запятой", ErrInvalidSBPQR)
+		}
+		qr.Amount = req.Amount
+	}
+
+	if purpose := strings.TrimSpace(req.Purpose); purpose != "" {
+		if utf8.RuneCountInString(purpose) > maxSBPPurposeLength {
+			return nil, fmt.Errorf("%w: назначение длиннее %d символов", ErrInvalidSBPQR, maxSBPPurposeLength)
+		}
+		qr.Purpose = &purpose
+	}
+
+	qr.QRID, err = sbp.NewQRID(sbpType(qr.Type))
+	if err != nil {
+		return nil, err
+	}
+	return s.sbpRepo.Create(ctx, qr)
+}
+
+// Payload собирает платежную ссылку для QR-кода
+func (s *SBPService) Payload(qr *models.SBPQRCode) string {
+	p := &sbp.Payload{
+		BaseURL:  s.sbpCfg.BaseURL,
+		QRID:     qr.QRID,
+		Type:     sbpType(qr.Type),
+		BankID:   s.sbpCfg.MemberID,
+		Amount:   qr.Amount,
+		Currency: string(models.RUB),
+	}
+	if qr.Purpose != nil {
+		p.Purpose = *qr.Purpose
+	}
+	return p.String()
+}
+
+func (s *SBPService) GetQR(ctx context.Context, id int64, userID int64) (*models.SBPQRCode, error) {
+	qr, err := s.sbpRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSBPQRNotFound
+		}
+		return nil, err
+	}
+	if qr.UserID != userID {
+		return nil, ErrSBPQRNotFound
+	}
+	return withEffectiveStatus(qr, time.Now()), nil
+}
+
+func (s *SBPService) GetUserQRs(ctx context.Context, userID int64) ([]*models.SBPQRCode, error) {
+	codes, err := s.sbpRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, qr := range codes {
+		withEffectiveStatus(qr, now)
+	}
+	return codes, nil
+}
+
+// RenderPNG рисует QR-код с платежной ссылкой. scale - размер модуля в
+// пикселях, 0 - значение по умолчанию
+func (s *SBPService) RenderPNG(ctx context.Context, id int64, userID int64, scale int) ([]byte, error) {
+	qr, err := s.GetQR(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scale <= 0 {
+		scale = s.sbpCfg.DefaultScale
+	}
+	scale = min(scale, s.sbpCfg.MaxScale)
+
+	code, err := qrcode.Encode([]byte(s.Payload(qr)), qrcode.LevelM)
+	if err != nil {
+		return nil, err
+	}
+	return code.PNG(scale)
+}
+
+// PreviewPayment разбирает платежную ссылку и заполняет по ней перевод
+func (s *SBPService) PreviewPayment(ctx context.Context, payload string) (*types.SBPTransferPreviewRes, error) {
+	qr, err := s.resolve(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.userRepo.GetByID(ctx, qr.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &types.SBPTransferPreviewRes{
+		QRID:          qr.QRID,
+		Type:          qr.Type,
+		RecipientName: recipientDisplayName(recipient),
+		BankName:      s.statementCfg.BankName,
+		Amount:        qr.Amount,
+		AmountFixed:   qr.Amount != nil,
+		Currency:      models.RUB,
+	}
+	if qr.Purpose != nil {
+		res.Purpose = *qr.Purpose
+	}
+	return res, nil
+}
+
+// Pay оплачивает ссылку переводом со счета пользователя. Сумму плательщик
+// указывает, только если ее нет в ссылке. Динамическая ссылка помечается
+// оплаченной до перевода, чтобы ее нельзя было оплатить дважды
+func (s *SBPService) Pay(ctx context.Context, userID int64, req types.SBPPayReq) (*types.SBPPayRes, error) {
+	qr, err := s.resolve(ctx, req.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var amount decimal.Decimal
+	switch {
+	case qr.Amount != nil:
+		if req.Amount != nil && !req.Amount.Equal(*qr.Amount) {
+			return nil, ErrSBPAmountMismatch
+		}
+		amount = *qr.Amount
+	case req.Amount != nil:
+		amount = *req.Amount
+	default:
+		return nil, ErrNegativeAmount
+	}
+
+	if qr.Type == models.SBPQRDynamic {
+		if err := s.sbpRepo.MarkPaid(ctx, qr.ID); err != nil {
+			if errors.Is(err, repository.ErrSBPQRStatusConflict) {
+				return nil, ErrSBPQRInactive
+			}
+			return nil, err
+		}
+	}
+
+	// Одноразовая ссылка возвращается в активные, только если перевод
+	// отклонен до движения средств
+	_, err = s.accountService.transferFromUser(ctx, req.FromAccountID, userID, qr.AccountID, amount, qr.Purpose)
+	if err != nil && transferRejected(err) && qr.Type == models.SBPQRDynamic {
+		if reopenErr := s.sbpRepo.Reopen(ctx, qr.ID); reopenErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("возврат ссылки в активные: %w", reopenErr))
+		}
+	}
+	if err != nil && !errors.Is(err, ErrTransferIncomplete) {
+		return nil, err
+	}
+
+	recipient, err := s.userRepo.GetByID(ctx, qr.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &types.SBPPayRes{
+		Status:        "success",
+		RecipientName: recipientDisplayName(recipient),
+		Amount:        amount,
+		Currency:      models.RUB,
+	}
+	if qr.Purpose != nil {
+		res.Purpose = *qr.Purpose
+	}
+	return res, nil
+}
+
+// resolve разбирает ссылку и находит выпущенный по ней QR-код. Ссылка
+// должна совпадать с сохраненной: подмена суммы или назначения с
+// пересчитанной контрольной суммой не пройдет
+func (s *SBPService) resolve(ctx context.Context, payload string) (*models.SBPQRCode, error) {
+	p, err := sbp.Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	if p.BankID != s.sbpCfg.MemberID {
+		return nil, ErrSBPForeignBank
+	}
+
+	qr, err := s.sbpRepo.GetByQRID(ctx, p.QRID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSBPQRNotFound
+		}
+		return nil, err
+	}
+	if s.Payload(qr) != p.String() {
+		return nil, fmt.Errorf("%w: ссылка не совпадает с выпущенной", sbp.ErrInvalidPayload)
+	}
+
+	if withEffectiveStatus(qr, time.Now()).Status != models.SBPQRActive {
+		return nil, ErrSBPQRInactive
+	}
+	return qr, nil
+}
+
+// withEffectiveStatus показывает истекшую динамическую ссылку в статусе
+// EXPIRED, в базе она остается активной
+func withEffectiveStatus(qr *models.SBPQRCode, now time.Time) *models.SBPQRCode {
+	if qr.Status == models.SBPQRActive && qr.ExpiresAt != nil && !now.Before(*qr.ExpiresAt) {
+		qr.Status = models.SBPQRExpired
+	}
+	return qr
+}
+
+func sbpType(t models.SBPQRType) sbp.QRType {
+	if t == models.SBPQRDynamic {
+		return sbp.Dynamic
+	}
+	return sbp.Static
+}
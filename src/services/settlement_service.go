@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+)
+
+var ErrSettlementNotFound = errors.New("расчет не найден")
+
+type SettlementService struct {
+	settlementRepo  *repository.SettlementRepository
+	merchantService *MerchantService
+	accountService  *AccountService
+	settlementCfg   config.SettlementConfig
+	// Ручной запуск и фоновая задача не должны собирать пакеты одновременно
+	mu sync.Mutex
+}
+
+func NewSettlementService(settlementRepo *repository.SettlementRepository, merchantService *MerchantService,
+	accountService *AccountService, settlementCfg config.SettlementConfig) *SettlementService {
+	return &SettlementService{
+		settlementRepo:  settlementRepo,
+		merchantService: merchantService,
+		accountService:  accountService,
+		settlementCfg:   settlementCfg,
+	}
+}
+
+// SettleDaily закрывает прошедшие операционные дни: собирает списанные
+// платежи каждого мерчанта в пакет, зачисляет сумму за вычетом комиссии
+// на его расчетный счет, а комиссию - на счет доходов банка. Повторный
+// запуск в тот же день ничего не делает
+func (s *SettlementService) SettleDaily(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().In(s.settlementCfg.Location)
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.settlementCfg.Location)
+	businessDate := cutoff.AddDate(0, 0, -1)
+
+	keys, err := s.settlementRepo.GetPendingKeys(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, key := range keys {
+		merchant, err := s.merchantService.GetMerchant(ctx, key.MerchantID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("мерчант %d: %w", key.MerchantID, err))
+			continue
+		}
+
+		_, err = s.settlementRepo.CreateBatch(ctx, key, businessDate, cutoff, s.settlementCfg.FeeRate(merchant.MCC))
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			errs = append(errs, fmt.Errorf("мерчант %d: %w", key.MerchantID, err))
+		}
+	}
+
+	// Выплачиваем и новые пакеты, и оставшиеся после прошлых сбоев
+	batches, err := s.settlementRepo.GetUnpaidBatches(ctx)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	for _, batch := range batches {
+		if err := s.payout(ctx, batch); err != nil && !errors.Is(err, repository.ErrSettlementPaying) {
+			errs = append(errs, fmt.Errorf("расчет %d: %w", batch.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// payout переводит сумму пакета со счета расчетов по картам: NetAmount на
+// расчетный счет мерчанта, FeeAmount на счет доходов банка. Пакет
+// захватывается до перевода, поэтому ручной запуск и фоновая задача не
+// выплатят его дважды
+func (s *SettlementService) payout(ctx context.Context, batch *models.SettlementBatch) error {
+	merchant, err := s.merchantService.GetMerchant(ctx, batch.MerchantID)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountService.GetAccount(ctx, merchant.SettlementAccountID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения расчетного счета: %w", err)
+	}
+	if account.Currency != batch.Currency {
+		return ErrCurrencyMismatch
+	}
+
+	clearingAcc, err := s.accountService.GetAccountByNumber(ctx, s.settlementCfg.ClearingAccount)
+	if err != nil {
+		return fmt.Errorf("счет расчетов по картам: %w", err)
+	}
+	incomeAcc, err := s.accountService.GetAccountByNumber(ctx, s.settlementCfg.FeeIncomeAccount)
+	if err != nil {
+		return fmt.Errorf("счет доходов по эквайрингу: %w", err)
+	}
+
+	if err := s.settlementRepo.Claim(ctx, batch.ID); err != nil {
+		return err
+	}
+
+	if err := s.payoutTransfers(ctx, batch, clearingAcc, account.ID, incomeAcc); err != nil {
+		if transferRejected(err) {
+			if releaseErr := s.settlementRepo.Release(ctx, batch.ID); releaseErr != nil {
+				return errors.Join(err, releaseErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// payoutTransfers проводит еще не проведенные переводы по пакету
+func (s *SettlementService) payoutTransfers(ctx context.Context, batch *models.SettlementBatch, clearingAcc *models.Account,
+	accountID int64, incomeAcc *models.Account) error {
+	if batch.TransactionID == nil {
+		description := fmt.Sprintf("Возмещение по операциям с картами за %s", batch.BusinessDate.Format("02.01.2006"))
+		tx, err := s.accountService.transferFromBank(ctx, clearingAcc, accountID, batch.NetAmount, &description)
+		if err != nil {
+			return err
+		}
+		if err := s.settlementRepo.SetTransactionID(ctx, batch.ID, tx.ID); err != nil {
+			return err
+		}
+		batch.TransactionID = &tx.ID
+	}
+
+	if batch.FeeTransactionID == nil && batch.FeeAmount.IsPositive() {
+		description := fmt.Sprintf("Комиссия эквайринга по расчету №%d", batch.ID)
+		tx, err := s.accountService.transferToBank(ctx, clearingAcc, incomeAcc, batch.FeeAmount, &description)
+		if err != nil {
+			return err
+		}
+		if err := s.settlementRepo.SetFeeTransactionID(ctx, batch.ID, tx.ID); err != nil {
+			return err
+		}
+		batch.FeeTransactionID = &tx.ID
+	}
+	return nil
+}
+
+func (s *SettlementService) GetBatches(ctx context.Context) ([]*models.SettlementBatch, error) {
+	return s.settlementRepo.GetAll(ctx)
+}
+
+func (s *SettlementService) GetMerchantBatches(ctx context.Context, merchantID int64) ([]*models.SettlementBatch, error) {
+	return s.settlementRepo.GetByMerchantID(ctx, merchantID)
+}
+
+// GetReport собирает отчет по пакету. Если merchantID не 0, пакет должен
+// принадлежать этому мерчанту
+func (s *SettlementService) GetReport(ctx context.Context, batchID int64, merchantID int64) (*models.SettlementReport, error) {
+	batch, err := s.settlementRepo.GetByID(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSettlementNotFound
+		}
+		return nil, err
+	}
+
+	if merchantID != 0 && batch.MerchantID != merchantID {
+		return nil, ErrSettlementNotFound
+	}
+
+	merchant, err := s.merchantService.GetMerchant(ctx, batch.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.settlementRepo.GetBatchPayments(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SettlementReport{
+		Batch:    batch,
+		Merchant: merchant,
+		Lines:    make([]models.SettlementReportLine, 0, len(payments)),
+	}
+	for _, p := range payments {
+		fee := p.Amount.Mul(batch.FeeRate).Round(2)
+		report.Lines = append(report.Lines, models.SettlementReportLine{
+			PaymentID:  p.ID,
+			CardID:     p.CardID,
+			Amount:     p.Amount,
+			Fee:        fee,
+			Net:        p.Amount.Sub(fee),
+			CapturedAt: p.CreatedAt,
+		})
+	}
+
+	return report, nil
+}
+
+// WriteSettlementReportCSV выводит отчет в CSV: строка на платеж и итоговая строка
+func WriteSettlementReportCSV(w io.Writer, report *models.SettlementReport) error {
+	cw := csv.NewWriter(w)
+
+	batch := report.Batch
+	records := [][]string{
+		{"merchant_id", "merchant_name", "business_date", "batch_id", "currency", "fee_rate"},
+		{
+			strconv.FormatInt(batch.MerchantID, 10), report.Merchant.Name, batch.BusinessDate.Format(time.DateOnly),
+			strconv.FormatInt(batch.ID, 10), string(batch.Currency), batch.FeeRate.String(),
+		},
+		{},
+		{"payment_id", "card_id", "captured_at", "amount", "fee", "net"},
+	}
+	for _, line := range report.Lines {
+		records = append(records, []string{
+			strconv.FormatInt(line.PaymentID, 10), strconv.FormatInt(line.CardID, 10), line.CapturedAt.Format(time.RFC3339),
+			line.Amount.StringFixed(2), line.Fee.StringFixed(2), line.Net.StringFixed(2),
+		})
+	}
+	records = append(records, []string{
+		"TOTAL", strconv.Itoa(batch.PaymentCount), "",
+		batch.GrossAmount.StringFixed(2), batch.FeeAmount.StringFixed(2), batch.NetAmount.StringFixed(2),
+	})
+
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
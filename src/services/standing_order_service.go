@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/scheduler"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrStandingOrderNotFound   = errors.New("поручение не найдено")
+	ErrInvalidSchedule         = errors.New("неверное расписание поручения")
+	ErrStandingOrderTransition = errors.New("недопустимая смена статуса поручения")
+)
+
+// StandingOrderService ведет регулярные и отложенные переводы. Получатель
+// фиксируется при создании: свой счет по ID или основной счет другого
+// пользователя по email или телефону, как в P2PService
+type StandingOrderService struct {
+	orderRepo      *repository.StandingOrderRepository
+	accountService *AccountService
+	p2pService     *P2PService
+	notifier       Notifier
+	orderCfg       config.StandingOrderConfig
+}
+
+func NewStandingOrderService(orderRepo *repository.StandingOrderRepository, accountService *AccountService,
+	p2pService *P2PService, notifier Notifier, orderCfg config.StandingOrderConfig) *StandingOrderService {
+	return &StandingOrderService{
+		orderRepo:      orderRepo,
+		accountService: accountService,
+		p2pService:     p2pService,
+		notifier:       notifier,
+		orderCfg:       orderCfg,
+	}
+}
+
+func (s *StandingOrderService) CreateOrder(ctx context.Context, userID int64, req types.CreateStandingOrderReq) (*models.StandingOrder, error) {
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+
+	fromAcc, err := s.accountService.GetAccount(ctx, req.FromAccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if fromAcc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	toAccountID, err := s.resolveDestination(ctx, userID, fromAcc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &models.StandingOrder{
+		UserID:        userID,
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAccountID,
+		Amount:        req.Amount,
+		ScheduleType:  req.ScheduleType,
+		RunAt:         req.RunAt,
+		Weekday:       req.Weekday,
+		DayOfMonth:    req.DayOfMonth,
+		Status:        models.StandingOrderActive,
+	}
+	if req.Description != "" {
+		order.Description = &req.Description
+	}
+	if req.Cron != "" {
+		order.CronExpr = &req.Cron
+	}
+
+	if err := s.validateSchedule(order); err != nil {
+		return nil, err
+	}
+
+	first, ok := s.nextOccurrence(order, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("%w: нет ни одной даты исполнения в будущем", ErrInvalidSchedule)
+	}
+	order.ScheduledFor = &first
+	order.NextRunAt = &first
+
+	return s.orderRepo.Create(ctx, order)
+}
+
+func (s *StandingOrderService) resolveDestination(ctx context.Context, userID int64, fromAcc *models.Account,
+	req types.CreateStandingOrderReq) (int64, error) {
+	if req.ToAccountID == 0 {
+		_, toAcc, err := s.p2pService.resolveRecipientAccount(ctx, userID, req.Email, req.Phone, fromAcc.Currency)
+		if err != nil {
+			return 0, err
+		}
+		return toAcc.ID, nil
+	}
+
+	if req.Email != "" || req.Phone != "" {
+		return 0, ErrRecipientRequired
+	}
+
+	toAcc, err := s.accountService.GetAccount(ctx, req.ToAccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrAccountNotFound
+		}
+		return 0, err
+	}
+	if toAcc.UserID != userID {
+		return 0, ErrAccountNotFound
+	}
+	if toAcc.ID == fromAcc.ID {
+		return 0, ErrSameAccount
+	}
+	if toAcc.Currency != fromAcc.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	return toAcc.ID, nil
+}
+
+func (s *StandingOrderService) validateSchedule(order *models.StandingOrder) error {
+	switch order.ScheduleType {
+	case models.ScheduleOnce:
+		if order.RunAt == nil || !order.RunAt.After(time.Now()) {
+			return fmt.Errorf("%w: run_at должен быть в будущем", ErrInvalidSchedule)
+		}
+	case models.ScheduleWeekly:
+		if order.Weekday == nil || *order.Weekday < 0 || *order.Weekday > 6 {
+			return fmt.Errorf("%w: weekday должен быть от 0 (воскресенье) до 6", ErrInvalidSchedule)
+		}
+	case models.ScheduleMonthly:
+		if order.DayOfMonth == nil || *order.DayOfMonth < 1 || *order.DayOfMonth > 31 {
+			return fmt.Errorf("%w: day_of_month должен быть от 1 до 31", ErrInvalidSchedule)
+		}
+	case models.ScheduleCron:
+		if order.CronExpr == nil {
+			return fmt.Errorf("%w: не указано cron-выражение", ErrInvalidSchedule)
+		}
+		if _, err := scheduler.ParseCron(*order.CronExpr); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+	default:
+		return fmt.Errorf("%w: неизвестный тип %q", ErrInvalidSchedule, order.ScheduleType)
+	}
+	return nil
+}
+
+// nextOccurrence возвращает плановое время первого запуска после after.
+// false - запусков больше нет
+func (s *StandingOrderService) nextOccurrence(order *models.StandingOrder, after time.Time) (time.Time, bool) {
+	loc := s.orderCfg.Location
+	local := after.In(loc)
+
+	switch order.ScheduleType {
+	case models.ScheduleOnce:
+		if order.ScheduledFor != nil || order.RunAt == nil {
+			return time.Time{}, false
+		}
+		return *order.RunAt, true
+
+	case models.ScheduleWeekly:
+		candidate := time.Date(local.Year(), local.Month(), local.Day(), s.orderCfg.RunHour, 0, 0, 0, loc)
+		for int(candidate.Weekday()) != *order.Weekday || !candidate.After(after) {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+		return candidate, true
+
+	case models.ScheduleMonthly:
+		for i := 0; i < 2; i++ {
+			firstOfMonth := time.Date(local.Year(), local.Month()+time.Month(i), 1, 0, 0, 0, 0, loc)
+			// В коротких месяцах перевод уходит в последний день
+			day := min(*order.DayOfMonth, firstOfMonth.AddDate(0, 1, -1).Day())
+			candidate := time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, s.orderCfg.RunHour, 0, 0, 0, loc)
+			if candidate.After(after) {
+				return candidate, true
+			}
+		}
+		return time.Time{}, false
+
+	case models.ScheduleCron:
+		cron, err := scheduler.ParseCron(*order.CronExpr)
+		if err != nil {
+			return time.Time{}, false
+		}
+		next := cron.Next(local)
+		return next, !next.IsZero()
+	}
+
+	return time.Time{}, false
+}
+
+// ExecuteDue выполняет поручения, срок которых наступил. При нехватке средств
+// запуск повторяется с паузами из RetryBackoff, остальные ошибки сразу
+// считаются неудачей запуска
+func (s *StandingOrderService) ExecuteDue(ctx context.Context) error {
+	orders, err := s.orderRepo.ClaimDue(ctx, time.Now(), s.orderCfg.Lease)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, order := range orders {
+		if err := s.execute(ctx, order); err != nil {
+			errs = append(errs, fmt.Errorf("поручение %d: %w", order.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *StandingOrderService) execute(ctx context.Context, order *models.StandingOrder) error {
+	now := time.Now()
+	scheduledFor := now
+	if order.ScheduledFor != nil {
+		scheduledFor = *order.ScheduledFor
+	}
+
+	execution := &models.StandingOrderExecution{
+		OrderID:      order.ID,
+		ScheduledFor: scheduledFor,
+		Attempt:      order.RetryCount + 1,
+		Status:       models.ExecutionSucceeded,
+	}
+
+	_, transferErr := s.accountService.transferFromUser(ctx, order.FromAccountID, order.UserID, order.ToAccountID,
+		order.Amount, order.Description)
+	if transferErr != nil {
+		reason := transferErr.Error()
+		execution.Error = &reason
+	}
+
+	var notifyErr error
+	switch {
+	case transferErr == nil:
+		s.advance(order, scheduledFor, now, models.StandingOrderCompleted)
+
+	case errors.Is(transferErr, ErrInsufficientFunds) && order.RetryCount < len(s.orderCfg.RetryBackoff):
+		execution.Status = models.ExecutionRetrying
+		retryAt := now.Add(s.orderCfg.RetryBackoff[order.RetryCount])
+		order.NextRunAt = &retryAt
+		order.RetryCount++
+
+	default:
+		execution.Status = models.ExecutionFailed
+		s.advance(order, scheduledFor, now, models.StandingOrderFailed)
+		notifyErr = s.notifier.Notify(ctx, order.UserID, "Перевод по поручению не выполнен",
+			fmt.Sprintf("Перевод %s по поручению %d за %s не выполнен: %v",
+				order.Amount.StringFixed(2), order.ID, scheduledFor.In(s.orderCfg.Location).Format("02.01.2006"), transferErr))
+	}
+
+	if err := s.orderRepo.CompleteRun(ctx, order, execution); err != nil {
+		return errors.Join(err, notifyErr)
+	}
+	return notifyErr
+}
+
+// advance переводит поручение к следующему плановому запуску. Пропущенные
+// за время повторов запуски не наверстываются. Если запусков больше нет,
+// поручение получает статус final
+func (s *StandingOrderService) advance(order *models.StandingOrder, scheduledFor, now time.Time, final models.StandingOrderStatus) {
+	after := scheduledFor
+	if now.After(after) {
+		after = now
+	}
+
+	order.RetryCount = 0
+	next, ok := s.nextOccurrence(order, after)
+	if !ok {
+		order.Status = final
+		order.NextRunAt = nil
+		return
+	}
+	order.ScheduledFor = &next
+	order.NextRunAt = &next
+}
+
+func (s *StandingOrderService) GetOrder(ctx context.Context, orderID int64, userID int64) (*models.StandingOrder, []*models.StandingOrderExecution, error) {
+	order, err := s.getUserOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	executions, err := s.orderRepo.GetExecutions(ctx, order.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, executions, nil
+}
+
+func (s *StandingOrderService) GetUserOrders(ctx context.Context, userID int64) ([]*models.StandingOrder, error) {
+	return s.orderRepo.GetByUserID(ctx, userID)
+}
+
+func (s *StandingOrderService) PauseOrder(ctx context.Context, orderID int64, userID int64) (*models.StandingOrder, error) {
+	order, err := s.getUserOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.StandingOrderActive {
+		return nil, ErrStandingOrderTransition
+	}
+
+	order.Status = models.StandingOrderPaused
+	return order, s.updateStatus(ctx, order, models.StandingOrderActive)
+}
+
+// ResumeOrder возобновляет поручение. Запуски, пропущенные на паузе, не
+// выполняются, кроме разового перевода - он уходит сразу
+func (s *StandingOrderService) ResumeOrder(ctx context.Context, orderID int64, userID int64) (*models.StandingOrder, error) {
+	order, err := s.getUserOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.StandingOrderPaused {
+		return nil, ErrStandingOrderTransition
+	}
+
+	now := time.Now()
+	order.Status = models.StandingOrderActive
+	order.RetryCount = 0
+	if order.ScheduleType == models.ScheduleOnce {
+		if order.ScheduledFor != nil && order.ScheduledFor.Before(now) {
+			order.NextRunAt = &now
+		} else {
+			order.NextRunAt = order.ScheduledFor
+		}
+	} else {
+		next, ok := s.nextOccurrence(order, now)
+		if !ok {
+			return nil, ErrInvalidSchedule
+		}
+		order.ScheduledFor = &next
+		order.NextRunAt = &next
+	}
+
+	return order, s.updateStatus(ctx, order, models.StandingOrderPaused)
+}
+
+func (s *StandingOrderService) CancelOrder(ctx context.Context, orderID int64, userID int64) (*models.StandingOrder, error) {
+	order, err := s.getUserOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := order.Status
+	if from != models.StandingOrderActive && from != models.StandingOrderPaused {
+		return nil, ErrStandingOrderTransition
+	}
+
+	order.Status = models.StandingOrderCancelled
+	order.NextRunAt = nil
+	return order, s.updateStatus(ctx, order, from)
+}
+
+func (s *StandingOrderService) updateStatus(ctx context.Context, order *models.StandingOrder, from models.StandingOrderStatus) error {
+	err := s.orderRepo.UpdateStatus(ctx, order, from)
+	if errors.Is(err, repository.ErrStandingOrderStatusConflict) {
+		return ErrStandingOrderTransition
+	}
+	return err
+}
+
+func (s *StandingOrderService) getUserOrder(ctx context.Context, orderID int64, userID int64) (*models.StandingOrder, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStandingOrderNotFound
+		}
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, ErrStandingOrderNotFound
+	}
+	return order, nil
+}
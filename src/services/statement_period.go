@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"sf-finances/src/config"
+)
+
+// ParseStatementPeriod переводит даты периода выписки (YYYY-MM-DD, обе
+// включительно) в полуинтервал [from, to) по часовому поясу из конфига.
+// По умолчанию период - с начала текущего месяца по сегодня
+func ParseStatementPeriod(fromDate, toDate string, statementCfg config.StatementConfig, now time.Time) (time.Time, time.Time, error) {
+	loc := statementCfg.Location
+	today := now.In(loc)
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+
+	var err error
+	if fromDate != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, fromDate, loc); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: неверная дата начала %q", ErrInvalidPeriod, fromDate)
+		}
+	}
+	if toDate != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, toDate, loc); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: неверная дата конца %q", ErrInvalidPeriod, toDate)
+		}
+	}
+
+	to = to.AddDate(0, 0, 1)
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	if to.After(from.AddDate(0, 0, statementCfg.MaxPeriodDays)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: период длиннее %d дней", ErrInvalidPeriod, statementCfg.MaxPeriodDays)
+	}
+	return from, to, nil
+}
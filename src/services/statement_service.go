@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/pdf"
+	"sf-finances/src/repository"
+	"sf-finances/src/statement"
+)
+
+var ErrFontsNotLoaded = errors.New("шрифты печатной формы не загружены")
+
+// StatementService готовит выписки по счетам в машиночитаемых форматах и
+// печатную форму в PDF
+type StatementService struct {
+	accountService *AccountService
+	userRepo       repository.UserRepository
+	statementCfg   config.StatementConfig
+	bankingCfg     config.BankingConfig
+	regular        *pdf.Font
+	bold           *pdf.Font
+}
+
+func NewStatementService(accountService *AccountService, userRepo repository.UserRepository,
+	statementCfg config.StatementConfig, bankingCfg config.BankingConfig) *StatementService {
+	return &StatementService{
+		accountService: accountService,
+		userRepo:       userRepo,
+		statementCfg:   statementCfg,
+		bankingCfg:     bankingCfg,
+	}
+}
+
+func (s *StatementService) GetStatement(ctx context.Context, accountID int64, userID int64, from, to time.Time) (*models.Statement, error) {
+	return s.accountService.GetStatement(ctx, accountID, userID, from, to)
+}
+
+func (s *StatementService) Options(now time.Time) statement.Options {
+	return statement.Options{
+		BankID:      s.bankingCfg.BIC,
+		Location:    s.statementCfg.Location,
+		GeneratedAt: now,
+	}
+}
+
+// RenderPDF формирует печатную форму выписки за [from, to)
+func (s *StatementService) RenderPDF(ctx context.Context, accountID int64, userID int64, from, to time.Time) ([]byte, error) {
+	if s.regular == nil || s.bold == nil {
+		return nil, ErrFontsNotLoaded
+	}
+
+	st, err := s.accountService.GetStatement(ctx, accountID, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	holder, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holderName := strings.TrimSpace(holder.FirstName + " " + holder.LastName)
+	if holderName == "" {
+		holderName = holder.Email
+	}
+
+	var buf bytes.Buffer
+	err = statement.WritePDF(&buf, st, statement.PDFOptions{
+		Options:  s.Options(time.Now()),
+		BankName: s.statementCfg.BankName,
+		Holder:   holderName,
+		Regular:  s.regular,
+		Bold:     s.bold,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadFonts загружает шрифты печатной формы. Вызывается при старте, чтобы
+// ошибка в пути к шрифту не обнаружилась только на первом запросе выписки
+func (s *StatementService) LoadFonts() error {
+	regular, err := pdf.LoadFont(s.statementCfg.FontPath)
+	if err != nil {
+		return fmt.Errorf("загрузка шрифта %s: %w", s.statementCfg.FontPath, err)
+	}
+	bold, err := pdf.LoadFont(s.statementCfg.BoldFontPath)
+	if err != nil {
+		return fmt.Errorf("загрузка шрифта %s: %w", s.statementCfg.BoldFontPath, err)
+	}
+	s.regular, s.bold = regular, bold
+	return nil
+}
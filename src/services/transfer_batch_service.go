@@ -0,0 +1,415 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"sf-finances/src/banking"
+	"sf-finances/src/config"
+	"sf-finances/src/models"
+	"sf-finances/src/repository"
+	"sf-finances/src/types"
+)
+
+var (
+	ErrInvalidTransferBatch  = errors.New("неверный пакет переводов")
+	ErrTransferBatchNotFound = errors.New("пакет переводов не найден")
+)
+
+// TransferBatchValidationError перечисляет все ошибочные строки пакета, чтобы
+// клиент мог исправить их за один раз
+type TransferBatchValidationError struct {
+	Errors []types.TransferBatchItemError
+}
+
+func (e *TransferBatchValidationError) Error() string {
+	return fmt.Sprintf("%v: ошибок в строках: %d", ErrInvalidTransferBatch, len(e.Errors))
+}
+
+func (e *TransferBatchValidationError) Unwrap() error {
+	return ErrInvalidTransferBatch
+}
+
+// TransferBatchService выполняет пакетные переводы с одного счета. Общая сумма
+// списывается с источника в резерв сразу при создании пакета, фоновый
+// исполнитель переводит получателям из резерва, суммы неудавшихся переводов
+// возвращаются
+type TransferBatchService struct {
+	batchRepo      *repository.TransferBatchRepository
+	accountService *AccountService
+	notifier       Notifier
+	batchCfg       config.TransferBatchConfig
+}
+
+func NewTransferBatchService(batchRepo *repository.TransferBatchRepository, accountService *AccountService,
+	notifier Notifier, batchCfg config.TransferBatchConfig) *TransferBatchService {
+	return &TransferBatchService{
+		batchRepo:      batchRepo,
+		accountService: accountService,
+		notifier:       notifier,
+		batchCfg:       batchCfg,
+	}
+}
+
+func (s *TransferBatchService) CreateBatch(ctx context.Context, userID int64, req types.CreateTransferBatchReq) (*models.TransferBatch, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: пакет пуст", ErrInvalidTransferBatch)
+	}
+	if len(req.Items) > s.batchCfg.MaxItems {
+		return nil, fmt.Errorf("%w: в пакете больше %d переводов", ErrInvalidTransferBatch, s.batchCfg.MaxItems)
+	}
+
+	fromAcc, err := s.accountService.GetAccount(ctx, req.FromAccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if fromAcc.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	items, total, err := s.validateItems(ctx, fromAcc, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	reserve, err := s.accountService.Debit(ctx, fromAcc.ID, total)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.batchRepo.Create(ctx, &models.TransferBatch{
+		UserID:               userID,
+		FromAccountID:        fromAcc.ID,
+		Currency:             fromAcc.Currency,
+		TotalAmount:          total,
+		ItemCount:            len(items),
+		Status:               models.TransferBatchProcessing,
+		ReserveTransactionID: reserve.ID,
+	}, items)
+	if err != nil {
+		if _, refundErr := s.accountService.Credit(ctx, fromAcc.ID, total); refundErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("возврат резерва: %w", refundErr))
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// validateItems проверяет все строки пакета и возвращает их вместе с общей
+// суммой либо TransferBatchValidationError со списком всех ошибок
+func (s *TransferBatchService) validateItems(ctx context.Context, fromAcc *models.Account,
+	reqItems []types.TransferBatchItemReq) ([]*models.TransferBatchItem, decimal.Decimal, error) {
+	var (
+		items    []*models.TransferBatchItem
+		errs     []types.TransferBatchItemError
+		total    = decimal.Zero
+		accounts = map[string]*models.Account{}
+	)
+
+	for i, req := range reqItems {
+		line := req.Line
+		if line == 0 {
+			line = i + 1
+		}
+
+		toAcc, err := s.validateItem(ctx, fromAcc, req, accounts)
+		if err != nil {
+			errs = append(errs, types.TransferBatchItemError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		item := &models.TransferBatchItem{
+			Line:        line,
+			ToAccountID: toAcc.ID,
+			Amount:      req.Amount,
+			Status:      models.TransferItemPending,
+		}
+		if purpose := strings.TrimSpace(req.Purpose); purpose != "" {
+			item.Purpose = &purpose
+		}
+		items = append(items, item)
+		total = total.Add(req.Amount)
+	}
+
+	if len(errs) > 0 {
+		return nil, decimal.Zero, &TransferBatchValidationError{Errors: errs}
+	}
+	return items, total, nil
+}
+
+// validateItem проверяет строку пакета и возвращает счет получателя.
+// Найденные счета кэшируются в accounts по ID или номеру
+func (s *TransferBatchService) validateItem(ctx context.Context, fromAcc *models.Account, req types.TransferBatchItemReq,
+	accounts map[string]*models.Account) (*models.Account, error) {
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+	if req.Amount.Exponent() < -2 {
+		return nil, errors.New("сумма не может содержать больше двух знаков после запятой")
+	}
+
+	key := req.ToAccountNumber
+	if key == "" {
+		key = strconv.FormatInt(req.ToAccountID, 10)
+	}
+	toAcc, ok := accounts[key]
+	if !ok {
+		acc, err := s.findRecipient(ctx, fromAcc.UserID, req)
+		if err != nil && !errors.Is(err, ErrAccountNotFound) {
+			return nil, err
+		}
+		accounts[key] = acc
+		toAcc = acc
+	}
+	if toAcc == nil {
+		return nil, ErrAccountNotFound
+	}
+	if toAcc.ID == fromAcc.ID {
+		return nil, ErrSameAccount
+	}
+	if toAcc.Currency != fromAcc.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+	return toAcc, nil
+}
+
+// findRecipient находит счет получателя по номеру. По ID ищутся только
+// счета самого пользователя userID, чтобы по результатам строк нельзя
+// было перебирать чужие счета
+func (s *TransferBatchService) findRecipient(ctx context.Context, userID int64, req types.TransferBatchItemReq) (*models.Account, error) {
+	if req.ToAccountNumber != "" {
+		return s.accountService.GetAccountByNumber(ctx, req.ToAccountNumber)
+	}
+	return s.accountService.userAccount(ctx, req.ToAccountID, userID)
+}
+
+// ProcessBatches зачисляет переводы пакетов, находящихся в обработке
+func (s *TransferBatchService) ProcessBatches(ctx context.Context) error {
+	batches, err := s.batchRepo.ClaimProcessing(ctx, time.Now(), s.batchCfg.Lease)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, batch := range batches {
+		if err := s.processBatch(ctx, batch); err != nil {
+			errs = append(errs, fmt.Errorf("пакет %d: %w", batch.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *TransferBatchService) processBatch(ctx context.Context, batch *models.TransferBatch) error {
+	items, err := s.batchRepo.GetItems(ctx, batch.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Status != models.TransferItemPending {
+			continue
+		}
+
+		// Результат сохраняется сразу после перевода: если пакет прервется,
+		// следующий запуск продолжит с необработанных строк
+		tx, err := s.accountService.transferReserved(ctx, batch.FromAccountID, item.ToAccountID, item.Amount, item.Purpose)
+		switch {
+		case err == nil:
+			item.Status = models.TransferItemSucceeded
+			item.TransactionID = &tx.ID
+		case transferRejected(err):
+			reason := err.Error()
+			item.Status = models.TransferItemFailed
+			item.Error = &reason
+		case errors.Is(err, ErrTransferIncomplete):
+			// Средства уже у получателя: строка выполнена, возвращать нечего
+			reason := err.Error()
+			item.Status = models.TransferItemSucceeded
+			item.Error = &reason
+			if tx != nil {
+				item.TransactionID = &tx.ID
+			}
+		default:
+			// Перевод не проведен, строка останется в очереди до следующего запуска
+			return fmt.Errorf("строка %d: %w", item.Line, err)
+		}
+
+		if err := s.batchRepo.SetItemResult(ctx, item); err != nil {
+			return err
+		}
+	}
+
+	succeeded, failed := 0, decimal.Zero
+	for _, item := range items {
+		if item.Status == models.TransferItemSucceeded {
+			succeeded++
+		} else {
+			failed = failed.Add(item.Amount)
+		}
+	}
+
+	// Возврат сохраняется сразу: если пакет не удастся завершить, следующий
+	// запуск не вернет сумму повторно
+	if failed.IsPositive() && batch.RefundTransactionID == nil {
+		refund, err := s.accountService.Credit(ctx, batch.FromAccountID, failed)
+		if err != nil {
+			return fmt.Errorf("возврат суммы неудавшихся переводов: %w", err)
+		}
+		batch.RefundTransactionID = &refund.ID
+		if err := s.batchRepo.SetRefundTransactionID(ctx, batch.ID, refund.ID); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case succeeded == len(items):
+		batch.Status = models.TransferBatchCompleted
+	case succeeded == 0:
+		batch.Status = models.TransferBatchFailed
+	default:
+		batch.Status = models.TransferBatchPartial
+	}
+
+	if err := s.batchRepo.Complete(ctx, batch); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Пакет переводов %d обработан: выполнено %d из %d", batch.ID, succeeded, len(items))
+	if failed.IsPositive() {
+		message += fmt.Sprintf(", на счет возвращено %s %s", failed.StringFixed(2), batch.Currency)
+	}
+	return s.notifier.Notify(ctx, batch.UserID, "Пакет переводов обработан", message)
+}
+
+func (s *TransferBatchService) GetBatch(ctx context.Context, batchID int64, userID int64) (*models.TransferBatch, []*models.TransferBatchItem, error) {
+	batch, err := s.batchRepo.GetByID(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrTransferBatchNotFound
+		}
+		return nil, nil, err
+	}
+	if batch.UserID != userID {
+		return nil, nil, ErrTransferBatchNotFound
+	}
+
+	items, err := s.batchRepo.GetItems(ctx, batch.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return batch, items, nil
+}
+
+func (s *TransferBatchService) GetUserBatches(ctx context.Context, userID int64) ([]*models.TransferBatch, error) {
+	return s.batchRepo.GetByUserID(ctx, userID)
+}
+
+// ParseTransferBatchCSV читает строки пакета из CSV с колонками
+// to_account, amount, purpose. Получатель - ID счета или его 20-значный
+// номер. Заголовок необязателен. Разделителем может
+// быть запятая или точка с запятой; во втором случае в сумме допускается
+// десятичная запятая, как в выгрузках из Excel
+func ParseTransferBatchCSV(r io.Reader) ([]types.TransferBatchItemReq, error) {
+	br := bufio.NewReader(r)
+	firstLine, err := br.Peek(br.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	if i := strings.IndexByte(string(firstLine), '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	semicolon := strings.Contains(string(firstLine), ";")
+	if semicolon {
+		cr.Comma = ';'
+	}
+
+	var (
+		items []types.TransferBatchItemReq
+		errs  []types.TransferBatchItemError
+	)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: строка %d: %v", ErrInvalidTransferBatch, line, err)
+		}
+
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(items) == 0 && len(errs) == 0 && isTransferBatchHeader(record[0]) {
+			continue
+		}
+
+		item, err := parseTransferBatchRecord(record, semicolon)
+		if err != nil {
+			errs = append(errs, types.TransferBatchItemError{Line: line, Error: err.Error()})
+			continue
+		}
+		item.Line = line
+		items = append(items, item)
+	}
+
+	if len(errs) > 0 {
+		return nil, &TransferBatchValidationError{Errors: errs}
+	}
+	return items, nil
+}
+
+func parseTransferBatchRecord(record []string, decimalComma bool) (types.TransferBatchItemReq, error) {
+	if len(record) < 2 || len(record) > 3 {
+		return types.TransferBatchItemReq{}, errors.New("ожидаются колонки to_account, amount, purpose")
+	}
+
+	var item types.TransferBatchItemReq
+	recipient := strings.TrimSpace(record[0])
+	if len(recipient) == banking.AccountNumberLength {
+		item.ToAccountNumber = recipient
+	} else {
+		toAccountID, err := strconv.ParseInt(recipient, 10, 64)
+		if err != nil {
+			return types.TransferBatchItemReq{}, errors.New("неверный номер счета получателя")
+		}
+		item.ToAccountID = toAccountID
+	}
+
+	rawAmount := strings.TrimSpace(record[1])
+	if decimalComma {
+		rawAmount = strings.Replace(rawAmount, ",", ".", 1)
+	}
+	amount, err := decimal.NewFromString(rawAmount)
+	if err != nil {
+		return types.TransferBatchItemReq{}, errors.New("неверная сумма")
+	}
+
+	item.Amount = amount
+	if len(record) == 3 {
+		item.Purpose = record[2]
+	}
+	return item, nil
+}
+
+func isTransferBatchHeader(field string) bool {
+	field = strings.TrimSpace(field)
+	return strings.EqualFold(field, "to_account") || strings.EqualFold(field, "to_account_id")
+}
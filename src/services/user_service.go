@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -22,6 +23,7 @@ type UserService interface {
 	Register(ctx context.Context, req types.RegisterReq) (int64, error)
 	Login(ctx context.Context, req types.LoginReq) (string, error)
 	ParseToken(tokenString string) (int64, error)
+	IsAdmin(ctx context.Context, userID int64) (bool, error)
 }
 
 type userService struct {
@@ -43,8 +45,10 @@ func (s *userService) Register(ctx context.Context, req types.RegisterReq) (int6
 	}
 
 	user := &models.User{
-		Email:    req.Email,
-		Password: string(hashedPassword),
+		Email:     req.Email,
+		Password:  string(hashedPassword),
+		FirstName: strings.TrimSpace(req.FirstName),
+		LastName:  strings.TrimSpace(req.LastName),
 	}
 
 	id, err := s.userRepo.Create(ctx, user)
@@ -120,4 +124,13 @@ func (s *userService) ParseToken(tokenString string) (int64, error) {
 	}
 
 	return int64(userID), nil
-}
\ No newline at end of file
+}
+
+func (s *userService) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.Role == models.RoleAdmin, nil
+}
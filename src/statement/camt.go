@@ -0,0 +1,194 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camtDocument struct {
+	XMLName   xml.Name      `xml:"Document"`
+	Namespace string        `xml:"xmlns,attr"`
+	Statement camtBkToCstmr `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmr struct {
+	GroupHeader camtGroupHeader `xml:"GrpHdr"`
+	Statement   camtStmt        `xml:"Stmt"`
+}
+
+type camtGroupHeader struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStmt struct {
+	ID       string        `xml:"Id"`
+	CreDtTm  string        `xml:"CreDtTm"`
+	FrToDt   camtFromTo    `xml:"FrToDt"`
+	Account  camtAccount   `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Summary  camtSummary   `xml:"TxsSummry"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtFromTo struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string        `xml:"Id>Othr>Id"`
+	Currency string        `xml:"Ccy"`
+	Servicer *camtServicer `xml:"Svcr,omitempty"`
+}
+
+type camtServicer struct {
+	MemberID string `xml:"FinInstnId>ClrSysMmbId>MmbId"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type   string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount camtAmount `xml:"Amt"`
+	CdtDbt string     `xml:"CdtDbtInd"`
+	Date   string     `xml:"Dt>Dt"`
+}
+
+type camtSummary struct {
+	Credits camtSummaryTotal `xml:"TtlCdtNtries"`
+	Debits  camtSummaryTotal `xml:"TtlDbtNtries"`
+}
+
+type camtSummaryTotal struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Ref         string           `xml:"NtryRef"`
+	Amount      camtAmount       `xml:"Amt"`
+	CdtDbt      string           `xml:"CdtDbtInd"`
+	Status      string           `xml:"Sts"`
+	BookingDate string           `xml:"BookgDt>DtTm"`
+	ValueDate   string           `xml:"ValDt>Dt"`
+	ServicerRef string           `xml:"AcctSvcrRef"`
+	BankTxCode  string           `xml:"BkTxCd>Prtry>Cd"`
+	Details     *camtTransaction `xml:"NtryDtls>TxDtls,omitempty"`
+}
+
+type camtTransaction struct {
+	DebtorAccount   *camtParty `xml:"RltdPties>DbtrAcct,omitempty"`
+	CreditorAccount *camtParty `xml:"RltdPties>CdtrAcct,omitempty"`
+	Unstructured    string     `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+type camtParty struct {
+	ID string `xml:"Id>Othr>Id"`
+}
+
+// WriteCamt053 выводит выписку в формате ISO 20022 camt.053.001.02
+func WriteCamt053(w io.Writer, st *models.Statement, opts Options) error {
+	loc := opts.Location
+	currency := string(st.Account.Currency)
+	created := opts.GeneratedAt.In(loc).Format(time.RFC3339)
+	statementID := fmt.Sprintf("STMT-%d-%s", st.Account.ID, st.From.In(loc).Format("20060102"))
+
+	stmt := camtStmt{
+		ID:      statementID,
+		CreDtTm: created,
+		FrToDt: camtFromTo{
+			From: st.From.In(loc).Format(time.RFC3339),
+			To:   st.To.In(loc).Add(-time.Second).Format(time.RFC3339),
+		},
+		Account: camtAccount{ID: accountNumber(st), Currency: currency},
+		Balances: []camtBalance{
+			camtBalanceOf("OPBD", st.OpeningBalance, currency, st.From.In(loc)),
+			camtBalanceOf("CLBD", st.ClosingBalance, currency, st.To.In(loc).AddDate(0, 0, -1)),
+		},
+	}
+	if opts.BankID != "" {
+		stmt.Account.Servicer = &camtServicer{MemberID: opts.BankID}
+	}
+
+	for _, tx := range st.Transactions {
+		cdtDbt := "DBIT"
+		if tx.Type == models.DEPOSIT {
+			cdtDbt = "CRDT"
+			stmt.Summary.Credits.Count++
+		} else {
+			stmt.Summary.Debits.Count++
+		}
+
+		ref := strconv.FormatInt(tx.ID, 10)
+		entry := camtEntry{
+			Ref:         ref,
+			Amount:      camtAmount{Currency: currency, Value: tx.Amount.StringFixed(2)},
+			CdtDbt:      cdtDbt,
+			Status:      "BOOK",
+			BookingDate: tx.CreatedAt.In(loc).Format(time.RFC3339),
+			ValueDate:   tx.CreatedAt.In(loc).Format(time.DateOnly),
+			ServicerRef: ref,
+			BankTxCode:  string(tx.Type),
+		}
+		if cp := counterparty(tx); cp != "" || tx.Description != nil {
+			details := &camtTransaction{Unstructured: truncate(description(tx), 140)}
+			// Вторая сторона для зачисления - плательщик, для списания - получатель
+			switch {
+			case cp == "":
+			case tx.Type == models.DEPOSIT:
+				details.DebtorAccount = &camtParty{ID: cp}
+			default:
+				details.CreditorAccount = &camtParty{ID: cp}
+			}
+			entry.Details = details
+		}
+		stmt.Entries = append(stmt.Entries, entry)
+	}
+	stmt.Summary.Credits.Sum = st.TotalIn.StringFixed(2)
+	stmt.Summary.Debits.Sum = st.TotalOut.StringFixed(2)
+
+	doc := camtDocument{
+		Namespace: camt053Namespace,
+		Statement: camtBkToCstmr{
+			GroupHeader: camtGroupHeader{MsgID: statementID, CreDtTm: created},
+			Statement:   stmt,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// camtBalanceOf записывает остаток модулем суммы с признаком CRDT/DBIT
+func camtBalanceOf(balanceType string, amount decimal.Decimal, currency string, date time.Time) camtBalance {
+	cdtDbt := "CRDT"
+	if amount.IsNegative() {
+		cdtDbt = "DBIT"
+	}
+	return camtBalance{
+		Type:   balanceType,
+		Amount: camtAmount{Currency: currency, Value: amount.Abs().StringFixed(2)},
+		CdtDbt: cdtDbt,
+		Date:   date.Format(time.DateOnly),
+	}
+}
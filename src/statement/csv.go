@@ -0,0 +1,43 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"sf-finances/src/models"
+)
+
+// WriteCSV выводит выписку в CSV: реквизиты счета и остатки, затем строка на
+// операцию и итоговые обороты
+func WriteCSV(w io.Writer, st *models.Statement, opts Options) error {
+	cw := csv.NewWriter(w)
+	loc := opts.Location
+
+	records := [][]string{
+		{"account_number", "currency", "from", "to", "opening_balance", "closing_balance"},
+		{
+			accountNumber(st), string(st.Account.Currency),
+			st.From.In(loc).Format(time.DateOnly), st.To.In(loc).AddDate(0, 0, -1).Format(time.DateOnly),
+			st.OpeningBalance.StringFixed(2), st.ClosingBalance.StringFixed(2),
+		},
+		{},
+		{"transaction_id", "booked_at", "type", "amount", "counterparty_account_number", "description"},
+	}
+	for _, tx := range st.Transactions {
+		records = append(records, []string{
+			strconv.FormatInt(tx.ID, 10), tx.CreatedAt.In(loc).Format(time.RFC3339), string(tx.Type),
+			signedAmount(tx), counterparty(tx), description(tx),
+		})
+	}
+	records = append(records,
+		[]string{"TOTAL_IN", "", "", st.TotalIn.StringFixed(2), "", ""},
+		[]string{"TOTAL_OUT", "", "", st.TotalOut.Neg().StringFixed(2), "", ""},
+	)
+
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
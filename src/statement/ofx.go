@@ -0,0 +1,151 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"sf-finances/src/models"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+type ofxDocument struct {
+	XMLName xml.Name        `xml:"OFX"`
+	SignOn  ofxSignOn       `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStmtResponse `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStmtResponse struct {
+	TrnUID    string    `xml:"TRNUID"`
+	Status    ofxStatus `xml:"STATUS"`
+	Statement ofxStmt   `xml:"STMTRS"`
+}
+
+type ofxStmt struct {
+	CurDef    string         `xml:"CURDEF"`
+	Account   ofxBankAccount `xml:"BANKACCTFROM"`
+	TranList  ofxTranList    `xml:"BANKTRANLIST"`
+	LedgerBal ofxBalance     `xml:"LEDGERBAL"`
+	AvailBal  ofxBalance     `xml:"AVAILBAL"`
+}
+
+type ofxBankAccount struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxTranList struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// WriteOFX выводит выписку в OFX 2.2 (XML) как ответ на запрос выписки по
+// текущему счету
+func WriteOFX(w io.Writer, st *models.Statement, opts Options) error {
+	loc := opts.Location
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			DTServer: ofxTime(opts.GeneratedAt.In(loc)),
+			Language: "RUS",
+		},
+		Bank: ofxStmtResponse{
+			TrnUID: fmt.Sprintf("%d-%d", st.Account.ID, opts.GeneratedAt.Unix()),
+			Status: ofxStatus{Code: 0, Severity: "INFO"},
+			Statement: ofxStmt{
+				CurDef: string(st.Account.Currency),
+				Account: ofxBankAccount{
+					BankID:   opts.BankID,
+					AcctID:   accountNumber(st),
+					AcctType: "CHECKING",
+				},
+				TranList: ofxTranList{
+					DTStart: ofxTime(st.From.In(loc)),
+					DTEnd:   ofxTime(st.To.In(loc)),
+				},
+				LedgerBal: ofxBalance{BalAmt: st.ClosingBalance.StringFixed(2), DTAsOf: ofxTime(st.To.In(loc))},
+				AvailBal:  ofxBalance{BalAmt: st.ClosingBalance.StringFixed(2), DTAsOf: ofxTime(st.To.In(loc))},
+			},
+		},
+	}
+
+	for _, tx := range st.Transactions {
+		trnType := "DEBIT"
+		if tx.Type == models.DEPOSIT {
+			trnType = "CREDIT"
+		}
+		if tx.CounterpartyAccountID != nil {
+			trnType = "XFER"
+		}
+
+		ofxTx := ofxTransaction{
+			TrnType:  trnType,
+			DTPosted: ofxTime(tx.CreatedAt.In(loc)),
+			TrnAmt:   signedAmount(tx),
+			FITID:    strconv.FormatInt(tx.ID, 10),
+			Memo:     truncate(description(tx), 255),
+		}
+		if cp := counterparty(tx); cp != "" {
+			ofxTx.Name = "Счет " + cp
+		}
+		doc.Bank.Statement.TranList.Transactions = append(doc.Bank.Statement.TranList.Transactions, ofxTx)
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ofxTime форматирует время как YYYYMMDDHHMMSS.XXX[смещение:зона]
+func ofxTime(t time.Time) string {
+	name, offset := t.Zone()
+	return fmt.Sprintf("%s[%+g:%s]", t.Format("20060102150405.000"), float64(offset)/3600, name)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,282 @@
+package statement
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+	"sf-finances/src/pdf"
+)
+
+// PDFOptions - реквизиты для печатной формы выписки
+type PDFOptions struct {
+	Options
+	BankName string
+	Holder   string
+	Regular  *pdf.Font
+	Bold     *pdf.Font
+}
+
+const (
+	pdfMargin     = 40.0
+	pdfFooter     = 50.0
+	pdfRowHeight  = 16.0
+	pdfStampSpace = 130.0
+)
+
+type pdfColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+var pdfColumns = []pdfColumn{
+	{title: "Дата", width: 56},
+	{title: "№ операции", width: 66},
+	{title: "Контрагент", width: 63},
+	{title: "Назначение платежа", width: 190},
+	{title: "Поступление", width: 70, right: true},
+	{title: "Списание", width: 70, right: true},
+}
+
+// WritePDF выводит печатную форму выписки: реквизиты счета и остатки,
+// таблицу операций с переносом на следующие страницы и штамп банка
+func WritePDF(w io.Writer, st *models.Statement, opts PDFOptions) error {
+	loc := opts.Location
+	doc := pdf.NewDocument()
+	doc.Title = fmt.Sprintf("Выписка по счету %s", accountNumber(st))
+	doc.Author = opts.BankName
+	doc.Created = opts.GeneratedAt
+
+	l := &pdfLayout{doc: doc, opts: opts}
+	page := l.newPage()
+
+	page.SetFont(opts.Bold, 13)
+	page.Text(pdfMargin, l.y, opts.BankName)
+	page.SetFont(opts.Regular, 8)
+	page.TextRight(pdf.A4Width-pdfMargin, l.y, "Сформирована "+opts.GeneratedAt.In(loc).Format("02.01.2006 15:04"))
+	l.y -= 36
+
+	page.SetFont(opts.Bold, 16)
+	page.TextCenter(pdf.A4Width/2, l.y, "ВЫПИСКА ПО СЧЕТУ")
+	l.y -= 18
+	page.SetFont(opts.Regular, 10)
+	page.TextCenter(pdf.A4Width/2, l.y, fmt.Sprintf("за период с %s по %s",
+		st.From.In(loc).Format("02.01.2006"), st.To.In(loc).AddDate(0, 0, -1).Format("02.01.2006")))
+	l.y -= 30
+
+	currency := string(st.Account.Currency)
+	details := [][2]string{
+		{"Владелец счета", opts.Holder},
+		{"Номер счета", accountNumber(st)},
+		{"Валюта счета", currency},
+		{"Входящий остаток", formatMoney(st.OpeningBalance) + " " + currency},
+		{"Поступления за период", formatMoney(st.TotalIn) + " " + currency},
+		{"Списания за период", formatMoney(st.TotalOut) + " " + currency},
+		{"Исходящий остаток", formatMoney(st.ClosingBalance) + " " + currency},
+	}
+	for _, d := range details {
+		page.SetFont(opts.Regular, 10)
+		page.Text(pdfMargin, l.y, d[0]+":")
+		page.SetFont(opts.Bold, 10)
+		page.Text(pdfMargin+140, l.y, d[1])
+		l.y -= 15
+	}
+	l.y -= 15
+
+	l.tableHeader()
+	for i, tx := range st.Transactions {
+		if l.y-pdfRowHeight < pdfFooter {
+			l.newPage()
+			l.tableHeader()
+		}
+
+		in, out := "", ""
+		if tx.Type == models.DEPOSIT {
+			in = formatMoney(tx.Amount)
+		} else {
+			out = formatMoney(tx.Amount)
+		}
+		l.row(i%2 == 1, opts.Regular, []string{
+			tx.CreatedAt.In(loc).Format("02.01.2006"),
+			strconv.FormatInt(tx.ID, 10),
+			counterparty(tx),
+			description(tx),
+			in,
+			out,
+		})
+	}
+	if len(st.Transactions) == 0 {
+		l.page.SetFont(opts.Regular, 9)
+		l.page.Text(pdfMargin+4, l.y-11, "Операций за период нет")
+		l.y -= pdfRowHeight
+	}
+	l.row(false, opts.Bold, []string{"Итого", "", "", "", formatMoney(st.TotalIn), formatMoney(st.TotalOut)})
+
+	if l.y-pdfStampSpace < pdfFooter {
+		l.newPage()
+	}
+	l.stamp(st)
+	l.footers()
+
+	return doc.Write(w)
+}
+
+type pdfLayout struct {
+	doc  *pdf.Document
+	opts PDFOptions
+	page *pdf.Page
+	y    float64
+}
+
+func (l *pdfLayout) newPage() *pdf.Page {
+	l.page = l.doc.AddPage(pdf.A4Width, pdf.A4Height)
+	l.page.SetLineWidth(0.5)
+	l.page.SetStrokeColor(0.6, 0.6, 0.6)
+	l.y = pdf.A4Height - pdfMargin - 10
+	return l.page
+}
+
+func (l *pdfLayout) tableHeader() {
+	p := l.page
+	p.SetFillColor(0.88, 0.9, 0.94)
+	p.Rect(pdfMargin, l.y-pdfRowHeight, pdf.A4Width-2*pdfMargin, pdfRowHeight, true, true)
+	p.SetFillColor(0, 0, 0)
+	l.cells(l.opts.Bold, headerTitles())
+	l.y -= pdfRowHeight
+}
+
+func headerTitles() []string {
+	titles := make([]string, len(pdfColumns))
+	for i, c := range pdfColumns {
+		titles[i] = c.title
+	}
+	return titles
+}
+
+func (l *pdfLayout) row(shaded bool, font *pdf.Font, values []string) {
+	p := l.page
+	if shaded {
+		p.SetFillColor(0.97, 0.97, 0.97)
+		p.Rect(pdfMargin, l.y-pdfRowHeight, pdf.A4Width-2*pdfMargin, pdfRowHeight, true, false)
+		p.SetFillColor(0, 0, 0)
+	}
+	l.cells(font, values)
+	p.Line(pdfMargin, l.y-pdfRowHeight, pdf.A4Width-pdfMargin, l.y-pdfRowHeight)
+	l.y -= pdfRowHeight
+}
+
+// cells выводит значения по колонкам, обрезая не помещающийся текст
+func (l *pdfLayout) cells(font *pdf.Font, values []string) {
+	const padding = 4.0
+	p := l.page
+	p.SetFont(font, 8)
+
+	x := pdfMargin
+	baseline := l.y - pdfRowHeight + 5
+	for i, c := range pdfColumns {
+		text := fitText(font, 8, values[i], c.width-2*padding)
+		if c.right {
+			p.TextRight(x+c.width-padding, baseline, text)
+		} else {
+			p.Text(x+padding, baseline, text)
+		}
+		x += c.width
+	}
+}
+
+// stamp рисует отметку банка: подпись уполномоченного лица, круглый штамп
+// и контрольный код выписки
+func (l *pdfLayout) stamp(st *models.Statement) {
+	p := l.page
+	opts := l.opts
+	l.y -= 30
+
+	p.SetFont(opts.Regular, 9)
+	p.Text(pdfMargin, l.y, "Выписка сформирована в электронном виде на основании данных учета банка.")
+	l.y -= 13
+	p.Text(pdfMargin, l.y, "Контрольный код: "+verificationCode(st))
+	l.y -= 40
+
+	p.SetStrokeColor(0, 0, 0)
+	p.Line(pdfMargin+150, l.y, pdfMargin+290, l.y)
+	p.Text(pdfMargin, l.y+2, "Уполномоченный сотрудник")
+	p.SetFont(opts.Regular, 7)
+	p.TextCenter(pdfMargin+220, l.y-9, "(подпись)")
+
+	cx, cy := pdf.A4Width-pdfMargin-70, l.y+10
+	p.SetStrokeColor(0.15, 0.25, 0.65)
+	p.SetFillColor(0.15, 0.25, 0.65)
+	p.SetLineWidth(1.5)
+	p.Circle(cx, cy, 50)
+	p.SetLineWidth(0.75)
+	p.Circle(cx, cy, 42)
+	p.SetFont(opts.Bold, 8)
+	p.TextCenter(cx, cy+10, fitText(opts.Bold, 8, opts.BankName, 76))
+	p.SetFont(opts.Bold, 9)
+	p.TextCenter(cx, cy-3, "ВЫПИСКА ВЕРНА")
+	p.SetFont(opts.Regular, 7)
+	p.TextCenter(cx, cy-15, opts.GeneratedAt.In(opts.Location).Format("02.01.2006"))
+
+	p.SetFillColor(0, 0, 0)
+	p.SetStrokeColor(0.6, 0.6, 0.6)
+	p.SetLineWidth(0.5)
+	l.y -= 70
+}
+
+func (l *pdfLayout) footers() {
+	pages := l.doc.Pages()
+	for i, p := range pages {
+		p.SetFont(l.opts.Regular, 7)
+		p.SetFillColor(0.4, 0.4, 0.4)
+		p.Text(pdfMargin, 25, l.opts.BankName)
+		p.TextRight(pdf.A4Width-pdfMargin, 25, fmt.Sprintf("Страница %d из %d", i+1, len(pages)))
+	}
+}
+
+// verificationCode - короткий отпечаток выписки: по нему банк может
+// подтвердить, что печатная форма не изменялась
+func verificationCode(st *models.Statement) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%d|%d|%s|%s", st.Account.ID, st.From.Unix(), st.To.Unix(),
+		st.OpeningBalance.StringFixed(2), st.ClosingBalance.StringFixed(2))
+	for _, tx := range st.Transactions {
+		fmt.Fprintf(h, "|%d:%s", tx.ID, tx.Amount.StringFixed(2))
+	}
+	sum := strings.ToUpper(fmt.Sprintf("%x", h.Sum(nil)[:8]))
+	return sum[:4] + "-" + sum[4:8] + "-" + sum[8:12] + "-" + sum[12:]
+}
+
+func fitText(font *pdf.Font, size float64, s string, width float64) string {
+	if font.TextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && font.TextWidth(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// formatMoney форматирует сумму по-русски: 1 234 567,89
+func formatMoney(d decimal.Decimal) string {
+	s := d.Abs().StringFixed(2)
+	intPart, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if d.IsNegative() {
+		b.WriteString("-")
+	}
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(" ")
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString("," + frac)
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/pdf"
+)
+
+// Разряды разделяются неразрывным пробелом, чтобы сумма не переносилась
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"0", "0,00"},
+		{"5.5", "5,50"},
+		{"999.99", "999,99"},
+		{"1000", "1\u00a0000,00"},
+		{"1234567.891", "1\u00a0234\u00a0567,89"},
+		{"-1500.5", "-1\u00a0500,50"},
+		{"-100", "-100,00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			if got := formatMoney(decimal.RequireFromString(tt.amount)); got != tt.want {
+				t.Errorf("formatMoney = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerificationCode(t *testing.T) {
+	st := testStatement()
+	code := verificationCode(st)
+	if len(code) != 19 || strings.Count(code, "-") != 3 {
+		t.Fatalf("код %q", code)
+	}
+	if verificationCode(testStatement()) != code {
+		t.Error("код одной и той же выписки различается")
+	}
+
+	st.Transactions[1].Amount = decimal.RequireFromString("1500.51")
+	if verificationCode(st) == code {
+		t.Error("код не изменился после изменения суммы операции")
+	}
+}
+
+func loadFonts(t *testing.T) (*pdf.Font, *pdf.Font) {
+	t.Helper()
+	dir := filepath.Join("..", "..", "data", "fonts")
+	regular, err := pdf.LoadFont(filepath.Join(dir, "DejaVuSans.ttf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bold, err := pdf.LoadFont(filepath.Join(dir, "DejaVuSans-Bold.ttf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return regular, bold
+}
+
+func TestFitText(t *testing.T) {
+	regular, _ := loadFonts(t)
+	tests := []struct {
+		name  string
+		text  string
+		width float64
+	}{
+		{"помещается", "Перевод", 100},
+		{"обрезается", "Перевод по номеру телефона на счет в другом банке", 100},
+		{"пустая строка", "", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fitText(regular, 8, tt.text, tt.width)
+			if regular.TextWidth(got, 8) > tt.width && got != "…" {
+				t.Errorf("%q шире %g", got, tt.width)
+			}
+			if regular.TextWidth(tt.text, 8) <= tt.width && got != tt.text {
+				t.Errorf("%q обрезан до %q", tt.text, got)
+			}
+			if got != tt.text && !strings.HasPrefix(tt.text, strings.TrimSuffix(got, "…")) {
+				t.Errorf("%q не начало %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	regular, bold := loadFonts(t)
+
+	tests := []struct {
+		rows  int
+		pages int
+	}{
+		{0, 1},
+		{2, 1},
+		{120, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d операций", tt.rows), func(t *testing.T) {
+			st := testStatement()
+			template := st.Transactions
+			st.Transactions = nil
+			for i := 0; i < tt.rows; i++ {
+				tx := *template[i%len(template)]
+				tx.ID = int64(100 + i)
+				st.Transactions = append(st.Transactions, &tx)
+			}
+
+			var buf bytes.Buffer
+			err := WritePDF(&buf, st, PDFOptions{
+				Options:  testOptions(),
+				BankName: "SF Finances",
+				Holder:   "Иван Петров",
+				Regular:  regular,
+				Bold:     bold,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := buf.Bytes()
+			if !bytes.HasPrefix(out, []byte("%PDF-1.7")) || !bytes.HasSuffix(bytes.TrimSpace(out), []byte("%%EOF")) {
+				t.Error("нет заголовка или конца PDF")
+			}
+			if got := bytes.Count(out, []byte("/Type /Page /Parent")); got != tt.pages {
+				t.Errorf("страниц %d, ожидалось %d", got, tt.pages)
+			}
+			// Шрифты встраиваются подмножеством, а не целиком (~1 МБ на два шрифта)
+			if buf.Len() > 150<<10 {
+				t.Errorf("размер %d байт", buf.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package statement выводит выписку по счету (models.Statement) в форматах
+// для внешних программ: CSV, OFX 2.x и ISO 20022 camt.053.
+package statement
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sf-finances/src/models"
+)
+
+var ErrUnsupportedFormat = errors.New("неподдерживаемый формат выписки")
+
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatCSV     Format = "csv"
+	FormatOFX     Format = "ofx"
+	FormatCamt053 Format = "camt053"
+)
+
+// Options - реквизиты, которых нет в самой выписке
+type Options struct {
+	// БИК банка, обслуживающего счет
+	BankID string
+	// Часовой пояс дат операций
+	Location *time.Location
+	// Время формирования выписки
+	GeneratedAt time.Time
+}
+
+var mediaTypes = map[Format]string{
+	FormatJSON:    "application/json",
+	FormatCSV:     "text/csv; charset=utf-8",
+	FormatOFX:     "application/x-ofx",
+	FormatCamt053: "application/xml",
+}
+
+var formatsByMediaType = map[string]Format{
+	"application/json":                      FormatJSON,
+	"text/csv":                              FormatCSV,
+	"application/x-ofx":                     FormatOFX,
+	"application/ofx":                       FormatOFX,
+	"application/xml":                       FormatCamt053,
+	"text/xml":                              FormatCamt053,
+	"application/vnd.iso20022.camt.053+xml": FormatCamt053,
+}
+
+func ContentType(f Format) string {
+	return mediaTypes[f]
+}
+
+// FileExtension возвращает расширение файла выписки без точки
+func FileExtension(f Format) string {
+	switch f {
+	case FormatCamt053:
+		return "xml"
+	default:
+		return string(f)
+	}
+}
+
+// Negotiate выбирает формат выписки. Параметр format запроса важнее
+// заголовка Accept; без того и другого выписка отдается в JSON
+func Negotiate(format, accept string) (Format, error) {
+	if format != "" {
+		f := Format(strings.ToLower(strings.ReplaceAll(format, ".", "")))
+		if _, ok := mediaTypes[f]; !ok {
+			return "", ErrUnsupportedFormat
+		}
+		return f, nil
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, nil
+	}
+
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if f, ok := formatsByMediaType[c.mediaType]; ok {
+			return f, nil
+		}
+		if c.mediaType == "*/*" || c.mediaType == "application/*" {
+			return FormatJSON, nil
+		}
+	}
+	return "", ErrUnsupportedFormat
+}
+
+func Write(w io.Writer, f Format, st *models.Statement, opts Options) error {
+	switch f {
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(st)
+	case FormatCSV:
+		return WriteCSV(w, st, opts)
+	case FormatOFX:
+		return WriteOFX(w, st, opts)
+	case FormatCamt053:
+		return WriteCamt053(w, st, opts)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// signedAmount возвращает сумму операции со знаком: зачисления
+// положительные, списания отрицательные
+func signedAmount(tx *models.Transaction) string {
+	if tx.Type == models.DEPOSIT {
+		return tx.Amount.StringFixed(2)
+	}
+	return tx.Amount.Neg().StringFixed(2)
+}
+
+func accountNumber(st *models.Statement) string {
+	return st.Account.Number
+}
+
+func counterparty(tx *models.Transaction) string {
+	if tx.CounterpartyAccountNumber == nil {
+		return ""
+	}
+	return *tx.CounterpartyAccountNumber
+}
+
+func description(tx *models.Transaction) string {
+	if tx.Description == nil {
+		return ""
+	}
+	return *tx.Description
+}
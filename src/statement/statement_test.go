@@ -0,0 +1,197 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+var msk = time.FixedZone("MSK", 3*60*60)
+
+func testStatement() *models.Statement {
+	counterparty := "40817810500000000002"
+	salary, transfer := "Зарплата за сентябрь", "Перевод; «за ужин», часть 1"
+	return &models.Statement{
+		Account:        &models.Account{ID: 1, Number: "40817810500000000001", Currency: models.RUB},
+		From:           time.Date(2026, 10, 1, 0, 0, 0, 0, msk),
+		To:             time.Date(2026, 11, 1, 0, 0, 0, 0, msk),
+		OpeningBalance: decimal.RequireFromString("100"),
+		TotalIn:        decimal.RequireFromString("50000"),
+		TotalOut:       decimal.RequireFromString("1500.5"),
+		ClosingBalance: decimal.RequireFromString("48599.5"),
+		Transactions: []*models.Transaction{
+			{ID: 10, Amount: decimal.RequireFromString("50000"), Type: models.DEPOSIT, Description: &salary,
+				CreatedAt: time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)},
+			{ID: 11, Amount: decimal.RequireFromString("1500.5"), Type: models.WITHDRAWAL,
+				CounterpartyAccountID: new(int64), CounterpartyAccountNumber: &counterparty, Description: &transfer,
+				CreatedAt: time.Date(2026, 10, 31, 22, 30, 0, 0, time.UTC)},
+		},
+	}
+}
+
+func testOptions() Options {
+	return Options{BankID: "044525000", Location: msk, GeneratedAt: time.Date(2026, 11, 1, 8, 0, 0, 0, msk)}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		accept  string
+		want    Format
+		wantErr error
+	}{
+		{"по умолчанию JSON", "", "", FormatJSON, nil},
+		{"параметр format", "csv", "application/json", FormatCSV, nil},
+		{"format с точкой и в верхнем регистре", ".OFX", "", FormatOFX, nil},
+		{"неизвестный format", "xlsx", "", "", ErrUnsupportedFormat},
+		{"Accept", "", "text/csv", FormatCSV, nil},
+		{"Accept с параметрами", "", "text/csv; charset=utf-8", FormatCSV, nil},
+		{"Accept по весу", "", "application/json;q=0.5, application/x-ofx", FormatOFX, nil},
+		{"Accept camt.053", "", "application/vnd.iso20022.camt.053+xml", FormatCamt053, nil},
+		{"q=0 исключает формат", "", "text/csv;q=0, */*;q=0.1", FormatJSON, nil},
+		{"любой тип", "", "*/*", FormatJSON, nil},
+		{"неподдерживаемый Accept", "", "image/png", "", ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Negotiate(tt.format, tt.accept)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("формат %q, ожидался %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testStatement(), testOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Пустая строка между остатками и операциями при чтении пропускается
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"account_number", "currency", "from", "to", "opening_balance", "closing_balance"},
+		{"40817810500000000001", "RUB", "2026-10-01", "2026-10-31", "100.00", "48599.50"},
+		{"transaction_id", "booked_at", "type", "amount", "counterparty_account_number", "description"},
+		{"10", "2026-10-05T12:00:00+03:00", "DEPOSIT", "50000.00", "", "Зарплата за сентябрь"},
+		{"11", "2026-11-01T01:30:00+03:00", "WITHDRAWAL", "-1500.50", "40817810500000000002", "Перевод; «за ужин», часть 1"},
+		{"TOTAL_IN", "", "", "50000.00", "", ""},
+		{"TOTAL_OUT", "", "", "-1500.50", "", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("строк %d, ожидалось %d", len(records), len(want))
+	}
+	for i := range want {
+		if !slices.Equal(records[i], want[i]) {
+			t.Errorf("строка %d: %q, ожидалось %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestWriteOFX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteOFX(&buf, testStatement(), testOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<?OFX OFXHEADER="200" VERSION="220"`) {
+		t.Error("нет заголовка OFX 2.2")
+	}
+
+	var doc ofxDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	stmt := doc.Bank.Statement
+	if stmt.Account.AcctID != "40817810500000000001" || stmt.Account.BankID != "044525000" || stmt.CurDef != "RUB" {
+		t.Errorf("реквизиты счета %+v", stmt.Account)
+	}
+	if stmt.LedgerBal.BalAmt != "48599.50" {
+		t.Errorf("остаток %s", stmt.LedgerBal.BalAmt)
+	}
+	if stmt.TranList.DTStart != "20261001000000.000[+3:MSK]" {
+		t.Errorf("DTSTART %s", stmt.TranList.DTStart)
+	}
+
+	want := []ofxTransaction{
+		{TrnType: "CREDIT", DTPosted: "20261005120000.000[+3:MSK]", TrnAmt: "50000.00", FITID: "10", Memo: "Зарплата за сентябрь"},
+		{TrnType: "XFER", DTPosted: "20261101013000.000[+3:MSK]", TrnAmt: "-1500.50", FITID: "11",
+			Name: "Счет 40817810500000000002", Memo: "Перевод; «за ужин», часть 1"},
+	}
+	if !slices.Equal(stmt.TranList.Transactions, want) {
+		t.Errorf("операции %+v, ожидалось %+v", stmt.TranList.Transactions, want)
+	}
+}
+
+func TestWriteCamt053(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCamt053(&buf, testStatement(), testOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc camtDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.XMLName.Space != camt053Namespace {
+		t.Errorf("пространство имен %q", doc.XMLName.Space)
+	}
+	stmt := doc.Statement.Statement
+	if stmt.Account.ID != "40817810500000000001" || stmt.Account.Servicer == nil ||
+		stmt.Account.Servicer.MemberID != "044525000" {
+		t.Errorf("счет %+v", stmt.Account)
+	}
+	if stmt.FrToDt.To != "2026-10-31T23:59:59+03:00" {
+		t.Errorf("конец периода %s", stmt.FrToDt.To)
+	}
+
+	wantBalances := []camtBalance{
+		{Type: "OPBD", Amount: camtAmount{Currency: "RUB", Value: "100.00"}, CdtDbt: "CRDT", Date: "2026-10-01"},
+		{Type: "CLBD", Amount: camtAmount{Currency: "RUB", Value: "48599.50"}, CdtDbt: "CRDT", Date: "2026-10-31"},
+	}
+	if !slices.Equal(stmt.Balances, wantBalances) {
+		t.Errorf("остатки %+v", stmt.Balances)
+	}
+	if stmt.Summary.Credits != (camtSummaryTotal{Count: 1, Sum: "50000.00"}) ||
+		stmt.Summary.Debits != (camtSummaryTotal{Count: 1, Sum: "1500.50"}) {
+		t.Errorf("обороты %+v", stmt.Summary)
+	}
+
+	if len(stmt.Entries) != 2 {
+		t.Fatalf("записей %d", len(stmt.Entries))
+	}
+	credit, debit := stmt.Entries[0], stmt.Entries[1]
+	if credit.CdtDbt != "CRDT" || credit.Details == nil || credit.Details.DebtorAccount != nil ||
+		credit.Details.Unstructured != "Зарплата за сентябрь" {
+		t.Errorf("зачисление %+v", credit)
+	}
+	if debit.CdtDbt != "DBIT" || debit.Amount.Value != "1500.50" || debit.ValueDate != "2026-11-01" ||
+		debit.Details == nil || debit.Details.CreditorAccount == nil ||
+		debit.Details.CreditorAccount.ID != "40817810500000000002" {
+		t.Errorf("списание %+v", debit)
+	}
+}
+
+func TestCamtNegativeBalance(t *testing.T) {
+	b := camtBalanceOf("CLBD", decimal.RequireFromString("-12.3"), "RUB", time.Date(2026, 10, 31, 0, 0, 0, 0, msk))
+	if b.CdtDbt != "DBIT" || b.Amount.Value != "12.30" {
+		t.Errorf("остаток %+v", b)
+	}
+}
@@ -14,25 +14,59 @@ type UpdateBalanceRequest struct {
 }
 
 type TransferReq struct {
-	FromAccountID int64           `json:"from_account_id"`
-	ToAccountID   int64           `json:"to_account_id"`
-	Amount        decimal.Decimal `json:"amount"`
+	FromAccountID int64 `json:"from_account_id"`
+	// Получатель - счет банка по ID либо по номеру
+	ToAccountID     int64           `json:"to_account_id,omitempty"`
+	ToAccountNumber string          `json:"to_account_number,omitempty"`
+	Amount          decimal.Decimal `json:"amount"`
 }
 
 type AccountRes struct {
 	ID        int64            `json:"id"`
 	UserID    int64            `json:"user_id"`
+	Number    string           `json:"number"`
+	Nickname  *string          `json:"nickname,omitempty"`
 	Balance   decimal.Decimal  `json:"balance"`
 	Currency  models.Currency `json:"currency"`
+	Status    models.AccountStatus `json:"status"`
+	FreezeReason *string       `json:"freeze_reason,omitempty"`
+	ClosedAt  *string          `json:"closed_at,omitempty"`
+	OverdraftLimit decimal.Decimal `json:"overdraft_limit"`
 	CreatedAt string           `json:"created_at"`
 }
 
+type SetAccountNicknameReq struct {
+	Nickname string `json:"nickname"`
+}
+
+// CloseAccountReq задает свой счет, на который переводится остаток
+// закрываемого счета. Счет с нулевым балансом закрывается без него
+type CloseAccountReq struct {
+	SweepToAccountID *int64 `json:"sweep_to_account_id,omitempty"`
+}
+
+type FreezeAccountReq struct {
+	Reason string `json:"reason"`
+}
+
+// SetOverdraftLimitReq задает лимит овердрафта, 0 отключает овердрафт
+type SetOverdraftLimitReq struct {
+	Limit decimal.Decimal `json:"limit"`
+}
+
+type OverdraftChargesRes struct {
+	Charges []*models.OverdraftCharge `json:"charges"`
+}
+
 type TransactionRes struct {
 	ID        int64              `json:"id"`
 	AccountID int64              `json:"account_id"`
 	Amount    decimal.Decimal    `json:"amount"`
 	Type      models.TransactionType   `json:"type"`
 	Status    models.TransactionStatus `json:"status"`
+	CounterpartyAccountID *int64 `json:"counterparty_account_id,omitempty"`
+	CounterpartyAccountNumber *string `json:"counterparty_account_number,omitempty"`
+	Description           *string `json:"description,omitempty"`
 	CreatedAt string             `json:"created_at"`
 }
 
@@ -42,4 +76,18 @@ type AccountsListRes struct {
 
 type TransactionListRes struct {
 	Transactions []TransactionRes `json:"transactions"`
-}
\ No newline at end of file
+}
+
+type P2PTransferReq struct {
+	FromAccountID int64           `json:"from_account_id"`
+	Email         string          `json:"email,omitempty"`
+	Phone         string          `json:"phone,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`
+}
+
+type P2PTransferRes struct {
+	Status        string          `json:"status"`
+	RecipientName string          `json:"recipient_name"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      models.Currency `json:"currency"`
+}
@@ -1,22 +1,44 @@
 package types
 
+import (
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
 type CreateCardReq struct {
-	PGPKey string `json:"pgp_key"`
+	PGPKey       string             `json:"pgp_key"`
+	AccountID    int64              `json:"account_id"`
+	Product      models.CardProduct `json:"product"`
+	Type         models.CardType    `json:"type"`
+	PaymentLimit *decimal.Decimal   `json:"payment_limit,omitempty"`
 }
 
 type CreateCardRes struct {
-	ID         int64  `json:"id"`
-	UserID     int64  `json:"user_id"`
-	CreatedAt  string `json:"created_at"`
-	CardNumber string `json:"card_number"`
-	Expire     string `json:"expire"`
-	CVV        string `json:"cvv"`
+	ID         int64              `json:"id"`
+	UserID     int64              `json:"user_id"`
+	AccountID  int64              `json:"account_id"`
+	Product    models.CardProduct `json:"product"`
+	Type       models.CardType    `json:"type"`
+	CreatedAt  string             `json:"created_at"`
+	ExpiresAt  string             `json:"expires_at"`
+	CardNumber string             `json:"card_number"`
+	Expire     string             `json:"expire"`
+	CVV        string             `json:"cvv"`
+}
+
+type ActivateCardReq struct {
+	PGPKey string `json:"pgp_key"`
 }
 
 type CardRes struct {
-	ID        int64  `json:"id"`
-	UserID    int64  `json:"user_id"`
-	CreatedAt string `json:"created_at"`
+	ID        int64              `json:"id"`
+	UserID    int64              `json:"user_id"`
+	AccountID int64              `json:"account_id"`
+	Product   models.CardProduct `json:"product"`
+	Type      models.CardType    `json:"type"`
+	Status    models.CardStatus  `json:"status"`
+	ExpiresAt string             `json:"expires_at"`
+	CreatedAt string             `json:"created_at"`
 }
 
 type CardDetailsRes struct {
@@ -29,15 +51,15 @@ type CardListRes struct {
 	Cards []CardRes `json:"cards"`
 }
 
-type PaymentReq struct {
-	CardID int64  `json:"card_id"`
-	Amount string `json:"amount"`
-	CVV    string `json:"cvv"`
-	PGPKey string `json:"pgp_key"`
+type SetPINReq struct {
+	PIN        string `json:"pin"`
+	CurrentPIN string `json:"current_pin,omitempty"`
 }
 
-type PaymentRes struct {
-	Success     bool   `json:"success"`
-	PaymentID   string `json:"payment_id,omitempty"`
-	Description string `json:"description,omitempty"`
-}
\ No newline at end of file
+// ResetPINReq - новый PIN, CVV карты и код, отправленный владельцу по
+// запросу сброса PIN
+type ResetPINReq struct {
+	PIN  string `json:"pin"`
+	CVV  string `json:"cvv"`
+	Code string `json:"code"`
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// OpenDepositReq открывает вклад на новом счете. Срочный вклад пополняется
+// при открытии на amount со счета from_account_id, накопительный счет можно
+// открыть и пустым
+type OpenDepositReq struct {
+	Type          models.DepositType `json:"type"`
+	TermMonths    *int               `json:"term_months,omitempty"`
+	FromAccountID int64              `json:"from_account_id,omitempty"`
+	Amount        decimal.Decimal    `json:"amount"`
+}
+
+// CloseDepositReq задает свой счет, на который переводятся средства вклада
+type CloseDepositReq struct {
+	ToAccountID int64 `json:"to_account_id"`
+}
+
+type DepositRes struct {
+	Deposit       *models.Deposit         `json:"deposit"`
+	AccountNumber string                  `json:"account_number"`
+	Balance       decimal.Decimal         `json:"balance"`
+	Interest      *models.DepositInterest `json:"interest"`
+}
+
+type DepositListRes struct {
+	Deposits []DepositRes `json:"deposits"`
+}
+
+type DepositRateRes struct {
+	Type       models.DepositType `json:"type"`
+	TermMonths *int               `json:"term_months,omitempty"`
+	AnnualRate decimal.Decimal    `json:"annual_rate"`
+}
+
+type DepositRatesRes struct {
+	Rates []DepositRateRes `json:"rates"`
+}
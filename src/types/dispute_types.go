@@ -0,0 +1,17 @@
+package types
+
+import "sf-finances/src/models"
+
+type OpenDisputeReq struct {
+	ReasonCode models.DisputeReason `json:"reason_code"`
+	Comment    string               `json:"comment,omitempty"`
+}
+
+type ResolveDisputeReq struct {
+	Outcome    models.DisputeStatus `json:"outcome"`
+	Resolution string               `json:"resolution,omitempty"`
+}
+
+type DisputeListRes struct {
+	Disputes []*models.Dispute `json:"disputes"`
+}
@@ -0,0 +1,27 @@
+package types
+
+import "github.com/shopspring/decimal"
+
+const (
+	ImportDocumentSucceeded = "SUCCEEDED"
+	ImportDocumentFailed    = "FAILED"
+	ImportDocumentSkipped   = "SKIPPED"
+)
+
+// ImportDocumentRes - результат исполнения одного документа из файла обмена
+type ImportDocumentRes struct {
+	Index  int             `json:"index"`
+	Kind   string          `json:"kind"`
+	Number string          `json:"number"`
+	Date   string          `json:"date"`
+	Amount decimal.Decimal `json:"amount"`
+	Status string          `json:"status"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type ImportRes struct {
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Skipped   int                 `json:"skipped"`
+	Documents []ImportDocumentRes `json:"documents"`
+}
@@ -0,0 +1,16 @@
+package types
+
+import (
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// FeePreviewRes - комиссия за операцию и сумма к списанию вместе с ней
+type FeePreviewRes struct {
+	Quote *models.FeeQuote `json:"quote"`
+	Total decimal.Decimal  `json:"total"`
+}
+
+type FeeListRes struct {
+	Fees []*models.Fee `json:"fees"`
+}
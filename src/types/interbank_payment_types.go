@@ -0,0 +1,42 @@
+package types
+
+import (
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// CreateInterbankPaymentReq - платежное поручение в другой банк. VATRate -
+// ставка НДС в процентах, без нее в назначение добавляется "НДС не облагается"
+type CreateInterbankPaymentReq struct {
+	FromAccountID        int64           `json:"from_account_id"`
+	Amount               decimal.Decimal `json:"amount"`
+	RecipientName        string          `json:"recipient_name"`
+	RecipientINN         string          `json:"recipient_inn,omitempty"`
+	RecipientKPP         string          `json:"recipient_kpp,omitempty"`
+	RecipientBIC         string          `json:"recipient_bic"`
+	CorrespondentAccount string          `json:"correspondent_account,omitempty"`
+	RecipientAccount     string          `json:"recipient_account"`
+	Purpose              string          `json:"purpose"`
+	VATRate              *int            `json:"vat_rate,omitempty"`
+}
+
+type FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+type FieldErrorsRes struct {
+	Errors []FieldError `json:"errors"`
+}
+
+type InterbankPaymentListRes struct {
+	Payments []*models.InterbankPayment `json:"payments"`
+}
+
+type BankRes struct {
+	BIC                   string   `json:"bic"`
+	Name                  string   `json:"name"`
+	City                  string   `json:"city,omitempty"`
+	CorrespondentAccounts []string `json:"correspondent_accounts"`
+	Restricted            bool     `json:"restricted"`
+}
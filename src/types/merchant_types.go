@@ -0,0 +1,35 @@
+package types
+
+import "sf-finances/src/models"
+
+type CreateMerchantReq struct {
+	Name                string `json:"name"`
+	MCC                 string `json:"mcc"`
+	SettlementAccountID int64  `json:"settlement_account_id"`
+}
+
+type CreateMerchantRes struct {
+	Merchant      *models.Merchant `json:"merchant"`
+	SigningSecret string           `json:"signing_secret"`
+}
+
+type MerchantListRes struct {
+	Merchants []*models.Merchant `json:"merchants"`
+}
+
+type SigningSecretRes struct {
+	SigningSecret string `json:"signing_secret"`
+}
+
+type CreateAPIKeyReq struct {
+	Scopes []models.MerchantScope `json:"scopes"`
+}
+
+type CreateAPIKeyRes struct {
+	Key    *models.MerchantAPIKey `json:"key"`
+	APIKey string                 `json:"api_key"`
+}
+
+type APIKeyListRes struct {
+	Keys []*models.MerchantAPIKey `json:"keys"`
+}
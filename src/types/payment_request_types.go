@@ -0,0 +1,38 @@
+package types
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// CreatePaymentRequestReq задает плательщика по email или телефону и свой
+// счет для зачисления. Без expires_at запрос действует срок по умолчанию
+type CreatePaymentRequestReq struct {
+	Email       string          `json:"email,omitempty"`
+	Phone       string          `json:"phone,omitempty"`
+	ToAccountID int64           `json:"to_account_id"`
+	Amount      decimal.Decimal `json:"amount"`
+	Comment     string          `json:"comment,omitempty"`
+	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
+}
+
+type PayPaymentRequestReq struct {
+	FromAccountID int64 `json:"from_account_id"`
+}
+
+type DeclinePaymentRequestReq struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// PaymentRequestRes показывает запрос вместе с маскированным именем второй
+// стороны: запросившего для плательщика и плательщика для запросившего
+type PaymentRequestRes struct {
+	Request          *models.PaymentRequest `json:"request"`
+	CounterpartyName string                 `json:"counterparty_name"`
+}
+
+type PaymentRequestListRes struct {
+	Requests []PaymentRequestRes `json:"requests"`
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+type PaymentReq struct {
+	CardID   int64           `json:"card_id"`
+	Amount   string          `json:"amount"`
+	Currency models.Currency `json:"currency,omitempty"`
+	CVV      string          `json:"cvv,omitempty"`
+	PIN      string          `json:"pin,omitempty"`
+}
+
+type PaymentRes struct {
+	Success     bool                 `json:"success"`
+	PaymentID   string               `json:"payment_id,omitempty"`
+	Status      models.PaymentStatus `json:"status,omitempty"`
+	Description string               `json:"description,omitempty"`
+}
+
+type ConfirmPaymentReq struct {
+	Code string `json:"code"`
+}
+
+type PaymentStatusChangeRes struct {
+	Status    models.PaymentStatus `json:"status"`
+	Reason    *string              `json:"reason,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+type PaymentDetailsRes struct {
+	PaymentID     string                   `json:"payment_id"`
+	MerchantID    int64                    `json:"merchant_id"`
+	CardID        int64                    `json:"card_id"`
+	Amount        decimal.Decimal          `json:"amount"`
+	Currency      models.Currency          `json:"currency"`
+	Status        models.PaymentStatus     `json:"status"`
+	FailureReason *string                  `json:"failure_reason,omitempty"`
+	TransactionID *int64                   `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at"`
+	History       []PaymentStatusChangeRes `json:"history,omitempty"`
+}
+
+type PaymentListRes struct {
+	Payments []PaymentDetailsRes `json:"payments"`
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// CreateSavingsGoalReq открывает цель на новом счете в валюте основного
+// счета main_account_id. target_date - дата в формате 2006-01-02
+type CreateSavingsGoalReq struct {
+	MainAccountID  int64            `json:"main_account_id"`
+	Name           string           `json:"name"`
+	TargetAmount   decimal.Decimal  `json:"target_amount"`
+	TargetDate     string           `json:"target_date,omitempty"`
+	RoundUpStep    *int             `json:"round_up_step,omitempty"`
+	DepositPercent *decimal.Decimal `json:"deposit_percent,omitempty"`
+}
+
+// UpdateSavingsGoalRulesReq заменяет правила пополнения цели, пропущенное
+// правило отключается
+type UpdateSavingsGoalRulesReq struct {
+	RoundUpStep    *int             `json:"round_up_step,omitempty"`
+	DepositPercent *decimal.Decimal `json:"deposit_percent,omitempty"`
+}
+
+// SavingsGoalRes - цель с прогрессом. MonthlyNeeded - сколько откладывать в
+// месяц, чтобы успеть к target_date
+type SavingsGoalRes struct {
+	Goal            *models.SavingsGoal           `json:"goal"`
+	AccountNumber   string                        `json:"account_number"`
+	Balance         decimal.Decimal               `json:"balance"`
+	Remaining       decimal.Decimal               `json:"remaining"`
+	ProgressPercent decimal.Decimal               `json:"progress_percent"`
+	MonthlyNeeded   *decimal.Decimal              `json:"monthly_needed,omitempty"`
+	AutoContributed decimal.Decimal               `json:"auto_contributed"`
+	Contributions   []*models.SavingsContribution `json:"contributions,omitempty"`
+}
+
+type SavingsGoalListRes struct {
+	Goals []SavingsGoalRes `json:"goals"`
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+type CreateSBPQRReq struct {
+	AccountID int64            `json:"account_id"`
+	Type      models.SBPQRType `json:"type"`
+	Amount    *decimal.Decimal `json:"amount,omitempty"`
+	Purpose   string           `json:"purpose,omitempty"`
+}
+
+type SBPQRRes struct {
+	QRCode  *models.SBPQRCode `json:"qr_code"`
+	Payload string            `json:"payload"`
+}
+
+type SBPQRListRes struct {
+	QRCodes []SBPQRRes `json:"qr_codes"`
+}
+
+type SBPPayloadReq struct {
+	Payload string `json:"payload"`
+}
+
+// SBPTransferPreviewRes - перевод, заполненный по платежной ссылке. Если
+// AmountFixed, сумму менять нельзя, иначе ее вводит плательщик
+type SBPTransferPreviewRes struct {
+	QRID          string           `json:"qr_id"`
+	Type          models.SBPQRType `json:"type"`
+	RecipientName string           `json:"recipient_name"`
+	BankName      string           `json:"bank_name"`
+	Amount        *decimal.Decimal `json:"amount,omitempty"`
+	AmountFixed   bool             `json:"amount_fixed"`
+	Currency      models.Currency  `json:"currency"`
+	Purpose       string           `json:"purpose,omitempty"`
+}
+
+type SBPPayReq struct {
+	Payload       string           `json:"payload"`
+	FromAccountID int64            `json:"from_account_id"`
+	Amount        *decimal.Decimal `json:"amount,omitempty"`
+}
+
+type SBPPayRes struct {
+	Status        string          `json:"status"`
+	RecipientName string          `json:"recipient_name"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      models.Currency `json:"currency"`
+	Purpose       string          `json:"purpose,omitempty"`
+}
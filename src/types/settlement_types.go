@@ -0,0 +1,7 @@
+package types
+
+import "sf-finances/src/models"
+
+type SettlementListRes struct {
+	Batches []*models.SettlementBatch `json:"batches"`
+}
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+// CreateStandingOrderReq задает получателя одним из способов: to_account_id
+// (свой счет) либо email или phone другого пользователя
+type CreateStandingOrderReq struct {
+	FromAccountID int64               `json:"from_account_id"`
+	ToAccountID   int64               `json:"to_account_id,omitempty"`
+	Email         string              `json:"email,omitempty"`
+	Phone         string              `json:"phone,omitempty"`
+	Amount        decimal.Decimal     `json:"amount"`
+	Description   string              `json:"description,omitempty"`
+	ScheduleType  models.ScheduleType `json:"schedule_type"`
+	RunAt         *time.Time          `json:"run_at,omitempty"`
+	Weekday       *int                `json:"weekday,omitempty"`
+	DayOfMonth    *int                `json:"day_of_month,omitempty"`
+	Cron          string              `json:"cron,omitempty"`
+}
+
+type StandingOrderListRes struct {
+	Orders []*models.StandingOrder `json:"orders"`
+}
+
+type StandingOrderDetailsRes struct {
+	Order      *models.StandingOrder            `json:"order"`
+	Executions []*models.StandingOrderExecution `json:"executions"`
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"github.com/shopspring/decimal"
+	"sf-finances/src/models"
+)
+
+type TransferBatchItemReq struct {
+	// Line - номер строки в загруженном CSV, для JSON - номер элемента с 1
+	Line int `json:"-"`
+	// Получатель задается номером счета либо ID своего счета
+	ToAccountID     int64           `json:"to_account_id,omitempty"`
+	ToAccountNumber string          `json:"to_account_number,omitempty"`
+	Amount          decimal.Decimal `json:"amount"`
+	Purpose         string          `json:"purpose,omitempty"`
+}
+
+type CreateTransferBatchReq struct {
+	FromAccountID int64                  `json:"from_account_id"`
+	Items         []TransferBatchItemReq `json:"items"`
+}
+
+type TransferBatchItemError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type TransferBatchErrorRes struct {
+	Errors []TransferBatchItemError `json:"errors"`
+}
+
+type TransferBatchListRes struct {
+	Batches []*models.TransferBatch `json:"batches"`
+}
+
+type TransferBatchDetailsRes struct {
+	Batch *models.TransferBatch       `json:"batch"`
+	Items []*models.TransferBatchItem `json:"items"`
+}
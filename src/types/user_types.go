@@ -1,8 +1,10 @@
 package types
 
 type RegisterReq struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required,min=6"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
 }
 
 type LoginReq struct {
@@ -12,4 +14,26 @@ type LoginReq struct {
 
 type LoginRes struct {
 	Token string `json:"token"`
-}
\ No newline at end of file
+}
+
+type ProfileRes struct {
+	ID            int64  `json:"id"`
+	Email         string `json:"email"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Phone         string `json:"phone,omitempty"`
+	PhoneVerified bool   `json:"phone_verified"`
+}
+
+type UpdateProfileReq struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type PhoneVerificationReq struct {
+	Phone string `json:"phone"`
+}
+
+type VerifyPhoneReq struct {
+	Code string `json:"code"`
+}